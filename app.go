@@ -2,29 +2,41 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha1"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/base64"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/scrypt"
 	"gorm.io/gorm"
 
 	"topology/internal/backup"
 	"topology/internal/db"
+	"topology/internal/keyring"
 	"topology/internal/logger"
 	"topology/internal/sshtunnel"
 
@@ -52,7 +64,116 @@ func (a *App) startup(ctx context.Context) {
 	} else {
 		logger.Info("topology started; log dir %s", logDir)
 	}
+	settings := getSettings()
+	backup.SetToolPaths(settings.ToolPaths)
+	if settings.MaxHistorySize > 0 || settings.HistoryDisabled {
+		historyMu.Lock()
+		if settings.MaxHistorySize > 0 {
+			maxHistorySize = settings.MaxHistorySize
+		}
+		historyEnabled = !settings.HistoryDisabled
+		historyMu.Unlock()
+	}
+	if settings.MaxResultRows != 0 {
+		maxResultRowsMu.Lock()
+		maxResultRows = settings.MaxResultRows
+		maxResultRowsMu.Unlock()
+	}
+	if settings.InsertBatchSize > 0 {
+		insertBatchSizeMu.Lock()
+		insertBatchSize = settings.InsertBatchSize
+		insertBatchSizeMu.Unlock()
+	}
+	if settings.BackupTimeoutMinutes > 0 {
+		backupRunMu.Lock()
+		backupTimeout = time.Duration(settings.BackupTimeoutMinutes) * time.Minute
+		backupRunMu.Unlock()
+	}
+	if settings.SlowQueryThresholdMs > 0 {
+		slowQueryMu.Lock()
+		slowQueryThreshold = settings.SlowQueryThresholdMs
+		slowQueryMu.Unlock()
+	}
+	db.SetNumericBooleans(settings.NumericBooleans)
+	if settings.DisplayTimezone != "" {
+		if loc, err := time.LoadLocation(settings.DisplayTimezone); err == nil {
+			db.SetDisplayLocation(loc)
+		} else {
+			logger.Warn("invalid display timezone %q: %v", settings.DisplayTimezone, err)
+		}
+	}
+	if settings.UseKeychain {
+		keychainMu.Lock()
+		useKeychain = true
+		keychainMu.Unlock()
+	}
+	if settings.TxIdleTimeoutMinutes != 0 {
+		txMu.Lock()
+		txIdleTimeout = time.Duration(settings.TxIdleTimeoutMinutes) * time.Minute
+		txMu.Unlock()
+	}
+	if settings.ConnectionRetries > 0 {
+		if err := db.SetRetryPolicy(settings.ConnectionRetries, time.Duration(settings.ConnectionRetryDelayMs)*time.Millisecond); err != nil {
+			logger.Warn("invalid connection retry settings: %v", err)
+		}
+	}
+	if settings.PoolIdleSeconds > 0 || settings.PoolLifetimeSeconds > 0 {
+		idle, lifetime := poolTimeouts(settings.PoolIdleSeconds, settings.PoolLifetimeSeconds)
+		if err := db.SetPoolTimeouts(idle, lifetime); err != nil {
+			logger.Warn("invalid connection pool settings: %v", err)
+		}
+	}
+	if settings.KeepAliveSeconds > 0 {
+		keepAliveMu.Lock()
+		keepAliveInterval = time.Duration(settings.KeepAliveSeconds) * time.Second
+		keepAliveMu.Unlock()
+	}
 	go runBackupScheduler()
+	go a.runTxReaper()
+	go runKeepAlive()
+	go runCursorReaper()
+}
+
+// shutdown is called when the app is closing. It rolls back open transactions, stops monitors, schema loads
+// and SSH tunnels, closes every pooled connection and flushes the log file.
+func (a *App) shutdown(ctx context.Context) {
+	txMu.Lock()
+	txs := make(map[string]*gorm.DB, len(activeTx))
+	for k, tx := range activeTx {
+		txs[k] = tx
+		forgetTxLocked(k)
+	}
+	txMu.Unlock()
+	for k, tx := range txs {
+		if err := tx.Rollback().Error; err != nil {
+			logger.Warn("rollback transaction %q on shutdown: %v", k, err)
+		}
+	}
+	closeCursors("*", "*")
+	backupRunMu.Lock()
+	for _, cancel := range backupCancels {
+		cancel()
+	}
+	backupRunMu.Unlock()
+
+	monitorMu.Lock()
+	for id, ch := range monitorStop {
+		delete(monitorStop, id)
+		close(ch)
+	}
+	monitorMu.Unlock()
+	schemaLoadMu.Lock()
+	for id, ch := range schemaLoadStop {
+		delete(schemaLoadStop, id)
+		close(ch)
+	}
+	schemaLoadMu.Unlock()
+
+	flushLastConnected()
+	db.CloseAll()
+	sshtunnel.StopAll()
+	logger.Info("topology stopped")
+	logger.Close()
 }
 
 // Connection types
@@ -70,6 +191,36 @@ type Connection struct {
 	Status    string     `json:"status"`
 	CreatedAt string     `json:"createdAt,omitempty"`
 	ReadOnly  bool       `json:"readOnly,omitempty"`
+	// DefaultSchema is the PostgreSQL search_path (or MySQL default database) applied to every session.
+	DefaultSchema string `json:"defaultSchema,omitempty"`
+	// Socket is the Unix socket path of a local MySQL server. When set (or when Host starts with "/") Host and
+	// Port are not used, and the SSH tunnel does not apply since there is no TCP endpoint to forward.
+	Socket string `json:"socket,omitempty"`
+	// SQLiteBusyTimeout is how long (ms) to wait on a locked SQLite file; 0 uses db.DefaultSQLiteBusyTimeout.
+	SQLiteBusyTimeout int `json:"sqliteBusyTimeout,omitempty"`
+	// SQLiteJournalMode sets PRAGMA journal_mode (e.g. "WAL") on open; empty keeps the file's mode.
+	SQLiteJournalMode string `json:"sqliteJournalMode,omitempty"`
+	// PasswordInKeychain marks that Password is kept in the OS keychain rather than connections.json.
+	PasswordInKeychain bool `json:"passwordInKeychain,omitempty"`
+	// Charset overrides the connection character set: the MySQL DSN charset (default utf8mb4) or the PostgreSQL
+	// client_encoding. Set it to the legacy encoding (e.g. latin1) of old databases to read their text correctly.
+	Charset string `json:"charset,omitempty"`
+	// Options are extra driver parameters merged into the DSN (e.g. MySQL readTimeout, PostgreSQL
+	// connect_timeout, SQLite _foreign_keys). They take precedence over the parameters set above.
+	Options map[string]string `json:"options,omitempty"`
+	// ApplicationName is how the client shows up in pg_stat_activity / the MySQL processlist attributes;
+	// empty uses "topology - <Name>".
+	ApplicationName string `json:"applicationName,omitempty"`
+	// DSN is a pasted connection string (postgres://, mysql://, sqlite:// URL or a driver's own format, see
+	// db.DSNDriver). When set it is used as is and decides Type; Host, Username and the other connection fields
+	// are only filled in from it for display and backups, and connection options do not apply.
+	DSN string `json:"dsn,omitempty"`
+	// LastConnectedAt is when a pool for the connection was last opened (RFC 3339); see touchLastConnected.
+	LastConnectedAt string `json:"lastConnectedAt,omitempty"`
+	// LogStatements appends every statement run on the connection to its SQL log; see SetStatementLogging.
+	LogStatements bool `json:"logStatements,omitempty"`
+	// Incognito keeps the connection's statements out of the query history.
+	Incognito bool `json:"incognito,omitempty"`
 }
 
 type SSHTunnel struct {
@@ -102,6 +253,8 @@ type navicatConnEntry struct {
 	SSH_UserName     string `xml:"SSH_UserName,attr"`
 	SSH_AuthenMethod string `xml:"SSH_AuthenMethod,attr"`
 	SSH_PrivateKey   string `xml:"SSH_PrivateKey,attr"`
+	Password         string `xml:"Password,attr"`
+	SSH_Password     string `xml:"SSH_Password,attr"`
 }
 
 // ImportNavicatResult is the JSON returned by ImportNavicatConnections.
@@ -125,6 +278,9 @@ type Column struct {
 	DefaultValue string `json:"defaultValue,omitempty"`
 	IsPrimaryKey bool   `json:"isPrimaryKey"`
 	IsUnique     bool   `json:"isUnique"`
+	// AutoIncrement and Generated columns are skipped by InsertTableRows/ImportData.
+	AutoIncrement bool `json:"autoIncrement,omitempty"`
+	Generated     bool `json:"generated,omitempty"`
 }
 
 type Index struct {
@@ -148,9 +304,12 @@ type TableSchema struct {
 	Columns     []Column     `json:"columns"`
 	Indexes     []Index      `json:"indexes"`
 	ForeignKeys []ForeignKey `json:"foreignKeys"`
+	Error       *ApiError    `json:"error,omitempty"`
 }
 
 type QueryResult struct {
+	// Columns are unique and name the keys of Rows; a repeated name (e.g. a.id and b.id of a join) gets a
+	// numeric suffix, as does a blank one (see db.UniqueColumnNames).
 	Columns       []string                 `json:"columns"`
 	Rows          []map[string]interface{} `json:"rows"`
 	RowCount      int                      `json:"rowCount"`
@@ -158,6 +317,19 @@ type QueryResult struct {
 	AffectedRows  int                      `json:"affectedRows,omitempty"`
 	Error         string                   `json:"error,omitempty"`
 	Cached        bool                     `json:"cached,omitempty"`
+	// Truncated is set when an ad-hoc SELECT hit the result row cap (see SetMaxResultRows).
+	Truncated bool `json:"truncated,omitempty"`
+	// Message is an informational note shown with the result (e.g. a CALL returned more result sets).
+	Message string `json:"message,omitempty"`
+	// ColumnTypes describes Columns (same order) for SELECT results; absent when the driver reports no types.
+	ColumnTypes []db.ColumnMeta `json:"columnTypes,omitempty"`
+	// StatementType is the detected kind of statement: SELECT, INSERT, UPDATE, DELETE, DDL or OTHER (see db.StatementType).
+	StatementType string `json:"statementType,omitempty"`
+	// PrettyJSON maps row index to column to a pretty-printed copy of JSON held in a TEXT/BLOB/untyped column;
+	// Rows keep the original value so edits round-trip (see db.JSONFallback).
+	PrettyJSON map[int]map[string]string `json:"prettyJson,omitempty"`
+	// ErrorDetail locates a syntax error in the statement when the server reported where it is.
+	ErrorDetail *ApiError `json:"errorDetail,omitempty"`
 }
 
 // ExecutionPlanNode represents one step in EXPLAIN result for visualization.
@@ -195,9 +367,13 @@ type IndexSuggestion struct {
 type TableData struct {
 	Columns   []string                 `json:"columns"`
 	Rows      []map[string]interface{} `json:"rows"`
-	TotalRows int                      `json:"totalRows"`
+	TotalRows int                      `json:"totalRows"` // -1 when requested without a count
 	Page      int                      `json:"page"`
 	PageSize  int                      `json:"pageSize"`
+	// HasMore reports whether rows follow this page; set only when the count was skipped.
+	HasMore bool `json:"hasMore,omitempty"`
+	// Error is set when the table could not be read, e.g. NOT_FOUND for a table that does not exist.
+	Error *ApiError `json:"error,omitempty"`
 }
 
 type UpdateRecord struct {
@@ -205,6 +381,9 @@ type UpdateRecord struct {
 	Column   string      `json:"column"`
 	OldValue interface{} `json:"oldValue"`
 	NewValue interface{} `json:"newValue"`
+	// Original is the full row snapshot as loaded. When set, the UPDATE matches on the original values
+	// (optimistic locking) and fails if the row was changed meanwhile.
+	Original map[string]interface{} `json:"original,omitempty"`
 }
 
 type QueryHistory struct {
@@ -215,14 +394,56 @@ type QueryHistory struct {
 	Success      bool   `json:"success"`
 	Duration     int    `json:"duration,omitempty"` // milliseconds
 	RowCount     int    `json:"rowCount,omitempty"`
+	// Slow is set when Duration exceeded the slow-query threshold at the time (see SetSlowQueryThreshold).
+	Slow bool `json:"slow,omitempty"`
 }
 
 // Snippet holds a saved SQL fragment with an alias for quick insert.
 type Snippet struct {
-	ID        string `json:"id"`
-	Alias     string `json:"alias"`
-	SQL       string `json:"sql"`
-	CreatedAt string `json:"createdAt"`
+	ID        string   `json:"id"`
+	Alias     string   `json:"alias"`
+	SQL       string   `json:"sql"`
+	Category  string   `json:"category,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	CreatedAt string   `json:"createdAt"`
+}
+
+// Placeholders returns the distinct ${name} placeholders in the snippet SQL, in order of first appearance.
+func (s Snippet) Placeholders() []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, m := range snippetPlaceholderRegex.FindAllStringSubmatch(s.SQL, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// Expand substitutes ${name} placeholders from params (raw text, not quoted). Fails listing any unfilled placeholders.
+func (s Snippet) Expand(params map[string]string) (string, error) {
+	var missing []string
+	for _, name := range s.Placeholders() {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unfilled placeholders: %s", strings.Join(missing, ", "))
+	}
+	return snippetPlaceholderRegex.ReplaceAllStringFunc(s.SQL, func(m string) string {
+		return params[m[2:len(m)-1]]
+	}), nil
+}
+
+// FavoriteQuery is a pinned, runnable query tied to a connection (unlike history, never trimmed or cleared).
+type FavoriteQuery struct {
+	ID           string `json:"id"`
+	ConnectionID string `json:"connectionId"`
+	Name         string `json:"name"`
+	SQL          string `json:"sql"`
+	CreatedAt    string `json:"createdAt"`
 }
 
 // ProcessItem represents one row from SHOW FULL PROCESSLIST for live monitor.
@@ -245,6 +466,28 @@ type LiveStatsPayload struct {
 	Error            string        `json:"error,omitempty"`
 }
 
+// QueryEvent is emitted to frontend via "query-start" and "query-finish" events around ExecuteQuery.
+// DurationMs, RowCount and Error are only set on "query-finish".
+type QueryEvent struct {
+	ConnectionID string `json:"connectionId"`
+	SessionID    string `json:"sessionId"`
+	SQL          string `json:"sql"` // truncated to queryEventSQLMax runes
+	DurationMs   int    `json:"durationMs"`
+	RowCount     int    `json:"rowCount"`
+	Error        string `json:"error,omitempty"`
+}
+
+// DataMutation is emitted to frontend via "data-mutation" after a grid edit commits, when SetMutationSQLEvents
+// is on. Statements have their bound values inlined for display.
+type DataMutation struct {
+	ConnectionID string   `json:"connectionId"`
+	SessionID    string   `json:"sessionId"`
+	Database     string   `json:"database"`
+	Table        string   `json:"table"`
+	Operation    string   `json:"operation"` // update, delete or insert
+	Statements   []string `json:"statements"`
+}
+
 // Schema metadata for SQL completion (tables + columns per connection).
 type SchemaTableMeta struct {
 	Name    string             `json:"name"`
@@ -263,10 +506,24 @@ type SchemaMetadata struct {
 	Databases    []SchemaDBMeta `json:"databases"`
 }
 
+// CompletionItem is one editor suggestion returned by GetCompletions.
+type CompletionItem struct {
+	Label  string `json:"label"`
+	Kind   string `json:"kind"`             // table | column | keyword
+	Detail string `json:"detail,omitempty"` // database for tables, type for columns
+}
+
 var (
 	connMu              sync.RWMutex
 	connections         []Connection
 	connectionsLoadOnce sync.Once
+	vaultMu             sync.RWMutex
+	vaultKey            []byte     // derived from the master password; nil until UnlockVault succeeds
+	vaultConf           *vaultFile // cached vault.json; nil when no master password is set
+	vaultConfLoaded     bool
+	keychainMu          sync.Mutex
+	useKeychain         bool
+	keychainSynced      = make(map[string]string) // connection ID -> password last read from / written to the keychain
 	schemaMetaMu        sync.RWMutex
 	schemaMetaCache     = make(map[string]SchemaMetadata)
 	connFileOnce        sync.Once
@@ -276,10 +533,25 @@ var (
 	historyFileOnce     sync.Once
 	historyFilePath     string
 	maxHistorySize      = 100 // Keep last 100 queries
+	historyEnabled      = true
 	snippetsMu          sync.RWMutex
 	snippets            []Snippet
 	snippetsFileOnce    sync.Once
 	snippetsFilePath    string
+	favoritesMu         sync.RWMutex
+	favorites           []FavoriteQuery
+	favoritesFileOnce   sync.Once
+	favoritesFilePath   string
+	tableViewsMu        sync.Mutex
+	tableViews          map[string]map[string]TableView // connection ID -> table -> layout; nil until loaded
+	tableViewsFileOnce  sync.Once
+	tableViewsFilePath  string
+	draftsMu            sync.Mutex
+	editorDrafts        map[string]EditorDraft // session (tab) ID -> draft; nil until loaded
+	draftsFileOnce      sync.Once
+	draftsFilePath      string
+	schemaLoadMu        sync.Mutex
+	schemaLoadStop      = make(map[string]chan struct{}) // connectionID -> stop channel of a running LoadSchemaMetadata
 	monitorMu           sync.Mutex
 	monitorStop         = make(map[string]chan struct{}) // connectionID -> stop channel
 	backupMu            sync.Mutex
@@ -288,42 +560,118 @@ var (
 	scheduleMu          sync.Mutex
 	backupSchedules     []BackupSchedule
 	schedulesFilePath   string
+	maxResultRowsMu     sync.Mutex
+	maxResultRows       = defaultMaxResultRows
+	insertBatchSizeMu   sync.Mutex
+	insertBatchSize     int // rows per INSERT in InsertTableRows/ImportData; 0 = automatic
+	backupRunMu         sync.Mutex
+	backupCancels       = make(map[string]context.CancelFunc) // connection ID -> cancel of its running backup
+	backupTimeout       = defaultBackupTimeout
+	slowQueryMu         sync.Mutex
+	slowQueryThreshold  = defaultSlowQueryMs // ms above which saveQueryHistory flags an entry as slow
 	queryCacheMu        sync.Mutex
 	queryCache          = make(map[string]queryCacheEntry)
 	queryCacheOrder     []string
 	queryCacheHits      int64
 	queryCacheMisses    int64
 	txMu                sync.Mutex
-	activeTx            = make(map[string]*gorm.DB) // key = txKey(connID, sessionID)
+	activeTx            = make(map[string]*gorm.DB)      // key = txKey(connID, sessionID)
+	txLastUsed          = make(map[string]time.Time)     // same keys as activeTx
+	txBusy              = make(map[string]int)           // same keys as activeTx; statements still running
+	txPools             = make(map[gorm.ConnPool]string) // *sql.Tx of each active transaction -> its key
+	txReadOnly          = make(map[string]bool)          // same keys as activeTx; begun with BeginReadOnlyTx
+	txIdleTimeout       = defaultTxIdleTimeout           // <= 0 disables the idle reaper
+	sessionDBMu         sync.Mutex
+	sessionDatabase     = make(map[string]string)          // txKey(connID, sessionID) -> database chosen with UseDatabase
+	sessionAttachments  = make(map[string][]db.Attachment) // txKey(connID, sessionID) -> SQLite files attached with AttachDatabase
+	cursorMu            sync.Mutex
+	openCursors         = make(map[string]*resultCursor) // handle -> cursor opened by ExecuteQueryCursor
 )
 
 type queryCacheEntry struct {
-	cols     []string
-	rows     []map[string]interface{}
-	rowCount int
-	execMs   int
-	at       time.Time
+	cols      []string
+	colTypes  []db.ColumnMeta
+	rows      []map[string]interface{}
+	pretty    map[int]map[string]string
+	rowCount  int
+	execMs    int
+	truncated bool
+	at        time.Time
 }
 
 var (
 	wsRegex       = regexp.MustCompile(`\s+`)
 	fromJoinRegex = regexp.MustCompile(`(?i)(?:FROM|JOIN)\s+(?:[\w.]+\.)?(\w+)`)
-	whereColRegex = regexp.MustCompile(`\b(\w+)\s*[=<>]`)
-	indexHintSkip = map[string]bool{"AND": true, "OR": true, "ON": true, "IN": true, "AS": true, "SELECT": true, "WHERE": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true, "NULL": true}
+	// whereColRegex needs whitespace before IN/LIKE/BETWEEN so words ending in them (admin, JOIN) are not split.
+	whereColRegex = regexp.MustCompile(`(?i)\b(?:(\w+)\.)?(\w+)(?:\s*[=<>!]|\s+(?:NOT\s+)?(?:IN|LIKE|BETWEEN)\b)`)
+	// rhsColRegex matches qualified columns on the right of a comparison (JOIN ... ON a.x = b.y).
+	rhsColRegex = regexp.MustCompile(`[=<>]\s*(\w+)\.(\w+)`)
+	// orderGroupRegex finds GROUP BY / ORDER BY; the column list runs until orderGroupEndRegex.
+	orderGroupRegex    = regexp.MustCompile(`(?i)\b(?:GROUP|ORDER)\s+BY\s+`)
+	orderGroupEndRegex = regexp.MustCompile(`(?i)\s+(?:HAVING|LIMIT|OFFSET|UNION|ORDER|FOR|WINDOW)\b|\)|;`)
+	orderItemRegex     = regexp.MustCompile(`(?i)^(?:(\w+)\.)?(\w+)(?:\s+(?:ASC|DESC))?$`)
+	// MySQL EXPLAIN ANALYZE tree output
+	explainAnalyzeLineRegex = regexp.MustCompile(`^(\s*)-> (.*)$`)
+	explainCostRegex        = regexp.MustCompile(`\(cost=([\d.]+) rows=([\d.e+]+)\)`)
+	explainActualRegex      = regexp.MustCompile(`\(actual time=([\d.]+)\.\.([\d.]+) rows=([\d.e+]+) loops=(\d+)\)`)
+	explainTableRegex       = regexp.MustCompile(`^(Table scan|Index scan|Index range scan|Index lookup|Single-row index lookup|Covering index lookup|Covering index scan) on (\w+)`)
+	// row cap: only plain SELECT/WITH statements without their own limit get LIMIT appended
+	rowCapStartRegex = regexp.MustCompile(`(?i)^\s*(?:SELECT|WITH)\b`)
+	rowCapSkipRegex  = regexp.MustCompile(`(?i)\b(?:LIMIT|FETCH\s+(?:FIRST|NEXT)|TOP|INTO|FOR\s+(?:UPDATE|SHARE))\b`)
+	// tableAliasRegex matches "FROM/JOIN [db.]table [AS] alias" for alias resolution.
+	tableAliasRegex = regexp.MustCompile(`(?i)(?:FROM|JOIN)\s+(?:[\w.]+\.)?(\w+)(?:\s+(?:AS\s+)?(\w+))?`)
+	// snippetPlaceholderRegex matches ${name} placeholders in snippet SQL.
+	snippetPlaceholderRegex = regexp.MustCompile(`\$\{(\w+)\}`)
+	indexHintSkip           = map[string]bool{"AND": true, "OR": true, "ON": true, "IN": true, "AS": true, "SELECT": true, "WHERE": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true, "NULL": true}
 )
 
+// sqlKeywords are offered by GetCompletions alongside schema names.
+var sqlKeywords = []string{
+	"SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "IN", "IS", "NULL", "LIKE", "BETWEEN", "EXISTS",
+	"JOIN", "LEFT", "RIGHT", "INNER", "OUTER", "CROSS", "ON", "USING", "AS", "DISTINCT",
+	"GROUP BY", "ORDER BY", "HAVING", "LIMIT", "OFFSET", "ASC", "DESC", "UNION", "ALL",
+	"INSERT INTO", "VALUES", "UPDATE", "SET", "DELETE FROM", "CREATE TABLE", "ALTER TABLE", "DROP TABLE",
+	"CREATE INDEX", "PRIMARY KEY", "FOREIGN KEY", "REFERENCES", "DEFAULT", "CASE", "WHEN", "THEN", "ELSE", "END",
+	"COUNT", "SUM", "AVG", "MIN", "MAX", "COALESCE", "CAST", "WITH",
+}
+
 const (
-	queryCacheTTL        = 5 * time.Minute
-	queryCacheMaxEntries = 100
+	maxCompletions          = 100
+	queryCacheTTL           = 5 * time.Minute
+	queryCacheMaxEntries    = 100
+	schemaMetaWorkers       = 8  // concurrent table-schema fetches in LoadSchemaMetadata
+	schemaMetaProgressEvery = 25 // tables between schema-metadata-progress events
+	defaultMaxResultRows    = 10000
+	maxResultRowsLimit      = 1000000 // upper bound for SetMaxResultRows
+	defaultInsertBatchSize  = 100
+	mysqlInsertBatchSize    = 1000  // MySQL default; multi-row INSERTs are cheap there until the placeholder limit
+	maxInsertBatchSize      = 10000 // upper bound for SetInsertBatchSize
+	defaultSlowQueryMs      = 1000
+	defaultBackupTimeout    = 10 * time.Minute
+	maxBackupTimeoutMinutes = 24 * 60 // upper bound for SetBackupTimeout
+	defaultTxIdleTimeout    = 30 * time.Minute
+	defaultPoolIdleTime     = 5 * time.Minute  // db.ConnMaxIdleTime default
+	defaultPoolLifetime     = 30 * time.Minute // db.ConnMaxLifetime default
+	minKeepAliveSeconds     = 30               // bounds for SetKeepAliveInterval
+	maxKeepAliveSeconds     = 3600
+	keepAlivePingTimeout    = 10 * time.Second
 )
 
 const (
-	connFileName     = "connections.json"
-	historyFileName  = "query_history.json"
-	snippetsFileName = "snippets.json"
-	backupsFileName  = "backups.json"
-	maxBackupRecords = 50
-	encKey           = "topology-connection-key-2026" // In production, use a proper key management system
+	connFileName      = "connections.json"
+	vaultFileName     = "vault.json"
+	vaultCheckText    = "topology-vault"
+	keychainService   = "topology"
+	historyFileName   = "query_history.json"
+	snippetsFileName  = "snippets.json"
+	favoritesFileName = "favorites.json"
+	viewsFileName     = "view_settings.json"
+	draftsFileName    = "drafts.json"
+	draftMaxAge       = 72 * time.Hour // drafts not saved for this long are dropped
+	backupsFileName   = "backups.json"
+	maxBackupRecords  = 50
+	historySizeLimit  = 100000                         // upper bound for SetMaxHistorySize
+	encKey            = "topology-connection-key-2026" // In production, use a proper key management system
 )
 
 // BackupRecord holds one backup entry for listing and restore.
@@ -337,14 +685,60 @@ type BackupRecord struct {
 type BackupSchedule struct {
 	ConnectionID string `json:"connectionId"`
 	Enabled      bool   `json:"enabled"`
-	Schedule     string `json:"schedule"` // "daily" | "weekly"
-	Time         string `json:"time"`     // "HH:MM" 24h
-	Day          int    `json:"day"`      // 0=Sun..6=Sat for weekly
+	Schedule     string `json:"schedule"`       // "daily" | "weekly"
+	Time         string `json:"time"`           // "HH:MM" 24h
+	Day          int    `json:"day"`            // 0=Sun..6=Sat for weekly; Days takes precedence when set
+	Days         []int  `json:"days,omitempty"` // weekly on each of these days (0=Sun..6=Sat)
 	OutputDir    string `json:"outputDir,omitempty"`
-	LastRun      string `json:"lastRun,omitempty"` // RFC3339
+	Mode         string `json:"mode,omitempty"`     // "full" (default) | "schema" | "data"
+	KeepLast     int    `json:"keepLast,omitempty"` // keep only the newest N backups in OutputDir (0 = unlimited)
+	KeepDays     int    `json:"keepDays,omitempty"` // delete backups in OutputDir older than N days (0 = never)
+	LastRun      string `json:"lastRun,omitempty"`  // RFC3339
+}
+
+// AppSettings holds user preferences persisted in settings.json.
+type AppSettings struct {
+	ToolPaths      backup.ToolPaths `json:"toolPaths"`
+	MaxHistorySize int              `json:"maxHistorySize,omitempty"`
+	// MaxResultRows caps ad-hoc SELECT results; 0 uses the default, negative disables the cap.
+	MaxResultRows int `json:"maxResultRows,omitempty"`
+	// DisplayTimezone is "", "UTC", "Local" or an IANA zone name; see SetDisplayTimezone.
+	DisplayTimezone string `json:"displayTimezone,omitempty"`
+	// UseKeychain stores connection passwords in the OS keychain; see SetUseKeychain.
+	UseKeychain bool `json:"useKeychain,omitempty"`
+	// TxIdleTimeoutMinutes rolls back idle transactions; 0 uses the default, negative disables it.
+	TxIdleTimeoutMinutes int `json:"txIdleTimeoutMinutes,omitempty"`
+	// ConnectionRetries and ConnectionRetryDelayMs configure db.SetRetryPolicy; retries 0 keeps the defaults.
+	ConnectionRetries      int `json:"connectionRetries,omitempty"`
+	ConnectionRetryDelayMs int `json:"connectionRetryDelayMs,omitempty"`
+	// InsertBatchSize is the rows per INSERT for InsertTableRows and ImportData; 0 picks one per driver.
+	InsertBatchSize int `json:"insertBatchSize,omitempty"`
+	// SlowQueryThresholdMs is the duration above which history entries are flagged slow; 0 uses the default.
+	SlowQueryThresholdMs int `json:"slowQueryThresholdMs,omitempty"`
+	// BackupTimeoutMinutes limits how long one backup may run; 0 uses the default of 10 minutes.
+	BackupTimeoutMinutes int `json:"backupTimeoutMinutes,omitempty"`
+	// MutationSQLEvents emits "data-mutation" events with the SQL run by grid edits; see SetMutationSQLEvents.
+	MutationSQLEvents bool `json:"mutationSqlEvents,omitempty"`
+	// PoolIdleSeconds and PoolLifetimeSeconds configure db.SetPoolTimeouts; 0 keeps the default (5 and 30 minutes).
+	PoolIdleSeconds     int `json:"poolIdleSeconds,omitempty"`
+	PoolLifetimeSeconds int `json:"poolLifetimeSeconds,omitempty"`
+	// HistoryDisabled stops recording query history; see SetHistoryEnabled.
+	HistoryDisabled bool `json:"historyDisabled,omitempty"`
+	// NumericBooleans renders boolean columns as 0/1 instead of false/true; see SetNumericBooleans.
+	NumericBooleans bool `json:"numericBooleans,omitempty"`
+	// KeepAliveSeconds is how often idle pools are pinged; 0 (the default) turns keep-alive off.
+	KeepAliveSeconds int `json:"keepAliveSeconds,omitempty"`
 }
 
+var (
+	settingsMu       sync.Mutex
+	appSettings      AppSettings
+	settingsLoaded   bool
+	settingsFilePath string
+)
+
 const (
+	settingsFileName  = "settings.json"
 	schedulesFileName = "backup_schedules.json"
 	defaultBackupDir  = "backups"
 	auditFileName     = "audit.jsonl"
@@ -398,6 +792,100 @@ func appendAuditLog(op, detail, connectionID, database, table string) {
 	_ = f.Close()
 }
 
+// statementLogMaxBytes is the size at which a statement log is rotated: it moves to "<file>.1", replacing the
+// previous one, and a new file is started.
+const statementLogMaxBytes = 10 << 20
+
+var (
+	statementLogMu  sync.Mutex
+	statementLogDir string // logs/sql in the app dir unless set (tests)
+)
+
+// getStatementLogPath returns the SQL log file of connID. Caller holds statementLogMu.
+func getStatementLogPath(connID string) string {
+	if statementLogDir == "" {
+		statementLogDir = filepath.Join(getAppDir(), "logs", "sql")
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, connID)
+	return filepath.Join(statementLogDir, name+".sql")
+}
+
+// SetStatementLogging turns the statement log of a connection on or off. While on, every statement run through
+// the query editor, grid edits (once committed), table DDL and maintenance is appended to a per-connection SQL
+// file with its time, session, duration and outcome, so the sequence can be read back or replayed. Persisted.
+func (a *App) SetStatementLogging(connectionID string, enabled bool) error {
+	ensureConnectionsLoaded()
+	connMu.Lock()
+	defer connMu.Unlock()
+	for i := range connections {
+		if connections[i].ID == connectionID {
+			connections[i].LogStatements = enabled
+			return saveConnectionsToFile(connections)
+		}
+	}
+	return fmt.Errorf("connection not found")
+}
+
+// GetStatementLogPath returns the path of the connection's statement log (it may not exist yet).
+func (a *App) GetStatementLogPath(connectionID string) string {
+	statementLogMu.Lock()
+	defer statementLogMu.Unlock()
+	return getStatementLogPath(connectionID)
+}
+
+// logStatement appends sql to the statement log of connID if logging is on for it. A comment line carries the
+// time, session, duration (elapsedMs < 0 when unknown) and "ok" or "error: <errMsg>"; the statement follows,
+// terminated by ';', so the file reads as a script.
+func logStatement(connID, sessionID, sql string, elapsedMs int, errMsg string) {
+	if c := getConnByID(connID); c == nil || !c.LogStatements {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("-- " + time.Now().UTC().Format(time.RFC3339Nano))
+	if sessionID != "" {
+		b.WriteString(" session=" + strings.Join(strings.Fields(sessionID), "_"))
+	}
+	if elapsedMs >= 0 {
+		fmt.Fprintf(&b, " %dms", elapsedMs)
+	}
+	if errMsg == "" {
+		b.WriteString(" ok\n")
+	} else {
+		b.WriteString(" error: " + strings.Join(strings.Fields(errMsg), " ") + "\n")
+	}
+	b.WriteString(strings.TrimRight(strings.TrimSpace(sql), "; \t\r\n") + ";\n")
+
+	statementLogMu.Lock()
+	defer statementLogMu.Unlock()
+	path := getStatementLogPath(connID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		logger.Warn("statement log: %v", err)
+		return
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Size() >= statementLogMaxBytes {
+		_ = os.Rename(path, path+".1")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		logger.Warn("statement log: %v", err)
+		return
+	}
+	_, _ = f.WriteString(b.String())
+	_ = f.Close()
+}
+
+// logPlannedStatements logs committed table-operation statements with their arguments inlined.
+func logPlannedStatements(connID, sessionID, driver string, stmts []plannedStatement) {
+	for _, st := range stmts {
+		logStatement(connID, sessionID, inlineSQLArgs(driver, st.sql, st.args), -1, "")
+	}
+}
+
 // QueryAuditLog returns recent audit entries. limit cap 1000; since ISO8601 (optional); op filter (optional).
 func (a *App) QueryAuditLog(limit int, since, opFilter string) string {
 	if limit <= 0 || limit > 10000 {
@@ -514,6 +1002,45 @@ func getSnippetsFilePath() string {
 	return snippetsFilePath
 }
 
+func getTableViewsFilePath() string {
+	tableViewsFileOnce.Do(func() {
+		homeDir, err := os.UserConfigDir()
+		if err != nil {
+			homeDir = "."
+		}
+		appDir := filepath.Join(homeDir, "topology")
+		_ = os.MkdirAll(appDir, 0o755)
+		tableViewsFilePath = filepath.Join(appDir, viewsFileName)
+	})
+	return tableViewsFilePath
+}
+
+func getDraftsFilePath() string {
+	draftsFileOnce.Do(func() {
+		homeDir, err := os.UserConfigDir()
+		if err != nil {
+			homeDir = "."
+		}
+		appDir := filepath.Join(homeDir, "topology")
+		_ = os.MkdirAll(appDir, 0o755)
+		draftsFilePath = filepath.Join(appDir, draftsFileName)
+	})
+	return draftsFilePath
+}
+
+func getFavoritesFilePath() string {
+	favoritesFileOnce.Do(func() {
+		homeDir, err := os.UserConfigDir()
+		if err != nil {
+			homeDir = "."
+		}
+		appDir := filepath.Join(homeDir, "topology")
+		_ = os.MkdirAll(appDir, 0o755)
+		favoritesFilePath = filepath.Join(appDir, favoritesFileName)
+	})
+	return favoritesFilePath
+}
+
 func getBackupsFilePath() string {
 	if backupsFilePath == "" {
 		backupsFilePath = filepath.Join(getAppDir(), backupsFileName)
@@ -527,16 +1054,75 @@ func loadBackupRecords() []BackupRecord {
 		return nil
 	}
 	var recs []BackupRecord
-	_ = json.Unmarshal(data, &recs)
+	if err := json.Unmarshal(data, &recs); err != nil {
+		quarantineCorruptStore(getBackupsFilePath(), err)
+		return nil
+	}
 	return recs
 }
 
+// storeWarnings collects the messages of quarantineCorruptStore until the frontend fetches them.
+var storeWarningsMu sync.Mutex
+var storeWarnings []string
+
+// quarantineCorruptStore moves a JSON store that no longer parses to path.bak, so that the next save cannot
+// overwrite data the user may still recover by hand, and logs and records a warning for GetStoreWarnings.
+func quarantineCorruptStore(path string, parseErr error) {
+	name := filepath.Base(path)
+	msg := fmt.Sprintf("%s is corrupt (%v); it was moved to %s and an empty list is used", name, parseErr, name+".bak")
+	if err := os.Rename(path, path+".bak"); err != nil {
+		msg = fmt.Sprintf("%s is corrupt (%v) and could not be backed up: %v", name, parseErr, err)
+	}
+	logger.Error("%s", msg)
+	storeWarningsMu.Lock()
+	storeWarnings = append(storeWarnings, msg)
+	storeWarningsMu.Unlock()
+}
+
+// GetStoreWarnings returns, once, the warnings about corrupt data files that were set aside since the last call.
+func (a *App) GetStoreWarnings() []string {
+	storeWarningsMu.Lock()
+	defer storeWarningsMu.Unlock()
+	out := storeWarnings
+	storeWarnings = nil
+	if out == nil {
+		out = []string{}
+	}
+	return out
+}
+
+// writeFileAtomic replaces path with data so that a crash or full disk never leaves it truncated: data goes to a
+// temporary file in the same directory, which is synced and then renamed over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
 func saveBackupRecords(recs []BackupRecord) error {
 	data, err := json.MarshalIndent(recs, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(getBackupsFilePath(), data, 0o644)
+	return writeFileAtomic(getBackupsFilePath(), data, 0o644)
 }
 
 func appendBackupRecord(connID, path string) {
@@ -569,6 +1155,123 @@ func removeBackupRecord(path string) bool {
 	return false
 }
 
+// removeBackupRecords drops records whose path is in paths and saves once.
+func removeBackupRecords(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	drop := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		drop[p] = true
+	}
+	backupMu.Lock()
+	defer backupMu.Unlock()
+	if backupRecords == nil {
+		backupRecords = loadBackupRecords()
+	}
+	kept := backupRecords[:0]
+	for _, r := range backupRecords {
+		if !drop[r.Path] {
+			kept = append(kept, r)
+		}
+	}
+	backupRecords = kept
+	_ = saveBackupRecords(backupRecords)
+}
+
+// backupFile is one backup on disk considered for retention.
+type backupFile struct {
+	path string
+	at   time.Time
+}
+
+// connectionBackupFiles lists backups for connectionID. When dir is non-empty, only records inside dir are used and
+// dir is also scanned for scheduler-named files ("<namePrefix>-YYYYMMDD-HHMMSS.sql") that fell out of the record list.
+func connectionBackupFiles(connectionID, dir, namePrefix string) []backupFile {
+	backupMu.Lock()
+	if backupRecords == nil {
+		backupRecords = loadBackupRecords()
+	}
+	recs := make([]BackupRecord, len(backupRecords))
+	copy(recs, backupRecords)
+	backupMu.Unlock()
+
+	seen := make(map[string]bool)
+	var files []backupFile
+	for _, r := range recs {
+		if r.ConnectionID != connectionID || seen[r.Path] {
+			continue
+		}
+		if dir != "" && filepath.Clean(filepath.Dir(r.Path)) != filepath.Clean(dir) {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, r.At)
+		if err != nil {
+			continue
+		}
+		seen[r.Path] = true
+		files = append(files, backupFile{path: r.Path, at: at})
+	}
+	if dir == "" || namePrefix == "" {
+		return files
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, namePrefix+"-") || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, namePrefix+"-"), ".sql")
+		at, err := time.ParseInLocation("20060102-150405", stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		files = append(files, backupFile{path: path, at: at})
+	}
+	return files
+}
+
+// pruneBackupFiles deletes files beyond the newest keepLast and files older than keepDays (0 disables each rule),
+// then removes their records. Returns the removed paths.
+func pruneBackupFiles(files []backupFile, keepLast, keepDays int, now time.Time) []string {
+	if keepLast <= 0 && keepDays <= 0 {
+		return nil
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].at.After(files[j].at) })
+	cutoff := now.AddDate(0, 0, -keepDays)
+	var removed []string
+	for i, f := range files {
+		if !(keepLast > 0 && i >= keepLast) && !(keepDays > 0 && f.at.Before(cutoff)) {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("prune backup %s: %v", f.path, err)
+			continue
+		}
+		removed = append(removed, f.path)
+	}
+	removeBackupRecords(removed)
+	return removed
+}
+
+// safeFileName replaces characters that are not allowed in file names.
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '/' || r == '\\' || r == ':' {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
 func getSchedulesFilePath() string {
 	if schedulesFilePath == "" {
 		schedulesFilePath = filepath.Join(getAppDir(), schedulesFileName)
@@ -582,7 +1285,10 @@ func loadBackupSchedules() []BackupSchedule {
 		return nil
 	}
 	var s []BackupSchedule
-	_ = json.Unmarshal(data, &s)
+	if err := json.Unmarshal(data, &s); err != nil {
+		quarantineCorruptStore(getSchedulesFilePath(), err)
+		return nil
+	}
 	return s
 }
 
@@ -591,11 +1297,56 @@ func saveBackupSchedules(s []BackupSchedule) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(getSchedulesFilePath(), data, 0o644)
+	return writeFileAtomic(getSchedulesFilePath(), data, 0o644)
+}
+
+func getSettingsFilePath() string {
+	if settingsFilePath == "" {
+		settingsFilePath = filepath.Join(getAppDir(), settingsFileName)
+	}
+	return settingsFilePath
+}
+
+// getSettings returns a copy of the settings, loading settings.json on first use.
+func getSettings() AppSettings {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	return loadSettingsLocked()
+}
+
+// loadSettingsLocked returns the settings, reading settings.json on first use; caller holds settingsMu.
+func loadSettingsLocked() AppSettings {
+	if !settingsLoaded {
+		if data, err := os.ReadFile(getSettingsFilePath()); err == nil {
+			_ = json.Unmarshal(data, &appSettings)
+		}
+		settingsLoaded = true
+	}
+	return appSettings
+}
+
+// updateSettings applies fn to the settings and persists the result. The whole load-modify-save runs under
+// settingsMu so concurrent setters cannot overwrite each other's changes.
+func updateSettings(fn func(s *AppSettings)) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	s := loadSettingsLocked()
+	fn(&s)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	appSettings = s
+	return writeFileAtomic(getSettingsFilePath(), data, 0o600)
 }
 
+// errBackupCancelled is returned by backupToPath when CancelBackup stopped the dump.
+var errBackupCancelled = errors.New("backup cancelled")
+
 // backupToPath runs backup for connectionID to outputPath, appends record. Caller ensures path is absolute.
-func backupToPath(connectionID, outputPath string) error {
+// mode is passed to backup.Conn.Mode ("full", "schema", "data"; empty means full). One backup per connection
+// runs at a time; it is stopped by CancelBackup or after the backup timeout, and its partial file removed.
+func backupToPath(connectionID, outputPath, mode string) error {
 	conn := getConnByID(connectionID)
 	if conn == nil {
 		return fmt.Errorf("connection not found")
@@ -606,15 +1357,39 @@ func backupToPath(connectionID, outputPath string) error {
 	}
 	pc := &backup.Conn{
 		Type:     ty,
-		Host:     conn.Host,
+		Host:     backupHost(conn),
 		Port:     conn.Port,
 		Username: conn.Username,
 		Password: conn.Password,
 		Database: conn.Database,
+		Mode:     mode,
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
+	if ty == "sqlite" {
+		// lets the backup run without the sqlite3 CLI
+		pc.DB, _ = openSessionDB(connectionID, "", false)
+	}
+	backupRunMu.Lock()
+	if backupCancels[connectionID] != nil {
+		backupRunMu.Unlock()
+		return fmt.Errorf("a backup of this connection is already running")
+	}
+	timeout := backupTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	backupCancels[connectionID] = cancel
+	backupRunMu.Unlock()
+	defer func() {
+		backupRunMu.Lock()
+		delete(backupCancels, connectionID)
+		backupRunMu.Unlock()
+		cancel()
+	}()
 	if err := backup.RunBackup(ctx, pc, outputPath); err != nil {
+		switch ctx.Err() {
+		case context.Canceled:
+			return errBackupCancelled
+		case context.DeadlineExceeded:
+			return fmt.Errorf("backup timed out after %s; raise the backup timeout in settings", timeout)
+		}
 		return err
 	}
 	appendBackupRecord(connectionID, outputPath)
@@ -623,6 +1398,7 @@ func backupToPath(connectionID, outputPath string) error {
 
 // loadConnectionsFromFile returns (connections, fileExisted). When fileExisted is true, use the result
 // (even if empty); when false, use empty list so that explicit "no connections" is respected.
+// A file that does not parse is set aside by quarantineCorruptStore and reported as not existing.
 func loadConnectionsFromFile() ([]Connection, bool) {
 	filePath := getConnectionsFilePath()
 	data, err := os.ReadFile(filePath)
@@ -631,13 +1407,32 @@ func loadConnectionsFromFile() ([]Connection, bool) {
 	}
 	var connections []Connection
 	if err := json.Unmarshal(data, &connections); err != nil {
+		quarantineCorruptStore(filePath, err)
 		return nil, false
 	}
-	// Decrypt passwords
+	// Decrypt passwords. Entries still sealed with the built-in key (written before a master password
+	// was set) are accepted too, so an interrupted migration never loses a password.
 	for i := range connections {
+		// no pool outlives the process
+		connections[i].Status = "disconnected"
+		if dsn := connections[i].DSN; dsn != "" {
+			if decrypted, err := decryptPassword(dsn); err == nil {
+				connections[i].DSN = decrypted
+			}
+		}
+		if connections[i].PasswordInKeychain {
+			if pw, err := keychainGet(connections[i].ID); err == nil {
+				connections[i].Password = pw
+			} else {
+				logger.Warn("read password for connection %s from keychain: %v", connections[i].ID, err)
+			}
+			continue
+		}
 		if connections[i].Password != "" {
 			if decrypted, err := decryptPassword(connections[i].Password); err == nil {
 				connections[i].Password = decrypted
+			} else if decrypted, err := decryptWithKey(legacyEncryptionKey(), connections[i].Password); err == nil {
+				connections[i].Password = decrypted
 			}
 		}
 	}
@@ -645,14 +1440,52 @@ func loadConnectionsFromFile() ([]Connection, bool) {
 }
 
 func saveConnectionsToFile(connections []Connection) error {
+	if vaultLocked() {
+		return fmt.Errorf("vault is locked")
+	}
+	return saveConnectionsWithKey(connections, getEncryptionKey())
+}
+
+// saveConnectionsWithKey writes connections.json with passwords and DSNs sealed under key.
+func saveConnectionsWithKey(connections []Connection, key []byte) error {
 	// Create a copy to encrypt passwords
 	saveConnections := make([]Connection, len(connections))
 	copy(saveConnections, connections)
+	keychain := keychainEnabled()
 	for i := range saveConnections {
-		if saveConnections[i].Password != "" {
-			if encrypted, err := encryptPassword(saveConnections[i].Password); err == nil {
-				saveConnections[i].Password = encrypted
+		c := &saveConnections[i]
+		// a DSN may hold the password; it is always sealed in the file
+		if c.DSN != "" {
+			if encrypted, err := encryptWithKey(key, c.DSN); err == nil {
+				c.DSN = encrypted
+			}
+		}
+		// An empty password with PasswordInKeychain set is an entry that could not be read at load
+		// time; keep the flag so the secret is not orphaned. If the keychain did hold a password for it,
+		// the user cleared it: drop the entry so it is not loaded again on the next start.
+		if c.Password == "" {
+			keychainMu.Lock()
+			_, synced := keychainSynced[c.ID]
+			keychainMu.Unlock()
+			if synced {
+				keychainDelete(c.ID)
+				c.PasswordInKeychain = false
+				connections[i].PasswordInKeychain = false
+			}
+			continue
+		}
+		if keychain {
+			err := keychainSet(c.ID, c.Password)
+			if err == nil {
+				c.Password = ""
+				c.PasswordInKeychain = true
+				continue
 			}
+			logger.Warn("store password for connection %s in keychain, using encrypted file: %v", c.ID, err)
+		}
+		c.PasswordInKeychain = false
+		if encrypted, err := encryptWithKey(key, c.Password); err == nil {
+			c.Password = encrypted
 		}
 	}
 	data, err := json.MarshalIndent(saveConnections, "", "  ")
@@ -660,11 +1493,16 @@ func saveConnectionsToFile(connections []Connection) error {
 		return err
 	}
 	filePath := getConnectionsFilePath()
-	return os.WriteFile(filePath, data, 0o600)
+	return writeFileAtomic(filePath, data, 0o600)
 }
 
 // ensureConnectionsLoaded loads connections from file once; if file is missing or invalid, keeps list empty.
+// While a master password is set and not yet entered, nothing is loaded so that a later save cannot
+// overwrite the file with undecrypted passwords.
 func ensureConnectionsLoaded() {
+	if vaultLocked() {
+		return
+	}
 	connectionsLoadOnce.Do(func() {
 		connMu.Lock()
 		defer connMu.Unlock()
@@ -700,22 +1538,183 @@ func requireWritableConnection(connectionID string) error {
 	return nil
 }
 
-func buildDSN(c *Connection) (string, error) {
-	return db.BuildDSN(c.Type, c.Host, c.Port, c.Username, c.Password, c.Database)
-}
-
-// effectiveHostPort returns (host, port) for building DSN. When SSH tunnel is enabled for MySQL, starts tunnel and returns 127.0.0.1:localPort.
-func effectiveHostPort(connID string, c *Connection) (host string, port int, err error) {
-	host, port = c.Host, c.Port
-	if c.Type != "mysql" {
-		return host, port, nil
+// buildDSN builds the DSN for c reached at host:port. DefaultSchema is part of the DSN so it applies to
+// every pooled connection: for MySQL it replaces the database, for PostgreSQL it sets search_path.
+// SQLite connections get a busy timeout and optional journal mode; read-only ones open the file with mode=ro.
+// A connection with a DSN uses it as is.
+func buildDSN(c *Connection, host string, port int) (string, error) {
+	if c.DSN != "" {
+		_, dsn, err := db.DSNDriver(c.DSN)
+		return dsn, err
 	}
-	if c.SSHTunnel == nil || !c.SSHTunnel.Enabled {
-		return host, port, nil
+	database := c.Database
+	if c.Type == "mysql" && c.DefaultSchema != "" {
+		database = c.DefaultSchema
 	}
-	sshPort := c.SSHTunnel.Port
-	if sshPort <= 0 {
-		sshPort = 22
+	if c.Type == "mysql" && c.Socket != "" {
+		host = c.Socket
+	}
+	dsn, err := db.BuildDSN(c.Type, host, port, c.Username, c.Password, database)
+	if err != nil {
+		return "", err
+	}
+	if (c.Type == "postgresql" || c.Type == "postgres") && c.DefaultSchema != "" {
+		dsn += " search_path='" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(c.DefaultSchema) + "'"
+	}
+	if c.Charset != "" && c.Type != "sqlite" {
+		cs, err := db.Charset(c.Charset)
+		if err != nil {
+			return "", err
+		}
+		if c.Type == "mysql" {
+			dsn = strings.Replace(dsn, "charset="+db.DefaultMySQLCharset, "charset="+cs, 1)
+		} else {
+			dsn += " client_encoding=" + cs
+		}
+	}
+	if c.Type != "sqlite" {
+		if dsn, err = db.WithDSNOptions(c.Type, dsn, c.Options); err != nil {
+			return "", err
+		}
+		name := c.ApplicationName
+		if name == "" {
+			name = db.DefaultApplicationName
+			if c.Name != "" {
+				name += " - " + c.Name
+			}
+		}
+		if dsn, err = db.WithClientName(c.Type, dsn, name); err != nil {
+			return "", err
+		}
+	}
+	if c.Type == "sqlite" {
+		var params []string
+		if c.ReadOnly {
+			// SQLite itself refuses writes, so a file held open by another process is never modified. A file we
+			// cannot write at all (read-only media) is also opened immutable: no lock or -shm file is attempted.
+			params = append(params, "mode", "ro")
+			if _, err := os.Stat(dsn); err == nil {
+				if f, err := os.OpenFile(dsn, os.O_RDWR, 0); err != nil {
+					params = append(params, "immutable", "1")
+				} else {
+					f.Close()
+				}
+			}
+		}
+		// The driver runs these PRAGMAs on every pooled connection right after it opens.
+		busy := c.SQLiteBusyTimeout
+		if busy <= 0 {
+			busy = db.DefaultSQLiteBusyTimeout
+		}
+		params = append(params, "_busy_timeout", strconv.Itoa(busy))
+		if c.SQLiteJournalMode != "" && !c.ReadOnly {
+			mode, err := db.SQLiteJournalMode(c.SQLiteJournalMode)
+			if err != nil {
+				return "", err
+			}
+			params = append(params, "_journal_mode", mode)
+		}
+		keys := make([]string, 0, len(c.Options))
+		for k := range c.Options {
+			if err := db.DSNOptionKey(k); err != nil {
+				return "", err
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			// The driver reads the first value of a repeated key, so an option replaces ours in place.
+			i := 0
+			for i < len(params) && params[i] != k {
+				i += 2
+			}
+			if i < len(params) {
+				params[i+1] = c.Options[k]
+			} else {
+				params = append(params, k, c.Options[k])
+			}
+		}
+		dsn = db.SQLiteURI(dsn, params...)
+	}
+	return dsn, nil
+}
+
+// usesSSHTunnel reports whether c reaches the database through an SSH tunnel (MySQL only, and not with a DSN,
+// whose host cannot be redirected, nor over a Unix socket).
+func usesSSHTunnel(c *Connection) bool {
+	return c.Type == "mysql" && c.DSN == "" && mysqlSocket(c) == "" && c.SSHTunnel != nil && c.SSHTunnel.Enabled
+}
+
+// mysqlSocket returns the Unix socket path a MySQL connection uses, or "" for TCP.
+func mysqlSocket(c *Connection) string {
+	if c.Type != "mysql" {
+		return ""
+	}
+	if c.Socket != "" {
+		return c.Socket
+	}
+	if strings.HasPrefix(c.Host, "/") {
+		return c.Host
+	}
+	return ""
+}
+
+// backupHost is the host handed to the dump tools: the socket path for a MySQL socket connection.
+func backupHost(c *Connection) string {
+	if s := mysqlSocket(c); s != "" {
+		return s
+	}
+	return c.Host
+}
+
+// applyDSN sets the driver and the connection fields of c from its DSN, if any.
+func applyDSN(c *Connection) error {
+	if c.DSN == "" {
+		return nil
+	}
+	info, err := db.ParseDSN(c.DSN)
+	if err != nil {
+		return err
+	}
+	c.Type, c.Host, c.Port, c.Username, c.Password, c.Database = info.Driver, info.Host, info.Port, info.User, info.Password, info.Database
+	return nil
+}
+
+// ParseDSN splits a pasted connection string into the fields of a connection, to pre-fill the connection form.
+// Returns Connection JSON with type, host, port, username, password, database and the remaining parameters as
+// options (useSSL is set when they ask for TLS).
+func (a *App) ParseDSN(dsn string) (string, error) {
+	info, err := db.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	conn := Connection{
+		Type:     info.Driver,
+		Host:     info.Host,
+		Port:     info.Port,
+		Username: info.User,
+		Password: info.Password,
+		Database: info.Database,
+		Options:  info.Options,
+	}
+	switch strings.ToLower(info.Options["sslmode"] + info.Options["tls"]) {
+	case "", "disable", "false":
+	default:
+		conn.UseSSL = true
+	}
+	data, err := json.Marshal(conn)
+	return string(data), err
+}
+
+// effectiveHostPort returns (host, port) for building DSN. When SSH tunnel is enabled for MySQL, starts tunnel and returns 127.0.0.1:localPort.
+func effectiveHostPort(connID string, c *Connection) (host string, port int, err error) {
+	host, port = c.Host, c.Port
+	if !usesSSHTunnel(c) {
+		return host, port, nil
+	}
+	sshPort := c.SSHTunnel.Port
+	if sshPort <= 0 {
+		sshPort = 22
 	}
 	localPort, err := sshtunnel.GetOrStart(connID, sshtunnel.Config{
 		SSHHost:     c.SSHTunnel.Host,
@@ -746,11 +1745,31 @@ func txKey(connID, sessionID string) string {
 func getOrOpenDB(connID, sessionID string) (*gorm.DB, error) {
 	txMu.Lock()
 	if tx := activeTx[txKey(connID, sessionID)]; tx != nil {
+		txLastUsed[txKey(connID, sessionID)] = time.Now()
 		txMu.Unlock()
 		return tx, nil
 	}
 	txMu.Unlock()
+	return openSessionDB(connID, sessionID, false)
+}
+
+// multiStatementSession is the cache session of the separate MySQL pool that accepts multi-statement scripts.
+// Regular pools never do, so a "SELECT ...; DELETE ..." cannot slip past the read-only and SELECT checks.
+func multiStatementSession(sessionID string) string {
+	return sessionID + "\x00multi"
+}
+
+// closeSessionDB closes the session's pooled connections, including its multi-statement pool.
+func closeSessionDB(connID, sessionID string) {
+	// a pool waits for open rows before it closes
+	closeCursors(connID, sessionID)
+	db.Close(connID, sessionID)
+	db.Close(connID, multiStatementSession(sessionID))
+}
 
+// openSessionDB opens (or reuses) the pooled DB for the connection and session, ignoring transactions.
+// multiStatements selects the MySQL pool that runs scripts; see multiStatementSession.
+func openSessionDB(connID, sessionID string, multiStatements bool) (*gorm.DB, error) {
 	conn := getConnByID(connID)
 	if conn == nil {
 		return nil, fmt.Errorf("connection not found: %s", connID)
@@ -759,22 +1778,120 @@ func getOrOpenDB(connID, sessionID string) (*gorm.DB, error) {
 	if driver != "mysql" && driver != "sqlite" && driver != "postgresql" && driver != "postgres" {
 		return nil, fmt.Errorf("unsupported driver: %s (mysql/postgresql/sqlite)", driver)
 	}
+	sessionDBMu.Lock()
+	if name, ok := sessionDatabase[txKey(connID, sessionID)]; ok {
+		conn.Database = name
+		if driver == "mysql" {
+			conn.DefaultSchema = "" // it would replace the database again
+		}
+	}
+	attach := sessionAttachments[txKey(connID, sessionID)]
+	sessionDBMu.Unlock()
 	host, port, err := effectiveHostPort(connID, conn)
 	if err != nil {
 		return nil, err
 	}
-	dsn, err := db.BuildDSN(driver, host, port, conn.Username, conn.Password, conn.Database)
+	dsn, err := buildDSN(conn, host, port)
 	if err != nil {
 		return nil, err
 	}
-	if g, ok := db.Get(connID, sessionID); ok {
+	cacheSession := sessionID
+	if multiStatements && driver == "mysql" {
+		cacheSession = multiStatementSession(sessionID)
+		dsn = db.MultiStatementDSN(dsn)
+	}
+	if g, ok := db.Get(connID, cacheSession); ok {
 		sqlDB, err := g.DB()
 		if err == nil && sqlDB.Ping() == nil {
 			return g, nil
 		}
-		db.Close(connID, sessionID)
+		db.Close(connID, cacheSession)
+	}
+	g, err := db.Open(connID, cacheSession, driver, dsn, attach...)
+	if err != nil && usesSSHTunnel(conn) && isTunnelDrop(err) && !sshtunnel.Alive(connID, tunnelProbeTimeout) {
+		// The jump host dropped the tunnel since GetOrStart last checked it: rebuild it and retry once.
+		sshtunnel.Stop(connID)
+		if host, port, err = effectiveHostPort(connID, conn); err != nil {
+			return nil, err
+		}
+		if dsn, err = buildDSN(conn, host, port); err != nil {
+			return nil, err
+		}
+		if cacheSession != sessionID {
+			dsn = db.MultiStatementDSN(dsn)
+		}
+		g, err = db.Open(connID, cacheSession, driver, dsn, attach...)
+	}
+	if err == nil {
+		touchLastConnected(connID)
+	}
+	return g, err
+}
+
+// lastConnectedSaveDelay batches LastConnectedAt updates, so opening pools for several tabs (or reconnecting
+// often) writes connections.json once.
+const lastConnectedSaveDelay = 30 * time.Second
+
+var (
+	lastConnectedMu    sync.Mutex
+	lastConnectedTimer *time.Timer // non-nil while a save is pending
+)
+
+// touchLastConnected marks connID connected with LastConnectedAt now and schedules a save of connections.json.
+func touchLastConnected(connID string) {
+	now := time.Now().Format(time.RFC3339)
+	connMu.Lock()
+	for i := range connections {
+		if connections[i].ID == connID {
+			connections[i].LastConnectedAt = now
+			connections[i].Status = "connected"
+		}
+	}
+	connMu.Unlock()
+	lastConnectedMu.Lock()
+	if lastConnectedTimer == nil {
+		lastConnectedTimer = time.AfterFunc(lastConnectedSaveDelay, flushLastConnected)
+	}
+	lastConnectedMu.Unlock()
+}
+
+// flushLastConnected saves connections.json now if a LastConnectedAt update is pending.
+func flushLastConnected() {
+	lastConnectedMu.Lock()
+	pending := lastConnectedTimer != nil
+	if pending {
+		lastConnectedTimer.Stop()
+		lastConnectedTimer = nil
+	}
+	lastConnectedMu.Unlock()
+	if !pending {
+		return
+	}
+	connMu.Lock()
+	err := saveConnectionsToFile(connections)
+	connMu.Unlock()
+	if err != nil {
+		logger.Warn("save last connected times: %v", err)
+	}
+}
+
+// tunnelProbeTimeout bounds the keepalive that decides whether a failed open should rebuild the SSH tunnel.
+const tunnelProbeTimeout = 3 * time.Second
+
+// isTunnelDrop reports whether err is a transport failure that a dead SSH tunnel would cause.
+// Server-side errors (lock wait timeouts, auth, unknown database) and query deadlines never match;
+// callers still confirm with sshtunnel.Alive before rebuilding.
+func isTunnelDrop(err error) bool {
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	low := strings.ToLower(err.Error())
+	for _, s := range []string{"connection refused", "connection reset", "broken pipe", "bad connection", "i/o timeout"} {
+		if strings.Contains(low, s) {
+			return true
+		}
 	}
-	return db.Open(connID, sessionID, driver, dsn)
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || strings.HasSuffix(low, "eof")
 }
 
 // BeginTx starts a transaction for the given connection and session. Fails if one is already active.
@@ -782,6 +1899,21 @@ func (a *App) BeginTx(connectionID, sessionID string) error {
 	if err := requireWritableConnection(connectionID); err != nil {
 		return err
 	}
+	return beginTx(connectionID, sessionID, false)
+}
+
+// BeginReadOnlyTx starts a READ ONLY transaction for the connection and session (PostgreSQL BEGIN READ ONLY,
+// MySQL START TRANSACTION READ ONLY; SQLite has no such mode and gets a deferred transaction). Statements of the
+// session then see one consistent snapshot, and write statements are refused with errReadOnlyTx until it is
+// committed or rolled back. It is allowed on read-only connections.
+func (a *App) BeginReadOnlyTx(connectionID, sessionID string) error {
+	if getConnByID(connectionID) == nil {
+		return fmt.Errorf("connection not found")
+	}
+	return beginTx(connectionID, sessionID, true)
+}
+
+func beginTx(connectionID, sessionID string, readOnly bool) error {
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
 		return err
@@ -792,31 +1924,90 @@ func (a *App) BeginTx(connectionID, sessionID string) error {
 	if activeTx[key] != nil {
 		return fmt.Errorf("transaction already active")
 	}
-	tx := g.Begin()
+	var opts []*sql.TxOptions
+	if readOnly {
+		opts = append(opts, &sql.TxOptions{ReadOnly: true})
+	}
+	tx := g.Begin(opts...)
 	if tx.Error != nil {
 		return tx.Error
 	}
 	activeTx[key] = tx
+	txLastUsed[key] = time.Now()
+	txPools[tx.Statement.ConnPool] = key
+	if readOnly {
+		txReadOnly[key] = true
+	}
+	return nil
+}
+
+// errReadOnlyTx is returned for write statements in a session whose transaction was begun with BeginReadOnlyTx.
+const errReadOnlyTx = "transaction is read-only; commit or roll back it before writing"
+
+// inReadOnlyTx reports whether the session has an active transaction begun with BeginReadOnlyTx.
+func inReadOnlyTx(connectionID, sessionID string) bool {
+	txMu.Lock()
+	defer txMu.Unlock()
+	return txReadOnly[txKey(connectionID, sessionID)]
+}
+
+// requireWritableSession is requireWritableConnection that also refuses sessions inside a read-only transaction.
+func requireWritableSession(connectionID, sessionID string) error {
+	if err := requireWritableConnection(connectionID); err != nil {
+		return err
+	}
+	if inReadOnlyTx(connectionID, sessionID) {
+		return errors.New(errReadOnlyTx)
+	}
 	return nil
 }
 
+// The statement hooks mark a transaction busy while one of its statements runs and refresh its last use when the
+// statement finishes, so the idle reaper never rolls back a running statement. They are registered on every pool
+// as it is opened.
+func init() {
+	db.SetStatementHooks(txStatementStart, txStatementFinish)
+}
+
+func txStatementStart(d *gorm.DB) {
+	txMu.Lock()
+	defer txMu.Unlock()
+	if key, ok := txPools[d.Statement.ConnPool]; ok {
+		txBusy[key]++
+	}
+}
+
+func txStatementFinish(d *gorm.DB) {
+	txMu.Lock()
+	defer txMu.Unlock()
+	if key, ok := txPools[d.Statement.ConnPool]; ok {
+		if txBusy[key]--; txBusy[key] <= 0 {
+			delete(txBusy, key)
+		}
+		txLastUsed[key] = time.Now()
+	}
+}
+
 // CommitTx commits the active transaction for the connection+session.
 func (a *App) CommitTx(connectionID, sessionID string) error {
 	txMu.Lock()
 	tx := activeTx[txKey(connectionID, sessionID)]
-	delete(activeTx, txKey(connectionID, sessionID))
+	forgetTxLocked(txKey(connectionID, sessionID))
 	txMu.Unlock()
 	if tx == nil {
 		return fmt.Errorf("no active transaction")
 	}
-	return tx.Commit().Error
+	err := tx.Commit().Error
+	// results cached before or outside the transaction predate its changes
+	clearQueryCacheForConnection(connectionID)
+	return err
 }
 
 // RollbackTx rolls back the active transaction for the connection+session.
 func (a *App) RollbackTx(connectionID, sessionID string) error {
 	txMu.Lock()
 	tx := activeTx[txKey(connectionID, sessionID)]
-	delete(activeTx, txKey(connectionID, sessionID))
+	forgetTxLocked(txKey(connectionID, sessionID))
 	txMu.Unlock()
 	if tx == nil {
 		return fmt.Errorf("no active transaction")
@@ -824,18 +2015,259 @@ func (a *App) RollbackTx(connectionID, sessionID string) error {
 	return tx.Rollback().Error
 }
 
-// GetTransactionStatus returns JSON {"active": true|false} for the connection+session.
+// forgetTxLocked drops the bookkeeping for the transaction under key; caller holds txMu.
+func forgetTxLocked(key string) {
+	if tx := activeTx[key]; tx != nil {
+		delete(txPools, tx.Statement.ConnPool)
+	}
+	delete(activeTx, key)
+	delete(txLastUsed, key)
+	delete(txBusy, key)
+	delete(txReadOnly, key)
+}
+
+// GetTransactionStatus returns JSON {"active": true|false, "readOnly": true|false} for the connection+session.
 func (a *App) GetTransactionStatus(connectionID, sessionID string) string {
 	txMu.Lock()
-	active := activeTx[txKey(connectionID, sessionID)] != nil
+	key := txKey(connectionID, sessionID)
+	active := activeTx[key] != nil
+	readOnly := txReadOnly[key]
 	txMu.Unlock()
 	out := struct {
-		Active bool `json:"active"`
-	}{Active: active}
+		Active   bool `json:"active"`
+		ReadOnly bool `json:"readOnly"`
+	}{Active: active, ReadOnly: readOnly}
 	b, _ := json.Marshal(out)
 	return string(b)
 }
 
+// reapIdleTx rolls back transactions unused since before now-timeout and returns their keys. Transactions with
+// a statement still running are skipped however long it takes.
+func reapIdleTx(now time.Time, timeout time.Duration) []string {
+	txMu.Lock()
+	var reaped []string
+	var txs []*gorm.DB
+	for k, at := range txLastUsed {
+		if now.Sub(at) > timeout && txBusy[k] == 0 {
+			reaped = append(reaped, k)
+			txs = append(txs, activeTx[k])
+			forgetTxLocked(k)
+		}
+	}
+	txMu.Unlock()
+	for i, tx := range txs {
+		if tx == nil {
+			continue
+		}
+		if err := tx.Rollback().Error; err != nil {
+			logger.Warn("rollback idle transaction %q: %v", reaped[i], err)
+		}
+	}
+	return reaped
+}
+
+// runTxReaper periodically rolls back transactions idle longer than txIdleTimeout so that a forgotten
+// BeginTx does not hold locks indefinitely, and emits transaction-timeout for each one.
+func (a *App) runTxReaper() {
+	tick := time.NewTicker(30 * time.Second)
+	defer tick.Stop()
+	for range tick.C {
+		txMu.Lock()
+		timeout := txIdleTimeout
+		txMu.Unlock()
+		if timeout <= 0 {
+			continue
+		}
+		for _, k := range reapIdleTx(time.Now(), timeout) {
+			connID, sessionID, _ := strings.Cut(k, "\x00")
+			logger.Warn("rolled back transaction idle for more than %s (connection %s, session %q)", timeout, connID, sessionID)
+			runtime.EventsEmit(a.ctx, "transaction-timeout", map[string]string{"connectionId": connID, "sessionId": sessionID})
+		}
+	}
+}
+
+// SetTxIdleTimeout sets after how many minutes an unused transaction is rolled back automatically
+// (default 30). minutes <= 0 disables the timeout. The setting is persisted.
+func (a *App) SetTxIdleTimeout(minutes int) error {
+	if minutes <= 0 {
+		minutes = -1
+	}
+	if err := updateSettings(func(s *AppSettings) { s.TxIdleTimeoutMinutes = minutes }); err != nil {
+		return err
+	}
+	txMu.Lock()
+	txIdleTimeout = time.Duration(minutes) * time.Minute
+	txMu.Unlock()
+	return nil
+}
+
+// UseDatabase makes database the current database of the connection's session, so unqualified names and
+// DATABASE()-based schema lookups resolve against it. A plain USE would only reach one connection of the pool,
+// so the session is reopened with database in its DSN instead (for PostgreSQL that is the only way to switch).
+// Empty database goes back to the connection's configured one. The connection itself is not modified; see
+// SwitchPgDatabase for that.
+func (a *App) UseDatabase(connectionID, sessionID, database string) error {
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found")
+	}
+	if conn.Type != "mysql" && conn.Type != "postgresql" && conn.Type != "postgres" {
+		return fmt.Errorf("switching database is not supported for %s", conn.Type)
+	}
+	if conn.DSN != "" {
+		return errDSNDatabase
+	}
+	key := txKey(connectionID, sessionID)
+	txMu.Lock()
+	inTx := activeTx[key] != nil
+	txMu.Unlock()
+	if inTx {
+		return fmt.Errorf("commit or roll back the transaction before switching database")
+	}
+	sessionDBMu.Lock()
+	prev, hadPrev := sessionDatabase[key]
+	if database == "" {
+		delete(sessionDatabase, key)
+	} else {
+		sessionDatabase[key] = database
+	}
+	sessionDBMu.Unlock()
+	closeSessionDB(connectionID, sessionID)
+	// cached results are keyed by connection, not database
+	clearQueryCacheForConnection(connectionID)
+	if _, err := getOrOpenDB(connectionID, sessionID); err != nil {
+		sessionDBMu.Lock()
+		if hadPrev {
+			sessionDatabase[key] = prev
+		} else {
+			delete(sessionDatabase, key)
+		}
+		sessionDBMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// errDSNDatabase is returned when switching the database of a connection defined by a DSN, which names it.
+var errDSNDatabase = errors.New("the database of a connection string cannot be switched; edit the connection string instead")
+
+// clearSessionDatabases forgets UseDatabase choices and attached SQLite databases for all sessions of the connection.
+func clearSessionDatabases(connID string) {
+	sessionDBMu.Lock()
+	defer sessionDBMu.Unlock()
+	prefix := connID + "\x00"
+	for k := range sessionDatabase {
+		if k == connID || strings.HasPrefix(k, prefix) {
+			delete(sessionDatabase, k)
+		}
+	}
+	for k := range sessionAttachments {
+		if k == connID || strings.HasPrefix(k, prefix) {
+			delete(sessionAttachments, k)
+		}
+	}
+}
+
+// sqliteAliasRegex matches the schema names AttachDatabase accepts.
+var sqliteAliasRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// AttachDatabase attaches the SQLite file at filePath to the connection's session as alias, so queries can
+// reach its tables as alias.table and GetDatabases/GetTables list it. Like UseDatabase, the session is reopened
+// rather than running ATTACH once, because ATTACH only reaches one connection of the pool.
+func (a *App) AttachDatabase(connectionID, sessionID, filePath, alias string) error {
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found")
+	}
+	if conn.Type != "sqlite" {
+		return fmt.Errorf("attaching databases is only supported for SQLite")
+	}
+	if !sqliteAliasRegex.MatchString(alias) || strings.EqualFold(alias, "main") || strings.EqualFold(alias, "temp") {
+		return fmt.Errorf("invalid alias %q: use letters, digits and underscores, not main or temp", alias)
+	}
+	path, err := filepath.Abs(strings.TrimSpace(filePath))
+	if err != nil {
+		return err
+	}
+	if st, err := os.Stat(path); err != nil || st.IsDir() {
+		return fmt.Errorf("database file not found: %s", filePath)
+	}
+	key := txKey(connectionID, sessionID)
+	txMu.Lock()
+	inTx := activeTx[key] != nil
+	txMu.Unlock()
+	if inTx {
+		return fmt.Errorf("commit or roll back the transaction before attaching a database")
+	}
+	sessionDBMu.Lock()
+	prev := sessionAttachments[key]
+	for _, at := range prev {
+		if strings.EqualFold(at.Alias, alias) {
+			sessionDBMu.Unlock()
+			return fmt.Errorf("alias %s is already attached", at.Alias)
+		}
+	}
+	next := make([]db.Attachment, 0, len(prev)+1)
+	sessionAttachments[key] = append(append(next, prev...), db.Attachment{Alias: alias, Path: path})
+	sessionDBMu.Unlock()
+	closeSessionDB(connectionID, sessionID)
+	if _, err := getOrOpenDB(connectionID, sessionID); err != nil {
+		sessionDBMu.Lock()
+		if len(prev) == 0 {
+			delete(sessionAttachments, key)
+		} else {
+			sessionAttachments[key] = prev
+		}
+		sessionDBMu.Unlock()
+		closeSessionDB(connectionID, sessionID)
+		return err
+	}
+	return nil
+}
+
+// DetachDatabase removes a database attached with AttachDatabase from the session.
+func (a *App) DetachDatabase(connectionID, sessionID, alias string) error {
+	key := txKey(connectionID, sessionID)
+	txMu.Lock()
+	inTx := activeTx[key] != nil
+	txMu.Unlock()
+	if inTx {
+		return fmt.Errorf("commit or roll back the transaction before detaching a database")
+	}
+	sessionDBMu.Lock()
+	prev := sessionAttachments[key]
+	var next []db.Attachment
+	for _, at := range prev {
+		if !strings.EqualFold(at.Alias, alias) {
+			next = append(next, at)
+		}
+	}
+	if len(next) == len(prev) {
+		sessionDBMu.Unlock()
+		return fmt.Errorf("%s is not attached", alias)
+	}
+	if len(next) == 0 {
+		delete(sessionAttachments, key)
+	} else {
+		sessionAttachments[key] = next
+	}
+	sessionDBMu.Unlock()
+	closeSessionDB(connectionID, sessionID)
+	return nil
+}
+
+// ListAttachedDatabases returns the session's attached SQLite databases as a JSON array of {alias, path}.
+func (a *App) ListAttachedDatabases(connectionID, sessionID string) string {
+	sessionDBMu.Lock()
+	list := sessionAttachments[txKey(connectionID, sessionID)]
+	sessionDBMu.Unlock()
+	if list == nil {
+		list = []db.Attachment{}
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
 func clearActiveTxForConnection(connID string) {
 	txMu.Lock()
 	defer txMu.Unlock()
@@ -843,7 +2275,7 @@ func clearActiveTxForConnection(connID string) {
 	for k := range activeTx {
 		if k == connID || strings.HasPrefix(k, prefix) {
 			tx := activeTx[k]
-			delete(activeTx, k)
+			forgetTxLocked(k)
 			if tx != nil {
 				_ = tx.Rollback().Error
 			}
@@ -872,6 +2304,9 @@ func (a *App) CreateConnection(connJSON string) error {
 	if err := json.Unmarshal([]byte(connJSON), &conn); err != nil {
 		return err
 	}
+	if err := applyDSN(&conn); err != nil {
+		return err
+	}
 	conn.ID = fmt.Sprintf("%d", time.Now().UnixNano())
 	conn.Status = "disconnected"
 	conn.CreatedAt = time.Now().Format(time.RFC3339)
@@ -883,7 +2318,7 @@ func (a *App) CreateConnection(connJSON string) error {
 
 // ImportNavicatConnectionsFromDialog opens a file dialog for .ncx, then imports and creates connections.
 // Returns same JSON as ImportNavicatConnections; if user cancels the dialog, returns imported=0 and no error.
-func (a *App) ImportNavicatConnectionsFromDialog() string {
+func (a *App) ImportNavicatConnectionsFromDialog(decodePasswords bool) string {
 	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
 		Title: "选择 Navicat 连接文件",
 		Filters: []runtime.FileFilter{
@@ -895,13 +2330,15 @@ func (a *App) ImportNavicatConnectionsFromDialog() string {
 		out, _ := json.Marshal(ImportNavicatResult{})
 		return string(out)
 	}
-	return a.ImportNavicatConnections(path)
+	return a.ImportNavicatConnections(path, decodePasswords)
 }
 
-// ImportNavicatConnections reads a Navicat .ncx file and creates connections for MySQL and SQLite.
-// Password is not stored in NCX; imported connections have empty password (user can edit later).
+// ImportNavicatConnections reads a Navicat .ncx file and creates connections for MySQL, PostgreSQL and SQLite;
+// other connection types are counted as skipped.
+// Passwords are only in the file when Navicat exported them (its "export password" option); with decodePasswords
+// they are decoded (see decodeNavicatPassword), otherwise or when undecodable the password is left empty.
 // Returns JSON ImportNavicatResult: imported count, skipped count, and any errors.
-func (a *App) ImportNavicatConnections(filePath string) string {
+func (a *App) ImportNavicatConnections(filePath string, decodePasswords bool) string {
 	var result ImportNavicatResult
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -922,6 +2359,8 @@ func (a *App) ImportNavicatConnections(filePath string) string {
 		switch connType {
 		case "MYSQL":
 			driver = "mysql"
+		case "POSTGRESQL", "POSTGRES", "PGSQL":
+			driver = "postgresql"
 		case "SQLITE":
 			driver = "sqlite"
 		default:
@@ -933,21 +2372,29 @@ func (a *App) ImportNavicatConnections(filePath string) string {
 			name = n.Host + ":" + n.Port
 		}
 		port := 0
-		if driver == "mysql" {
+		if driver != "sqlite" {
 			if n.Port != "" {
 				port, _ = strconv.Atoi(n.Port)
 			}
 			if port <= 0 {
 				port = 3306
+				if driver == "postgresql" {
+					port = 5432
+				}
 			}
 		}
+		password, sshPassword := "", ""
+		if decodePasswords {
+			password, _ = decodeNavicatPassword(n.Password)
+			sshPassword, _ = decodeNavicatPassword(n.SSH_Password)
+		}
 		conn := Connection{
 			Name:     name,
 			Type:     driver,
 			Host:     strings.TrimSpace(n.Host),
 			Port:     port,
 			Username: strings.TrimSpace(n.UserName),
-			Password: "",
+			Password: password,
 			Database: strings.TrimSpace(n.Database),
 			UseSSL:   strings.ToLower(n.SSL) == "true",
 			Status:   "disconnected",
@@ -959,7 +2406,7 @@ func (a *App) ImportNavicatConnections(filePath string) string {
 			conn.Host = ""
 			conn.Port = 0
 		}
-		if strings.ToLower(n.SSH) == "true" && n.SSH_Host != "" && driver == "mysql" {
+		if strings.ToLower(n.SSH) == "true" && n.SSH_Host != "" && driver != "sqlite" {
 			sshPort := 22
 			if n.SSH_Port != "" {
 				if p, _ := strconv.Atoi(n.SSH_Port); p > 0 {
@@ -971,7 +2418,7 @@ func (a *App) ImportNavicatConnections(filePath string) string {
 				Host:     strings.TrimSpace(n.SSH_Host),
 				Port:     sshPort,
 				Username: strings.TrimSpace(n.SSH_UserName),
-				Password: "",
+				Password: sshPassword,
 			}
 			if strings.ToUpper(n.SSH_AuthenMethod) == "PUBLICKEY" && n.SSH_PrivateKey != "" {
 				conn.SSHTunnel.PrivateKey = strings.TrimSpace(n.SSH_PrivateKey)
@@ -988,19 +2435,266 @@ func (a *App) ImportNavicatConnections(filePath string) string {
 	return string(out)
 }
 
-// TestConnection tests a database connection. When SSH tunnel is enabled, starts a temporary tunnel then closes it.
-func (a *App) TestConnection(connJSON string) bool {
-	var conn Connection
-	if err := json.Unmarshal([]byte(connJSON), &conn); err != nil {
-		return false
+// decodeNavicatPassword decodes a password exported by Navicat: hex of AES-128-CBC under a fixed key (Navicat 12
+// and later) or of Blowfish in Navicat's own chaining mode (Navicat 11). ok is false when hexStr is empty or
+// decodes to nothing readable under either scheme.
+func decodeNavicatPassword(hexStr string) (string, bool) {
+	raw, err := hex.DecodeString(strings.TrimSpace(hexStr))
+	if err != nil || len(raw) == 0 {
+		return "", false
 	}
-	driver := conn.Type
-	if driver != "mysql" && driver != "sqlite" && driver != "postgresql" && driver != "postgres" {
-		return false
+	if len(raw)%aes.BlockSize == 0 {
+		block, _ := aes.NewCipher([]byte("libcckeylibcckey"))
+		plain := make([]byte, len(raw))
+		cipher.NewCBCDecrypter(block, []byte("libcciv libcciv ")).CryptBlocks(plain, raw)
+		if pad := int(plain[len(plain)-1]); pad >= 1 && pad <= aes.BlockSize &&
+			bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) && utf8.Valid(plain[:len(plain)-pad]) {
+			return string(plain[:len(plain)-pad]), true
+		}
+	}
+	key := sha1.Sum([]byte("3DC5CA39"))
+	bf, _ := blowfish.NewCipher(key[:])
+	cv := bytes.Repeat([]byte{0xff}, blowfish.BlockSize)
+	bf.Encrypt(cv, cv)
+	plain := make([]byte, 0, len(raw))
+	for ; len(raw) >= blowfish.BlockSize; raw = raw[blowfish.BlockSize:] {
+		var t [blowfish.BlockSize]byte
+		bf.Decrypt(t[:], raw[:blowfish.BlockSize])
+		for i := range t {
+			plain = append(plain, t[i]^cv[i])
+			cv[i] ^= raw[i]
+		}
+	}
+	if len(raw) > 0 {
+		bf.Encrypt(cv, cv)
+		for i := range raw {
+			plain = append(plain, raw[i]^cv[i])
+		}
+	}
+	if !utf8.Valid(plain) {
+		return "", false
+	}
+	return string(plain), true
+}
+
+// DBeaver data-sources.json structures (workspace6/General/.dbeaver/data-sources.json)
+type dbeaverDataSources struct {
+	Connections map[string]dbeaverConnection `json:"connections"`
+}
+
+type dbeaverConnection struct {
+	Provider      string `json:"provider"`
+	Driver        string `json:"driver"`
+	Name          string `json:"name"`
+	Configuration struct {
+		Host     string                    `json:"host"`
+		Port     interface{}               `json:"port"` // a string in current versions, a number in some older ones
+		Database string                    `json:"database"`
+		URL      string                    `json:"url"`
+		User     string                    `json:"user"`
+		Password string                    `json:"password"`
+		Handlers map[string]dbeaverHandler `json:"handlers"`
+	} `json:"configuration"`
+}
+
+type dbeaverHandler struct {
+	Enabled    bool                   `json:"enabled"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// dbeaverCredentials is the decrypted credentials-config.json: data source id -> "#connection" or a
+// handler such as "network/ssh_tunnel" -> user and password.
+type dbeaverCredentials map[string]map[string]dbeaverCredential
+
+type dbeaverCredential struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// dbeaverCredentialsKey is the fixed AES key DBeaver encrypts credentials-config.json with.
+const dbeaverCredentialsKey = "babb4a9f774ab853c96c2d653dfe544a"
+
+// ImportConnectionsFromDBeaverDialog opens a file dialog for DBeaver's data-sources.json, then imports it.
+// Returns same JSON as ImportConnectionsFromDBeaver; if user cancels the dialog, returns imported=0 and no error.
+func (a *App) ImportConnectionsFromDBeaverDialog() string {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择 DBeaver data-sources.json",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "DBeaver Data Sources (*.json)", Pattern: "*.json"},
+			{DisplayName: "All Files", Pattern: "*"},
+		},
+	})
+	if err != nil || path == "" {
+		out, _ := json.Marshal(ImportNavicatResult{})
+		return string(out)
+	}
+	return a.ImportConnectionsFromDBeaver(path)
+}
+
+// ImportConnectionsFromDBeaver reads DBeaver's data-sources.json and creates connections for its MySQL (and
+// MariaDB), PostgreSQL and SQLite data sources; others are counted as skipped. Users and passwords are taken from
+// credentials-config.json in the same directory when it exists. Returns JSON ImportNavicatResult.
+func (a *App) ImportConnectionsFromDBeaver(path string) string {
+	var result ImportNavicatResult
+	data, err := os.ReadFile(path)
+	if err != nil {
+		result.Errors = append(result.Errors, "read file: "+err.Error())
+		out, _ := json.Marshal(result)
+		return string(out)
+	}
+	var sources dbeaverDataSources
+	if err := json.Unmarshal(data, &sources); err != nil {
+		result.Errors = append(result.Errors, "parse JSON: "+err.Error())
+		out, _ := json.Marshal(result)
+		return string(out)
+	}
+	creds, err := readDBeaverCredentials(filepath.Join(filepath.Dir(path), "credentials-config.json"))
+	if err != nil {
+		result.Errors = append(result.Errors, "credentials-config.json: "+err.Error())
+	}
+	ids := make([]string, 0, len(sources.Connections))
+	for id := range sources.Connections {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	ensureConnectionsLoaded()
+	for _, id := range ids {
+		conn, ok := dbeaverToConnection(sources.Connections[id], creds[id])
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		connJSON, _ := json.Marshal(conn)
+		if err := a.CreateConnection(string(connJSON)); err != nil {
+			result.Errors = append(result.Errors, conn.Name+": "+err.Error())
+			continue
+		}
+		result.Imported++
+	}
+	out, _ := json.Marshal(result)
+	return string(out)
+}
+
+// readDBeaverCredentials decrypts credentials-config.json (AES-128-CBC, IV in the first block). A missing file
+// yields no credentials and no error.
+func readDBeaverCredentials(path string) (dbeaverCredentials, error) {
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 2*aes.BlockSize || len(raw)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("unexpected file size %d", len(raw))
+	}
+	key, _ := hex.DecodeString(dbeaverCredentialsKey)
+	block, _ := aes.NewCipher(key)
+	plain := make([]byte, len(raw)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, raw[:aes.BlockSize]).CryptBlocks(plain, raw[aes.BlockSize:])
+	if pad := int(plain[len(plain)-1]); pad >= 1 && pad <= aes.BlockSize {
+		plain = plain[:len(plain)-pad]
+	}
+	var creds dbeaverCredentials
+	if err := json.Unmarshal(plain, &creds); err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return creds, nil
+}
+
+// dbeaverToConnection maps a DBeaver data source to a Connection; ok is false for unsupported drivers.
+func dbeaverToConnection(d dbeaverConnection, creds map[string]dbeaverCredential) (Connection, bool) {
+	cfg := d.Configuration
+	var driver string
+	switch provider, drv := strings.ToLower(d.Provider), strings.ToLower(d.Driver); {
+	case provider == "mysql" || strings.Contains(drv, "mariadb"):
+		driver = "mysql"
+	case provider == "postgresql":
+		driver = "postgresql"
+	case provider == "sqlite" || strings.Contains(drv, "sqlite"):
+		driver = "sqlite"
+	default:
+		return Connection{}, false
+	}
+	conn := Connection{
+		Name:     strings.TrimSpace(d.Name),
+		Type:     driver,
+		Host:     strings.TrimSpace(cfg.Host),
+		Username: cfg.User,
+		Password: cfg.Password,
+		Database: strings.TrimSpace(cfg.Database),
+		Status:   "disconnected",
+	}
+	if c, ok := creds["#connection"]; ok {
+		conn.Username, conn.Password = c.User, c.Password
+	}
+	if driver == "sqlite" {
+		if conn.Database == "" {
+			conn.Database = strings.TrimPrefix(cfg.URL, "jdbc:sqlite:")
+		}
+		conn.Host = ""
+	} else {
+		if cfg.Port != nil {
+			conn.Port, _ = strconv.Atoi(fmt.Sprint(cfg.Port))
+		}
+		if conn.Port <= 0 {
+			conn.Port = 3306
+			if driver == "postgresql" {
+				conn.Port = 5432
+			}
+		}
+	}
+	if conn.Name == "" {
+		conn.Name = fmt.Sprintf("%s:%d", conn.Host, conn.Port)
+	}
+	for id, h := range cfg.Handlers {
+		if !h.Enabled {
+			continue
+		}
+		if id == "ssl" || strings.HasSuffix(id, "_ssl") {
+			conn.UseSSL = true
+		}
+		if id == "ssh_tunnel" && driver != "sqlite" {
+			prop := func(k string) string {
+				if v, ok := h.Properties[k]; ok && v != nil {
+					return strings.TrimSpace(fmt.Sprint(v))
+				}
+				return ""
+			}
+			tunnel := &SSHTunnel{Enabled: true, Host: prop("host"), Username: prop("user"), Password: prop("password")}
+			if tunnel.Port, _ = strconv.Atoi(prop("port")); tunnel.Port <= 0 {
+				tunnel.Port = 22
+			}
+			if c, ok := creds["network/ssh_tunnel"]; ok {
+				tunnel.Username, tunnel.Password = c.User, c.Password
+			}
+			if keyPath := prop("keyPath"); keyPath != "" && strings.EqualFold(prop("authType"), "PUBLIC_KEY") {
+				if pem, err := os.ReadFile(keyPath); err == nil {
+					tunnel.PrivateKey = string(pem)
+				}
+			}
+			conn.SSHTunnel = tunnel
+		}
+	}
+	return conn, true
+}
+
+// TestConnection tests a database connection. When SSH tunnel is enabled, starts a temporary tunnel then closes it.
+func (a *App) TestConnection(connJSON string) bool {
+	var conn Connection
+	if err := json.Unmarshal([]byte(connJSON), &conn); err != nil {
+		return false
+	}
+	if applyDSN(&conn) != nil {
+		return false
+	}
+	driver := conn.Type
+	if driver != "mysql" && driver != "sqlite" && driver != "postgresql" && driver != "postgres" {
+		return false
 	}
 	var dsn string
 	var err error
-	if driver == "mysql" && conn.SSHTunnel != nil && conn.SSHTunnel.Enabled {
+	if usesSSHTunnel(&conn) {
 		testID := fmt.Sprintf("test-%d", time.Now().UnixNano())
 		sshPort := conn.SSHTunnel.Port
 		if sshPort <= 0 {
@@ -1019,12 +2713,12 @@ func (a *App) TestConnection(connJSON string) bool {
 			return false
 		}
 		defer sshtunnel.Stop(testID)
-		dsn, err = db.BuildDSN(driver, "127.0.0.1", localPort, conn.Username, conn.Password, conn.Database)
+		dsn, err = buildDSN(&conn, "127.0.0.1", localPort)
 		if err != nil {
 			return false
 		}
 	} else {
-		dsn, err = buildDSN(&conn)
+		dsn, err = buildDSN(&conn, conn.Host, conn.Port)
 		if err != nil {
 			return false
 		}
@@ -1042,9 +2736,17 @@ func (a *App) UpdateConnection(connJSON string) error {
 	if conn.ID == "" {
 		return fmt.Errorf("connection ID required")
 	}
+	if err := applyDSN(&conn); err != nil {
+		return err
+	}
 	clearActiveTxForConnection(conn.ID)
+	clearSessionDatabases(conn.ID)
+	closeCursors(conn.ID, "*")
+	cancelSchemaMetadataLoad(conn.ID)
 	db.CloseConnection(conn.ID)
 	sshtunnel.Stop(conn.ID)
+	// cached results may depend on the previous default schema
+	clearQueryCacheForConnection(conn.ID)
 	schemaMetaMu.Lock()
 	delete(schemaMetaCache, conn.ID)
 	schemaMetaMu.Unlock()
@@ -1053,6 +2755,7 @@ func (a *App) UpdateConnection(connJSON string) error {
 	for i, c := range connections {
 		if c.ID == conn.ID {
 			conn.CreatedAt = c.CreatedAt
+			conn.LastConnectedAt = c.LastConnectedAt
 			if conn.Status == "" {
 				conn.Status = c.Status
 			}
@@ -1063,9 +2766,45 @@ func (a *App) UpdateConnection(connJSON string) error {
 	return fmt.Errorf("connection not found")
 }
 
+// connectionStatusTimeout bounds the ping of GetConnectionStatus.
+const connectionStatusTimeout = 3 * time.Second
+
+// ConnectionStatus is the result of GetConnectionStatus. Message explains an "error" status.
+type ConnectionStatus struct {
+	Status  string `json:"status"` // "connected" | "disconnected" | "error"
+	Message string `json:"message,omitempty"`
+}
+
+// GetConnectionStatus returns ConnectionStatus JSON for the connection: "connected" when one of its open pools
+// answers a ping, "error" when none does, and "disconnected" when nothing is open. It never opens a connection,
+// so it is cheap enough to poll. The connection's Status is updated to match.
+func (a *App) GetConnectionStatus(connectionID string) string {
+	st := ConnectionStatus{Status: "disconnected"}
+	if getConnByID(connectionID) == nil {
+		data, _ := json.Marshal(ConnectionStatus{Status: "error", Message: "connection not found"})
+		return string(data)
+	}
+	if open, err := db.PingConnection(connectionID, connectionStatusTimeout); err != nil {
+		st = ConnectionStatus{Status: "error", Message: userFacingError(err).Message}
+	} else if open {
+		st.Status = "connected"
+	}
+	connMu.Lock()
+	for i := range connections {
+		if connections[i].ID == connectionID {
+			connections[i].Status = st.Status
+		}
+	}
+	connMu.Unlock()
+	data, _ := json.Marshal(st)
+	return string(data)
+}
+
 // ReconnectConnection closes cached DB and SSH tunnel for the connection so it reconnects on next use.
 func (a *App) ReconnectConnection(id string) error {
 	clearActiveTxForConnection(id)
+	closeCursors(id, "*")
+	cancelSchemaMetadataLoad(id)
 	db.CloseConnection(id)
 	sshtunnel.Stop(id)
 	schemaMetaMu.Lock()
@@ -1074,10 +2813,43 @@ func (a *App) ReconnectConnection(id string) error {
 	return nil
 }
 
+// DisconnectConnection releases everything the connection holds on the server and locally: open transactions
+// are rolled back, cursors, the live monitor, schema loads, every session pool and the SSH tunnel are closed, and
+// session database choices and cached schema and results are dropped. The connection itself is kept with its
+// status set to "disconnected"; the next query connects again.
+func (a *App) DisconnectConnection(id string) error {
+	if getConnByID(id) == nil {
+		return fmt.Errorf("connection not found")
+	}
+	a.StopMonitor(id)
+	clearActiveTxForConnection(id)
+	closeCursors(id, "*")
+	clearSessionDatabases(id)
+	cancelSchemaMetadataLoad(id)
+	db.CloseConnection(id)
+	sshtunnel.Stop(id)
+	clearQueryCacheForConnection(id)
+	schemaMetaMu.Lock()
+	delete(schemaMetaCache, id)
+	schemaMetaMu.Unlock()
+	connMu.Lock()
+	defer connMu.Unlock()
+	for i := range connections {
+		if connections[i].ID == id {
+			connections[i].Status = "disconnected"
+			return saveConnectionsToFile(connections)
+		}
+	}
+	return fmt.Errorf("connection not found")
+}
+
 // DeleteConnection deletes a connection by ID
 func (a *App) DeleteConnection(id string) error {
 	ensureConnectionsLoaded()
 	clearActiveTxForConnection(id)
+	closeCursors(id, "*")
+	clearSessionDatabases(id)
+	cancelSchemaMetadataLoad(id)
 	db.CloseConnection(id)
 	sshtunnel.Stop(id)
 	schemaMetaMu.Lock()
@@ -1088,138 +2860,880 @@ func (a *App) DeleteConnection(id string) error {
 	for i, c := range connections {
 		if c.ID == id {
 			connections = append(connections[:i], connections[i+1:]...)
+			if keychainEnabled() || c.PasswordInKeychain {
+				keychainDelete(id)
+			}
+			forgetTableViews(id)
 			return saveConnectionsToFile(connections)
 		}
 	}
 	return fmt.Errorf("connection not found")
 }
 
+// errMultipleStatements is returned by the single-statement entry points; scripts go through ExecuteMultiResult.
+const errMultipleStatements = "multiple statements are not allowed here; run the script as a multi-statement query"
+
 // ExecuteQuery executes a SQL query. sessionID optionally isolates this tab's DB session (e.g. tab id).
-// SELECT results are cached by connectionID + normalized SQL; TTL and size limits apply.
+// SELECT ... INTO runs as a write and reports affected rows; CALL returns the procedure's first result set, if any.
+// SELECT results are cached by connectionID + normalized SQL; TTL and size limits apply. The cache is bypassed
+// while a transaction is active for the connection+session, and cleared for the connection by any other
+// statement and by CommitTx.
 func (a *App) ExecuteQuery(connectionID, sessionID, sql string) string {
 	conn := getConnByID(connectionID)
 	if conn == nil {
 		return mustMarshalResult(nil, nil, 0, 0, userFacingError(fmt.Errorf("connection not found: %s", connectionID)).Message)
 	}
 
-	if db.IsSelect(sql) {
+	if db.HasMultipleStatements(sql) {
+		return mustMarshalResult(nil, nil, 0, 0, errMultipleStatements)
+	}
+	if !db.IsSelect(sql) && conn.ReadOnly {
+		return mustMarshalResult(nil, nil, 0, 0, "connection is read-only")
+	}
+	if !db.IsSelect(sql) && inReadOnlyTx(connectionID, sessionID) {
+		return mustMarshalResult(nil, nil, 0, 0, errReadOnlyTx)
+	}
+
+	ev := QueryEvent{ConnectionID: connectionID, SessionID: sessionID, SQL: truncateRunes(sql, queryEventSQLMax)}
+	a.emitQueryEvent("query-start", ev)
+	var errMsg string
+	var rowCount int
+	var elapsed int
+	defer func() {
+		ev.DurationMs, ev.RowCount, ev.Error = elapsed, rowCount, errMsg
+		a.emitQueryEvent("query-finish", ev)
+	}()
+
+	// Inside a transaction the cache would hide uncommitted changes (and must not store them either).
+	txMu.Lock()
+	inTx := activeTx[txKey(connectionID, sessionID)] != nil
+	txMu.Unlock()
+	useCache := db.IsSelect(sql) && !inTx
+
+	if useCache {
 		key := queryCacheKey(connectionID, sql)
 		if ent, hit := queryCacheGet(key); hit {
 			queryCacheRecordHit()
-			return marshalQueryResultCached(ent.cols, ent.rows, ent.rowCount, ent.execMs, true)
+			rowCount = ent.rowCount
+			return marshalQueryResultCached(ent.cols, ent.colTypes, ent.pretty, ent.rows, ent.rowCount, ent.execMs, true, ent.truncated)
 		}
 		queryCacheRecordMiss()
 	}
 
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
-		return mustMarshalResult(nil, nil, 0, 0, userFacingError(err).Message)
+		errMsg = userFacingError(err).Message
+		return mustMarshalResult(nil, nil, 0, 0, errMsg)
 	}
 	start := time.Now()
 	var result string
 	var success bool
-	var rowCount int
-	var elapsed int
 
 	if db.IsSelect(sql) {
-		cols, rows, err := db.RawSelect(g, sql)
+		rowCap := currentMaxResultRows()
+		set, err := db.RawSelectResult(g, applyRowCap(sql, rowCap), rowCap)
 		elapsed = int(time.Since(start).Milliseconds())
 		if err != nil {
-			result = mustMarshalResult(nil, nil, 0, elapsed, userFacingError(err).Message)
+			ae := userFacingError(err)
+			errMsg = ae.Message
+			result = marshalErrorResult(elapsed, ae)
 			success = false
+		} else {
+			rowCount = len(set.Rows)
+			result = marshalQueryResultCached(set.Columns, set.ColumnTypes, set.PrettyJSON, set.Rows, rowCount, elapsed, false, set.Truncated)
+			success = true
+			if useCache {
+				key := queryCacheKey(connectionID, sql)
+				queryCacheSet(key, queryCacheEntry{cols: set.Columns, colTypes: set.ColumnTypes, pretty: set.PrettyJSON, rows: set.Rows, rowCount: rowCount, execMs: elapsed, truncated: set.Truncated})
+			}
+		}
+	} else if db.IsCall(sql) {
+		// A procedure may return rows or only modify data; it is run once and read whichever way it answers.
+		cols, rows, truncated, extraSets, err := db.RawCall(g, sql, currentMaxResultRows())
+		elapsed = int(time.Since(start).Milliseconds())
+		if err != nil {
+			ae := userFacingError(err)
+			errMsg = ae.Message
+			result = marshalErrorResult(elapsed, ae)
 		} else {
 			rowCount = len(rows)
-			result = mustMarshalResult(cols, rows, rowCount, elapsed, "")
+			r := QueryResult{Columns: cols, Rows: rows, RowCount: rowCount, ExecutionTime: elapsed, Truncated: truncated, StatementType: db.StatementOther}
+			if extraSets > 0 {
+				r.Message = fmt.Sprintf("procedure returned %d result sets; showing the first", extraSets+1)
+			}
+			data, _ := json.Marshal(r)
+			result = string(data)
 			success = true
-			key := queryCacheKey(connectionID, sql)
-			queryCacheSet(key, queryCacheEntry{cols: cols, rows: rows, rowCount: rowCount, execMs: elapsed})
 		}
 	} else {
 		affected, err := db.RawExec(g, sql)
 		elapsed = int(time.Since(start).Milliseconds())
 		if err != nil {
-			result = mustMarshalResult(nil, nil, 0, elapsed, userFacingError(err).Message)
+			ae := userFacingError(err)
+			errMsg = ae.Message
+			result = marshalErrorResult(elapsed, ae)
 			success = false
 		} else {
-			result = mustMarshalResult(nil, nil, 0, elapsed, "", int(affected))
+			r := QueryResult{ExecutionTime: elapsed, AffectedRows: int(affected), StatementType: db.StatementType(sql)}
+			if r.StatementType == db.StatementDDL {
+				// DDL has no meaningful row count (SQLite even repeats the previous statement's), so it gets a message.
+				r.AffectedRows = 0
+				r.Message = db.DDLSummary(sql)
+			}
+			data, _ := json.Marshal(r)
+			result = string(data)
 			success = true
 		}
 	}
 
+	if !db.IsSelect(sql) {
+		// writes (and procedures, which may write) make cached SELECTs on this connection stale
+		clearQueryCacheForConnection(connectionID)
+	}
 	appendAuditLog("query", sql, connectionID, "", "")
 	saveQueryHistory(connectionID, sql, success, elapsed, rowCount)
+	logStatement(connectionID, sessionID, sql, elapsed, errMsg)
 
 	return result
 }
 
-// ReleaseSession closes the DB session for the given connection and tab/session. Call when a tab is closed so transactions do not leak.
-func (a *App) ReleaseSession(connectionID, sessionID string) {
-	if sessionID == "" {
+// queryEventSQLMax caps the statement text carried by query lifecycle events.
+const queryEventSQLMax = 200
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// emitQueryEvent sends a query lifecycle event to the frontend. It is a no-op before startup (e.g. in tests).
+func (a *App) emitQueryEvent(name string, ev QueryEvent) {
+	if a.ctx == nil {
 		return
 	}
-	db.Close(connectionID, sessionID)
+	runtime.EventsEmit(a.ctx, name, ev)
 }
 
-// StartMonitor starts a background goroutine that polls MySQL live stats every 5s and emits "live-stats" events.
-// Only MySQL is supported. Returns JSON object with "error" key on failure, or "{}" on success.
-func (a *App) StartMonitor(connectionID string) string {
+// ExecuteParamQuery runs a single statement with bound parameters and returns QueryResult JSON like
+// ExecuteQuery. paramsJSON is a JSON array for ? placeholders or an object for :name placeholders (see
+// db.BindParams); values are never spliced into the SQL text. Results are not cached, and history records the
+// statement without its values.
+func (a *App) ExecuteParamQuery(connectionID, sessionID, sql, paramsJSON string) string {
 	conn := getConnByID(connectionID)
 	if conn == nil {
-		return `{"error":"connection not found"}`
+		return mustMarshalResult(nil, nil, 0, 0, userFacingError(fmt.Errorf("connection not found: %s", connectionID)).Message)
 	}
-	if conn.Type != "mysql" {
-		return `{"error":"live monitor is only supported for MySQL"}`
+	if db.HasMultipleStatements(sql) {
+		return mustMarshalResult(nil, nil, 0, 0, errMultipleStatements)
 	}
-	monitorMu.Lock()
-	if monitorStop == nil {
-		monitorStop = make(map[string]chan struct{})
+	isSelect := db.IsSelect(sql)
+	if !isSelect && conn.ReadOnly {
+		return mustMarshalResult(nil, nil, 0, 0, "connection is read-only")
 	}
-	if _, running := monitorStop[connectionID]; running {
-		monitorMu.Unlock()
-		return `{}`
+	if !isSelect && inReadOnlyTx(connectionID, sessionID) {
+		return mustMarshalResult(nil, nil, 0, 0, errReadOnlyTx)
 	}
-	stopCh := make(chan struct{})
-	monitorStop[connectionID] = stopCh
-	monitorMu.Unlock()
-	go a.liveMonitorWorker(connectionID, stopCh)
-	return `{}`
+	bound, args, err := db.BindParams(sql, paramsJSON)
+	if err != nil {
+		return mustMarshalResult(nil, nil, 0, 0, err.Error())
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return mustMarshalResult(nil, nil, 0, 0, userFacingError(err).Message)
+	}
+
+	start := time.Now()
+	var r QueryResult
+	if isSelect {
+		rowCap := currentMaxResultRows()
+		var set db.ResultSet
+		set, err = db.RawSelectResult(g, applyRowCap(bound, rowCap), rowCap, args...)
+		r = QueryResult{Columns: set.Columns, ColumnTypes: set.ColumnTypes, PrettyJSON: set.PrettyJSON, Rows: set.Rows,
+			RowCount: len(set.Rows), Truncated: set.Truncated, StatementType: db.StatementSelect}
+	} else {
+		var affected int64
+		affected, err = db.RawExec(g, bound, args...)
+		r = QueryResult{AffectedRows: int(affected), StatementType: db.StatementType(sql)}
+		if r.StatementType == db.StatementDDL {
+			r.AffectedRows = 0
+			r.Message = db.DDLSummary(sql)
+		}
+		clearQueryCacheForConnection(connectionID)
+	}
+	elapsed := int(time.Since(start).Milliseconds())
+	appendAuditLog("query", sql, connectionID, "", "")
+	saveQueryHistory(connectionID, sql, err == nil, elapsed, r.RowCount)
+	if err != nil {
+		logStatement(connectionID, sessionID, inlineSQLArgs(conn.Type, bound, args), elapsed, userFacingError(err).Message)
+		return mustMarshalResult(nil, nil, 0, elapsed, userFacingError(err).Message)
+	}
+	logStatement(connectionID, sessionID, inlineSQLArgs(conn.Type, bound, args), elapsed, "")
+	r.ExecutionTime = elapsed
+	data, _ := json.Marshal(r)
+	return string(data)
 }
 
-// StopMonitor stops the background monitor for the given connection.
-func (a *App) StopMonitor(connectionID string) {
-	monitorMu.Lock()
-	ch, ok := monitorStop[connectionID]
-	if ok {
-		delete(monitorStop, connectionID)
-		close(ch)
+// ExecuteMultiResult runs a stored procedure CALL or a multi-statement script and returns every result set as a
+// JSON array of QueryResult (one per set, each capped by SetMaxResultRows). Statements that return no rows do not
+// get an entry; if none return rows the array holds a single empty result. On failure the array holds one result
+// with Error set. Only MySQL returns more than one set; other drivers return the first.
+func (a *App) ExecuteMultiResult(connectionID, sessionID, sql string) string {
+	fail := func(msg string) string {
+		data, _ := json.Marshal([]QueryResult{{Error: msg}})
+		return string(data)
 	}
-	monitorMu.Unlock()
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fail(userFacingError(fmt.Errorf("connection not found: %s", connectionID)).Message)
+	}
+	multi := db.HasMultipleStatements(sql)
+	if conn.ReadOnly && (!db.IsSelect(sql) || multi) {
+		return fail("connection is read-only")
+	}
+	if (!db.IsSelect(sql) || multi) && inReadOnlyTx(connectionID, sessionID) {
+		return fail(errReadOnlyTx)
+	}
+	var g *gorm.DB
+	var err error
+	if multi && conn.Type == "mysql" {
+		// Scripts need the multi-statement pool, which has no view of a transaction open on the session.
+		txMu.Lock()
+		inTx := activeTx[txKey(connectionID, sessionID)] != nil
+		txMu.Unlock()
+		if inTx {
+			return fail("multi-statement scripts cannot run inside a transaction; run the statements one at a time")
+		}
+		g, err = openSessionDB(connectionID, sessionID, true)
+	} else {
+		g, err = getOrOpenDB(connectionID, sessionID)
+	}
+	if err != nil {
+		return fail(userFacingError(err).Message)
+	}
+	start := time.Now()
+	sets, err := db.RawSelectMultiLimit(g, sql, currentMaxResultRows())
+	elapsed := int(time.Since(start).Milliseconds())
+	if !db.IsSelect(sql) || multi {
+		clearQueryCacheForConnection(connectionID)
+	}
+	appendAuditLog("query", sql, connectionID, "", "")
+	if err != nil {
+		saveQueryHistory(connectionID, sql, false, elapsed, 0)
+		logStatement(connectionID, sessionID, sql, elapsed, userFacingError(err).Message)
+		return fail(userFacingError(err).Message)
+	}
+	results := make([]QueryResult, 0, len(sets))
+	// a script mixes statement kinds, so only a single statement is classified
+	stmtType := ""
+	if !multi {
+		stmtType = db.StatementType(sql)
+	}
+	rowCount := 0
+	for _, set := range sets {
+		rowCount += len(set.Rows)
+		results = append(results, QueryResult{
+			Columns:       set.Columns,
+			ColumnTypes:   set.ColumnTypes,
+			PrettyJSON:    set.PrettyJSON,
+			Rows:          set.Rows,
+			RowCount:      len(set.Rows),
+			ExecutionTime: elapsed,
+			Truncated:     set.Truncated,
+			StatementType: stmtType,
+		})
+	}
+	if len(results) == 0 {
+		results = append(results, QueryResult{ExecutionTime: elapsed, StatementType: stmtType})
+	}
+	saveQueryHistory(connectionID, sql, true, elapsed, rowCount)
+	logStatement(connectionID, sessionID, sql, elapsed, "")
+	data, _ := json.Marshal(results)
+	return string(data)
 }
 
-const liveMonitorInterval = 5 * time.Second
+// QueryCursor positions ExecuteQueryPaged. Column is the sort key of the page; After is the value of Column in the
+// last row already seen (omit it for the first page); Desc pages in descending order. Integer and date keys
+// travel as strings tagged by AfterKind ("int", "time"), so BIGINT values beyond 2^53 survive JavaScript numbers.
+type QueryCursor struct {
+	Column    string      `json:"column"`
+	Desc      bool        `json:"desc,omitempty"`
+	After     interface{} `json:"after,omitempty"`
+	AfterKind string      `json:"afterKind,omitempty"`
+}
+
+// cursorAfter encodes v, the cursor column of the last row of a page, as After and AfterKind. Floating-point and
+// binary keys are rejected: they cannot be passed back exactly, so pages would skip or repeat rows.
+func cursorAfter(column string, v interface{}) (interface{}, string, error) {
+	switch x := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(x), "int", nil
+	case time.Time:
+		return x.Format(time.RFC3339Nano), "time", nil
+	case string, bool:
+		return x, "", nil
+	case nil:
+		return nil, "", fmt.Errorf("cursor column %q is NULL in the last row; page on a non-null column", column)
+	default:
+		return nil, "", fmt.Errorf("cursor column %q must hold integer, text or date values", column)
+	}
+}
 
-// liveMonitorWorker polls MySQL for Threads_connected and PROCESSLIST, then emits "live-stats".
-func (a *App) liveMonitorWorker(connectionID string, stopCh <-chan struct{}) {
-	ticker := time.NewTicker(liveMonitorInterval)
-	defer ticker.Stop()
-	emit := func(payload LiveStatsPayload) {
-		data, _ := json.Marshal(payload)
-		runtime.EventsEmit(a.ctx, "live-stats", string(data))
+// cursorBindValue decodes the After of a cursor passed back by the client into the value bound to the query.
+func cursorBindValue(cur QueryCursor) (interface{}, error) {
+	if cur.After == nil {
+		return nil, nil
 	}
-	for {
-		payload := LiveStatsPayload{ConnectionID: connectionID}
-		conn := getConnByID(connectionID)
-		if conn == nil {
-			payload.Error = "connection not found"
-			emit(payload)
-			return
+	s, isString := cur.After.(string)
+	switch cur.AfterKind {
+	case "int":
+		if isString {
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return n, nil
+			}
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				return n, nil
+			}
 		}
-		g, err := getOrOpenDB(connectionID, "")
-		if err != nil {
-			payload.Error = err.Error()
-			emit(payload)
-		} else {
-			// Threads_connected
+		return nil, fmt.Errorf("invalid cursor: %v is not an integer", cur.After)
+	case "time":
+		if isString {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid cursor: %v is not a time", cur.After)
+	case "":
+	default:
+		return nil, fmt.Errorf("invalid cursor: unknown afterKind %q", cur.AfterKind)
+	}
+	switch v := cur.After.(type) {
+	case string, bool:
+		return v, nil
+	case float64:
+		// a hand-written cursor with a small integer; larger ones were already rounded by the JSON decoder
+		if v == float64(int64(v)) && v >= -(1<<53) && v <= 1<<53 {
+			return int64(v), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid cursor: after must be an integer, text or date value")
+}
+
+// PagedQueryResult is a QueryResult page plus the cursor for the next page (nil on the last page).
+type PagedQueryResult struct {
+	QueryResult
+	NextCursor *QueryCursor `json:"nextCursor,omitempty"`
+	HasMore    bool         `json:"hasMore"`
+}
+
+// buildKeysetQuery wraps a SELECT as a derived table and pages it on cur.Column, fetching one extra row to
+// detect whether another page follows.
+func buildKeysetQuery(driver, sql string, cur QueryCursor, pageSize int) (string, []interface{}) {
+	inner := strings.TrimRight(strings.TrimSpace(sql), "; \t\r\n")
+	col := "_page." + quoteIdent(driver, cur.Column)
+	cmp, dir := ">", "ASC"
+	if cur.Desc {
+		cmp, dir = "<", "DESC"
+	}
+	q := "SELECT * FROM (\n" + inner + "\n) AS _page"
+	var args []interface{}
+	if cur.After != nil {
+		q += " WHERE " + col + " " + cmp + " ?"
+		args = append(args, cur.After)
+	}
+	q += fmt.Sprintf(" ORDER BY %s %s LIMIT %d", col, dir, pageSize+1)
+	return q, args
+}
+
+// ExecuteQueryPaged runs a SELECT one page at a time using keyset pagination: each page starts after the
+// cursor's value instead of skipping OFFSET rows, so deep pages stay as fast as the first one. cursorJSON is a
+// QueryCursor; the sort column must be returned by the query, be unique and non-null (otherwise rows are
+// skipped or repeated across pages), and should be indexed. pageSize defaults to 100.
+// Returns PagedQueryResult JSON; pass nextCursor back to fetch the following page.
+func (a *App) ExecuteQueryPaged(connectionID, sessionID, sql, cursorJSON string, pageSize int) string {
+	var out PagedQueryResult
+	fail := func(msg string) string {
+		out.Error = msg
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	if !db.IsSelect(sql) {
+		return fail("only SELECT queries can be paged")
+	}
+	if db.HasMultipleStatements(sql) {
+		return fail(errMultipleStatements)
+	}
+	var cur QueryCursor
+	if err := json.Unmarshal([]byte(cursorJSON), &cur); err != nil {
+		return fail("invalid cursor: " + err.Error())
+	}
+	if strings.TrimSpace(cur.Column) == "" {
+		return fail("cursor column required")
+	}
+	firstPage := cur.After == nil
+	after, err := cursorBindValue(cur)
+	if err != nil {
+		return fail(err.Error())
+	}
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	if pageSize > maxResultRowsLimit {
+		pageSize = maxResultRowsLimit
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fail("connection not found")
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return fail(userFacingError(err).Message)
+	}
+
+	bound := cur
+	bound.After = after
+	q, args := buildKeysetQuery(conn.Type, sql, bound, pageSize)
+	start := time.Now()
+	cols, rows, _, err := db.RawSelectLimit(g, q, 0, args...)
+	elapsed := int(time.Since(start).Milliseconds())
+	if err != nil {
+		return fail(userFacingError(err).Message)
+	}
+	if len(rows) > pageSize {
+		rows = rows[:pageSize]
+		out.HasMore = true
+		last := rows[len(rows)-1]
+		v, ok := last[cur.Column]
+		if !ok {
+			for k, val := range last {
+				if strings.EqualFold(k, cur.Column) {
+					v, ok = val, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return fail(fmt.Sprintf("cursor column %q is not in the result", cur.Column))
+		}
+		after, kind, err := cursorAfter(cur.Column, v)
+		if err != nil {
+			return fail(err.Error())
+		}
+		out.NextCursor = &QueryCursor{Column: cur.Column, Desc: cur.Desc, After: after, AfterKind: kind}
+	}
+	out.Columns, out.Rows, out.RowCount, out.ExecutionTime = cols, rows, len(rows), elapsed
+	if firstPage {
+		// later pages continue the same query; record it once
+		appendAuditLog("query", sql, connectionID, "", "")
+		saveQueryHistory(connectionID, sql, true, elapsed, len(rows))
+		logStatement(connectionID, sessionID, sql, elapsed, "")
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// cursorIdleTimeout is how long a result cursor may go without FetchMore before it is closed.
+const cursorIdleTimeout = 5 * time.Minute
+
+// resultCursor is a SELECT held open by ExecuteQueryCursor; guarded by cursorMu except cur, which locks itself.
+type resultCursor struct {
+	cur       *db.Cursor
+	connID    string
+	sessionID string
+	lastUsed  time.Time
+}
+
+// CursorResult is one page of a SELECT read through a result cursor. Handle is set while more rows remain;
+// pass it to FetchMore for the next page or to CloseCursor to drop the rest.
+type CursorResult struct {
+	QueryResult
+	Handle  string `json:"handle,omitempty"`
+	HasMore bool   `json:"hasMore"`
+}
+
+// ExecuteQueryCursor runs a SELECT and returns only its first pageSize rows (default 500), keeping the result
+// open on the server so FetchMore can read the rest without marshaling it all at once. A session holds at most
+// one cursor: running another query through this method closes the previous one, as does releasing the
+// session. Cursors unused for five minutes are closed. Inside a transaction an open cursor would block the
+// transaction's other statements, so the result is read at once (up to SetMaxResultRows) and no handle is kept.
+func (a *App) ExecuteQueryCursor(connectionID, sessionID, sql string, pageSize int) string {
+	var out CursorResult
+	fail := func(msg string) string {
+		out.Error = msg
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	if !db.IsSelect(sql) {
+		return fail("only SELECT queries can be read through a cursor")
+	}
+	if db.HasMultipleStatements(sql) {
+		return fail(errMultipleStatements)
+	}
+	if getConnByID(connectionID) == nil {
+		return fail("connection not found")
+	}
+	if pageSize <= 0 {
+		pageSize = 500
+	}
+	if pageSize > maxResultRowsLimit {
+		pageSize = maxResultRowsLimit
+	}
+	closeCursors(connectionID, sessionID)
+	txMu.Lock()
+	inTx := activeTx[txKey(connectionID, sessionID)] != nil
+	txMu.Unlock()
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return fail(userFacingError(err).Message)
+	}
+
+	start := time.Now()
+	var set db.ResultSet
+	if inTx {
+		rowCap := currentMaxResultRows()
+		set, err = db.RawSelectResult(g, applyRowCap(sql, rowCap), rowCap)
+		out.Truncated = set.Truncated
+	} else {
+		var cur *db.Cursor
+		if cur, err = db.OpenCursor(g, sql); err == nil {
+			if set, err = cur.Fetch(pageSize); err == nil && set.Truncated {
+				out.Handle, out.HasMore = registerCursor(cur, connectionID, sessionID), true
+			}
+		}
+	}
+	elapsed := int(time.Since(start).Milliseconds())
+	if err != nil {
+		saveQueryHistory(connectionID, sql, false, elapsed, 0)
+		logStatement(connectionID, sessionID, sql, elapsed, userFacingError(err).Message)
+		return fail(userFacingError(err).Message)
+	}
+	out.Columns, out.ColumnTypes, out.PrettyJSON, out.Rows = set.Columns, set.ColumnTypes, set.PrettyJSON, set.Rows
+	out.RowCount, out.ExecutionTime, out.StatementType = len(set.Rows), elapsed, db.StatementSelect
+	appendAuditLog("query", sql, connectionID, "", "")
+	saveQueryHistory(connectionID, sql, true, elapsed, len(set.Rows))
+	logStatement(connectionID, sessionID, sql, elapsed, "")
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// FetchMore returns the next n rows (default 500) of a cursor from ExecuteQueryCursor as CursorResult JSON.
+// Handle is cleared on the last page, after which the cursor is gone. PrettyJSON row indexes are relative to
+// the page.
+func (a *App) FetchMore(handle string, n int) string {
+	var out CursorResult
+	cursorMu.Lock()
+	rc := openCursors[handle]
+	if rc != nil {
+		rc.lastUsed = time.Now()
+	}
+	cursorMu.Unlock()
+	if rc == nil {
+		out.Error = "cursor not found; it was closed or expired"
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	if n <= 0 {
+		n = 500
+	}
+	if n > maxResultRowsLimit {
+		n = maxResultRowsLimit
+	}
+	start := time.Now()
+	set, err := rc.cur.Fetch(n)
+	out.ExecutionTime = int(time.Since(start).Milliseconds())
+	if err != nil || !set.Truncated {
+		cursorMu.Lock()
+		delete(openCursors, handle)
+		cursorMu.Unlock()
+	}
+	if err != nil {
+		out.Error = userFacingError(err).Message
+	} else {
+		out.Columns, out.ColumnTypes, out.PrettyJSON, out.Rows = set.Columns, set.ColumnTypes, set.PrettyJSON, set.Rows
+		out.RowCount, out.StatementType = len(set.Rows), db.StatementSelect
+		if set.Truncated {
+			out.Handle, out.HasMore = handle, true
+		}
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// CloseCursor drops a cursor from ExecuteQueryCursor before its last page. Unknown handles are ignored.
+func (a *App) CloseCursor(handle string) {
+	cursorMu.Lock()
+	rc := openCursors[handle]
+	delete(openCursors, handle)
+	cursorMu.Unlock()
+	if rc != nil {
+		rc.cur.Close()
+	}
+}
+
+// registerCursor stores cur under a new random handle and returns the handle.
+func registerCursor(cur *db.Cursor, connID, sessionID string) string {
+	b := make([]byte, 16)
+	_, _ = io.ReadFull(rand.Reader, b)
+	handle := hex.EncodeToString(b)
+	cursorMu.Lock()
+	openCursors[handle] = &resultCursor{cur: cur, connID: connID, sessionID: sessionID, lastUsed: time.Now()}
+	cursorMu.Unlock()
+	return handle
+}
+
+// closeCursors closes the cursors of a connection and session; "*" matches any connection or session.
+func closeCursors(connID, sessionID string) {
+	cursorMu.Lock()
+	var closing []*db.Cursor
+	for h, rc := range openCursors {
+		if (connID == "*" || rc.connID == connID) && (sessionID == "*" || rc.sessionID == sessionID) {
+			closing = append(closing, rc.cur)
+			delete(openCursors, h)
+		}
+	}
+	cursorMu.Unlock()
+	for _, cur := range closing {
+		cur.Close()
+	}
+}
+
+// reapIdleCursors closes cursors unused since before now-timeout and returns how many it closed.
+func reapIdleCursors(now time.Time, timeout time.Duration) int {
+	cursorMu.Lock()
+	var closing []*db.Cursor
+	for h, rc := range openCursors {
+		if now.Sub(rc.lastUsed) > timeout {
+			closing = append(closing, rc.cur)
+			delete(openCursors, h)
+		}
+	}
+	cursorMu.Unlock()
+	for _, cur := range closing {
+		cur.Close()
+	}
+	return len(closing)
+}
+
+// runCursorReaper periodically closes result cursors the UI stopped reading, releasing their connections.
+func runCursorReaper() {
+	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+	for range tick.C {
+		if n := reapIdleCursors(time.Now(), cursorIdleTimeout); n > 0 {
+			logger.Info("closed %d result cursors idle for more than %s", n, cursorIdleTimeout)
+		}
+	}
+}
+
+// CellChange is the old (result A) and new (result B) value of a cell that differs.
+type CellChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// RowChange is a row present in both results, identified by its key values, with the cells that differ.
+type RowChange struct {
+	Key   map[string]interface{} `json:"key"`
+	Cells map[string]CellChange  `json:"cells"`
+}
+
+// ResultDiff is the output of DiffResults. Columns are those in both results (in A's order) and the only ones
+// compared; OnlyInA and OnlyInB list the rest. Added rows are in B only, Removed rows in A only.
+type ResultDiff struct {
+	KeyColumns []string                 `json:"keyColumns"`
+	Columns    []string                 `json:"columns"`
+	OnlyInA    []string                 `json:"onlyInA,omitempty"`
+	OnlyInB    []string                 `json:"onlyInB,omitempty"`
+	Added      []map[string]interface{} `json:"added"`
+	Removed    []map[string]interface{} `json:"removed"`
+	Changed    []RowChange              `json:"changed"`
+	Unchanged  int                      `json:"unchanged"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// DiffResults compares two QueryResult JSON blobs (e.g. the same query before and after a change) and returns
+// a ResultDiff. Rows are matched on keyColumnsJSON, a JSON array of column names that must be in both results;
+// empty matches on all shared columns, so any difference shows as a removed plus an added row. Rows sharing a
+// key are paired in order. Values are compared as decoded from JSON, so 1 and "1" differ.
+func (a *App) DiffResults(resultAJSON, resultBJSON, keyColumnsJSON string) string {
+	out := ResultDiff{Added: []map[string]interface{}{}, Removed: []map[string]interface{}{}, Changed: []RowChange{}}
+	fail := func(msg string) string {
+		out.Error = msg
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	decode := func(name, js string) (QueryResult, error) {
+		var r QueryResult
+		dec := json.NewDecoder(strings.NewReader(js))
+		dec.UseNumber() // keep large integers exact
+		if err := dec.Decode(&r); err != nil {
+			return r, fmt.Errorf("invalid result %s: %v", name, err)
+		}
+		if r.Error != "" {
+			return r, fmt.Errorf("result %s is an error: %s", name, r.Error)
+		}
+		return r, nil
+	}
+	resA, err := decode("A", resultAJSON)
+	if err != nil {
+		return fail(err.Error())
+	}
+	resB, err := decode("B", resultBJSON)
+	if err != nil {
+		return fail(err.Error())
+	}
+	if strings.TrimSpace(keyColumnsJSON) != "" {
+		if err := json.Unmarshal([]byte(keyColumnsJSON), &out.KeyColumns); err != nil {
+			return fail("invalid key columns: " + err.Error())
+		}
+	}
+
+	inB := make(map[string]bool, len(resB.Columns))
+	for _, c := range resB.Columns {
+		inB[c] = true
+	}
+	inA := make(map[string]bool, len(resA.Columns))
+	for _, c := range resA.Columns {
+		inA[c] = true
+		if inB[c] {
+			out.Columns = append(out.Columns, c)
+		} else {
+			out.OnlyInA = append(out.OnlyInA, c)
+		}
+	}
+	for _, c := range resB.Columns {
+		if !inA[c] {
+			out.OnlyInB = append(out.OnlyInB, c)
+		}
+	}
+	for _, k := range out.KeyColumns {
+		if !inA[k] || !inB[k] {
+			return fail(fmt.Sprintf("key column %q is not in both results", k))
+		}
+	}
+	if len(out.KeyColumns) == 0 {
+		out.KeyColumns = out.Columns
+	}
+
+	rowKey := func(row map[string]interface{}) string {
+		vals := make([]interface{}, len(out.KeyColumns))
+		for i, k := range out.KeyColumns {
+			vals[i] = row[k]
+		}
+		b, _ := json.Marshal(vals)
+		return string(b)
+	}
+	pending := make(map[string][]int) // key -> indexes of unmatched B rows, in order
+	for i, row := range resB.Rows {
+		k := rowKey(row)
+		pending[k] = append(pending[k], i)
+	}
+	matched := make([]bool, len(resB.Rows))
+	for _, rowA := range resA.Rows {
+		k := rowKey(rowA)
+		idx := pending[k]
+		if len(idx) == 0 {
+			out.Removed = append(out.Removed, rowA)
+			continue
+		}
+		pending[k] = idx[1:]
+		matched[idx[0]] = true
+		rowB := resB.Rows[idx[0]]
+		cells := make(map[string]CellChange)
+		for _, c := range out.Columns {
+			if !reflect.DeepEqual(rowA[c], rowB[c]) {
+				cells[c] = CellChange{Old: rowA[c], New: rowB[c]}
+			}
+		}
+		if len(cells) == 0 {
+			out.Unchanged++
+			continue
+		}
+		key := make(map[string]interface{}, len(out.KeyColumns))
+		for _, kc := range out.KeyColumns {
+			key[kc] = rowA[kc]
+		}
+		out.Changed = append(out.Changed, RowChange{Key: key, Cells: cells})
+	}
+	for i, row := range resB.Rows {
+		if !matched[i] {
+			out.Added = append(out.Added, row)
+		}
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// ReleaseSession closes the DB session for the given connection and tab/session. Call when a tab is closed so transactions do not leak.
+func (a *App) ReleaseSession(connectionID, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	closeSessionDB(connectionID, sessionID)
+}
+
+// StartMonitor starts a background goroutine that polls MySQL live stats every 5s and emits "live-stats" events.
+// Only MySQL is supported. Returns JSON object with "error" key on failure, or "{}" on success.
+func (a *App) StartMonitor(connectionID string) string {
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return `{"error":"connection not found"}`
+	}
+	if conn.Type != "mysql" {
+		return `{"error":"live monitor is only supported for MySQL"}`
+	}
+	monitorMu.Lock()
+	if monitorStop == nil {
+		monitorStop = make(map[string]chan struct{})
+	}
+	if _, running := monitorStop[connectionID]; running {
+		monitorMu.Unlock()
+		return `{}`
+	}
+	stopCh := make(chan struct{})
+	monitorStop[connectionID] = stopCh
+	monitorMu.Unlock()
+	go a.liveMonitorWorker(connectionID, stopCh)
+	return `{}`
+}
+
+// StopMonitor stops the background monitor for the given connection.
+func (a *App) StopMonitor(connectionID string) {
+	monitorMu.Lock()
+	ch, ok := monitorStop[connectionID]
+	if ok {
+		delete(monitorStop, connectionID)
+		close(ch)
+	}
+	monitorMu.Unlock()
+}
+
+const liveMonitorInterval = 5 * time.Second
+
+// liveMonitorWorker polls MySQL for Threads_connected and PROCESSLIST, then emits "live-stats".
+func (a *App) liveMonitorWorker(connectionID string, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(liveMonitorInterval)
+	defer ticker.Stop()
+	emit := func(payload LiveStatsPayload) {
+		data, _ := json.Marshal(payload)
+		runtime.EventsEmit(a.ctx, "live-stats", string(data))
+	}
+	for {
+		payload := LiveStatsPayload{ConnectionID: connectionID}
+		conn := getConnByID(connectionID)
+		if conn == nil {
+			payload.Error = "connection not found"
+			emit(payload)
+			return
+		}
+		g, err := getOrOpenDB(connectionID, "")
+		if err != nil {
+			payload.Error = err.Error()
+			emit(payload)
+		} else {
+			// Threads_connected
 			_, rows, err := db.RawSelect(g, "SHOW GLOBAL STATUS LIKE 'Threads_connected'")
 			if err == nil && len(rows) > 0 {
 				for _, r := range rows {
@@ -1279,8 +3793,9 @@ func (a *App) liveMonitorWorker(connectionID string, stopCh <-chan struct{}) {
 }
 
 // GetExecutionPlan runs EXPLAIN on the given SQL (SELECT only) and returns a structured plan for visualization.
-// Only MySQL is supported; SQLite returns error in summary.
-func (a *App) GetExecutionPlan(connectionID, sessionID, sql string) string {
+// MySQL and PostgreSQL are supported. analyze asks MySQL 8.0.18+ for EXPLAIN ANALYZE (actual rows and timings);
+// older servers, or an ANALYZE that fails, fall back to plain EXPLAIN with a warning. PostgreSQL always uses EXPLAIN ANALYZE.
+func (a *App) GetExecutionPlan(connectionID, sessionID, sql string, analyze bool) string {
 	var out ExecutionPlanResult
 	conn := getConnByID(connectionID)
 	if conn == nil {
@@ -1294,6 +3809,11 @@ func (a *App) GetExecutionPlan(connectionID, sessionID, sql string) string {
 		data, _ := json.Marshal(out)
 		return string(data)
 	}
+	if db.HasMultipleStatements(sql) {
+		out.Error = errMultipleStatements
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
 		out.Error = userFacingError(err).Message
@@ -1303,76 +3823,37 @@ func (a *App) GetExecutionPlan(connectionID, sessionID, sql string) string {
 
 	switch conn.Type {
 	case "mysql":
-		explainSQL := "EXPLAIN " + sql
-		cols, rows, err := db.RawSelect(g, explainSQL)
+		if analyze {
+			ver, verr := db.ServerVersion(g, conn.Type)
+			if verr == nil && !mysqlSupportsExplainAnalyze(ver) {
+				out.Summary.Warnings = append(out.Summary.Warnings, "EXPLAIN ANALYZE requires MySQL 8.0.18 or later; showing estimates")
+			} else if _, rows, err := db.RawSelect(g, "EXPLAIN ANALYZE "+sql); err != nil {
+				out.Summary.Warnings = append(out.Summary.Warnings, "EXPLAIN ANALYZE failed ("+userFacingError(err).Message+"); showing estimates")
+			} else if len(rows) > 0 {
+				nodes, totalMs, warnings := parseMySQLExplainAnalyze(firstStringValue(rows[0]))
+				out.Nodes = nodes
+				out.Summary.TotalDurationMs = int(totalMs + 0.5)
+				out.Summary.Warnings = warnings
+				break
+			}
+		}
+		if _, rows, err := db.RawSelect(g, "EXPLAIN FORMAT=JSON "+sql); err == nil && len(rows) > 0 {
+			if nodes, warnings, perr := parseMySQLExplainJSON(firstStringValue(rows[0])); perr == nil {
+				out.Nodes = nodes
+				out.Summary.Warnings = append(out.Summary.Warnings, warnings...)
+				break
+			}
+		}
+		// Servers without FORMAT=JSON (or unparseable output) fall back to the tabular format.
+		_, rows, err := db.RawSelect(g, "EXPLAIN "+sql)
 		if err != nil {
 			out.Error = userFacingError(err).Message
 			data, _ := json.Marshal(out)
 			return string(data)
 		}
-		_ = cols
-		getVal := func(row map[string]interface{}, keys ...string) string {
-			for _, k := range keys {
-				for mapK, v := range row {
-					if strings.EqualFold(mapK, k) && v != nil {
-						return fmt.Sprint(v)
-					}
-				}
-			}
-			return ""
-		}
-		getInt64 := func(row map[string]interface{}, key string) int64 {
-			s := getVal(row, key)
-			if s == "" {
-				return 0
-			}
-			var n int64
-			_, _ = fmt.Sscanf(s, "%d", &n)
-			return n
-		}
-		var warnings []string
-		nodes := make([]ExecutionPlanNode, 0, len(rows))
-		var lastID *string
-		for i, row := range rows {
-			id := fmt.Sprintf("%d", i+1)
-			typeVal := getVal(row, "type", "Type")
-			tableVal := getVal(row, "table", "Table")
-			keyVal := getVal(row, "key", "Key")
-			extraVal := getVal(row, "extra", "Extra")
-			selectType := getVal(row, "select_type", "select_type")
-			rowsEst := getInt64(row, "rows")
-			fullScan := typeVal == "ALL" || typeVal == "index"
-			indexUsed := keyVal != "" && keyVal != "NULL"
-			nodeType := "Table"
-			if strings.Contains(strings.ToLower(extraVal), "where") {
-				nodeType = "Filter"
-			}
-			if selectType == "SIMPLE" && tableVal != "" {
-				nodeType = "Scan"
-			}
-			label := tableVal
-			if label == "" {
-				label = typeVal
-			}
-			node := ExecutionPlanNode{
-				ID:            id,
-				ParentID:      lastID,
-				Type:          nodeType,
-				Label:         label,
-				Detail:        typeVal,
-				Rows:          rowsEst,
-				Extra:         extraVal,
-				FullTableScan: fullScan,
-				IndexUsed:     indexUsed,
-			}
-			nodes = append(nodes, node)
-			lastID = &id
-			if fullScan && !indexUsed && tableVal != "" {
-				warnings = append(warnings, "Full table scan on '"+tableVal+"'; consider adding an index")
-			}
-		}
+		nodes, warnings := parseMySQLExplainRows(rows)
 		out.Nodes = nodes
-		out.Summary.Warnings = warnings
+		out.Summary.Warnings = append(out.Summary.Warnings, warnings...)
 	case "postgresql", "postgres":
 		explainSQL := "EXPLAIN (ANALYZE, VERBOSE, FORMAT JSON) " + sql
 		cols, rows, err := db.RawSelect(g, explainSQL)
@@ -1400,6 +3881,7 @@ func (a *App) GetExecutionPlan(connectionID, sessionID, sql string) string {
 		}
 		out.Nodes = nodes
 		out.Summary.Warnings = warnings
+		out.Summary.TotalDurationMs = int(pgExplainExecutionTime(jsonStr) + 0.5)
 	default:
 		out.Error = "execution plan is supported for MySQL and PostgreSQL only"
 	}
@@ -1407,9 +3889,291 @@ func (a *App) GetExecutionPlan(connectionID, sessionID, sql string) string {
 	return string(data)
 }
 
-// extractPGExplainJSON gets the JSON string from EXPLAIN (FORMAT JSON) result (one row, one column).
-func extractPGExplainJSON(row map[string]interface{}, cols []string) string {
-	for _, c := range cols {
+// parseMySQLExplainRows converts tabular MySQL EXPLAIN rows into a linear chain of nodes plus full-scan warnings.
+func parseMySQLExplainRows(rows []map[string]interface{}) ([]ExecutionPlanNode, []string) {
+	getVal := func(row map[string]interface{}, keys ...string) string {
+		for _, k := range keys {
+			for mapK, v := range row {
+				if strings.EqualFold(mapK, k) && v != nil {
+					return fmt.Sprint(v)
+				}
+			}
+		}
+		return ""
+	}
+	getInt64 := func(row map[string]interface{}, key string) int64 {
+		s := getVal(row, key)
+		if s == "" {
+			return 0
+		}
+		var n int64
+		_, _ = fmt.Sscanf(s, "%d", &n)
+		return n
+	}
+	var warnings []string
+	nodes := make([]ExecutionPlanNode, 0, len(rows))
+	var lastID *string
+	for i, row := range rows {
+		id := fmt.Sprintf("%d", i+1)
+		typeVal := getVal(row, "type", "Type")
+		tableVal := getVal(row, "table", "Table")
+		keyVal := getVal(row, "key", "Key")
+		extraVal := getVal(row, "extra", "Extra")
+		selectType := getVal(row, "select_type", "select_type")
+		rowsEst := getInt64(row, "rows")
+		fullScan := typeVal == "ALL" || typeVal == "index"
+		indexUsed := keyVal != "" && keyVal != "NULL"
+		nodeType := "Table"
+		if strings.Contains(strings.ToLower(extraVal), "where") {
+			nodeType = "Filter"
+		}
+		if selectType == "SIMPLE" && tableVal != "" {
+			nodeType = "Scan"
+		}
+		label := tableVal
+		if label == "" {
+			label = typeVal
+		}
+		node := ExecutionPlanNode{
+			ID:            id,
+			ParentID:      lastID,
+			Type:          nodeType,
+			Label:         label,
+			Detail:        typeVal,
+			Rows:          rowsEst,
+			Extra:         extraVal,
+			FullTableScan: fullScan,
+			IndexUsed:     indexUsed,
+		}
+		nodes = append(nodes, node)
+		lastID = &id
+		if fullScan && !indexUsed && tableVal != "" {
+			warnings = append(warnings, "Full table scan on '"+tableVal+"'; consider adding an index")
+		}
+	}
+	return nodes, warnings
+}
+
+// parseMySQLExplainJSON parses MySQL EXPLAIN FORMAT=JSON output. The query_block tree (ordering/grouping
+// operations, nested_loop, tables, materialized subqueries, unions) becomes nodes with real ParentID links.
+func parseMySQLExplainJSON(jsonStr string) (nodes []ExecutionPlanNode, warnings []string, err error) {
+	var top map[string]interface{}
+	if e := json.Unmarshal([]byte(jsonStr), &top); e != nil {
+		return nil, nil, fmt.Errorf("invalid EXPLAIN JSON: %w", e)
+	}
+	if _, ok := top["query_block"].(map[string]interface{}); !ok {
+		return nil, nil, fmt.Errorf("EXPLAIN JSON missing query_block")
+	}
+
+	nodes = make([]ExecutionPlanNode, 0)
+	warnings = make([]string, 0)
+	add := func(parent *string, node ExecutionPlanNode) *string {
+		id := fmt.Sprintf("%d", len(nodes)+1)
+		node.ID = id
+		node.ParentID = parent
+		nodes = append(nodes, node)
+		return &id
+	}
+	costOf := func(m map[string]interface{}, key string) string {
+		ci, _ := m["cost_info"].(map[string]interface{})
+		if ci == nil {
+			return ""
+		}
+		return getStr(ci, key)
+	}
+
+	var walk func(m map[string]interface{}, parent *string)
+	walkList := func(v interface{}, parent *string) {
+		list, _ := v.([]interface{})
+		for _, item := range list {
+			if sub, _ := item.(map[string]interface{}); sub != nil {
+				walk(sub, parent)
+			}
+		}
+	}
+	walk = func(m map[string]interface{}, parent *string) {
+		if qb, _ := m["query_block"].(map[string]interface{}); qb != nil {
+			label := "SELECT"
+			if sid := getStr(qb, "select_id"); sid != "" {
+				label = "SELECT #" + sid
+			}
+			id := add(parent, ExecutionPlanNode{Type: "Query", Label: label, Detail: "query_block", Cost: costOf(qb, "query_cost")})
+			walk(qb, id)
+		}
+		for _, op := range []struct{ key, typ, label string }{
+			{"ordering_operation", "Sort", "Sort"},
+			{"grouping_operation", "Aggregate", "Group"},
+			{"duplicates_removal", "Aggregate", "Distinct"},
+			{"windowing", "Aggregate", "Window"},
+		} {
+			if sub, _ := m[op.key].(map[string]interface{}); sub != nil {
+				node := ExecutionPlanNode{Type: op.typ, Label: op.label, Detail: op.key}
+				if getStr(sub, "using_filesort") == "true" {
+					node.Extra = "Using filesort"
+				}
+				if getStr(sub, "using_temporary_table") == "true" {
+					node.Extra = strings.TrimPrefix(node.Extra+"; Using temporary", "; ")
+				}
+				walk(sub, add(parent, node))
+			}
+		}
+		if nl, ok := m["nested_loop"]; ok {
+			walkList(nl, add(parent, ExecutionPlanNode{Type: "Join", Label: "Nested loop", Detail: "nested_loop"}))
+		}
+		if ur, _ := m["union_result"].(map[string]interface{}); ur != nil {
+			id := add(parent, ExecutionPlanNode{Type: "Union", Label: "Union", Detail: getStr(ur, "table_name")})
+			walkList(ur["query_specifications"], id)
+		}
+		if t, _ := m["table"].(map[string]interface{}); t != nil {
+			name := getStr(t, "table_name")
+			access := getStr(t, "access_type")
+			key := getStr(t, "key")
+			fullScan := access == "ALL" || access == "index"
+			node := ExecutionPlanNode{
+				Type:          "Scan",
+				Label:         name,
+				Detail:        access,
+				Rows:          int64(getFloat(t, "rows_examined_per_scan")),
+				Cost:          costOf(t, "prefix_cost"),
+				FullTableScan: fullScan,
+				IndexUsed:     key != "",
+			}
+			if key != "" {
+				node.Extra = "Index: " + key
+			}
+			if cond := getStr(t, "attached_condition"); cond != "" {
+				node.Type = "Filter"
+				node.Extra = strings.TrimPrefix(node.Extra+"; "+cond, "; ")
+			}
+			id := add(parent, node)
+			if fullScan && key == "" && name != "" {
+				warnings = append(warnings, "Full table scan on '"+name+"'; consider adding an index")
+			}
+			if mat, _ := t["materialized_from_subquery"].(map[string]interface{}); mat != nil {
+				walk(mat, id)
+			}
+			walkList(t["attached_subqueries"], id)
+		}
+		walkList(m["attached_subqueries"], parent)
+		walkList(m["optimized_away_subqueries"], parent)
+	}
+	walk(top, nil)
+	return nodes, warnings, nil
+}
+
+// mysqlSupportsExplainAnalyze reports whether a VERSION() string is MySQL 8.0.18 or later (MariaDB is excluded).
+func mysqlSupportsExplainAnalyze(version string) bool {
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		return false
+	}
+	var major, minor, patch int
+	if n, _ := fmt.Sscanf(version, "%d.%d.%d", &major, &minor, &patch); n < 2 {
+		return false
+	}
+	if major != 8 {
+		return major > 8
+	}
+	return minor > 0 || patch >= 18
+}
+
+// firstStringValue returns the first non-nil value of a single-column row as string (e.g. EXPLAIN ANALYZE output).
+func firstStringValue(row map[string]interface{}) string {
+	for _, v := range row {
+		switch x := v.(type) {
+		case string:
+			return x
+		case []byte:
+			return string(x)
+		}
+	}
+	return ""
+}
+
+// parseMySQLExplainAnalyze parses MySQL EXPLAIN ANALYZE tree output ("-> Node  (cost=.. rows=..) (actual time=a..b rows=n loops=l)")
+// into nodes nested by indentation. Returns nodes, the root's actual total time in ms, and full-scan warnings.
+func parseMySQLExplainAnalyze(text string) (nodes []ExecutionPlanNode, totalMs float64, warnings []string) {
+	type frame struct {
+		indent int
+		id     string
+	}
+	var stack []frame
+	nodes = make([]ExecutionPlanNode, 0)
+	for _, line := range strings.Split(text, "\n") {
+		m := explainAnalyzeLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		indent, body := len(m[1]), m[2]
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		var parent *string
+		if len(stack) > 0 {
+			p := stack[len(stack)-1].id
+			parent = &p
+		}
+		label := body
+		if i := strings.Index(body, "  ("); i >= 0 {
+			label = body[:i]
+		} else if i := strings.Index(body, " (cost="); i >= 0 {
+			label = body[:i]
+		}
+		node := ExecutionPlanNode{ID: fmt.Sprintf("%d", len(nodes)+1), ParentID: parent, Type: "Table", Label: label, Detail: label}
+		if cm := explainCostRegex.FindStringSubmatch(body); cm != nil {
+			node.Cost = cm[1]
+			est, _ := strconv.ParseFloat(cm[2], 64)
+			node.Rows = int64(est)
+		}
+		if am := explainActualRegex.FindStringSubmatch(body); am != nil {
+			end, _ := strconv.ParseFloat(am[2], 64)
+			actualRows, _ := strconv.ParseFloat(am[3], 64)
+			node.Rows = int64(actualRows)
+			node.Extra = "actual time=" + am[1] + ".." + am[2] + " ms, loops=" + am[4]
+			if parent == nil && end > totalMs {
+				totalMs = end
+			}
+		}
+		lower := strings.ToLower(label)
+		switch {
+		case strings.Contains(lower, "join") || strings.Contains(lower, "nested loop"):
+			node.Type = "Join"
+		case strings.HasPrefix(lower, "sort"):
+			node.Type = "Sort"
+		case strings.Contains(lower, "aggregate") || strings.Contains(lower, "group"):
+			node.Type = "Aggregate"
+		case strings.HasPrefix(lower, "limit"):
+			node.Type = "Limit"
+		case strings.HasPrefix(lower, "filter"):
+			node.Type = "Filter"
+		case strings.Contains(lower, "scan") || strings.Contains(lower, "lookup"):
+			node.Type = "Scan"
+		}
+		if tm := explainTableRegex.FindStringSubmatch(label); tm != nil {
+			node.Label = tm[2]
+			node.FullTableScan = strings.EqualFold(tm[1], "Table scan")
+			node.IndexUsed = !node.FullTableScan
+			if node.FullTableScan {
+				warnings = append(warnings, "Full table scan on '"+tm[2]+"'; consider adding an index")
+			}
+		}
+		nodes = append(nodes, node)
+		stack = append(stack, frame{indent: indent, id: node.ID})
+	}
+	return nodes, totalMs, warnings
+}
+
+// pgExplainExecutionTime returns the top-level "Execution Time" (ms) from EXPLAIN (ANALYZE, FORMAT JSON) output, or 0.
+func pgExplainExecutionTime(jsonStr string) float64 {
+	var arr []map[string]interface{}
+	if json.Unmarshal([]byte(jsonStr), &arr) != nil || len(arr) == 0 {
+		return 0
+	}
+	return getFloat(arr[0], "Execution Time")
+}
+
+// extractPGExplainJSON gets the JSON string from EXPLAIN (FORMAT JSON) result (one row, one column).
+func extractPGExplainJSON(row map[string]interface{}, cols []string) string {
+	for _, c := range cols {
 		if v, ok := row[c]; ok && v != nil {
 			switch x := v.(type) {
 			case string:
@@ -1568,13 +4332,49 @@ func getFloat(m map[string]interface{}, key string) float64 {
 type ApiError struct {
 	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
+	// Position is the 1-based character offset of a syntax error in the statement (PostgreSQL).
+	Position int `json:"position,omitempty"`
+	// Line is the 1-based line of a syntax error in the statement (MySQL).
+	Line int `json:"line,omitempty"`
+	// Near is the text the server quoted at the error (MySQL, SQLite, PostgreSQL).
+	Near string `json:"near,omitempty"`
+}
+
+var (
+	mysqlSyntaxNearRe  = regexp.MustCompile(`(?s)near '(.*)' at line (\d+)`)
+	pgSyntaxNearRe     = regexp.MustCompile(`at or near "((?:[^"]|"")*)"`)
+	sqliteSyntaxNearRe = regexp.MustCompile(`near "((?:[^"]|"")*)": syntax error`)
+	mysqlSyntaxLineRe  = regexp.MustCompile(`at line (\d+)`)
+)
+
+// syntaxErrorLocation fills the Position, Line and Near of a syntax error from the driver error: PostgreSQL
+// reports a character position, MySQL "near '...' at line N" and SQLite `near "...": syntax error`.
+func syntaxErrorLocation(err error, msg string, out *ApiError) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		out.Position = int(pgErr.Position)
+	}
+	if m := mysqlSyntaxNearRe.FindStringSubmatch(msg); m != nil {
+		out.Near = m[1]
+		out.Line, _ = strconv.Atoi(m[2])
+	} else if m := mysqlSyntaxLineRe.FindStringSubmatch(msg); m != nil {
+		out.Line, _ = strconv.Atoi(m[1])
+	}
+	if out.Near != "" {
+		return
+	}
+	if m := pgSyntaxNearRe.FindStringSubmatch(msg); m != nil {
+		out.Near = strings.ReplaceAll(m[1], `""`, `"`)
+	} else if m := sqliteSyntaxNearRe.FindStringSubmatch(msg); m != nil {
+		out.Near = strings.ReplaceAll(m[1], `""`, `"`)
+	}
 }
 
 func userFacingError(err error) ApiError {
 	if err == nil {
 		return ApiError{}
 	}
-	msg := err.Error()
+	msg := logger.Redact(err.Error())
 	low := strings.ToLower(msg)
 	switch {
 	case strings.Contains(low, "connection not found"):
@@ -1583,8 +4383,11 @@ func userFacingError(err error) ApiError {
 		return ApiError{Code: "CONNECTION_REFUSED", Message: "Cannot connect to database: connection refused. Check host, port, and that the server is running."}
 	case strings.Contains(low, "access denied") || (strings.Contains(low, "password") && strings.Contains(low, "failed")) || strings.Contains(low, "authentication failed"):
 		return ApiError{Code: "ACCESS_DENIED", Message: "Access denied. Check username and password."}
-	case strings.Contains(low, "syntax error") || strings.Contains(low, "syntaxerror") || strings.Contains(low, "unexpected token"):
-		return ApiError{Code: "SYNTAX_ERROR", Message: "SQL syntax error. Check your query."}
+	case strings.Contains(low, "syntax error") || strings.Contains(low, "syntaxerror") || strings.Contains(low, "unexpected token") ||
+		strings.Contains(low, "error in your sql syntax"):
+		out := ApiError{Code: "SYNTAX_ERROR", Message: "SQL syntax error. Check your query."}
+		syntaxErrorLocation(err, msg, &out)
+		return out
 	case strings.Contains(low, "does not exist") || strings.Contains(low, "relation ") && strings.Contains(low, " does not exist"):
 		return ApiError{Code: "NOT_FOUND", Message: msg}
 	case strings.Contains(low, "duplicate key") || strings.Contains(low, "unique constraint"):
@@ -1596,6 +4399,16 @@ func userFacingError(err error) ApiError {
 	}
 }
 
+// marshalErrorResult is the QueryResult of a failed statement, carrying the error's location when known.
+func marshalErrorResult(execMs int, e ApiError) string {
+	r := QueryResult{ExecutionTime: execMs, Error: e.Message}
+	if e.Position > 0 || e.Line > 0 || e.Near != "" {
+		r.ErrorDetail = &e
+	}
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
 func mustMarshalResult(cols []string, rows []map[string]interface{}, rowCount, execMs int, errMsg string, affected ...int) string {
 	r := QueryResult{
 		Columns:       cols,
@@ -1611,12 +4424,30 @@ func mustMarshalResult(cols []string, rows []map[string]interface{}, rowCount, e
 	return string(data)
 }
 
-func marshalQueryResultCached(cols []string, rows []map[string]interface{}, rowCount, execMs int, cached bool) string {
-	r := QueryResult{Columns: cols, Rows: rows, RowCount: rowCount, ExecutionTime: execMs, Cached: cached}
+func marshalQueryResultCached(cols []string, colTypes []db.ColumnMeta, pretty map[int]map[string]string, rows []map[string]interface{}, rowCount, execMs int, cached, truncated bool) string {
+	r := QueryResult{Columns: cols, ColumnTypes: colTypes, PrettyJSON: pretty, Rows: rows, RowCount: rowCount, ExecutionTime: execMs, Cached: cached, Truncated: truncated, StatementType: db.StatementSelect}
 	data, _ := json.Marshal(r)
 	return string(data)
 }
 
+func currentMaxResultRows() int {
+	maxResultRowsMu.Lock()
+	defer maxResultRowsMu.Unlock()
+	return maxResultRows
+}
+
+// applyRowCap appends LIMIT maxRows+1 to a SELECT/WITH statement that has no LIMIT/FETCH/TOP of its own,
+// so the server stops early; the extra row tells RawSelectLimit the result was truncated. Statements it cannot
+// safely rewrite are returned unchanged and are only capped while reading. maxRows <= 0 disables the cap.
+func applyRowCap(sql string, maxRows int) string {
+	if maxRows <= 0 || !rowCapStartRegex.MatchString(sql) || rowCapSkipRegex.MatchString(sql) {
+		return sql
+	}
+	trimmed := strings.TrimRight(strings.TrimSpace(sql), "; \t\r\n")
+	// newline so a trailing "-- comment" cannot swallow the LIMIT
+	return fmt.Sprintf("%s\nLIMIT %d", trimmed, maxRows+1)
+}
+
 func normalizeSQL(sql string) string {
 	s := strings.TrimSpace(sql)
 	return wsRegex.ReplaceAllString(s, " ")
@@ -1667,6 +4498,48 @@ func queryCacheSet(key string, e queryCacheEntry) {
 	queryCacheOrder = append(queryCacheOrder, key)
 }
 
+func clearQueryCache() {
+	queryCacheMu.Lock()
+	defer queryCacheMu.Unlock()
+	queryCache = make(map[string]queryCacheEntry)
+	queryCacheOrder = nil
+}
+
+func clearQueryCacheForConnection(connID string) {
+	prefix := connID + "\x00"
+	queryCacheMu.Lock()
+	defer queryCacheMu.Unlock()
+	kept := queryCacheOrder[:0]
+	for _, k := range queryCacheOrder {
+		if strings.HasPrefix(k, prefix) {
+			delete(queryCache, k)
+			continue
+		}
+		kept = append(kept, k)
+	}
+	queryCacheOrder = kept
+}
+
+// invalidateQueryCacheForTable drops cached results of connID whose SQL mentions tableName.
+func invalidateQueryCacheForTable(connID, tableName string) {
+	prefix := connID + "\x00"
+	re, err := regexp.Compile(`(?i)\b` + regexp.QuoteMeta(tableName) + `\b`)
+	if err != nil {
+		return
+	}
+	queryCacheMu.Lock()
+	defer queryCacheMu.Unlock()
+	kept := queryCacheOrder[:0]
+	for _, k := range queryCacheOrder {
+		if strings.HasPrefix(k, prefix) && re.MatchString(k[len(prefix):]) {
+			delete(queryCache, k)
+			continue
+		}
+		kept = append(kept, k)
+	}
+	queryCacheOrder = kept
+}
+
 func queryCacheStats() (hits, misses int64) {
 	queryCacheMu.Lock()
 	defer queryCacheMu.Unlock()
@@ -1696,7 +4569,7 @@ func (a *App) GetQueryCacheStats() string {
 	return string(b)
 }
 
-// ExtractIndexHintTablesAndCols parses SQL for table (FROM/JOIN) and column (WHERE/ON) hints. Used by index suggestions.
+// ExtractIndexHintTablesAndCols parses SQL for table (FROM/JOIN) and column (WHERE/ON/GROUP BY/ORDER BY) hints. Used by index suggestions.
 func ExtractIndexHintTablesAndCols(sql string) (tables []string, cols []string) {
 	norm := wsRegex.ReplaceAllString(strings.TrimSpace(sql), " ")
 	for _, m := range fromJoinRegex.FindAllStringSubmatch(norm, -1) {
@@ -1705,22 +4578,88 @@ func ExtractIndexHintTablesAndCols(sql string) (tables []string, cols []string)
 		}
 	}
 	seenCol := make(map[string]bool)
-	for _, m := range whereColRegex.FindAllStringSubmatch(norm, -1) {
-		if len(m) > 1 && m[1] != "" && !indexHintSkip[strings.ToUpper(m[1])] && !seenCol[m[1]] {
-			seenCol[m[1]] = true
-			cols = append(cols, m[1])
+	for _, ref := range indexColumnRefs(norm) {
+		if !seenCol[ref[1]] {
+			seenCol[ref[1]] = true
+			cols = append(cols, ref[1])
 		}
 	}
 	return tables, cols
 }
 
+// indexColumnRefs returns [qualifier, column] pairs from comparisons (WHERE and JOIN ... ON, both sides when qualified)
+// followed by GROUP BY / ORDER BY columns. qualifier is empty for unqualified columns.
+func indexColumnRefs(norm string) [][2]string {
+	var refs [][2]string
+	add := func(qual, col string) {
+		if col == "" || col[0] >= '0' && col[0] <= '9' || indexHintSkip[strings.ToUpper(col)] || isSQLKeyword(col) {
+			return
+		}
+		refs = append(refs, [2]string{qual, col})
+	}
+	for _, m := range whereColRegex.FindAllStringSubmatch(norm, -1) {
+		add(m[1], m[2])
+	}
+	for _, m := range rhsColRegex.FindAllStringSubmatch(norm, -1) {
+		add(m[1], m[2])
+	}
+	for _, loc := range orderGroupRegex.FindAllStringIndex(norm, -1) {
+		list := norm[loc[1]:]
+		if end := orderGroupEndRegex.FindStringIndex(list); end != nil {
+			list = list[:end[0]]
+		}
+		for _, item := range strings.Split(list, ",") {
+			if im := orderItemRegex.FindStringSubmatch(strings.TrimSpace(item)); im != nil {
+				add(im[1], im[2])
+			}
+		}
+	}
+	return refs
+}
+
+// extractIndexColumnsByTable maps lower-cased table names to the columns the query filters, joins, groups or sorts on.
+// Qualifiers are resolved through table aliases; unqualified columns go to the only table, or under "" when ambiguous.
+func extractIndexColumnsByTable(sql string) map[string][]string {
+	norm := wsRegex.ReplaceAllString(strings.TrimSpace(sql), " ")
+	aliases := sqlTableAliases(norm)
+	distinct := make(map[string]bool)
+	for _, t := range aliases {
+		distinct[strings.ToLower(t)] = true
+	}
+	var only string
+	if len(distinct) == 1 {
+		for t := range distinct {
+			only = t
+		}
+	}
+	out := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, ref := range indexColumnRefs(norm) {
+		table := only
+		if ref[0] != "" {
+			table = strings.ToLower(ref[0])
+			if real, ok := aliases[table]; ok {
+				table = strings.ToLower(real)
+			}
+		}
+		key := table + "." + strings.ToLower(ref[1])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out[table] = append(out[table], ref[1])
+	}
+	return out
+}
+
 func extractIndexHintTablesAndCols(sql string) (tables []string, cols []string) {
 	return ExtractIndexHintTablesAndCols(sql)
 }
 
 // GetIndexSuggestions runs EXPLAIN on the given SELECT, detects full-table scans, and returns CREATE INDEX suggestions.
 // MySQL and PostgreSQL supported. Uses simple SQL parsing to infer tables and WHERE/JOIN columns.
-func (a *App) GetIndexSuggestions(connectionID, sessionID, sql string) string {
+// database is the active database (MySQL) or schema (PostgreSQL) used to qualify the suggested DDL; optional.
+func (a *App) GetIndexSuggestions(connectionID, database, sessionID, sql string) string {
 	var out struct {
 		Suggestions []IndexSuggestion `json:"suggestions"`
 		Error       string            `json:"error,omitempty"`
@@ -1737,18 +4676,23 @@ func (a *App) GetIndexSuggestions(connectionID, sessionID, sql string) string {
 		b, _ := json.Marshal(out)
 		return string(b)
 	}
+	if db.HasMultipleStatements(sql) {
+		out.Error = errMultipleStatements
+		b, _ := json.Marshal(out)
+		return string(b)
+	}
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
 		out.Error = userFacingError(err).Message
 		b, _ := json.Marshal(out)
 		return string(b)
 	}
-	_, colsFromSQL := extractIndexHintTablesAndCols(sql)
+	colsByTable := extractIndexColumnsByTable(sql)
+	aliases := sqlTableAliases(sql)
 	driver := conn.Type
 	if driver == "postgres" {
 		driver = "postgresql"
 	}
-	quote := func(s string) string { return quoteIdent(driver, s) }
 
 	var fullScanTables []string
 	switch conn.Type {
@@ -1816,64 +4760,155 @@ func (a *App) GetIndexSuggestions(connectionID, sessionID, sql string) string {
 		return string(b)
 	}
 
+	seenTable := make(map[string]bool)
 	for _, t := range fullScanTables {
-		reason := "Full table scan on '" + t + "'"
-		var cols []string
-		for _, c := range colsFromSQL {
-			cols = append(cols, c)
+		// MySQL EXPLAIN reports the alias; suggest on the real table.
+		if real, ok := aliases[strings.ToLower(t)]; ok {
+			t = real
 		}
-		var createIndex string
-		if len(cols) > 0 {
-			var idxCols []string
-			for _, c := range cols {
-				idxCols = append(idxCols, quote(c))
-			}
-			idxName := "idx_" + t
-			if len(idxName) > 50 {
-				idxName = idxName[:50]
-			}
-			createIndex = fmt.Sprintf("CREATE INDEX %s ON %s (%s);", quote(idxName), quote(t), strings.Join(idxCols, ", "))
-		} else {
-			createIndex = "-- Consider adding an index on table " + quote(t) + ". Add columns from WHERE/JOIN. Example: CREATE INDEX " + quote("idx_"+t) + " ON " + quote(t) + "(col1, col2);"
+		if seenTable[strings.ToLower(t)] {
+			continue
+		}
+		seenTable[strings.ToLower(t)] = true
+		tableCols := colsByTable[strings.ToLower(t)]
+		if len(tableCols) == 0 {
+			tableCols = colsByTable[""]
 		}
+		createIndex, cols := buildCreateIndexDDL(driver, database, t, tableCols)
 		out.Suggestions = append(out.Suggestions, IndexSuggestion{
 			Table:       t,
 			Columns:     cols,
 			CreateIndex: createIndex,
-			Reason:      reason,
+			Reason:      "Full table scan on '" + t + "'",
 		})
 	}
 	b, _ := json.Marshal(out)
 	return string(b)
 }
 
+// indexNameMaxLen is the identifier length limit for index names (MySQL 64, PostgreSQL 63).
+func indexNameMaxLen(driver string) int {
+	if driver == "mysql" {
+		return 64
+	}
+	return 63
+}
+
+// buildCreateIndexDDL builds a CREATE INDEX on the qualified table for cols (deduplicated, case-insensitive).
+// Returns the DDL and the columns used; without columns the DDL is a commented-out template.
+func buildCreateIndexDDL(driver, database, table string, cols []string) (string, []string) {
+	quote := func(s string) string { return quoteIdent(driver, s) }
+	qualified := db.QualTable(driver, database, table)
+	var uniq []string
+	seen := make(map[string]bool)
+	for _, c := range cols {
+		k := strings.ToLower(c)
+		if c == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		uniq = append(uniq, c)
+	}
+	name := "idx_" + table
+	if len(uniq) > 0 {
+		name += "_" + strings.Join(uniq, "_")
+	}
+	if limit := indexNameMaxLen(driver); len(name) > limit {
+		// cut on a rune boundary so multibyte table or column names stay valid UTF-8
+		for limit > 0 && !utf8.RuneStart(name[limit]) {
+			limit--
+		}
+		name = strings.TrimRight(name[:limit], "_")
+	}
+	if len(uniq) == 0 {
+		return "-- Consider adding an index on table " + qualified + ". Add columns from WHERE/JOIN. Example: CREATE INDEX " + quote(name) + " ON " + qualified + " (col1, col2);", nil
+	}
+	idxCols := make([]string, len(uniq))
+	for i, c := range uniq {
+		idxCols[i] = quote(c)
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", quote(name), qualified, strings.Join(idxCols, ", ")), uniq
+}
+
 // FormatSQL formats a SQL query (no-op for now)
 func (a *App) FormatSQL(sql string) string {
 	return sql
 }
 
 // LoadSchemaMetadata starts a background goroutine to fetch all databases, tables, and columns for the connection.
-// When done, caches the result and emits "schema-metadata-ready" with connectionID for the frontend.
+// Table schemas are fetched by a bounded worker pool; "schema-metadata-progress" events ({connectionId, done, total})
+// report progress. When done, caches the result and emits "schema-metadata-ready" with connectionID for the frontend.
+// A load already running for the connection is canceled first.
 func (a *App) LoadSchemaMetadata(connectionID string) {
-	go a.loadSchemaMetadataWorker(connectionID)
+	stopCh := make(chan struct{})
+	schemaLoadMu.Lock()
+	if old, ok := schemaLoadStop[connectionID]; ok {
+		close(old)
+	}
+	schemaLoadStop[connectionID] = stopCh
+	schemaLoadMu.Unlock()
+	go a.loadSchemaMetadataWorker(connectionID, stopCh)
+}
+
+// cancelSchemaMetadataLoad stops a running LoadSchemaMetadata for the connection, if any.
+func cancelSchemaMetadataLoad(connectionID string) {
+	schemaLoadMu.Lock()
+	defer schemaLoadMu.Unlock()
+	if ch, ok := schemaLoadStop[connectionID]; ok {
+		delete(schemaLoadStop, connectionID)
+		close(ch)
+	}
+}
+
+// storeSchemaMetadata caches meta unless its load was canceled. The check happens under schemaMetaMu: callers
+// cancel the load before deleting the cache entry, so a load finishing concurrently with DeleteConnection or
+// ReconnectConnection either sees the cancel or is overwritten by the delete, and never resurrects the entry.
+func storeSchemaMetadata(connectionID string, meta SchemaMetadata, stopCh <-chan struct{}) bool {
+	schemaMetaMu.Lock()
+	defer schemaMetaMu.Unlock()
+	select {
+	case <-stopCh:
+		return false
+	default:
+	}
+	schemaMetaCache[connectionID] = meta
+	return true
 }
 
-func (a *App) loadSchemaMetadataWorker(connectionID string) {
+func (a *App) loadSchemaMetadataWorker(connectionID string, stopCh chan struct{}) {
+	defer func() {
+		schemaLoadMu.Lock()
+		if schemaLoadStop[connectionID] == stopCh {
+			delete(schemaLoadStop, connectionID)
+		}
+		schemaLoadMu.Unlock()
+	}()
+	stopped := func() bool {
+		select {
+		case <-stopCh:
+			return true
+		default:
+			return false
+		}
+	}
+	finish := func(meta SchemaMetadata) {
+		if storeSchemaMetadata(connectionID, meta, stopCh) {
+			runtime.EventsEmit(a.ctx, "schema-metadata-ready", connectionID)
+		}
+	}
+
 	meta := SchemaMetadata{ConnectionID: connectionID}
+	if stopped() {
+		return
+	}
 	g, err := getOrOpenDB(connectionID, "")
 	if err != nil {
-		schemaMetaMu.Lock()
-		schemaMetaCache[connectionID] = meta
-		schemaMetaMu.Unlock()
-		runtime.EventsEmit(a.ctx, "schema-metadata-ready", connectionID)
+		finish(meta)
 		return
 	}
 	conn := getConnByID(connectionID)
 	if conn == nil {
-		schemaMetaMu.Lock()
-		schemaMetaCache[connectionID] = meta
-		schemaMetaMu.Unlock()
-		runtime.EventsEmit(a.ctx, "schema-metadata-ready", connectionID)
+		finish(meta)
 		return
 	}
 	var dbNames []string
@@ -1882,64 +4917,318 @@ func (a *App) loadSchemaMetadataWorker(connectionID string) {
 	} else {
 		dbNames, _ = db.DatabaseNames(g, conn.Type)
 	}
-	for _, dbName := range dbNames {
-		dbMeta := SchemaDBMeta{Name: dbName}
+
+	type job struct{ dbIdx, tblIdx int }
+	var jobs []job
+	meta.Databases = make([]SchemaDBMeta, len(dbNames))
+	for i, dbName := range dbNames {
+		if stopped() {
+			return
+		}
+		meta.Databases[i] = SchemaDBMeta{Name: dbName}
 		tableNames, err := db.TableNames(g, conn.Type, dbName)
 		if err != nil {
-			meta.Databases = append(meta.Databases, dbMeta)
 			continue
 		}
-		for _, tblName := range tableNames {
-			tblMeta := SchemaTableMeta{Name: tblName}
-			schemaJSON := a.GetTableSchema(connectionID, dbName, tblName, "")
-			var ts TableSchema
-			if json.Unmarshal([]byte(schemaJSON), &ts) == nil {
-				for _, c := range ts.Columns {
-					tblMeta.Columns = append(tblMeta.Columns, SchemaColumnMeta{Name: c.Name, Type: c.Type})
+		meta.Databases[i].Tables = make([]SchemaTableMeta, len(tableNames))
+		for j, tblName := range tableNames {
+			meta.Databases[i].Tables[j] = SchemaTableMeta{Name: tblName}
+			jobs = append(jobs, job{i, j})
+		}
+	}
+
+	total := len(jobs)
+	jobCh := make(chan job)
+	var wg sync.WaitGroup
+	var progressMu sync.Mutex
+	done := 0
+	workers := schemaMetaWorkers
+	if total < workers {
+		workers = total
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for jb := range jobCh {
+				// g is shared rather than reopened per table: after a cancel (CloseConnection, DeleteConnection)
+				// getOrOpenDB would bring back the pooled connection that was just closed.
+				if stopped() {
+					continue
+				}
+				dbMeta := &meta.Databases[jb.dbIdx]
+				tblMeta := &dbMeta.Tables[jb.tblIdx]
+				if info, err := db.TableSchema(g, conn.Type, dbMeta.Name, tblMeta.Name); err == nil {
+					for _, c := range info.Columns {
+						tblMeta.Columns = append(tblMeta.Columns, SchemaColumnMeta{Name: c.Name, Type: c.Type})
+					}
+				}
+				progressMu.Lock()
+				done++
+				n := done
+				progressMu.Unlock()
+				if (n%schemaMetaProgressEvery == 0 || n == total) && !stopped() {
+					runtime.EventsEmit(a.ctx, "schema-metadata-progress", map[string]interface{}{
+						"connectionId": connectionID, "done": n, "total": total,
+					})
 				}
 			}
-			dbMeta.Tables = append(dbMeta.Tables, tblMeta)
+		}()
+	}
+feed:
+	for _, jb := range jobs {
+		select {
+		case <-stopCh:
+			break feed
+		case jobCh <- jb:
 		}
-		meta.Databases = append(meta.Databases, dbMeta)
 	}
-	schemaMetaMu.Lock()
-	schemaMetaCache[connectionID] = meta
-	schemaMetaMu.Unlock()
-	runtime.EventsEmit(a.ctx, "schema-metadata-ready", connectionID)
+	close(jobCh)
+	wg.Wait()
+	finish(meta)
 }
 
-// GetSchemaMetadata returns cached schema metadata (JSON) for the connection. Empty object if not loaded yet.
-func (a *App) GetSchemaMetadata(connectionID string) string {
+// RefreshTableMetadata re-reads one table's columns into the cached schema metadata after DDL, adding, replacing
+// or (when the table no longer exists) removing its entry, then emits "schema-metadata-ready". No-op when nothing is cached.
+func (a *App) RefreshTableMetadata(connectionID, database, tableName string) error {
 	schemaMetaMu.RLock()
-	meta, ok := schemaMetaCache[connectionID]
+	_, cached := schemaMetaCache[connectionID]
 	schemaMetaMu.RUnlock()
-	if !ok {
-		return `{"connectionId":"` + connectionID + `","databases":[]}`
+	if !cached {
+		return nil
+	}
+	g, err := getOrOpenDB(connectionID, "")
+	if err != nil {
+		return err
 	}
-	data, _ := json.Marshal(meta)
-	return string(data)
-}
-
-// BackupResult is JSON returned by BackupNow.
-type BackupResult struct {
-	Success bool   `json:"success"`
-	Path    string `json:"path,omitempty"`
-	Error   string `json:"error,omitempty"`
-}
-
-// RestoreResult is JSON returned by RestoreBackup.
-type RestoreResult struct {
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
-}
-
-// BackupNow opens a save-file dialog, runs mysqldump/pg_dump/sqlite3 .dump, saves to the chosen path, and records the backup. Returns BackupResult JSON.
-// SSH tunnel is not supported for backup.
-func (a *App) BackupNow(connectionID string) string {
-	var out BackupResult
 	conn := getConnByID(connectionID)
 	if conn == nil {
-		out.Error = "connection not found"
+		return fmt.Errorf("connection not found")
+	}
+	info, err := db.TableSchema(g, conn.Type, database, tableName)
+	if err != nil {
+		return err
+	}
+	tbl := SchemaTableMeta{Name: tableName}
+	for _, c := range info.Columns {
+		tbl.Columns = append(tbl.Columns, SchemaColumnMeta{Name: c.Name, Type: c.Type})
+	}
+	schemaMetaMu.Lock()
+	meta, ok := schemaMetaCache[connectionID]
+	if ok {
+		schemaMetaCache[connectionID] = upsertTableMeta(meta, database, tbl, len(info.Columns) == 0)
+	}
+	schemaMetaMu.Unlock()
+	if ok {
+		runtime.EventsEmit(a.ctx, "schema-metadata-ready", connectionID)
+	}
+	return nil
+}
+
+// upsertTableMeta returns meta with tbl replaced or added in database (created if missing), or removed when remove is set.
+// The Databases and Tables slices are copied so readers of the previous value are unaffected.
+func upsertTableMeta(meta SchemaMetadata, database string, tbl SchemaTableMeta, remove bool) SchemaMetadata {
+	dbs := make([]SchemaDBMeta, len(meta.Databases))
+	copy(dbs, meta.Databases)
+	dbIdx := -1
+	for i, d := range dbs {
+		if d.Name == database {
+			dbIdx = i
+			break
+		}
+	}
+	if dbIdx < 0 {
+		if remove {
+			return meta
+		}
+		dbs = append(dbs, SchemaDBMeta{Name: database})
+		dbIdx = len(dbs) - 1
+	}
+	tables := make([]SchemaTableMeta, 0, len(dbs[dbIdx].Tables)+1)
+	replaced := false
+	for _, t := range dbs[dbIdx].Tables {
+		if t.Name != tbl.Name {
+			tables = append(tables, t)
+			continue
+		}
+		if !remove {
+			tables = append(tables, tbl)
+			replaced = true
+		}
+	}
+	if !remove && !replaced {
+		tables = append(tables, tbl)
+	}
+	dbs[dbIdx].Tables = tables
+	meta.Databases = dbs
+	return meta
+}
+
+// GetSchemaMetadata returns cached schema metadata (JSON) for the connection. Empty object if not loaded yet.
+func (a *App) GetSchemaMetadata(connectionID string) string {
+	schemaMetaMu.RLock()
+	meta, ok := schemaMetaCache[connectionID]
+	schemaMetaMu.RUnlock()
+	if !ok {
+		return `{"connectionId":"` + connectionID + `","databases":[]}`
+	}
+	data, _ := json.Marshal(meta)
+	return string(data)
+}
+
+// sqlTableAliases maps aliases and table names (lower-cased) found in FROM/JOIN clauses to the table name.
+func sqlTableAliases(sql string) map[string]string {
+	out := make(map[string]string)
+	for _, m := range tableAliasRegex.FindAllStringSubmatch(sql, -1) {
+		tbl := m[1]
+		if tbl == "" || indexHintSkip[strings.ToUpper(tbl)] {
+			continue
+		}
+		out[strings.ToLower(tbl)] = tbl
+		if alias := m[2]; alias != "" && !indexHintSkip[strings.ToUpper(alias)] && !isSQLKeyword(alias) {
+			out[strings.ToLower(alias)] = tbl
+		}
+	}
+	return out
+}
+
+func isSQLKeyword(word string) bool {
+	up := strings.ToUpper(word)
+	for _, k := range sqlKeywords {
+		if k == up || strings.HasPrefix(k, up+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// rankCompletions keeps items matching word and orders them: case-sensitive prefix, case-insensitive prefix, then substring.
+// Ties sort by kind (column, table, keyword) and label. At most maxCompletions are returned.
+func rankCompletions(items []CompletionItem, word string) []CompletionItem {
+	lw := strings.ToLower(word)
+	kindOrder := map[string]int{"column": 0, "table": 1, "keyword": 2}
+	type scored struct {
+		item  CompletionItem
+		score int
+	}
+	var list []scored
+	seen := make(map[string]bool)
+	for _, it := range items {
+		key := it.Kind + "\x00" + it.Label + "\x00" + it.Detail
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ll := strings.ToLower(it.Label)
+		switch {
+		case strings.HasPrefix(it.Label, word):
+			list = append(list, scored{it, 0})
+		case strings.HasPrefix(ll, lw):
+			list = append(list, scored{it, 1})
+		case strings.Contains(ll, lw):
+			list = append(list, scored{it, 2})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score < list[j].score
+		}
+		if kindOrder[list[i].item.Kind] != kindOrder[list[j].item.Kind] {
+			return kindOrder[list[i].item.Kind] < kindOrder[list[j].item.Kind]
+		}
+		return list[i].item.Label < list[j].item.Label
+	})
+	if len(list) > maxCompletions {
+		list = list[:maxCompletions]
+	}
+	out := make([]CompletionItem, len(list))
+	for i, sc := range list {
+		out[i] = sc.item
+	}
+	return out
+}
+
+// GetCompletions returns editor suggestions (JSON array of CompletionItem) from the cached schema metadata.
+// prefix is the word being typed; "t." or "alias." lists that table's columns and "db." lists the database's tables.
+// context is the statement text, used to resolve aliases and to offer columns of tables in FROM/JOIN.
+// Call LoadSchemaMetadata first; without cached metadata only keywords are returned.
+func (a *App) GetCompletions(connectionID, prefix, context string) string {
+	schemaMetaMu.RLock()
+	meta := schemaMetaCache[connectionID]
+	schemaMetaMu.RUnlock()
+	aliases := sqlTableAliases(context)
+
+	tableColumns := func(table string) []CompletionItem {
+		var items []CompletionItem
+		for _, d := range meta.Databases {
+			for _, t := range d.Tables {
+				if strings.EqualFold(t.Name, table) {
+					for _, c := range t.Columns {
+						items = append(items, CompletionItem{Label: c.Name, Kind: "column", Detail: c.Type})
+					}
+				}
+			}
+		}
+		return items
+	}
+
+	var items []CompletionItem
+	word := prefix
+	if i := strings.LastIndex(prefix, "."); i >= 0 {
+		qualifier, rest := prefix[:i], prefix[i+1:]
+		if j := strings.LastIndex(qualifier, "."); j >= 0 {
+			qualifier = qualifier[j+1:]
+		}
+		word = rest
+		table := qualifier
+		if t, ok := aliases[strings.ToLower(qualifier)]; ok {
+			table = t
+		}
+		items = tableColumns(table)
+		for _, d := range meta.Databases {
+			if strings.EqualFold(d.Name, qualifier) {
+				for _, t := range d.Tables {
+					items = append(items, CompletionItem{Label: t.Name, Kind: "table", Detail: d.Name})
+				}
+			}
+		}
+	} else {
+		for _, d := range meta.Databases {
+			for _, t := range d.Tables {
+				items = append(items, CompletionItem{Label: t.Name, Kind: "table", Detail: d.Name})
+			}
+		}
+		for _, t := range aliases {
+			items = append(items, tableColumns(t)...)
+		}
+		for _, k := range sqlKeywords {
+			items = append(items, CompletionItem{Label: k, Kind: "keyword"})
+		}
+	}
+	data, _ := json.Marshal(rankCompletions(items, word))
+	return string(data)
+}
+
+// BackupResult is JSON returned by BackupNow.
+type BackupResult struct {
+	Success bool   `json:"success"`
+	Path    string `json:"path,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RestoreResult is JSON returned by RestoreBackup.
+type RestoreResult struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BackupNow opens a save-file dialog, runs mysqldump/pg_dump/sqlite3 .dump, saves to the chosen path, and records the backup. Returns BackupResult JSON.
+// mode: "full" (default when empty), "schema" (DDL only) or "data" (rows only). SSH tunnel is not supported for backup.
+func (a *App) BackupNow(connectionID, mode string) string {
+	var out BackupResult
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		out.Error = "connection not found"
 		data, _ := json.Marshal(out)
 		return string(data)
 	}
@@ -1954,12 +5243,7 @@ func (a *App) BackupNow(connectionID string) string {
 		ext = ".sql"
 	}
 	defName := fmt.Sprintf("topology-backup-%s-%s%s", conn.Name, time.Now().Format("20060102-150405"), ext)
-	safeName := strings.Map(func(r rune) rune {
-		if r == ' ' || r == '/' || r == '\\' || r == ':' {
-			return '-'
-		}
-		return r
-	}, defName)
+	safeName := safeFileName(defName)
 
 	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
 		Title:            "保存备份文件",
@@ -1979,12 +5263,12 @@ func (a *App) BackupNow(connectionID string) string {
 		data, _ := json.Marshal(out)
 		return string(data)
 	}
-	if err := backupToPath(connectionID, path); err != nil {
+	if err := backupToPath(connectionID, path, mode); err != nil {
 		out.Error = userFacingError(err).Message
 		data, _ := json.Marshal(out)
 		return string(data)
 	}
-	appendAuditLog("backup", path, connectionID, "", "")
+	appendAuditLog("backup", "mode="+mode+" path="+path, connectionID, "", "")
 	out.Success = true
 	out.Path = path
 	data, _ := json.Marshal(out)
@@ -2000,6 +5284,11 @@ func (a *App) RestoreBackup(connectionID, backupPath string) string {
 		data, _ := json.Marshal(out)
 		return string(data)
 	}
+	if conn.ReadOnly {
+		out.Error = "connection is read-only"
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
 	ty := conn.Type
 	if ty != "mysql" && ty != "postgresql" && ty != "postgres" && ty != "sqlite" {
 		out.Error = "restore only supported for MySQL, PostgreSQL, SQLite"
@@ -2013,12 +5302,15 @@ func (a *App) RestoreBackup(connectionID, backupPath string) string {
 	}
 	pc := &backup.Conn{
 		Type:     ty,
-		Host:     conn.Host,
+		Host:     backupHost(conn),
 		Port:     conn.Port,
 		Username: conn.Username,
 		Password: conn.Password,
 		Database: conn.Database,
 	}
+	if ty == "sqlite" {
+		pc.DB, _ = openSessionDB(connectionID, "", false)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
 	if err := backup.RunRestore(ctx, pc, backupPath); err != nil {
@@ -2074,36 +5366,139 @@ func (a *App) PickBackupFile() string {
 	return path
 }
 
-func nextRun(s *BackupSchedule, base time.Time) time.Time {
-	parts := strings.SplitN(s.Time, ":", 2)
-	if len(parts) != 2 {
-		return base
+// scheduleTimeRegex matches the "HH:MM" (24h) time of a BackupSchedule; one-digit hours are accepted.
+var scheduleTimeRegex = regexp.MustCompile(`^([01]?\d|2[0-3]):([0-5]\d)$`)
+
+// validateBackupSchedule reports the first problem with s, so a schedule that could never run (or would run on
+// every scheduler tick) is rejected when saved.
+func validateBackupSchedule(s BackupSchedule) error {
+	if s.ConnectionID == "" {
+		return fmt.Errorf("connection is required")
+	}
+	if s.Schedule != "daily" && s.Schedule != "weekly" {
+		return fmt.Errorf("schedule must be \"daily\" or \"weekly\", got %q", s.Schedule)
+	}
+	if !scheduleTimeRegex.MatchString(strings.TrimSpace(s.Time)) {
+		return fmt.Errorf("time must be HH:MM between 00:00 and 23:59, got %q", s.Time)
+	}
+	if s.Schedule == "weekly" {
+		for _, d := range s.weekdays() {
+			if d < 0 || d > 6 {
+				return fmt.Errorf("day must be 0 (Sunday) to 6 (Saturday), got %d", d)
+			}
+		}
+	}
+	if s.Mode != "" && s.Mode != "full" && s.Mode != "schema" && s.Mode != "data" {
+		return fmt.Errorf("mode must be \"full\", \"schema\" or \"data\", got %q", s.Mode)
+	}
+	if s.KeepLast < 0 || s.KeepDays < 0 {
+		return fmt.Errorf("retention must not be negative")
+	}
+	return nil
+}
+
+// weekdays returns the days a weekly schedule runs on: Days, or Day for schedules saved before Days existed.
+func (s *BackupSchedule) weekdays() []int {
+	if len(s.Days) > 0 {
+		return s.Days
 	}
-	h, _ := strconv.Atoi(strings.TrimSpace(parts[0]))
-	m, _ := strconv.Atoi(strings.TrimSpace(parts[1]))
-	if h < 0 || h > 23 || m < 0 || m > 59 {
-		return base
+	return []int{s.Day}
+}
+
+// nextRun returns the first time after base (now when zero) the schedule is due. ok is false when the schedule
+// is malformed (see validateBackupSchedule), e.g. one saved by an older version, so the scheduler skips it.
+func nextRun(s *BackupSchedule, base time.Time) (next time.Time, ok bool) {
+	m := scheduleTimeRegex.FindStringSubmatch(strings.TrimSpace(s.Time))
+	if m == nil {
+		return time.Time{}, false
 	}
+	h, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
 	if base.IsZero() {
 		base = time.Now()
 	}
-	candidate := time.Date(base.Year(), base.Month(), base.Day(), h, m, 0, 0, base.Location())
-	if s.Schedule == "daily" {
+	candidate := time.Date(base.Year(), base.Month(), base.Day(), h, minute, 0, 0, base.Location())
+	switch s.Schedule {
+	case "daily":
 		if base.Before(candidate) {
-			return candidate
-		}
-		return candidate.AddDate(0, 0, 1)
-	}
-	if s.Schedule == "weekly" {
-		for i := 0; i < 8; i++ {
-			if int(candidate.Weekday()) == s.Day && !candidate.Before(base) {
-				return candidate
+			return candidate, true
+		}
+		return candidate.AddDate(0, 0, 1), true
+	case "weekly":
+		var soonest time.Time
+		for _, d := range s.weekdays() {
+			if d < 0 || d > 6 {
+				return time.Time{}, false
+			}
+			next := candidate.AddDate(0, 0, (d-int(candidate.Weekday())+7)%7)
+			if !base.Before(next) {
+				next = next.AddDate(0, 0, 7)
+			}
+			if soonest.IsZero() || next.Before(soonest) {
+				soonest = next
 			}
-			candidate = candidate.AddDate(0, 0, 1)
 		}
-		return candidate
+		return soonest, true
+	}
+	return time.Time{}, false
+}
+
+// CancelBackup stops the backup of connectionID that BackupNow or a schedule is running; the partial file is
+// removed. It reports whether a backup was running.
+func (a *App) CancelBackup(connectionID string) bool {
+	backupRunMu.Lock()
+	cancel := backupCancels[connectionID]
+	backupRunMu.Unlock()
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// SetBackupTimeout sets how many minutes a backup may run before it is stopped (1..1440); 0 restores the
+// default of 10. The setting is persisted.
+func (a *App) SetBackupTimeout(minutes int) error {
+	if minutes < 0 || minutes > maxBackupTimeoutMinutes {
+		return fmt.Errorf("backup timeout must be between 0 and %d minutes", maxBackupTimeoutMinutes)
 	}
-	return base
+	if err := updateSettings(func(s *AppSettings) { s.BackupTimeoutMinutes = minutes }); err != nil {
+		return err
+	}
+	d := time.Duration(minutes) * time.Minute
+	if minutes == 0 {
+		d = defaultBackupTimeout
+	}
+	backupRunMu.Lock()
+	backupTimeout = d
+	backupRunMu.Unlock()
+	return nil
+}
+
+// scheduleSlack is how late the scheduler may start a run and still count it as on time: one tick of
+// runBackupScheduler plus a minute. It also stops a slot from running twice in consecutive ticks.
+const scheduleSlack = 2 * time.Minute
+
+// scheduleDue reports whether s should run at now, given when it last ran (zero if never). A slot that passed
+// while the app was closed makes the schedule due once, as soon as the app runs again, and missed is set; a
+// schedule that never ran waits for its next slot. A lastRun in the future (the clock was set back) is ignored.
+func scheduleDue(s *BackupSchedule, lastRun, now time.Time) (due, missed bool) {
+	if lastRun.After(now) {
+		lastRun = time.Time{}
+	}
+	base := lastRun
+	if base.IsZero() {
+		// only slots from the last tick on count for a schedule that never ran
+		base = now.Add(-scheduleSlack)
+	}
+	next, ok := nextRun(s, base)
+	if !ok || now.Before(next) {
+		return false, false
+	}
+	if !lastRun.IsZero() && now.Sub(lastRun) <= scheduleSlack {
+		return false, false
+	}
+	return true, now.Sub(next) > scheduleSlack
 }
 
 func runBackupScheduler() {
@@ -2128,38 +5523,108 @@ func runBackupScheduler() {
 			if s.LastRun != "" {
 				lastRun, _ = time.Parse(time.RFC3339, s.LastRun)
 			}
-			nr := nextRun(s, lastRun)
-			if !now.Before(nr) && (lastRun.IsZero() || now.Sub(lastRun) > 2*time.Minute) {
+			if due, missed := scheduleDue(s, lastRun, now); due {
 				conn := getConnByID(s.ConnectionID)
 				if conn == nil {
 					continue
 				}
-				outDir := s.OutputDir
-				if outDir == "" {
-					outDir = filepath.Join(getAppDir(), defaultBackupDir)
+				if missed {
+					logger.Info("running scheduled backup of %s missed while the app was closed", conn.Name)
 				}
-				_ = os.MkdirAll(outDir, 0o755)
-				safeName := strings.Map(func(r rune) rune {
-					if r == ' ' || r == '/' || r == '\\' || r == ':' {
-						return '-'
-					}
-					return r
-				}, conn.Name)
-				fname := fmt.Sprintf("%s-%s.sql", safeName, now.Format("20060102-150405"))
-				path := filepath.Join(outDir, fname)
-				if err := backupToPath(s.ConnectionID, path); err != nil {
+				if path, err := runScheduledBackup(s, now); err != nil {
 					logger.Warn("scheduled backup failed: %v", err)
 				} else {
 					logger.Info("scheduled backup ok: %s", path)
 				}
-				schedules[i].LastRun = now.Format(time.RFC3339)
-				scheduleMu.Lock()
-				backupSchedules = schedules
-				_ = saveBackupSchedules(backupSchedules)
-				scheduleMu.Unlock()
+				// a failed run counts too, so it is retried at the next slot rather than on every tick
+				recordScheduleRun(*s, now)
+			}
+		}
+	}
+}
+
+// runScheduledBackup backs up the schedule's connection into its output directory (the app's backup directory
+// when unset) under a "<name>-<connection ID>-YYYYMMDD-HHMMSS.sql" name, then prunes old backups there per
+// KeepLast/KeepDays. The ID keeps connections that share a name and directory from pruning each other's files.
+func runScheduledBackup(s *BackupSchedule, now time.Time) (string, error) {
+	conn := getConnByID(s.ConnectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection not found")
+	}
+	outDir := s.OutputDir
+	if outDir == "" {
+		outDir = filepath.Join(getAppDir(), defaultBackupDir)
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", err
+	}
+	safeName := safeFileName(conn.Name + "-" + conn.ID)
+	path := filepath.Join(outDir, fmt.Sprintf("%s-%s.sql", safeName, now.Format("20060102-150405")))
+	if err := backupToPath(s.ConnectionID, path, s.Mode); err != nil {
+		return "", err
+	}
+	files := connectionBackupFiles(s.ConnectionID, outDir, safeName)
+	if removed := pruneBackupFiles(files, s.KeepLast, s.KeepDays, now); len(removed) > 0 {
+		logger.Info("pruned %d old backups in %s", len(removed), outDir)
+	}
+	return path, nil
+}
+
+// recordScheduleRun sets LastRun of the saved schedule equal to s (apart from LastRun) to at and saves the
+// schedules. Edits made while the backup ran are kept; a schedule changed meanwhile is left alone.
+func recordScheduleRun(s BackupSchedule, at time.Time) {
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+	s.LastRun = ""
+	for i := range backupSchedules {
+		saved := backupSchedules[i]
+		saved.LastRun = ""
+		if reflect.DeepEqual(saved, s) {
+			backupSchedules[i].LastRun = at.Format(time.RFC3339)
+			if err := saveBackupSchedules(backupSchedules); err != nil {
+				logger.Warn("save backup schedules: %v", err)
 			}
+			return
+		}
+	}
+}
+
+// RunScheduleNow runs the first backup schedule of connectionID at once, with its output directory, mode and
+// retention, whether or not it is enabled, so a schedule can be tried out before relying on it. A successful run
+// updates LastRun. Returns BackupResult JSON.
+func (a *App) RunScheduleNow(connectionID string) string {
+	var out BackupResult
+	scheduleMu.Lock()
+	if backupSchedules == nil {
+		backupSchedules = loadBackupSchedules()
+	}
+	var sched *BackupSchedule
+	for i := range backupSchedules {
+		if backupSchedules[i].ConnectionID == connectionID {
+			s := backupSchedules[i]
+			sched = &s
+			break
 		}
 	}
+	scheduleMu.Unlock()
+	if sched == nil {
+		out.Error = "no backup schedule for this connection"
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	now := time.Now()
+	path, err := runScheduledBackup(sched, now)
+	if err != nil {
+		out.Error = userFacingError(err).Message
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	recordScheduleRun(*sched, now)
+	appendAuditLog("backup", "schedule mode="+sched.Mode+" path="+path, connectionID, "", "")
+	out.Success = true
+	out.Path = path
+	data, _ := json.Marshal(out)
+	return string(data)
 }
 
 // GetBackupSchedules returns JSON array of backup schedules.
@@ -2175,18 +5640,97 @@ func (a *App) GetBackupSchedules() string {
 	return string(data)
 }
 
-// SetBackupSchedules saves backup schedules from JSON array.
+// SetBackupSchedules saves backup schedules from JSON array. Every entry is validated first (see
+// validateBackupSchedule); nothing is saved if one is invalid.
 func (a *App) SetBackupSchedules(jsonSchedules string) error {
 	var s []BackupSchedule
 	if err := json.Unmarshal([]byte(jsonSchedules), &s); err != nil {
 		return err
 	}
+	for i := range s {
+		if err := validateBackupSchedule(s[i]); err != nil {
+			return fmt.Errorf("schedule %d: %w", i+1, err)
+		}
+		s[i].Time = strings.TrimSpace(s[i].Time)
+	}
 	scheduleMu.Lock()
 	backupSchedules = s
 	scheduleMu.Unlock()
 	return saveBackupSchedules(s)
 }
 
+// GetDisplayTimezone returns the configured display timezone ("" means values are shown as the driver returns them).
+func (a *App) GetDisplayTimezone() string {
+	return getSettings().DisplayTimezone
+}
+
+// SetDisplayTimezone sets the zone TIMESTAMP/DATETIME cells are rendered in: "" (as returned by the driver),
+// "UTC", "Local" or an IANA name such as "Asia/Shanghai". Only reads are converted; written values are stored as given.
+// MySQL TIMESTAMP is stored in UTC and converted to the session time zone, so converting it shows the true instant
+// in the chosen zone. DATETIME carries no zone: the driver reads it as local time (loc=Local), so the displayed
+// value is that wall-clock time re-expressed in the chosen zone.
+func (a *App) SetDisplayTimezone(tz string) error {
+	tz = strings.TrimSpace(tz)
+	var loc *time.Location
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("unknown timezone %q", tz)
+		}
+	}
+	if err := updateSettings(func(s *AppSettings) { s.DisplayTimezone = tz }); err != nil {
+		return err
+	}
+	db.SetDisplayLocation(loc)
+	// cached results were rendered in the previous zone
+	clearQueryCache()
+	return nil
+}
+
+// GetNumericBooleans reports whether boolean columns are rendered as 0/1 rather than false/true.
+func (a *App) GetNumericBooleans() bool {
+	return getSettings().NumericBooleans
+}
+
+// SetNumericBooleans chooses how BOOL/BOOLEAN, MySQL TINYINT(1) and BIT(1) cells are rendered: 0/1 when numeric is
+// set, false/true (the default) otherwise. Either form is accepted when writing such columns.
+func (a *App) SetNumericBooleans(numeric bool) error {
+	if err := updateSettings(func(s *AppSettings) { s.NumericBooleans = numeric }); err != nil {
+		return err
+	}
+	db.SetNumericBooleans(numeric)
+	clearQueryCache()
+	return nil
+}
+
+// GetToolPaths returns JSON of the configured mysqldump/mysql/pg_dump/psql/sqlite3 paths. Empty values use PATH lookup.
+func (a *App) GetToolPaths() string {
+	data, _ := json.Marshal(getSettings().ToolPaths)
+	return string(data)
+}
+
+// SetToolPaths saves explicit paths for the backup/restore binaries (JSON object, e.g. {"mysqldump":"/usr/local/bin/mysqldump"}).
+// Empty values fall back to PATH lookup. Each non-empty path must point to an existing file.
+func (a *App) SetToolPaths(jsonPaths string) error {
+	var p backup.ToolPaths
+	if err := json.Unmarshal([]byte(jsonPaths), &p); err != nil {
+		return err
+	}
+	for name, path := range map[string]string{"mysqldump": p.MySQLDump, "mysql": p.MySQL, "pg_dump": p.PGDump, "psql": p.PSQL, "sqlite3": p.SQLite3} {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+			return fmt.Errorf("%s not found at %s", name, path)
+		}
+	}
+	if err := updateSettings(func(s *AppSettings) { s.ToolPaths = p }); err != nil {
+		return err
+	}
+	backup.SetToolPaths(p)
+	return nil
+}
+
 // DeleteBackup removes a backup record and deletes the file. path must match a stored record.
 func (a *App) DeleteBackup(path string) string {
 	if path == "" {
@@ -2222,27 +5766,158 @@ func (a *App) DeleteBackup(path string) string {
 	return string(out)
 }
 
-// VerifyBackup returns JSON { "exists": bool, "size": int64 } for the given path.
-func (a *App) VerifyBackup(path string) string {
+// PruneBackups deletes all but the newest keepLast recorded backups for the connection (files and records).
+// Returns JSON { "success": bool, "removed": [paths], "error"?: string }.
+func (a *App) PruneBackups(connectionID string, keepLast int) string {
+	if connectionID == "" || keepLast < 1 {
+		// keepLast 0 would delete every backup; schedules use 0 to mean "unlimited", so it is rejected here
+		out, _ := json.Marshal(map[string]interface{}{"success": false, "error": "connectionID and keepLast >= 1 required"})
+		return string(out)
+	}
+	removed := pruneBackupFiles(connectionBackupFiles(connectionID, "", ""), keepLast, 0, time.Now())
+	if removed == nil {
+		removed = []string{}
+	}
+	appendAuditLog("backup_prune", fmt.Sprintf("keepLast=%d removed=%d", keepLast, len(removed)), connectionID, "", "")
+	out, _ := json.Marshal(map[string]interface{}{"success": true, "removed": removed})
+	return string(out)
+}
+
+// verifyHeadBytes is how much of a backup file VerifyBackup reads to detect its dump type.
+const verifyHeadBytes = 8 * 1024
+
+// VerifyBackup returns JSON { "exists", "size", "looksValid", "detectedType" } for the given path.
+// It reads the first few KB to detect the dump type; when connectionID is set, looksValid also requires
+// the dump to match the connection's type (plain SQL of unknown origin is accepted).
+func (a *App) VerifyBackup(connectionID, path string) string {
+	out := struct {
+		Exists       bool   `json:"exists"`
+		Size         int64  `json:"size"`
+		LooksValid   bool   `json:"looksValid"`
+		DetectedType string `json:"detectedType,omitempty"`
+	}{}
 	if path == "" {
-		data, _ := json.Marshal(map[string]interface{}{"exists": false, "size": int64(0)})
+		data, _ := json.Marshal(out)
 		return string(data)
 	}
 	fi, err := os.Stat(path)
 	if err != nil {
-		data, _ := json.Marshal(map[string]interface{}{"exists": false, "size": int64(0)})
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	out.Exists = true
+	out.Size = fi.Size()
+	f, err := os.Open(path)
+	if err != nil {
+		data, _ := json.Marshal(out)
 		return string(data)
 	}
-	data, _ := json.Marshal(map[string]interface{}{"exists": true, "size": fi.Size()})
+	defer f.Close()
+	head := make([]byte, verifyHeadBytes)
+	n, _ := io.ReadFull(f, head)
+	out.DetectedType = backup.DetectDumpType(head[:n])
+	out.LooksValid = out.DetectedType != ""
+	if conn := getConnByID(connectionID); conn != nil && out.LooksValid && out.DetectedType != "sql" {
+		ty := conn.Type
+		if ty == "postgres" {
+			ty = "postgresql"
+		}
+		out.LooksValid = out.DetectedType == ty
+	}
+	data, _ := json.Marshal(out)
 	return string(data)
 }
 
-// GetDatabases returns database names for a connection (MySQL: SHOW DATABASES; PostgreSQL: schema names of current DB; SQLite: ["main"]). sessionID optional for tab isolation.
-func (a *App) GetDatabases(connectionID, sessionID string) string {
-	g, err := getOrOpenDB(connectionID, sessionID)
-	if err != nil {
-		return "[]"
-	}
+// GetServerInfo returns JSON { "type", "version", "error"? } for the connection's server.
+func (a *App) GetServerInfo(connectionID string) string {
+	out := struct {
+		Type    string `json:"type"`
+		Version string `json:"version,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}{}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		out.Error = "connection not found"
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	out.Type = conn.Type
+	g, err := getOrOpenDB(connectionID, "")
+	if err != nil {
+		out.Error = userFacingError(err).Message
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	if out.Version, err = db.ServerVersion(g, conn.Type); err != nil {
+		out.Error = userFacingError(err).Message
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// GetConnectionCharset reports the session's character set settings (see db.ConnectionCharset) as JSON
+// { "type", "charset": {name: value}, "warning", "error" }. warning is set when the MySQL client, connection or
+// results charset is not utf8mb4, or the PostgreSQL client_encoding is not UTF8: text that does not fit the
+// connection's charset comes back garbled. Use Connection.Charset to match a legacy database on purpose.
+func (a *App) GetConnectionCharset(connectionID, sessionID string) string {
+	out := struct {
+		Type    string            `json:"type"`
+		Charset map[string]string `json:"charset,omitempty"`
+		Warning string            `json:"warning,omitempty"`
+		Error   string            `json:"error,omitempty"`
+	}{}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		out.Error = "connection not found"
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	out.Type = conn.Type
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		out.Error = userFacingError(err).Message
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	if out.Charset, err = db.ConnectionCharset(g, conn.Type); err != nil {
+		out.Error = userFacingError(err).Message
+	}
+	out.Warning = charsetWarning(conn.Type, out.Charset)
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// charsetWarning describes the settings in cs (from db.ConnectionCharset) that are not a full Unicode encoding.
+func charsetWarning(driver string, cs map[string]string) string {
+	var names []string
+	var want string
+	switch driver {
+	case "mysql":
+		names, want = []string{"character_set_client", "character_set_connection", "character_set_results"}, "utf8mb4"
+	case "postgresql", "postgres":
+		names, want = []string{"client_encoding"}, "UTF8"
+	default:
+		return ""
+	}
+	var bad []string
+	for _, n := range names {
+		// character_set_results may be NULL (empty), meaning results are sent unconverted
+		if v, ok := cs[n]; ok && v != "" && !strings.EqualFold(v, want) {
+			bad = append(bad, n+"="+v)
+		}
+	}
+	if len(bad) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s is not %s; text outside that character set may be garbled", strings.Join(bad, ", "), want)
+}
+
+// GetDatabases returns database names for a connection (MySQL: SHOW DATABASES; PostgreSQL: schema names of current DB; SQLite: ["main"]). sessionID optional for tab isolation.
+func (a *App) GetDatabases(connectionID, sessionID string) string {
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "[]"
+	}
 	conn := getConnByID(connectionID)
 	if conn == nil {
 		return "[]"
@@ -2260,6 +5935,53 @@ func (a *App) GetDatabases(connectionID, sessionID string) string {
 	return string(data)
 }
 
+// GetPgDatabases lists the databases on a PostgreSQL server (pg_database). GetDatabases returns the schemas of the
+// connected database instead; use SwitchPgDatabase to browse another one. Returns "[]" for other drivers or on error.
+func (a *App) GetPgDatabases(connectionID, sessionID string) string {
+	conn := getConnByID(connectionID)
+	if conn == nil || (conn.Type != "postgresql" && conn.Type != "postgres") {
+		return "[]"
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "[]"
+	}
+	names, err := db.DatabaseNames(g, conn.Type)
+	if err != nil {
+		return "[]"
+	}
+	data, _ := json.Marshal(names)
+	return string(data)
+}
+
+// SwitchPgDatabase points a PostgreSQL connection at another database on the same server. A PostgreSQL session is
+// bound to one database, so this saves the new dbname and reopens like UpdateConnection: open sessions, transactions
+// and cached metadata of the previous database are dropped.
+func (a *App) SwitchPgDatabase(connectionID, database string) error {
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fmt.Errorf("connection not found")
+	}
+	if conn.Type != "postgresql" && conn.Type != "postgres" {
+		return fmt.Errorf("only PostgreSQL connections can switch database")
+	}
+	if conn.DSN != "" {
+		return errDSNDatabase
+	}
+	if database == "" {
+		return fmt.Errorf("database required")
+	}
+	if conn.Database == database {
+		return nil
+	}
+	conn.Database = database
+	data, err := json.Marshal(conn)
+	if err != nil {
+		return err
+	}
+	return a.UpdateConnection(string(data))
+}
+
 // GetTables returns all tables for a connection and database. For SQLite, database is ignored. sessionID optional for tab isolation.
 func (a *App) GetTables(connectionID, database, sessionID string) string {
 	g, err := getOrOpenDB(connectionID, sessionID)
@@ -2321,7 +6043,9 @@ func (a *App) GetERMetadata(connectionID, database, sessionID, tablesJSON string
 }
 
 // GetTableData returns table data with pagination. database is optional (MySQL: qualify db.table). sessionID optional for tab isolation.
-func (a *App) GetTableData(connectionID, database, tableName string, limit, offset int, sessionID string) string {
+// withCount=false skips the SELECT COUNT(*), which is slow on large InnoDB/PostgreSQL tables: totalRows is then -1
+// and hasMore tells whether another page exists (see GetApproxRowCount for a cheap estimate).
+func (a *App) GetTableData(connectionID, database, tableName string, limit, offset int, sessionID string, withCount bool) string {
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
 		return `{"columns":[],"rows":[],"totalRows":0,"page":1,"pageSize":` + fmt.Sprint(limit) + `}`
@@ -2330,260 +6054,1706 @@ func (a *App) GetTableData(connectionID, database, tableName string, limit, offs
 	if conn == nil {
 		return `{"columns":[],"rows":[],"totalRows":0,"page":1,"pageSize":` + fmt.Sprint(limit) + `}`
 	}
-	cols, rows, total, err := db.TableData(g, conn.Type, database, tableName, limit, offset)
-	if err != nil {
-		return `{"columns":[],"rows":[],"totalRows":0,"page":1,"pageSize":` + fmt.Sprint(limit) + `}`
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		apiErr := userFacingError(err)
+		data, _ := json.Marshal(TableData{Columns: []string{}, Rows: []map[string]interface{}{}, Page: 1, PageSize: limit, Error: &apiErr})
+		return string(data)
 	}
 	page := 1
 	if limit > 0 {
 		page = offset/limit + 1
 	}
-	result := TableData{Columns: cols, Rows: rows, TotalRows: total, Page: page, PageSize: limit}
+	var result TableData
+	if withCount {
+		cols, rows, total, err := db.TableData(g, conn.Type, database, tableName, limit, offset)
+		if err != nil {
+			return `{"columns":[],"rows":[],"totalRows":0,"page":1,"pageSize":` + fmt.Sprint(limit) + `}`
+		}
+		result = TableData{Columns: cols, Rows: rows, TotalRows: total, Page: page, PageSize: limit}
+	} else {
+		// one extra row tells whether there is a next page
+		cols, rows, err := db.TableRows(g, conn.Type, database, tableName, limit+1, offset)
+		if err != nil {
+			return `{"columns":[],"rows":[],"totalRows":0,"page":1,"pageSize":` + fmt.Sprint(limit) + `}`
+		}
+		hasMore := len(rows) > limit
+		if hasMore {
+			rows = rows[:limit]
+		}
+		result = TableData{Columns: cols, Rows: rows, TotalRows: -1, Page: page, PageSize: limit, HasMore: hasMore}
+	}
+	if conn.Type == "mysql" {
+		// the driver reports TINYINT(1) as plain TINYINT; only the table schema tells it apart
+		formatBooleanColumns(result.Rows, booleanColumns(g, conn.Type, database, tableName))
+	}
 	data, _ := json.Marshal(result)
 	return string(data)
 }
 
-// UpdateTableData updates table data in a single transaction. database is optional (MySQL: qualify db.table).
-// On any failure, the whole transaction is rolled back. sessionID optional for tab isolation.
-func (a *App) UpdateTableData(connectionID, database, tableName, updatesJSON, sessionID string) error {
-	if err := requireWritableConnection(connectionID); err != nil {
-		return err
+// booleanColumns returns the names of the table's boolean columns (see db.IsBooleanType); nil when the schema
+// cannot be read.
+func booleanColumns(g *gorm.DB, driver, database, tableName string) map[string]bool {
+	info, err := db.TableSchema(g, driver, database, tableName)
+	if err != nil {
+		return nil
 	}
-	var updates []UpdateRecord
-	if err := json.Unmarshal([]byte(updatesJSON), &updates); err != nil {
-		return err
+	var cols map[string]bool
+	for _, c := range info.Columns {
+		if db.IsBooleanType(c.Type) {
+			if cols == nil {
+				cols = make(map[string]bool)
+			}
+			cols[c.Name] = true
+		}
 	}
-	if len(updates) == 0 {
-		return nil
+	return cols
+}
+
+// formatBooleanColumns renders the values of cols in rows like db.FormatColumnValueIn does for boolean types.
+func formatBooleanColumns(rows []map[string]interface{}, cols map[string]bool) {
+	for _, row := range rows {
+		for c := range cols {
+			if v, ok := row[c]; ok && v != nil {
+				row[c] = db.FormatColumnValueIn(v, "BOOLEAN", nil)
+			}
+		}
+	}
+}
+
+// booleanArgs maps the values of updates and inserts for cols to the driver's boolean representation, so "true",
+// false and 0/1 are accepted for any boolean column.
+func booleanArgs(driver string, row map[string]interface{}, cols map[string]bool) {
+	for c := range cols {
+		if v, ok := row[c]; ok && v != nil {
+			row[c] = db.BooleanArg(driver, v)
+		}
 	}
+}
+
+// GetApproxRowCount returns a fast row-count estimate from table statistics (exact for SQLite); -1 when PostgreSQL
+// has no statistics for the table yet.
+func (a *App) GetApproxRowCount(connectionID, database, tableName, sessionID string) (int64, error) {
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	conn := getConnByID(connectionID)
 	if conn == nil {
-		return fmt.Errorf("connection not found")
+		return 0, fmt.Errorf("connection not found")
 	}
-	tbl := db.QualTable(conn.Type, database, tableName)
-	err = g.Transaction(func(tx *gorm.DB) error {
-		for _, u := range updates {
-			col := quoteIdent(conn.Type, u.Column)
-			q := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? LIMIT 1", tbl, col, col)
-			if res := tx.Exec(q, u.NewValue, u.OldValue); res.Error != nil {
-				return res.Error
-			}
-		}
-		return nil
-	})
-	if err != nil {
-		return err
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return 0, err
 	}
-	appendAuditLog("table_update", fmt.Sprintf("%d updates", len(updates)), connectionID, database, tableName)
-	return nil
+	return db.ApproxRowCount(g, conn.Type, database, tableName)
 }
 
-// DeleteTableRows deletes rows by matching all columns (or PK columns when available). rowsJSON: []map[string]interface{}.
-func (a *App) DeleteTableRows(connectionID, database, tableName, rowsJSON, sessionID string) error {
-	if err := requireWritableConnection(connectionID); err != nil {
-		return err
-	}
-	var rows []map[string]interface{}
-	if err := json.Unmarshal([]byte(rowsJSON), &rows); err != nil {
-		return err
-	}
-	if len(rows) == 0 {
-		return nil
-	}
+// GetTableSize returns db.TableSize JSON: the bytes taken by the table's data and indexes. Sizes are 0 with
+// available false where the server cannot report them.
+func (a *App) GetTableSize(connectionID, database, tableName, sessionID string) (string, error) {
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
-		return err
+		return "", err
 	}
 	conn := getConnByID(connectionID)
 	if conn == nil {
-		return fmt.Errorf("connection not found")
+		return "", fmt.Errorf("connection not found")
 	}
-	info, err := db.TableSchema(g, conn.Type, database, tableName)
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return "", err
+	}
+	size, err := db.TableStorageSize(g, conn.Type, database, tableName)
 	if err != nil {
-		return err
+		return "", err
 	}
-	var keyCols []string
-	for _, c := range info.Columns {
-		if c.IsPrimaryKey {
-			keyCols = append(keyCols, c.Name)
-		}
+	data, err := json.Marshal(size)
+	return string(data), err
+}
+
+// GetColumnAllowedValues returns a JSON array of the values an ENUM or SET column accepts, in declaration order,
+// so the grid can offer them as a dropdown. Columns without such a restriction return "[]".
+func (a *App) GetColumnAllowedValues(connectionID, database, tableName, columnName, sessionID string) (string, error) {
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "", err
 	}
-	if len(keyCols) == 0 {
-		for _, c := range info.Columns {
-			keyCols = append(keyCols, c.Name)
-		}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection not found")
 	}
-	tbl := db.QualTable(conn.Type, database, tableName)
-	err = g.Transaction(func(tx *gorm.DB) error {
-		for _, row := range rows {
-			var args []interface{}
-			var preds []string
-			for _, col := range keyCols {
-				v, ok := row[col]
-				if !ok {
-					return fmt.Errorf("row missing key column %q", col)
-				}
-				qc := quoteIdent(conn.Type, col)
-				preds = append(preds, qc+" = ?")
-				args = append(args, v)
-			}
-			q := fmt.Sprintf("DELETE FROM %s WHERE %s", tbl, strings.Join(preds, " AND "))
-			if res := tx.Exec(q, args...); res.Error != nil {
-				return res.Error
-			}
-		}
-		return nil
-	})
+	if _, err := tableColumn(g, conn.Type, database, tableName, columnName); err != nil {
+		return "", err
+	}
+	values, err := db.ColumnAllowedValues(g, conn.Type, database, tableName, columnName)
 	if err != nil {
-		return err
+		return "", err
 	}
-	appendAuditLog("table_delete", fmt.Sprintf("%d rows", len(rows)), connectionID, database, tableName)
-	return nil
+	data, err := json.Marshal(values)
+	return string(data), err
 }
 
-// InsertTableRows inserts rows. rowsJSON: []map[string]interface{}. Uses table columns to build INSERT.
-func (a *App) InsertTableRows(connectionID, database, tableName, rowsJSON, sessionID string) error {
-	if err := requireWritableConnection(connectionID); err != nil {
-		return err
+// UpdateTableData updates table data in a single transaction. database is optional (MySQL: qualify db.table).
+// On any failure, the whole transaction is rolled back. sessionID optional for tab isolation.
+// Updates carrying an Original snapshot are grouped per row and matched on the original primary key and every
+// column that round-trips exactly (see optimisticCompareColumns); if no row matches, the transaction fails with
+// "row changed by another user". With dryRun nothing is executed and a DryRunResult JSON is returned instead;
+// otherwise the result is "".
+func (a *App) UpdateTableData(connectionID, database, tableName, updatesJSON, sessionID string, dryRun bool) (string, error) {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
+		return "", err
 	}
-	var rows []map[string]interface{}
-	if err := json.Unmarshal([]byte(rowsJSON), &rows); err != nil {
-		return err
+	var updates []UpdateRecord
+	if err := json.Unmarshal([]byte(updatesJSON), &updates); err != nil {
+		return "", err
 	}
-	if len(rows) == 0 {
-		return nil
+	if len(updates) == 0 {
+		if dryRun {
+			return marshalDryRun(DryRunResult{Statements: []string{}}), nil
+		}
+		return "", nil
 	}
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
-		return err
+		return "", err
 	}
 	conn := getConnByID(connectionID)
 	if conn == nil {
-		return fmt.Errorf("connection not found")
+		return "", fmt.Errorf("connection not found")
 	}
-	tableCols, err := getTableColumns(g, conn.Type, database, tableName)
+	tbl := db.QualTable(conn.Type, database, tableName)
+	stmts, err := planTableUpdates(g, conn.Type, database, tableName, updates)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if dryRun {
+		return previewStatements(g, conn.Type, tbl, stmts)
 	}
-	tbl := db.QualTable(conn.Type, database, tableName)
-	batchSize := 100
 	err = g.Transaction(func(tx *gorm.DB) error {
-		for i := 0; i < len(rows); i += batchSize {
-			end := i + batchSize
-			if end > len(rows) {
-				end = len(rows)
-			}
-			batch := rows[i:end]
-			var insertCols []string
-			for _, col := range tableCols {
-				for _, row := range batch {
-					if _, ok := row[col]; ok {
-						insertCols = append(insertCols, col)
-						break
-					}
-				}
-			}
-			if len(insertCols) == 0 {
-				continue
-			}
-			var values []string
-			for _, row := range batch {
-				var parts []string
-				for _, col := range insertCols {
-					v := row[col]
-					if v == nil {
-						parts = append(parts, "NULL")
-					} else {
-						parts = append(parts, escapeSQLValue(fmt.Sprint(v), conn.Type))
-					}
-				}
-				values = append(values, "("+strings.Join(parts, ", ")+")")
-			}
-			quoted := make([]string, len(insertCols))
-			for j, c := range insertCols {
-				quoted[j] = quoteIdent(conn.Type, c)
+		for _, st := range stmts {
+			res := tx.Exec(st.sql, st.args...)
+			if res.Error != nil {
+				return res.Error
 			}
-			sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", tbl, strings.Join(quoted, ", "), strings.Join(values, ", "))
-			if e := tx.Exec(sql).Error; e != nil {
-				return e
+			if st.mustMatch && res.RowsAffected == 0 {
+				return fmt.Errorf("row changed by another user")
 			}
 		}
 		return nil
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
-	appendAuditLog("table_insert", fmt.Sprintf("%d rows", len(rows)), connectionID, database, tableName)
-	return nil
+	appendAuditLog("table_update", fmt.Sprintf("%d updates", len(updates)), connectionID, database, tableName)
+	logPlannedStatements(connectionID, sessionID, conn.Type, stmts)
+	a.emitDataMutation(DataMutation{ConnectionID: connectionID, SessionID: sessionID, Database: database, Table: tableName, Operation: "update"},
+		conn.Type, stmts)
+	return "", nil
 }
 
-func quoteIdent(driver, name string) string {
-	if driver == "mysql" {
-		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+// planTableUpdates turns grid edits into the UPDATE statements UpdateTableData runs: one per edited row matched
+// on its Original snapshot when there is one (see buildOptimisticUpdate), otherwise one per cell matched on the
+// old value.
+func planTableUpdates(g *gorm.DB, driver, database, tableName string, updates []UpdateRecord) ([]plannedStatement, error) {
+	if err := requireTable(g, driver, database, tableName); err != nil {
+		return nil, err
 	}
-	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
-}
-
-func escapeSQLValue(value, driver string) string {
-	// Escape single quotes and backslashes
-	value = strings.ReplaceAll(value, `\`, `\\`)
-	value = strings.ReplaceAll(value, `'`, `''`)
-	// Wrap in single quotes
-	return "'" + value + "'"
-}
+	tbl := db.QualTable(driver, database, tableName)
+	var compare, bools map[string]bool
+	if len(updates) > 0 {
+		info, err := db.TableSchema(g, driver, database, tableName)
+		if err != nil {
+			return nil, err
+		}
+		compare = optimisticCompareColumns(info)
+		for _, c := range info.Columns {
+			if db.IsBooleanType(c.Type) {
+				if bools == nil {
+					bools = make(map[string]bool)
+				}
+				bools[c.Name] = true
+			}
+		}
+	}
+	var stmts []plannedStatement
+	var rowOrder []int
+	byRow := make(map[int][]UpdateRecord)
+	for _, u := range updates {
+		if bools[u.Column] && u.NewValue != nil {
+			u.NewValue = db.BooleanArg(driver, u.NewValue)
+		}
+		if u.Original != nil {
+			if _, ok := byRow[u.RowIndex]; !ok {
+				rowOrder = append(rowOrder, u.RowIndex)
+			}
+			byRow[u.RowIndex] = append(byRow[u.RowIndex], u)
+			continue
+		}
+		col := quoteIdent(driver, u.Column)
+		q := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? LIMIT 1", tbl, col, col)
+		stmts = append(stmts, plannedStatement{sql: q, args: []interface{}{decodeCellValue(u.NewValue), decodeCellValue(u.OldValue)}})
+	}
+	for _, idx := range rowOrder {
+		q, args, err := buildOptimisticUpdate(driver, tbl, byRow[idx], compare)
+		if err != nil {
+			return nil, err
+		}
+		if q == "" {
+			continue
+		}
+		stmts = append(stmts, plannedStatement{sql: q, args: args, mustMatch: true})
+	}
+	return stmts, nil
+}
+
+// GeneratedStatement is one statement of GenerateUpdateForChanges: SQL with ? placeholders and its Args, as
+// executed, and Display with the arguments inlined as literals for reading or handing off.
+type GeneratedStatement struct {
+	SQL     string        `json:"sql"`
+	Args    []interface{} `json:"args"`
+	Display string        `json:"display"`
+}
+
+// GenerateUpdateForChanges returns GeneratedStatement JSON for the UPDATE statements UpdateTableData would run
+// for updatesJSON ([]UpdateRecord with the rows' Original snapshots), without running them, so grid edits can be
+// reviewed or applied elsewhere. Blob arguments are returned as base64 blob markers.
+func (a *App) GenerateUpdateForChanges(connectionID, database, tableName, updatesJSON, sessionID string) (string, error) {
+	var updates []UpdateRecord
+	if err := json.Unmarshal([]byte(updatesJSON), &updates); err != nil {
+		return "", err
+	}
+	out := make([]GeneratedStatement, 0, len(updates))
+	if len(updates) > 0 {
+		g, err := getOrOpenDB(connectionID, sessionID)
+		if err != nil {
+			return "", err
+		}
+		conn := getConnByID(connectionID)
+		if conn == nil {
+			return "", fmt.Errorf("connection not found")
+		}
+		stmts, err := planTableUpdates(g, conn.Type, database, tableName, updates)
+		if err != nil {
+			return "", err
+		}
+		for _, st := range stmts {
+			args := make([]interface{}, len(st.args))
+			for i, v := range st.args {
+				if b, ok := v.([]byte); ok {
+					v = map[string]string{db.BlobKey: base64.StdEncoding.EncodeToString(b)}
+				}
+				args[i] = v
+			}
+			out = append(out, GeneratedStatement{SQL: st.sql, Args: args, Display: inlineSQLArgs(conn.Type, st.sql, st.args) + ";"})
+		}
+	}
+	data, err := json.Marshal(out)
+	return string(data), err
+}
+
+// mutationSQLMax caps each statement carried by a "data-mutation" event (multi-row INSERTs can be huge).
+const mutationSQLMax = 64 * 1024
+
+// SetMutationSQLEvents turns the "data-mutation" event on or off: when on, UpdateTableData, DeleteTableRows and
+// InsertTableRows report the statements they ran, so the UI can show or copy them. Off by default; persisted.
+func (a *App) SetMutationSQLEvents(enabled bool) error {
+	return updateSettings(func(s *AppSettings) { s.MutationSQLEvents = enabled })
+}
+
+// emitDataMutation sends m with stmts rendered for display, if mutation events are on and the frontend is up.
+func (a *App) emitDataMutation(m DataMutation, driver string, stmts []plannedStatement) {
+	if a.ctx == nil || !getSettings().MutationSQLEvents {
+		return
+	}
+	m.Statements = mutationStatements(driver, stmts)
+	runtime.EventsEmit(a.ctx, "data-mutation", m)
+}
+
+// mutationStatements renders executed statements with their arguments inlined, each cut to mutationSQLMax runes.
+func mutationStatements(driver string, stmts []plannedStatement) []string {
+	out := make([]string, 0, len(stmts))
+	for _, st := range stmts {
+		out = append(out, truncateRunes(inlineSQLArgs(driver, st.sql, st.args), mutationSQLMax))
+	}
+	return out
+}
+
+// DryRunResult is what the destructive table operations return with dryRun set: the statements that would run,
+// with their bound values inlined for display, and how many rows they would touch according to a matching
+// SELECT COUNT(*) (for DROP/TRUNCATE, the rows in the table).
+type DryRunResult struct {
+	Statements    []string `json:"statements"`
+	EstimatedRows int64    `json:"estimatedRows"`
+}
+
+// plannedStatement is one statement of a table operation, built before anything runs so it can be previewed.
+// mustMatch fails the operation when the statement affects no row.
+type plannedStatement struct {
+	sql       string
+	args      []interface{}
+	mustMatch bool
+}
+
+func marshalDryRun(r DryRunResult) string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// previewStatements renders stmts for a dry run of an operation on tbl and counts the rows each would touch.
+func previewStatements(g *gorm.DB, driver, tbl string, stmts []plannedStatement) (string, error) {
+	r := DryRunResult{Statements: make([]string, 0, len(stmts))}
+	for _, st := range stmts {
+		r.Statements = append(r.Statements, inlineSQLArgs(driver, st.sql, st.args))
+		n, err := dryRunCount(g, tbl, st.sql, st.args)
+		if err != nil {
+			return "", err
+		}
+		r.EstimatedRows += n
+	}
+	return marshalDryRun(r), nil
+}
+
+// dryRunCount counts the rows of tbl that q (an UPDATE/DELETE on tbl built by this file, or a DROP/TRUNCATE)
+// would touch by running SELECT COUNT(*) with q's WHERE clause and its arguments. A trailing LIMIT 1 caps it at 1.
+// The first WHERE is q's own; later ones belong to subqueries inside it.
+func dryRunCount(g *gorm.DB, tbl, q string, args []interface{}) (int64, error) {
+	countQ := "SELECT COUNT(*) FROM " + tbl
+	var countArgs []interface{}
+	if i := strings.Index(q, " WHERE "); i >= 0 {
+		countQ += " WHERE " + strings.TrimSuffix(q[i+len(" WHERE "):], " LIMIT 1")
+		// placeholders before WHERE belong to SET
+		if n := strings.Count(q[:i], "?"); n < len(args) {
+			countArgs = args[n:]
+		}
+	}
+	var n int64
+	if err := g.Raw(countQ, countArgs...).Scan(&n).Error; err != nil {
+		return 0, err
+	}
+	if strings.HasSuffix(q, " LIMIT 1") && n > 1 {
+		n = 1
+	}
+	return n, nil
+}
+
+// inlineSQLArgs replaces the ? placeholders of q (outside quotes) with args rendered as literals, for display only.
+func inlineSQLArgs(driver, q string, args []interface{}) string {
+	var b strings.Builder
+	var quote rune
+	n := 0
+	for _, r := range q {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '?' && n < len(args):
+			b.WriteString(displayLiteral(driver, args[n]))
+			n++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// displayLiteral renders a bound value as a SQL literal: numbers and booleans bare, bytes as a hex literal,
+// everything else as a quoted string.
+func displayLiteral(driver string, v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+		return fmt.Sprint(x)
+	case []byte:
+		return blobLiteral(x, driver)
+	}
+	s := fmt.Sprint(v)
+	if driver == "mysql" {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// optimisticCompareColumns returns the columns an optimistic UPDATE may match on: the primary key plus every
+// column whose value survives the trip to the grid and back unchanged. Floats and big integers pass through
+// JavaScript numbers, date-times through the display timezone and JSON through re-indenting, so they are skipped.
+func optimisticCompareColumns(info *db.TableSchemaInfo) map[string]bool {
+	cols := make(map[string]bool, len(info.Columns))
+	for _, c := range info.Columns {
+		if c.IsPrimaryKey || !isLossyGridType(c.Type) {
+			cols[c.Name] = true
+		}
+	}
+	return cols
+}
+
+// isLossyGridType reports whether values of a column type may not round-trip exactly through the data grid.
+func isLossyGridType(colType string) bool {
+	t := strings.ToLower(colType)
+	for _, k := range []string{"float", "double", "real", "bigint", "int8", "date", "time", "json"} {
+		if strings.Contains(t, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildOptimisticUpdate builds one UPDATE for a row's changes whose WHERE matches the original snapshot on the
+// compare columns (NULL via IS NULL, columns in sorted order). Unchanged values are not SET, since MySQL
+// reports 0 affected rows for no-op updates. Returns "" when nothing changed.
+func buildOptimisticUpdate(driver, tbl string, updates []UpdateRecord, compare map[string]bool) (string, []interface{}, error) {
+	var sets []string
+	var args []interface{}
+	for _, u := range updates {
+		if fmt.Sprint(u.OldValue) == fmt.Sprint(u.NewValue) {
+			continue
+		}
+		sets = append(sets, quoteIdent(driver, u.Column)+" = ?")
+		args = append(args, decodeCellValue(u.NewValue))
+	}
+	if len(sets) == 0 {
+		return "", nil, nil
+	}
+	original := updates[0].Original
+	cols := make([]string, 0, len(original))
+	for c := range original {
+		if compare[c] {
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) == 0 {
+		return "", nil, fmt.Errorf("row has no columns that can identify it")
+	}
+	sort.Strings(cols)
+	preds := make([]string, 0, len(cols))
+	for _, c := range cols {
+		qc := quoteIdent(driver, c)
+		if original[c] == nil {
+			preds = append(preds, qc+" IS NULL")
+			continue
+		}
+		preds = append(preds, qc+" = ?")
+		args = append(args, decodeCellValue(original[c]))
+	}
+	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s", tbl, strings.Join(sets, ", "), strings.Join(preds, " AND "))
+	if driver == "mysql" {
+		q += " LIMIT 1"
+	}
+	return q, args, nil
+}
+
+// DeleteTableRows deletes rows by matching all columns (or PK columns when available). rowsJSON: []map[string]interface{}.
+// On a SQLite table without a primary key each row deletes a single matching row, picked by rowid.
+// With dryRun nothing is deleted and a DryRunResult JSON is returned instead; otherwise the result is "".
+func (a *App) DeleteTableRows(connectionID, database, tableName, rowsJSON, sessionID string, dryRun bool) (string, error) {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
+		return "", err
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(rowsJSON), &rows); err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		if dryRun {
+			return marshalDryRun(DryRunResult{Statements: []string{}}), nil
+		}
+		return "", nil
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection not found")
+	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return "", err
+	}
+	info, err := db.TableSchema(g, conn.Type, database, tableName)
+	if err != nil {
+		return "", err
+	}
+	keyCols := rowKeyColumns(info)
+	tbl := db.QualTable(conn.Type, database, tableName)
+	byRowid := sqliteRowidKeyed(conn.Type, info)
+	stmts := make([]plannedStatement, 0, len(rows))
+	for _, row := range rows {
+		where, args, err := rowKeyWhere(conn.Type, keyCols, row)
+		if err != nil {
+			return "", err
+		}
+		if byRowid {
+			// without a primary key, identical rows all match; delete one of them per row given
+			where = fmt.Sprintf("rowid = (SELECT rowid FROM %s WHERE %s LIMIT 1)", tbl, where)
+		}
+		stmts = append(stmts, plannedStatement{sql: fmt.Sprintf("DELETE FROM %s WHERE %s", tbl, where), args: args})
+	}
+	if dryRun {
+		return previewStatements(g, conn.Type, tbl, stmts)
+	}
+	err = g.Transaction(func(tx *gorm.DB) error {
+		for _, st := range stmts {
+			if res := tx.Exec(st.sql, st.args...); res.Error != nil {
+				return res.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	appendAuditLog("table_delete", fmt.Sprintf("%d rows", len(rows)), connectionID, database, tableName)
+	logPlannedStatements(connectionID, sessionID, conn.Type, stmts)
+	a.emitDataMutation(DataMutation{ConnectionID: connectionID, SessionID: sessionID, Database: database, Table: tableName, Operation: "delete"},
+		conn.Type, stmts)
+	return "", nil
+}
+
+// DeleteImpact is what GetDeleteImpact returns: the rows of other tables that reference the rows to delete,
+// per foreign key, following ON DELETE CASCADE chains.
+type DeleteImpact struct {
+	Tables []DeleteImpactTable `json:"tables"`
+	// Blocked is set when a RESTRICT or NO ACTION reference has matching rows, so the delete would fail.
+	Blocked bool `json:"blocked"`
+	// Truncated is set when a cascade chain went deeper than maxDeleteImpactDepth and was not followed further.
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DeleteImpactTable counts the rows of Table that reference ReferencedTable through ForeignKey and would be
+// affected by the delete. Action is the normalized ON DELETE rule: CASCADE, SET NULL, SET DEFAULT, RESTRICT
+// or NO ACTION. Depth is 1 for references to the deleted rows themselves, 2 for rows cascading from those, ...
+type DeleteImpactTable struct {
+	Table           string `json:"table"`
+	ForeignKey      string `json:"foreignKey"`
+	ReferencedTable string `json:"referencedTable"`
+	Action          string `json:"action"`
+	Rows            int64  `json:"rows"`
+	Depth           int    `json:"depth"`
+}
+
+const (
+	maxDeleteImpactDepth = 5
+	deleteImpactTimeout  = 30 * time.Second
+)
+
+// deleteRef is a foreign key of Table, indexed by the table it references.
+type deleteRef struct {
+	table string
+	fk    db.SchemaForeignKey
+}
+
+// GetDeleteImpact estimates what deleting rowsJSON (rows of tableName, as for DeleteTableRows) would do to the
+// tables referencing it, from the foreign keys of every table in database. Nothing is modified. Counts of later
+// cascade levels include every row reachable through the chain, so rows reachable twice are counted twice.
+// SQLite only enforces foreign keys with PRAGMA foreign_keys on. Returns DeleteImpact JSON.
+func (a *App) GetDeleteImpact(connectionID, database, tableName, rowsJSON, sessionID string) string {
+	out := DeleteImpact{Tables: []DeleteImpactTable{}}
+	fail := func(err error) string {
+		out.Error = userFacingError(err).Message
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(rowsJSON), &rows); err != nil {
+		return fail(err)
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fail(fmt.Errorf("connection not found: %s", connectionID))
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return fail(err)
+	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return fail(err)
+	}
+	if len(rows) == 0 {
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	names, err := db.TableNames(g, conn.Type, database)
+	if err != nil {
+		return fail(err)
+	}
+	schemas := make(map[string]*db.TableSchemaInfo, len(names))
+	refs := make(map[string][]deleteRef)
+	for _, n := range names {
+		info, err := db.TableSchema(g, conn.Type, database, n)
+		if err != nil {
+			return fail(err)
+		}
+		schemas[n] = info
+		for _, fk := range info.ForeignKeys {
+			refs[fk.ReferencedTable] = append(refs[fk.ReferencedTable], deleteRef{table: n, fk: fk})
+		}
+	}
+	target, ok := schemas[tableName]
+	if !ok {
+		return fail(errTableNotFound(tableName))
+	}
+	keyCols := rowKeyColumns(target)
+	preds := make([]string, 0, len(rows))
+	var args []interface{}
+	for _, row := range rows {
+		w, wargs, err := rowKeyWhere(conn.Type, keyCols, row)
+		if err != nil {
+			return fail(err)
+		}
+		preds = append(preds, "("+w+")")
+		args = append(args, wargs...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), deleteImpactTimeout)
+	defer cancel()
+	gc := g.WithContext(ctx)
+	var walk func(parent, where string, depth int) error
+	walk = func(parent, where string, depth int) error {
+		for _, r := range refs[parent] {
+			refCols := r.fk.ReferencedColumns
+			if len(refCols) != len(r.fk.Columns) || slicesContainEmpty(refCols) {
+				// not reported by the driver: the key of the referenced table
+				refCols = nil
+				if info := schemas[parent]; info != nil {
+					for _, c := range info.Columns {
+						if c.IsPrimaryKey {
+							refCols = append(refCols, c.Name)
+						}
+					}
+				}
+				if len(refCols) != len(r.fk.Columns) {
+					continue
+				}
+			}
+			childWhere := fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE %s)", quotedColumnTuple(conn.Type, r.fk.Columns),
+				quotedColumnList(conn.Type, refCols), db.QualTable(conn.Type, database, parent), where)
+			var n int64
+			if err := gc.Raw("SELECT COUNT(*) FROM "+db.QualTable(conn.Type, database, r.table)+" WHERE "+childWhere, args...).Scan(&n).Error; err != nil {
+				return err
+			}
+			if n == 0 {
+				continue
+			}
+			action := strings.ToUpper(strings.TrimSpace(r.fk.OnDelete))
+			if action == "" {
+				action = "NO ACTION"
+			}
+			out.Tables = append(out.Tables, DeleteImpactTable{Table: r.table, ForeignKey: r.fk.Name, ReferencedTable: parent, Action: action, Rows: n, Depth: depth})
+			switch action {
+			case "CASCADE":
+				if depth >= maxDeleteImpactDepth {
+					out.Truncated = true
+					continue
+				}
+				if err := walk(r.table, childWhere, depth+1); err != nil {
+					return err
+				}
+			case "SET NULL", "SET DEFAULT":
+			default:
+				out.Blocked = true
+			}
+		}
+		return nil
+	}
+	if err := walk(tableName, strings.Join(preds, " OR "), 1); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("delete impact check timed out after %s", deleteImpactTimeout)
+		}
+		return fail(err)
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// slicesContainEmpty reports whether any of ss is "".
+func slicesContainEmpty(ss []string) bool {
+	for _, s := range ss {
+		if s == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// quotedColumnList quotes cols and joins them with ", ".
+func quotedColumnList(driver string, cols []string) string {
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = quoteIdent(driver, c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quotedColumnTuple is quotedColumnList in parentheses for more than one column, as the left side of IN.
+func quotedColumnTuple(driver string, cols []string) string {
+	if len(cols) == 1 {
+		return quoteIdent(driver, cols[0])
+	}
+	return "(" + quotedColumnList(driver, cols) + ")"
+}
+
+// DropTable drops a table. With dryRun nothing is dropped and a DryRunResult JSON (the DROP statement and the
+// table's row count) is returned instead; otherwise the result is "".
+func (a *App) DropTable(connectionID, database, tableName, sessionID string, dryRun bool) (string, error) {
+	return runTableDDL(connectionID, database, tableName, sessionID, dryRun, "table_drop", func(driver, tbl string) string {
+		return "DROP TABLE " + tbl
+	})
+}
+
+// TruncateTable removes every row of a table (DELETE FROM on SQLite, which has no TRUNCATE). With dryRun nothing
+// is removed and a DryRunResult JSON is returned instead; otherwise the result is "".
+func (a *App) TruncateTable(connectionID, database, tableName, sessionID string, dryRun bool) (string, error) {
+	return runTableDDL(connectionID, database, tableName, sessionID, dryRun, "table_truncate", func(driver, tbl string) string {
+		if driver == "sqlite" {
+			return "DELETE FROM " + tbl
+		}
+		return "TRUNCATE TABLE " + tbl
+	})
+}
+
+// MaintenanceResult is what RunMaintenance returns.
+type MaintenanceResult struct {
+	Operation string `json:"operation"`
+	SQL       string `json:"sql"`
+	// Messages are the status rows MySQL reports, e.g. "status: OK" or "note: Table does not support optimize, doing
+	// recreate + analyze instead". PostgreSQL and SQLite report nothing on success.
+	Messages   []string `json:"messages"`
+	DurationMs int64    `json:"durationMs"`
+}
+
+// maintenanceSQL returns the statement for a maintenance operation on the qualified table tbl: "analyze" on every
+// driver, "vacuum" on PostgreSQL and SQLite (where it rebuilds the whole database file of the table's schema) and
+// "optimize" on MySQL.
+func maintenanceSQL(driver, database, tbl, operation string) (string, error) {
+	switch operation {
+	case "analyze":
+		if driver == "mysql" {
+			return "ANALYZE TABLE " + tbl, nil
+		}
+		return "ANALYZE " + tbl, nil
+	case "vacuum":
+		switch driver {
+		case "postgresql", "postgres":
+			return "VACUUM " + tbl, nil
+		case "sqlite":
+			if database != "" && database != "main" {
+				return "VACUUM " + quoteIdent(driver, database), nil
+			}
+			return "VACUUM", nil
+		}
+	case "optimize":
+		if driver == "mysql" {
+			return "OPTIMIZE TABLE " + tbl, nil
+		}
+	default:
+		return "", fmt.Errorf("unknown maintenance operation %q (analyze, vacuum, optimize)", operation)
+	}
+	return "", fmt.Errorf("%s is not supported for %s", operation, driver)
+}
+
+// RunMaintenance runs a maintenance operation on a table: "analyze" updates planner statistics, "vacuum"
+// (PostgreSQL, SQLite) reclaims space and "optimize" (MySQL OPTIMIZE TABLE) rebuilds the table. VACUUM cannot
+// run inside a transaction, so none may be open on the session. Returns MaintenanceResult JSON.
+func (a *App) RunMaintenance(connectionID, database, tableName, operation, sessionID string) (string, error) {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
+		return "", err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection not found")
+	}
+	operation = strings.ToLower(strings.TrimSpace(operation))
+	tbl := db.QualTable(conn.Type, database, tableName)
+	q, err := maintenanceSQL(conn.Type, database, tbl, operation)
+	if err != nil {
+		return "", err
+	}
+	txMu.Lock()
+	inTx := activeTx[txKey(connectionID, sessionID)] != nil
+	txMu.Unlock()
+	if inTx && operation == "vacuum" {
+		return "", fmt.Errorf("commit or roll back the transaction before running VACUUM")
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return "", err
+	}
+	out := MaintenanceResult{Operation: operation, SQL: q, Messages: []string{}}
+	start := time.Now()
+	if conn.Type == "mysql" {
+		// ANALYZE / OPTIMIZE TABLE report errors as result rows rather than failing
+		var rows []map[string]interface{}
+		_, rows, err = db.RawSelect(g, q)
+		for _, r := range rows {
+			out.Messages = append(out.Messages, fmt.Sprintf("%v: %v", r["Msg_type"], r["Msg_text"]))
+			if fmt.Sprint(r["Msg_type"]) == "error" && err == nil {
+				err = fmt.Errorf("%s: %v", strings.ToUpper(operation), r["Msg_text"])
+			}
+		}
+	} else {
+		err = g.Exec(q).Error
+	}
+	out.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		logStatement(connectionID, sessionID, q, int(out.DurationMs), userFacingError(err).Message)
+		return "", err
+	}
+	logStatement(connectionID, sessionID, q, int(out.DurationMs), "")
+	appendAuditLog("table_"+operation, q, connectionID, database, tableName)
+	data, err := json.Marshal(out)
+	return string(data), err
+}
+
+// runTableDDL runs (or, with dryRun, previews) the statement build returns for the qualified table name, then
+// drops cached results for the table and records action in the audit log.
+func runTableDDL(connectionID, database, tableName, sessionID string, dryRun bool, action string, build func(driver, tbl string) string) (string, error) {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
+		return "", err
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection not found")
+	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return "", err
+	}
+	tbl := db.QualTable(conn.Type, database, tableName)
+	st := plannedStatement{sql: build(conn.Type, tbl)}
+	if dryRun {
+		return previewStatements(g, conn.Type, tbl, []plannedStatement{st})
+	}
+	start := time.Now()
+	err = g.Exec(st.sql).Error
+	if err != nil {
+		logStatement(connectionID, sessionID, st.sql, int(time.Since(start).Milliseconds()), userFacingError(err).Message)
+		return "", err
+	}
+	logStatement(connectionID, sessionID, st.sql, int(time.Since(start).Milliseconds()), "")
+	invalidateQueryCacheForTable(connectionID, tableName)
+	appendAuditLog(action, st.sql, connectionID, database, tableName)
+	return "", nil
+}
+
+// TableFilter is one predicate of a row filter: Column Op Value. Op is one of =, !=, <, <=, >, >=,
+// LIKE, NOT LIKE, IN, NOT IN (Value is an array), IS NULL and IS NOT NULL (Value is ignored).
+type TableFilter struct {
+	Column string      `json:"column"`
+	Op     string      `json:"op"`
+	Value  interface{} `json:"value,omitempty"`
+}
+
+// buildFilterWhere builds a parameterized WHERE clause (without the keyword) ANDing filters. Columns must
+// exist in info so that identifiers never come straight from the caller.
+func buildFilterWhere(driver string, info *db.TableSchemaInfo, filters []TableFilter) (string, []interface{}, error) {
+	known := make(map[string]bool, len(info.Columns))
+	for _, c := range info.Columns {
+		known[c.Name] = true
+	}
+	var preds []string
+	var args []interface{}
+	for _, f := range filters {
+		if !known[f.Column] {
+			return "", nil, fmt.Errorf("unknown column %q", f.Column)
+		}
+		qc := quoteIdent(driver, f.Column)
+		op := strings.ToUpper(strings.Join(strings.Fields(f.Op), " "))
+		switch op {
+		case "=", "!=", "<>", "<", "<=", ">", ">=", "LIKE", "NOT LIKE":
+			if f.Value == nil {
+				return "", nil, fmt.Errorf("filter on %q: %s needs a value", f.Column, op)
+			}
+			preds = append(preds, qc+" "+op+" ?")
+			args = append(args, decodeCellValue(f.Value))
+		case "IN", "NOT IN":
+			vals, ok := f.Value.([]interface{})
+			if !ok || len(vals) == 0 {
+				return "", nil, fmt.Errorf("filter on %q: %s needs a non-empty list", f.Column, op)
+			}
+			marks := strings.TrimSuffix(strings.Repeat("?, ", len(vals)), ", ")
+			preds = append(preds, qc+" "+op+" ("+marks+")")
+			for _, v := range vals {
+				args = append(args, decodeCellValue(v))
+			}
+		case "IS NULL", "IS NOT NULL":
+			preds = append(preds, qc+" "+op)
+		default:
+			return "", nil, fmt.Errorf("unsupported filter operator %q", f.Op)
+		}
+	}
+	return strings.Join(preds, " AND "), args, nil
+}
+
+// DeleteRowsByCondition deletes the rows matching filtersJSON (a TableFilter array) and returns how many were
+// removed. An empty filter list is refused unless allowAll is set.
+func (a *App) DeleteRowsByCondition(connectionID, database, tableName, filtersJSON, sessionID string, allowAll bool) (int64, error) {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
+		return 0, err
+	}
+	var filters []TableFilter
+	if strings.TrimSpace(filtersJSON) != "" {
+		if err := json.Unmarshal([]byte(filtersJSON), &filters); err != nil {
+			return 0, err
+		}
+	}
+	if len(filters) == 0 && !allowAll {
+		return 0, fmt.Errorf("refusing to delete all rows without a condition")
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return 0, fmt.Errorf("connection not found")
+	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return 0, err
+	}
+	info, err := db.TableSchema(g, conn.Type, database, tableName)
+	if err != nil {
+		return 0, err
+	}
+	where, args, err := buildFilterWhere(conn.Type, info, filters)
+	if err != nil {
+		return 0, err
+	}
+	q := "DELETE FROM " + db.QualTable(conn.Type, database, tableName)
+	if where != "" {
+		q += " WHERE " + where
+	}
+	res := g.Exec(q, args...)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	invalidateQueryCacheForTable(connectionID, tableName)
+	appendAuditLog("table_delete", fmt.Sprintf("%d rows where %s", res.RowsAffected, where), connectionID, database, tableName)
+	return res.RowsAffected, nil
+}
+
+// updateKeysBatch is how many keys one UPDATE of UpdateRowsByKeys matches, keeping its IN list well below the
+// drivers' bound-parameter limits.
+const updateKeysBatch = 500
+
+// UpdateRowsByKeys sets the same values on every row whose keyColumn is in keysJSON (a JSON array), e.g. status =
+// 'archived' for the rows selected in the grid. setJSON is an object of column to new value; blob markers are
+// decoded as for UpdateTableData. Columns must exist and not be generated, and keys must not be null. The rows
+// are updated in one transaction, with at most updateKeysBatch keys per statement. Returns the rows affected.
+func (a *App) UpdateRowsByKeys(connectionID, database, tableName, keyColumn, keysJSON, setJSON, sessionID string) (int64, error) {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
+		return 0, err
+	}
+	var keys []interface{}
+	if err := json.Unmarshal([]byte(keysJSON), &keys); err != nil {
+		return 0, err
+	}
+	var set map[string]interface{}
+	if err := json.Unmarshal([]byte(setJSON), &set); err != nil {
+		return 0, err
+	}
+	if len(set) == 0 {
+		return 0, fmt.Errorf("no columns to set")
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return 0, fmt.Errorf("connection not found")
+	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return 0, err
+	}
+	info, err := db.TableSchema(g, conn.Type, database, tableName)
+	if err != nil {
+		return 0, err
+	}
+	cols := make(map[string]db.SchemaColumn, len(info.Columns))
+	for _, c := range info.Columns {
+		cols[c.Name] = c
+	}
+	if _, ok := cols[keyColumn]; !ok {
+		return 0, fmt.Errorf("unknown column %q", keyColumn)
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		c, ok := cols[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown column %q", name)
+		}
+		if c.Generated {
+			return 0, fmt.Errorf("column %q is generated and cannot be set", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assigns := make([]string, len(names))
+	setArgs := make([]interface{}, len(names))
+	for i, name := range names {
+		assigns[i] = quoteIdent(conn.Type, name) + " = ?"
+		setArgs[i] = decodeCellValue(set[name])
+	}
+	prefix := "UPDATE " + db.QualTable(conn.Type, database, tableName) + " SET " + strings.Join(assigns, ", ") +
+		" WHERE " + quoteIdent(conn.Type, keyColumn) + " IN ("
+	var stmts []plannedStatement
+	for start := 0; start < len(keys); start += updateKeysBatch {
+		end := start + updateKeysBatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+		args := append([]interface{}{}, setArgs...)
+		for _, k := range batch {
+			if k == nil {
+				return 0, fmt.Errorf("key %s cannot be null", keyColumn)
+			}
+			args = append(args, decodeCellValue(k))
+		}
+		stmts = append(stmts, plannedStatement{sql: prefix + strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ") + ")", args: args})
+	}
+	var affected int64
+	err = g.Transaction(func(tx *gorm.DB) error {
+		for _, st := range stmts {
+			res := tx.Exec(st.sql, st.args...)
+			if res.Error != nil {
+				return res.Error
+			}
+			affected += res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	invalidateQueryCacheForTable(connectionID, tableName)
+	appendAuditLog("table_update", fmt.Sprintf("%d rows by %s: set %s", affected, keyColumn, strings.Join(names, ", ")),
+		connectionID, database, tableName)
+	logPlannedStatements(connectionID, sessionID, conn.Type, stmts)
+	a.emitDataMutation(DataMutation{ConnectionID: connectionID, SessionID: sessionID, Database: database, Table: tableName, Operation: "update"},
+		conn.Type, stmts)
+	return affected, nil
+}
+
+// rowKeyColumns returns the primary key columns of a table in key order, or all columns when it has none.
+func rowKeyColumns(info *db.TableSchemaInfo) []string {
+	var pk []db.SchemaColumn
+	for _, c := range info.Columns {
+		if c.IsPrimaryKey {
+			pk = append(pk, c)
+		}
+	}
+	sort.SliceStable(pk, func(i, j int) bool { return pk[i].PrimaryKeyOrdinal < pk[j].PrimaryKeyOrdinal })
+	var keyCols []string
+	for _, c := range pk {
+		keyCols = append(keyCols, c.Name)
+	}
+	if len(keyCols) == 0 {
+		for _, c := range info.Columns {
+			keyCols = append(keyCols, c.Name)
+		}
+	}
+	return keyCols
+}
+
+// sqliteRowidKeyed reports whether rows of a SQLite table are best addressed through the implicit rowid: the
+// table has no primary key (so it cannot be WITHOUT ROWID) and no column shadows the name rowid.
+func sqliteRowidKeyed(driver string, info *db.TableSchemaInfo) bool {
+	if driver != "sqlite" {
+		return false
+	}
+	for _, c := range info.Columns {
+		if c.IsPrimaryKey || strings.EqualFold(c.Name, "rowid") {
+			return false
+		}
+	}
+	return true
+}
+
+// rowKeyWhere builds a WHERE clause (without the keyword) matching row on keyCols. Blob markers are decoded.
+func rowKeyWhere(driver string, keyCols []string, row map[string]interface{}) (string, []interface{}, error) {
+	var args []interface{}
+	var preds []string
+	for _, col := range keyCols {
+		v, ok := row[col]
+		if !ok {
+			return "", nil, fmt.Errorf("row missing key column %q", col)
+		}
+		qc := quoteIdent(driver, col)
+		if v == nil {
+			preds = append(preds, qc+" IS NULL")
+			continue
+		}
+		preds = append(preds, qc+" = ?")
+		args = append(args, decodeCellValue(v))
+	}
+	return strings.Join(preds, " AND "), args, nil
+}
+
+// decodeCellValue turns a {"__blob__": base64} cell value back into bytes; other values are returned as is.
+func decodeCellValue(v interface{}) interface{} {
+	if b, ok := db.DecodeBlob(v); ok {
+		return b
+	}
+	return v
+}
+
+// blobLiteral renders bytes as a hex literal for the driver.
+func blobLiteral(b []byte, driver string) string {
+	h := hex.EncodeToString(b)
+	if driver == "postgresql" || driver == "postgres" {
+		return "decode('" + h + "', 'hex')"
+	}
+	return "X'" + h + "'"
+}
+
+// fetchCell scans column of the row as loaded in the grid into dest and returns the column's database type.
+// The row is matched on the primary key (or all columns when the table has none).
+func fetchCell(connectionID, database, tableName, column, rowJSON, sessionID string, dest interface{}) (string, error) {
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(rowJSON), &row); err != nil {
+		return "", err
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection not found")
+	}
+	info, err := db.TableSchema(g, conn.Type, database, tableName)
+	if err != nil {
+		return "", err
+	}
+	where, args, err := rowKeyWhere(conn.Type, rowKeyColumns(info), row)
+	if err != nil {
+		return "", err
+	}
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s", quoteIdent(conn.Type, column), db.QualTable(conn.Type, database, tableName), where)
+	rs, err := g.Raw(q, args...).Rows()
+	if err != nil {
+		return "", err
+	}
+	defer rs.Close()
+	if !rs.Next() {
+		if err := rs.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("row not found")
+	}
+	if err := rs.Scan(dest); err != nil {
+		return "", err
+	}
+	var dbType string
+	if types, err := rs.ColumnTypes(); err == nil && len(types) > 0 {
+		dbType = types[0].DatabaseTypeName()
+	}
+	return dbType, nil
+}
+
+// GetCellBlob returns the raw bytes of a binary cell for download. rowJSON is the row as loaded in the grid;
+// it is matched on the primary key (or all columns when the table has none).
+func (a *App) GetCellBlob(connectionID, database, tableName, column, rowJSON, sessionID string) ([]byte, error) {
+	var data []byte
+	if _, err := fetchCell(connectionID, database, tableName, column, rowJSON, sessionID, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetCellValue returns one cell of the row as loaded in the grid (matched like GetCellBlob). timezone renders
+// TIMESTAMP/DATETIME values in "UTC", "Local" or an IANA zone for this call only; "" uses the display timezone
+// setting. Binary values come back as {"__blob__": base64} markers.
+func (a *App) GetCellValue(connectionID, database, tableName, column, rowJSON, sessionID, timezone string) (interface{}, error) {
+	var loc *time.Location
+	if tz := strings.TrimSpace(timezone); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("unknown timezone %q", tz)
+		}
+	} else if tz := getSettings().DisplayTimezone; tz != "" {
+		loc, _ = time.LoadLocation(tz)
+	}
+	var v interface{}
+	dbType, err := fetchCell(connectionID, database, tableName, column, rowJSON, sessionID, &v)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return db.FormatColumnValueIn(v, dbType, loc), nil
+}
+
+// InsertTableRows inserts rows. rowsJSON: []map[string]interface{}. Uses table columns to build INSERT.
+// Generated columns are never inserted; auto-increment columns are skipped unless keepIdentity is set
+// and a row supplies a value (rows without one get DEFAULT). Returns the rows per INSERT that were used
+// (see SetInsertBatchSize).
+func (a *App) InsertTableRows(connectionID, database, tableName, rowsJSON, sessionID string, keepIdentity bool) (int, error) {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
+		return 0, err
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal([]byte(rowsJSON), &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return 0, fmt.Errorf("connection not found")
+	}
+	tableCols, err := getTableColumns(g, conn.Type, database, tableName)
+	if err != nil {
+		return 0, err
+	}
+	auto, generated := serverAssignedColumns(g, conn.Type, database, tableName)
+	if bools := booleanColumns(g, conn.Type, database, tableName); bools != nil {
+		for _, row := range rows {
+			booleanArgs(conn.Type, row, bools)
+		}
+	}
+	tbl := db.QualTable(conn.Type, database, tableName)
+	batchSize := effectiveInsertBatchSize(conn.Type, len(tableCols))
+	var executed []plannedStatement
+	err = g.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < len(rows); i += batchSize {
+			end := i + batchSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+			batch := rows[i:end]
+			insertCols := selectInsertColumns(tableCols, batch, auto, generated, keepIdentity)
+			if len(insertCols) == 0 {
+				continue
+			}
+			var values []string
+			for _, row := range batch {
+				var parts []string
+				for _, col := range insertCols {
+					parts = append(parts, insertLiteral(row[col], auto[col], conn.Type))
+				}
+				values = append(values, "("+strings.Join(parts, ", ")+")")
+			}
+			quoted := make([]string, len(insertCols))
+			for j, c := range insertCols {
+				quoted[j] = quoteIdent(conn.Type, c)
+			}
+			sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", tbl, strings.Join(quoted, ", "), strings.Join(values, ", "))
+			if e := tx.Exec(sql).Error; e != nil {
+				return e
+			}
+			executed = append(executed, plannedStatement{sql: sql})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	appendAuditLog("table_insert", fmt.Sprintf("%d rows", len(rows)), connectionID, database, tableName)
+	logPlannedStatements(connectionID, sessionID, conn.Type, executed)
+	a.emitDataMutation(DataMutation{ConnectionID: connectionID, SessionID: sessionID, Database: database, Table: tableName, Operation: "insert"},
+		conn.Type, executed)
+	return batchSize, nil
+}
+
+// maxStatementParams is how many values one statement may carry: the placeholder limit of MySQL and
+// PostgreSQL (65535) and SQLite's SQLITE_MAX_VARIABLE_NUMBER (32766). Inserts inline their values, but
+// staying under these also keeps each statement well inside packet limits.
+func maxStatementParams(driver string) int {
+	if driver == "sqlite" {
+		return 32766
+	}
+	return 65535
+}
+
+// effectiveInsertBatchSize returns the rows per multi-row INSERT for a table with numCols columns: the
+// SetInsertBatchSize setting, or by default 1000 for MySQL and 100 otherwise, reduced so that one batch
+// stays under maxStatementParams.
+func effectiveInsertBatchSize(driver string, numCols int) int {
+	insertBatchSizeMu.Lock()
+	n := insertBatchSize
+	insertBatchSizeMu.Unlock()
+	if n <= 0 {
+		n = defaultInsertBatchSize
+		if driver == "mysql" {
+			n = mysqlInsertBatchSize
+		}
+	}
+	if numCols > 0 {
+		if limit := maxStatementParams(driver) / numCols; n > limit {
+			n = limit
+		}
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func quoteIdent(driver, name string) string {
+	if driver == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func escapeSQLValue(value, driver string) string {
+	// Escape single quotes and backslashes
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `''`)
+	// Wrap in single quotes
+	return "'" + value + "'"
+}
 
 // Password encryption/decryption using AES-256
 func getEncryptionKey() []byte {
+	vaultMu.RLock()
+	defer vaultMu.RUnlock()
+	if vaultKey != nil {
+		return vaultKey
+	}
+	return legacyEncryptionKey()
+}
+
+// legacyEncryptionKey is the built-in key used when no master password is set.
+func legacyEncryptionKey() []byte {
 	hash := sha256.Sum256([]byte(encKey))
 	return hash[:]
 }
 
-func encryptPassword(password string) (string, error) {
-	key := getEncryptionKey()
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
+func encryptPassword(password string) (string, error) {
+	return encryptWithKey(getEncryptionKey(), password)
+}
+
+func decryptPassword(encrypted string) (string, error) {
+	return decryptWithKey(getEncryptionKey(), encrypted)
+}
+
+func encryptWithKey(key []byte, password string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, []byte(password), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func decryptWithKey(key []byte, encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func keychainEnabled() bool {
+	keychainMu.Lock()
+	defer keychainMu.Unlock()
+	return useKeychain
+}
+
+func keychainGet(id string) (string, error) {
+	pw, err := keyring.Get(keychainService, id)
+	if err != nil {
+		return "", err
+	}
+	keychainMu.Lock()
+	keychainSynced[id] = pw
+	keychainMu.Unlock()
+	return pw, nil
+}
+
+// keychainSet writes the password unless the keychain already holds it, which saves spawning a
+// helper process per connection on every save.
+func keychainSet(id, password string) error {
+	keychainMu.Lock()
+	pw, ok := keychainSynced[id]
+	keychainMu.Unlock()
+	if ok && pw == password {
+		return nil
+	}
+	if err := keyring.Set(keychainService, id, password); err != nil {
+		return err
+	}
+	keychainMu.Lock()
+	keychainSynced[id] = password
+	keychainMu.Unlock()
+	return nil
+}
+
+func keychainDelete(id string) {
+	if err := keyring.Delete(keychainService, id); err != nil {
+		logger.Warn("delete password for connection %s from keychain: %v", id, err)
+	}
+	keychainMu.Lock()
+	delete(keychainSynced, id)
+	keychainMu.Unlock()
+}
+
+// KeychainStatus is returned by GetKeychainStatus.
+type KeychainStatus struct {
+	Available bool `json:"available"`
+	Enabled   bool `json:"enabled"`
+}
+
+// GetKeychainStatus reports whether an OS keychain is usable and whether passwords are stored in it.
+func (a *App) GetKeychainStatus() string {
+	data, _ := json.Marshal(KeychainStatus{Available: keyring.Available(), Enabled: keychainEnabled()})
+	return string(data)
+}
+
+// SetUseKeychain moves saved connection passwords into the OS keychain (macOS Keychain, Secret Service,
+// Windows Credential Manager) or back into the encrypted connections file. Passwords that cannot be stored
+// in the keychain stay in the file. The setting is persisted.
+func (a *App) SetUseKeychain(enabled bool) error {
+	if enabled && !keyring.Available() {
+		return fmt.Errorf("OS keychain is not available")
+	}
+	ensureConnectionsLoaded()
+	if err := updateSettings(func(s *AppSettings) { s.UseKeychain = enabled }); err != nil {
+		return err
+	}
+	keychainMu.Lock()
+	useKeychain = enabled
+	keychainMu.Unlock()
+
+	connMu.Lock()
+	defer connMu.Unlock()
+	if err := saveConnectionsToFile(connections); err != nil {
+		return err
+	}
+	if !enabled {
+		for _, c := range connections {
+			keychainMu.Lock()
+			_, stored := keychainSynced[c.ID]
+			keychainMu.Unlock()
+			if stored && c.Password != "" {
+				keychainDelete(c.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// vaultFile is persisted next to connections.json once a master password is set. Check is
+// vaultCheckText sealed with the derived key and lets UnlockVault reject a wrong password.
+type vaultFile struct {
+	Salt  string `json:"salt"`
+	Check string `json:"check"`
+}
+
+// VaultStatus is returned by GetVaultStatus.
+type VaultStatus struct {
+	Enabled bool `json:"enabled"`
+	Locked  bool `json:"locked"`
+}
+
+func getVaultFilePath() string {
+	return filepath.Join(filepath.Dir(getConnectionsFilePath()), vaultFileName)
+}
+
+// loadVaultFile reads vault.json; it returns nil when no master password is configured.
+func loadVaultFile() *vaultFile {
+	data, err := os.ReadFile(getVaultFilePath())
+	if err != nil {
+		return nil
+	}
+	var vf vaultFile
+	if err := json.Unmarshal(data, &vf); err != nil || vf.Salt == "" {
+		return nil
+	}
+	return &vf
+}
+
+// currentVaultFile returns the vault state, reading vault.json on first use. SetMasterPassword and
+// UnlockVault keep the cached copy in sync with the file.
+func currentVaultFile() *vaultFile {
+	vaultMu.Lock()
+	defer vaultMu.Unlock()
+	if !vaultConfLoaded {
+		vaultConf, vaultConfLoaded = loadVaultFile(), true
+	}
+	return vaultConf
+}
+
+// setVaultState caches vf as the vault state and key as the unlocked key.
+func setVaultState(vf *vaultFile, key []byte) {
+	vaultMu.Lock()
+	vaultConf, vaultConfLoaded, vaultKey = vf, true, key
+	vaultMu.Unlock()
+}
+
+func vaultLocked() bool {
+	vf := currentVaultFile()
+	vaultMu.RLock()
+	defer vaultMu.RUnlock()
+	return vaultKey == nil && vf != nil
+}
+
+// deriveVaultKey stretches the master password into an AES-256 key with scrypt.
+func deriveVaultKey(password string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(password), salt, 1<<15, 8, 1, 32)
+}
+
+// verifyVaultPassword derives the key for password and checks it against vf.
+func verifyVaultPassword(vf *vaultFile, password string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(vf.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault file: %w", err)
+	}
+	key, err := deriveVaultKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	if check, err := decryptWithKey(key, vf.Check); err != nil || check != vaultCheckText {
+		return nil, fmt.Errorf("incorrect master password")
+	}
+	return key, nil
+}
+
+// GetVaultStatus reports whether a master password is set and whether it still has to be entered.
+func (a *App) GetVaultStatus() string {
+	vf := currentVaultFile()
+	vaultMu.RLock()
+	st := VaultStatus{Enabled: vf != nil, Locked: vf != nil && vaultKey == nil}
+	vaultMu.RUnlock()
+	data, _ := json.Marshal(st)
+	return string(data)
+}
+
+// UnlockVault derives the encryption key from the master password and loads saved connections.
+// Call once per session when GetVaultStatus reports locked.
+func (a *App) UnlockVault(password string) error {
+	vf := loadVaultFile()
+	if vf == nil {
+		setVaultState(nil, nil)
+		return fmt.Errorf("no master password is set")
+	}
+	key, err := verifyVaultPassword(vf, password)
+	if err != nil {
+		return err
+	}
+	setVaultState(vf, key)
+	ensureConnectionsLoaded()
+	return nil
+}
+
+// SetMasterPassword sets, changes or (with an empty password) removes the master password and
+// re-encrypts all saved connection passwords. current must match the existing master password, if any.
+// Files written before a master password existed keep working with the built-in key until one is set.
+func (a *App) SetMasterPassword(current, password string) error {
+	oldVault := loadVaultFile()
+	var oldKey []byte
+	if oldVault != nil {
+		key, err := verifyVaultPassword(oldVault, current)
+		if err != nil {
+			return err
+		}
+		setVaultState(oldVault, key)
+		oldKey = key
 	}
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
+	ensureConnectionsLoaded()
+
+	connMu.Lock()
+	defer connMu.Unlock()
+	oldFileKey := oldKey
+	if oldFileKey == nil {
+		oldFileKey = legacyEncryptionKey()
 	}
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	if password == "" {
+		// Re-seal under the built-in key before dropping the vault, so a failed save leaves both untouched.
+		if err := saveConnectionsWithKey(connections, legacyEncryptionKey()); err != nil {
+			return err
+		}
+		if err := os.Remove(getVaultFilePath()); err != nil && !os.IsNotExist(err) {
+			_ = saveConnectionsWithKey(connections, oldFileKey)
+			return err
+		}
+		setVaultState(nil, nil)
+		return nil
 	}
-	ciphertext := gcm.Seal(nonce, nonce, []byte(password), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
-}
 
-func decryptPassword(encrypted string) (string, error) {
-	key := getEncryptionKey()
-	data, err := base64.StdEncoding.DecodeString(encrypted)
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	key, err := deriveVaultKey(password, salt)
 	if err != nil {
-		return "", err
+		return err
 	}
-	block, err := aes.NewCipher(key)
+	check, err := encryptWithKey(key, vaultCheckText)
 	if err != nil {
-		return "", err
+		return err
 	}
-	gcm, err := cipher.NewGCM(block)
+	vf := vaultFile{Salt: base64.StdEncoding.EncodeToString(salt), Check: check}
+	data, err := json.MarshalIndent(vf, "", "  ")
 	if err != nil {
-		return "", err
+		return err
 	}
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+	// Save the connections under the new key first and keep the old vault until that succeeds; if the vault
+	// cannot be written afterwards, the connections go back under the old key.
+	if err := saveConnectionsWithKey(connections, key); err != nil {
+		return err
 	}
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
+	if err := writeFileAtomic(getVaultFilePath(), data, 0o600); err != nil {
+		_ = saveConnectionsWithKey(connections, oldFileKey)
+		return err
 	}
-	return string(plaintext), nil
+	setVaultState(&vf, key)
+	return nil
 }
 
 // Query history functions
@@ -2595,19 +7765,30 @@ func loadQueryHistory() {
 		return
 	}
 	if err := json.Unmarshal(data, &queryHistory); err != nil {
+		quarantineCorruptStore(filePath, err)
 		queryHistory = make([]QueryHistory, 0)
 	}
 }
 
 func saveQueryHistory(connectionID, sql string, success bool, duration, rowCount int) {
+	if c := getConnByID(connectionID); c != nil && c.Incognito {
+		return
+	}
 	historyMu.Lock()
 	defer historyMu.Unlock()
+	if !historyEnabled {
+		return
+	}
 
 	// Load history if not loaded
 	if queryHistory == nil {
 		loadQueryHistory()
 	}
 
+	slowQueryMu.Lock()
+	slowMs := slowQueryThreshold
+	slowQueryMu.Unlock()
+
 	// Add new history entry
 	history := QueryHistory{
 		ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
@@ -2617,6 +7798,7 @@ func saveQueryHistory(connectionID, sql string, success bool, duration, rowCount
 		Success:      success,
 		Duration:     duration,
 		RowCount:     rowCount,
+		Slow:         duration > slowMs,
 	}
 	queryHistory = append([]QueryHistory{history}, queryHistory...)
 
@@ -2635,7 +7817,7 @@ func saveHistoryToFile() {
 		return
 	}
 	filePath := getHistoryFilePath()
-	_ = os.WriteFile(filePath, data, 0o600)
+	_ = writeFileAtomic(filePath, data, 0o600)
 }
 
 // GetQueryHistory returns query history, optionally filtered by connectionID and search term
@@ -2671,6 +7853,381 @@ func (a *App) GetQueryHistory(connectionID, searchTerm string, limit int) string
 	return string(data)
 }
 
+// SetMaxHistorySize sets how many query history entries are kept (1..100000), trims the current history, and persists the setting.
+func (a *App) SetMaxHistorySize(n int) error {
+	if n < 1 || n > historySizeLimit {
+		return fmt.Errorf("history size must be between 1 and %d", historySizeLimit)
+	}
+	if err := updateSettings(func(s *AppSettings) { s.MaxHistorySize = n }); err != nil {
+		return err
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	maxHistorySize = n
+	if queryHistory == nil {
+		loadQueryHistory()
+	}
+	if len(queryHistory) > maxHistorySize {
+		queryHistory = queryHistory[:maxHistorySize]
+		saveHistoryToFile()
+	}
+	return nil
+}
+
+// SetHistoryEnabled turns query history recording on or off. While off nothing is added to the history; with
+// clear set the recorded history is deleted as well. The setting is persisted.
+func (a *App) SetHistoryEnabled(enabled, clear bool) error {
+	if err := updateSettings(func(s *AppSettings) { s.HistoryDisabled = !enabled }); err != nil {
+		return err
+	}
+	historyMu.Lock()
+	historyEnabled = enabled
+	historyMu.Unlock()
+	if !enabled && clear {
+		if err := a.ClearQueryHistory(); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetHistoryEnabled reports whether query history is being recorded.
+func (a *App) GetHistoryEnabled() bool {
+	historyMu.RLock()
+	defer historyMu.RUnlock()
+	return historyEnabled
+}
+
+// SetConnectionRetry sets how many attempts are made to open a MySQL or PostgreSQL connection (default 4; 1 fails
+// fast) and the delay in ms before the first retry (default 1000), doubled for each further one. SQLite connections
+// never retry. The setting is persisted.
+func (a *App) SetConnectionRetry(retries, baseDelayMs int) error {
+	if baseDelayMs < 0 || int64(baseDelayMs) > db.MaxOpenRetryDelay.Milliseconds() {
+		return fmt.Errorf("retry delay must be between 0 and %d ms", db.MaxOpenRetryDelay.Milliseconds())
+	}
+	if err := db.SetRetryPolicy(retries, time.Duration(baseDelayMs)*time.Millisecond); err != nil {
+		return err
+	}
+	return updateSettings(func(s *AppSettings) {
+		s.ConnectionRetries = retries
+		s.ConnectionRetryDelayMs = baseDelayMs
+	})
+}
+
+// poolTimeouts converts the pool settings to durations; 0 stands for the db package default.
+func poolTimeouts(idleSeconds, lifetimeSeconds int) (idle, lifetime time.Duration) {
+	idle, lifetime = defaultPoolIdleTime, defaultPoolLifetime
+	if idleSeconds > 0 {
+		idle = time.Duration(idleSeconds) * time.Second
+	}
+	if lifetimeSeconds > 0 {
+		lifetime = time.Duration(lifetimeSeconds) * time.Second
+	}
+	return idle, lifetime
+}
+
+// SetPoolTimeouts sets after how many seconds an idle pooled connection is closed (default 300) and how long one
+// connection is reused at most (default 1800); 0 restores the default. Open pools pick the values up at once, so
+// a server that drops idle sessions early can be matched. The setting is persisted.
+func (a *App) SetPoolTimeouts(idleSeconds, lifetimeSeconds int) error {
+	if idleSeconds < 0 || lifetimeSeconds < 0 {
+		return fmt.Errorf("pool timeouts must not be negative")
+	}
+	idle, lifetime := poolTimeouts(idleSeconds, lifetimeSeconds)
+	if err := db.SetPoolTimeouts(idle, lifetime); err != nil {
+		return err
+	}
+	return updateSettings(func(s *AppSettings) {
+		s.PoolIdleSeconds = idleSeconds
+		s.PoolLifetimeSeconds = lifetimeSeconds
+	})
+}
+
+var (
+	keepAliveMu       sync.Mutex
+	keepAliveInterval time.Duration // <= 0 disables runKeepAlive
+)
+
+// runKeepAlive pings the cached pools every keepAliveInterval (see db.PingCached), dropping those whose
+// server went away. The interval is checked every few seconds so changes apply without a restart.
+func runKeepAlive() {
+	tick := time.NewTicker(5 * time.Second)
+	defer tick.Stop()
+	var last time.Time
+	for now := range tick.C {
+		keepAliveMu.Lock()
+		interval := keepAliveInterval
+		keepAliveMu.Unlock()
+		if interval <= 0 || now.Sub(last) < interval {
+			continue
+		}
+		last = now
+		if n := db.PingCached(keepAlivePingTimeout); n > 0 {
+			logger.Warn("keep-alive dropped %d connection pools that no longer answer", n)
+		}
+	}
+}
+
+// GetKeepAliveInterval returns how often, in seconds, idle connections are pinged; 0 when keep-alive is off.
+func (a *App) GetKeepAliveInterval() int {
+	return getSettings().KeepAliveSeconds
+}
+
+// SetKeepAliveInterval pings every open connection pool each seconds (between 30 and 3600) so that sessions left
+// idle are not killed by the server's idle timeout (MySQL wait_timeout, often 8 hours but much less on hosted
+// servers); pools that stopped answering are closed and reopened on next use. 0 turns it off, which is the
+// default. The setting is persisted.
+func (a *App) SetKeepAliveInterval(seconds int) error {
+	if seconds != 0 && (seconds < minKeepAliveSeconds || seconds > maxKeepAliveSeconds) {
+		return fmt.Errorf("keep-alive interval must be 0 or between %d and %d seconds", minKeepAliveSeconds, maxKeepAliveSeconds)
+	}
+	if err := updateSettings(func(s *AppSettings) { s.KeepAliveSeconds = seconds }); err != nil {
+		return err
+	}
+	keepAliveMu.Lock()
+	keepAliveInterval = time.Duration(seconds) * time.Second
+	keepAliveMu.Unlock()
+	return nil
+}
+
+// ResetConnectionPool drops every pooled connection of the session and opens a fresh pool in its place, for when
+// the server failed over and the pooled connections are all dead. Unlike ReconnectConnection it leaves other
+// sessions, the SSH tunnel, the session's UseDatabase choice and attached databases alone. Open cursors of the
+// session are closed; it fails while the session has a transaction open.
+func (a *App) ResetConnectionPool(connectionID, sessionID string) error {
+	if getConnByID(connectionID) == nil {
+		return fmt.Errorf("connection not found")
+	}
+	txMu.Lock()
+	inTx := activeTx[txKey(connectionID, sessionID)] != nil
+	txMu.Unlock()
+	if inTx {
+		return fmt.Errorf("commit or roll back the transaction before resetting the connection pool")
+	}
+	closeCursors(connectionID, sessionID)
+	if err := db.ResetPool(connectionID, sessionID); err != nil {
+		return err
+	}
+	return db.ResetPool(connectionID, multiStatementSession(sessionID))
+}
+
+// SetMaxResultRows sets how many rows an ad-hoc SELECT returns at most (default 10000); results hitting the cap
+// are flagged truncated. n <= 0 disables the cap. The setting is persisted.
+func (a *App) SetMaxResultRows(n int) error {
+	if n > maxResultRowsLimit {
+		return fmt.Errorf("max result rows must be at most %d", maxResultRowsLimit)
+	}
+	if n <= 0 {
+		n = -1
+	}
+	if err := updateSettings(func(s *AppSettings) { s.MaxResultRows = n }); err != nil {
+		return err
+	}
+	maxResultRowsMu.Lock()
+	maxResultRows = n
+	maxResultRowsMu.Unlock()
+	// cached results were produced under the previous cap
+	clearQueryCache()
+	return nil
+}
+
+// SetInsertBatchSize sets how many rows InsertTableRows and ImportData put in one INSERT (1..10000); 0 restores
+// the per-driver default. Batches are still reduced for wide tables to stay under the driver's value limit.
+// The setting is persisted.
+func (a *App) SetInsertBatchSize(n int) error {
+	if n < 0 || n > maxInsertBatchSize {
+		return fmt.Errorf("insert batch size must be between 0 and %d", maxInsertBatchSize)
+	}
+	if err := updateSettings(func(s *AppSettings) { s.InsertBatchSize = n }); err != nil {
+		return err
+	}
+	insertBatchSizeMu.Lock()
+	insertBatchSize = n
+	insertBatchSizeMu.Unlock()
+	return nil
+}
+
+// SetSlowQueryThreshold sets the duration (ms) above which new history entries are flagged slow; 0 restores
+// the default of 1000. Entries already recorded keep their flag. The setting is persisted.
+func (a *App) SetSlowQueryThreshold(ms int) error {
+	if ms < 0 {
+		return fmt.Errorf("slow query threshold must not be negative")
+	}
+	if err := updateSettings(func(s *AppSettings) { s.SlowQueryThresholdMs = ms }); err != nil {
+		return err
+	}
+	if ms == 0 {
+		ms = defaultSlowQueryMs
+	}
+	slowQueryMu.Lock()
+	slowQueryThreshold = ms
+	slowQueryMu.Unlock()
+	return nil
+}
+
+// GetSlowQueries returns history entries (newest first) that took longer than thresholdMs as a JSON array,
+// optionally for one connection. thresholdMs <= 0 returns the entries flagged slow when they were recorded.
+func (a *App) GetSlowQueries(connectionID string, thresholdMs int) string {
+	historyMu.Lock()
+	if queryHistory == nil {
+		loadQueryHistory()
+	}
+	slow := []QueryHistory{}
+	for _, h := range queryHistory {
+		if connectionID != "" && h.ConnectionID != connectionID {
+			continue
+		}
+		if thresholdMs > 0 && h.Duration > thresholdMs || thresholdMs <= 0 && h.Slow {
+			slow = append(slow, h)
+		}
+	}
+	historyMu.Unlock()
+	data, _ := json.Marshal(slow)
+	return string(data)
+}
+
+// ExportQueryHistory writes the full query history to path as CSV (".csv" extension) or JSON (otherwise).
+// Returns JSON { "success", "path", "count" } or { "success": false, "error" }.
+func (a *App) ExportQueryHistory(path string) string {
+	if strings.TrimSpace(path) == "" {
+		return exportError("path required")
+	}
+	historyMu.Lock()
+	if queryHistory == nil {
+		loadQueryHistory()
+	}
+	list := make([]QueryHistory, len(queryHistory))
+	copy(list, queryHistory)
+	historyMu.Unlock()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		w := csv.NewWriter(f)
+		_ = w.Write([]string{"connectionId", "connectionName", "sql", "executedAt", "success", "duration", "rowCount"})
+		for _, h := range list {
+			name := ""
+			if c := getConnByID(h.ConnectionID); c != nil {
+				name = c.Name
+			}
+			_ = w.Write([]string{h.ConnectionID, name, h.SQL, h.ExecutedAt, strconv.FormatBool(h.Success),
+				strconv.Itoa(h.Duration), strconv.Itoa(h.RowCount)})
+		}
+		w.Flush()
+		if w.Error() != nil {
+			return exportError(w.Error().Error())
+		}
+	} else {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(list); err != nil {
+			return exportError(err.Error())
+		}
+	}
+	data, _ := json.Marshal(map[string]interface{}{"success": true, "path": path, "count": len(list)})
+	return string(data)
+}
+
+// EditorDraft is the unsaved content of a query editor tab, kept so it survives a crash.
+type EditorDraft struct {
+	SessionID string `json:"sessionId"`
+	SQL       string `json:"sql"`
+	SavedAt   string `json:"savedAt"`
+}
+
+// loadEditorDrafts reads drafts.json, dropping drafts older than draftMaxAge; caller holds draftsMu.
+func loadEditorDrafts() {
+	editorDrafts = make(map[string]EditorDraft)
+	data, err := os.ReadFile(getDraftsFilePath())
+	if err != nil {
+		return
+	}
+	var list []EditorDraft
+	if err := json.Unmarshal(data, &list); err != nil {
+		return
+	}
+	for _, d := range list {
+		if at, err := time.Parse(time.RFC3339, d.SavedAt); err == nil && time.Since(at) <= draftMaxAge {
+			editorDrafts[d.SessionID] = d
+		}
+	}
+}
+
+// saveDraftsToFile writes the drafts newest first; caller holds draftsMu.
+func saveDraftsToFile() error {
+	data, err := json.MarshalIndent(sortedDrafts(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(getDraftsFilePath(), data, 0o600)
+}
+
+// sortedDrafts lists the drafts newest first; caller holds draftsMu.
+func sortedDrafts() []EditorDraft {
+	list := make([]EditorDraft, 0, len(editorDrafts))
+	for _, d := range editorDrafts {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].SavedAt > list[j].SavedAt })
+	return list
+}
+
+// SaveEditorDraft records the current editor content of a tab; the UI calls it shortly after each edit. Blank
+// sql removes the draft, e.g. when the tab is closed. Unchanged content is not written again.
+func (a *App) SaveEditorDraft(sessionID, sql string) error {
+	if sessionID == "" {
+		return fmt.Errorf("session ID required")
+	}
+	draftsMu.Lock()
+	defer draftsMu.Unlock()
+	if editorDrafts == nil {
+		loadEditorDrafts()
+	}
+	if strings.TrimSpace(sql) == "" {
+		if _, ok := editorDrafts[sessionID]; !ok {
+			return nil
+		}
+		delete(editorDrafts, sessionID)
+	} else {
+		if d, ok := editorDrafts[sessionID]; ok && d.SQL == sql {
+			return nil
+		}
+		editorDrafts[sessionID] = EditorDraft{SessionID: sessionID, SQL: sql, SavedAt: time.Now().Format(time.RFC3339)}
+	}
+	return saveDraftsToFile()
+}
+
+// GetEditorDraft returns the EditorDraft JSON saved for a tab, or "{}" when there is none.
+func (a *App) GetEditorDraft(sessionID string) string {
+	draftsMu.Lock()
+	if editorDrafts == nil {
+		loadEditorDrafts()
+	}
+	d, ok := editorDrafts[sessionID]
+	draftsMu.Unlock()
+	if !ok {
+		return "{}"
+	}
+	data, _ := json.Marshal(d)
+	return string(data)
+}
+
+// ListEditorDrafts returns every draft less than three days old as a JSON array, newest first, so the UI can
+// offer to reopen them after a crash.
+func (a *App) ListEditorDrafts() string {
+	draftsMu.Lock()
+	if editorDrafts == nil {
+		loadEditorDrafts()
+	}
+	list := sortedDrafts()
+	draftsMu.Unlock()
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
 // ClearQueryHistory clears all query history
 func (a *App) ClearQueryHistory() error {
 	historyMu.Lock()
@@ -2688,16 +8245,110 @@ func loadSnippets() {
 		return
 	}
 	if err := json.Unmarshal(data, &snippets); err != nil {
+		quarantineCorruptStore(filePath, err)
 		snippets = make([]Snippet, 0)
 	}
 }
 
-func saveSnippetsToFile() {
-	data, err := json.MarshalIndent(snippets, "", "  ")
-	if err != nil {
-		return
+func saveSnippetsToFile() {
+	data, err := json.MarshalIndent(snippets, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = writeFileAtomic(getSnippetsFilePath(), data, 0o600)
+}
+
+// TableView is the data grid layout saved for a table: column widths in pixels, the visible columns in display
+// order (empty = all), the sort column and direction, and rows per page (0 = the grid's default).
+type TableView struct {
+	ColumnWidths   map[string]int `json:"columnWidths,omitempty"`
+	VisibleColumns []string       `json:"visibleColumns,omitempty"`
+	SortColumn     string         `json:"sortColumn,omitempty"`
+	SortDesc       bool           `json:"sortDesc,omitempty"`
+	PageSize       int            `json:"pageSize,omitempty"`
+}
+
+// loadTableViews reads view_settings.json; caller holds tableViewsMu.
+func loadTableViews() {
+	tableViews = make(map[string]map[string]TableView)
+	data, err := os.ReadFile(getTableViewsFilePath())
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &tableViews); err != nil || tableViews == nil {
+		tableViews = make(map[string]map[string]TableView)
+	}
+}
+
+// saveTableViewsToFile writes view_settings.json; caller holds tableViewsMu.
+func saveTableViewsToFile() error {
+	data, err := json.MarshalIndent(tableViews, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(getTableViewsFilePath(), data, 0o600)
+}
+
+// SaveTableView stores the grid layout for a table of a connection; settingsJSON is a TableView. An empty
+// settingsJSON (or "null") forgets the table's layout.
+func (a *App) SaveTableView(connectionID, table, settingsJSON string) error {
+	if connectionID == "" || table == "" {
+		return fmt.Errorf("connection and table are required")
+	}
+	var view *TableView
+	if s := strings.TrimSpace(settingsJSON); s != "" {
+		if err := json.Unmarshal([]byte(s), &view); err != nil {
+			return fmt.Errorf("invalid view settings: %w", err)
+		}
+	}
+	if view != nil && view.PageSize < 0 {
+		return fmt.Errorf("page size must not be negative")
+	}
+	tableViewsMu.Lock()
+	defer tableViewsMu.Unlock()
+	if tableViews == nil {
+		loadTableViews()
+	}
+	if view == nil {
+		delete(tableViews[connectionID], table)
+		if len(tableViews[connectionID]) == 0 {
+			delete(tableViews, connectionID)
+		}
+	} else {
+		if tableViews[connectionID] == nil {
+			tableViews[connectionID] = make(map[string]TableView)
+		}
+		tableViews[connectionID][table] = *view
+	}
+	return saveTableViewsToFile()
+}
+
+// GetTableView returns the saved TableView JSON for a table, or "{}" when none was saved.
+func (a *App) GetTableView(connectionID, table string) string {
+	tableViewsMu.Lock()
+	if tableViews == nil {
+		loadTableViews()
+	}
+	view, ok := tableViews[connectionID][table]
+	tableViewsMu.Unlock()
+	if !ok {
+		return "{}"
+	}
+	data, _ := json.Marshal(view)
+	return string(data)
+}
+
+// forgetTableViews drops the saved layouts of a deleted connection.
+func forgetTableViews(connectionID string) {
+	tableViewsMu.Lock()
+	defer tableViewsMu.Unlock()
+	if tableViews == nil {
+		loadTableViews()
+	}
+	if _, ok := tableViews[connectionID]; ok {
+		delete(tableViews, connectionID)
+		_ = saveTableViewsToFile()
 	}
-	_ = os.WriteFile(getSnippetsFilePath(), data, 0o600)
 }
 
 // GetSnippets returns all saved SQL snippets (alias + sql) as JSON array.
@@ -2715,11 +8366,25 @@ func (a *App) GetSnippets() string {
 }
 
 // SaveSnippet adds or updates a snippet by alias. If alias exists, the snippet is updated.
-func (a *App) SaveSnippet(alias, sql string) error {
+// category is optional; tagsJSON is an optional JSON array of tags (e.g. ["report","daily"]).
+func (a *App) SaveSnippet(alias, sql, category, tagsJSON string) error {
 	alias = strings.TrimSpace(alias)
 	if alias == "" {
 		return fmt.Errorf("alias is required")
 	}
+	var tags []string
+	if strings.TrimSpace(tagsJSON) != "" {
+		if err := json.Unmarshal([]byte(tagsJSON), &tags); err != nil {
+			return fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+	cleaned := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			cleaned = append(cleaned, t)
+		}
+	}
+	category = strings.TrimSpace(category)
 	snippetsMu.Lock()
 	defer snippetsMu.Unlock()
 	if snippets == nil {
@@ -2729,16 +8394,59 @@ func (a *App) SaveSnippet(alias, sql string) error {
 	for i := range snippets {
 		if snippets[i].Alias == alias {
 			snippets[i].SQL = sql
+			snippets[i].Category = category
+			snippets[i].Tags = cleaned
 			snippets[i].CreatedAt = time.Now().Format(time.RFC3339)
 			saveSnippetsToFile()
 			return nil
 		}
 	}
-	snippets = append(snippets, Snippet{ID: id, Alias: alias, SQL: sql, CreatedAt: time.Now().Format(time.RFC3339)})
+	snippets = append(snippets, Snippet{ID: id, Alias: alias, SQL: sql, Category: category, Tags: cleaned, CreatedAt: time.Now().Format(time.RFC3339)})
 	saveSnippetsToFile()
 	return nil
 }
 
+// matches reports whether the snippet's alias, SQL, category, or any tag contains term (case-insensitive).
+func (s Snippet) matches(term string) bool {
+	term = strings.ToLower(term)
+	if strings.Contains(strings.ToLower(s.Alias), term) || strings.Contains(strings.ToLower(s.SQL), term) ||
+		strings.Contains(strings.ToLower(s.Category), term) {
+		return true
+	}
+	for _, t := range s.Tags {
+		if strings.Contains(strings.ToLower(t), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchSnippets returns snippets (JSON array) matching term in alias, SQL, category, or tags.
+// category, when non-empty, restricts results to that category (case-insensitive). Empty term matches all.
+func (a *App) SearchSnippets(term, category string) string {
+	snippetsMu.Lock()
+	if snippets == nil {
+		loadSnippets()
+	}
+	all := make([]Snippet, len(snippets))
+	copy(all, snippets)
+	snippetsMu.Unlock()
+	term = strings.TrimSpace(term)
+	category = strings.TrimSpace(category)
+	out := make([]Snippet, 0)
+	for _, s := range all {
+		if category != "" && !strings.EqualFold(s.Category, category) {
+			continue
+		}
+		if term != "" && !s.matches(term) {
+			continue
+		}
+		out = append(out, s)
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
 // DeleteSnippet removes a snippet by id.
 func (a *App) DeleteSnippet(id string) error {
 	snippetsMu.Lock()
@@ -2756,6 +8464,133 @@ func (a *App) DeleteSnippet(id string) error {
 	return fmt.Errorf("snippet not found: %s", id)
 }
 
+func findSnippet(id string) (Snippet, bool) {
+	snippetsMu.Lock()
+	defer snippetsMu.Unlock()
+	if snippets == nil {
+		loadSnippets()
+	}
+	for _, s := range snippets {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Snippet{}, false
+}
+
+// GetSnippetPlaceholders returns JSON array of ${name} placeholders in the snippet so the UI can prompt for values.
+func (a *App) GetSnippetPlaceholders(id string) string {
+	s, ok := findSnippet(id)
+	if !ok {
+		return "[]"
+	}
+	data, _ := json.Marshal(s.Placeholders())
+	return string(data)
+}
+
+// ExpandSnippet substitutes ${name} placeholders in the snippet with values from paramsJSON ({"name":"value"}) and returns the SQL.
+// Values are inserted as-is (e.g. table names); quote string literals in the template. Errors list unfilled placeholders.
+func (a *App) ExpandSnippet(id, paramsJSON string) (string, error) {
+	s, ok := findSnippet(id)
+	if !ok {
+		return "", fmt.Errorf("snippet not found: %s", id)
+	}
+	params := map[string]string{}
+	if strings.TrimSpace(paramsJSON) != "" {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(paramsJSON), &raw); err != nil {
+			return "", fmt.Errorf("invalid params: %w", err)
+		}
+		for k, v := range raw {
+			if v != nil {
+				params[k] = fmt.Sprint(v)
+			}
+		}
+	}
+	return s.Expand(params)
+}
+
+func loadFavorites() {
+	data, err := os.ReadFile(getFavoritesFilePath())
+	if err != nil {
+		favorites = make([]FavoriteQuery, 0)
+		return
+	}
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		favorites = make([]FavoriteQuery, 0)
+	}
+}
+
+func saveFavoritesToFile() {
+	data, err := json.MarshalIndent(favorites, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = writeFileAtomic(getFavoritesFilePath(), data, 0o600)
+}
+
+// AddFavoriteQuery pins a full query for a connection. name and sql are required.
+func (a *App) AddFavoriteQuery(connectionID, name, sql string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.TrimSpace(sql) == "" {
+		return fmt.Errorf("sql is required")
+	}
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+	if favorites == nil {
+		loadFavorites()
+	}
+	favorites = append(favorites, FavoriteQuery{
+		ID:           fmt.Sprintf("%d", time.Now().UnixNano()),
+		ConnectionID: connectionID,
+		Name:         name,
+		SQL:          sql,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+	})
+	saveFavoritesToFile()
+	return nil
+}
+
+// GetFavoriteQueries returns favorites as JSON array, filtered by connectionID when non-empty.
+func (a *App) GetFavoriteQueries(connectionID string) string {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+	if favorites == nil {
+		loadFavorites()
+	}
+	out := make([]FavoriteQuery, 0, len(favorites))
+	for _, f := range favorites {
+		if connectionID == "" || f.ConnectionID == connectionID {
+			out = append(out, f)
+		}
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// DeleteFavoriteQuery removes a favorite by id.
+func (a *App) DeleteFavoriteQuery(id string) error {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+	if favorites == nil {
+		loadFavorites()
+	}
+	for i, f := range favorites {
+		if f.ID == id {
+			favorites = append(favorites[:i], favorites[i+1:]...)
+			saveFavoritesToFile()
+			return nil
+		}
+	}
+	return fmt.Errorf("favorite not found: %s", id)
+}
+
 // ImportDataPreview parses and returns preview of import data (first 10 rows)
 func (a *App) ImportDataPreview(filePath, format string) string {
 	data, err := os.ReadFile(filePath)
@@ -2845,8 +8680,8 @@ func parseCSV(data []byte) ([]string, [][]string, error) {
 }
 
 // ImportData imports data into a table. sessionID optional for tab isolation.
-func (a *App) ImportData(connectionID, database, tableName, filePath, format string, columnMappingJSON, sessionID string) string {
-	if err := requireWritableConnection(connectionID); err != nil {
+func (a *App) ImportData(connectionID, database, tableName, filePath, format string, columnMappingJSON, sessionID string, keepIdentity bool) string {
+	if err := requireWritableSession(connectionID, sessionID); err != nil {
 		return importError(err.Error())
 	}
 	g, err := getOrOpenDB(connectionID, sessionID)
@@ -2937,9 +8772,10 @@ func (a *App) ImportData(connectionID, database, tableName, filePath, format str
 	if err != nil {
 		return importError("failed to get table columns: " + err.Error())
 	}
+	auto, generated := serverAssignedColumns(g, conn.Type, database, tableName)
 
 	// Build INSERT statements and execute in batches
-	batchSize := 100
+	batchSize := effectiveInsertBatchSize(conn.Type, len(tableCols))
 	inserted := 0
 	for i := 0; i < len(rows); i += batchSize {
 		end := i + batchSize
@@ -2949,16 +8785,7 @@ func (a *App) ImportData(connectionID, database, tableName, filePath, format str
 		batch := rows[i:end]
 
 		// Build INSERT statement
-		insertCols := make([]string, 0)
-		for _, col := range tableCols {
-			// Check if this column exists in the data
-			for _, row := range batch {
-				if _, ok := row[col]; ok {
-					insertCols = append(insertCols, col)
-					break
-				}
-			}
-		}
+		insertCols := selectInsertColumns(tableCols, batch, auto, generated, keepIdentity)
 
 		if len(insertCols) == 0 {
 			continue
@@ -2969,13 +8796,7 @@ func (a *App) ImportData(connectionID, database, tableName, filePath, format str
 		for _, row := range batch {
 			rowValues := make([]string, 0, len(insertCols))
 			for _, col := range insertCols {
-				val := row[col]
-				if val == nil {
-					rowValues = append(rowValues, "NULL")
-				} else {
-					valStr := escapeSQLValue(fmt.Sprint(val), conn.Type)
-					rowValues = append(rowValues, valStr)
-				}
+				rowValues = append(rowValues, insertLiteral(row[col], auto[col], conn.Type))
 			}
 			values = append(values, "("+strings.Join(rowValues, ", ")+")")
 		}
@@ -2999,11 +8820,72 @@ func (a *App) ImportData(connectionID, database, tableName, filePath, format str
 		"success":   true,
 		"inserted":  inserted,
 		"totalRows": len(rows),
+		"batchSize": batchSize,
 	}
 	data2, _ := json.Marshal(result)
 	return string(data2)
 }
 
+// serverAssignedColumns returns the auto-increment and generated columns of a table. Best effort:
+// if the schema cannot be read, both maps are empty and every column is inserted as before.
+func serverAssignedColumns(g *gorm.DB, driver, database, tableName string) (auto, generated map[string]bool) {
+	auto = make(map[string]bool)
+	generated = make(map[string]bool)
+	info, err := db.TableSchema(g, driver, database, tableName)
+	if err != nil {
+		return auto, generated
+	}
+	for _, c := range info.Columns {
+		auto[c.Name] = c.AutoIncrement
+		generated[c.Name] = c.Generated
+	}
+	return auto, generated
+}
+
+// selectInsertColumns returns the table columns present in batch, in table order. Generated columns are
+// always dropped; auto-increment columns are kept only with keepIdentity and a non-empty value in some row.
+func selectInsertColumns(tableCols []string, batch []map[string]interface{}, auto, generated map[string]bool, keepIdentity bool) []string {
+	insertCols := make([]string, 0)
+	for _, col := range tableCols {
+		if generated[col] || (auto[col] && !keepIdentity) {
+			continue
+		}
+		for _, row := range batch {
+			v, ok := row[col]
+			if ok && (!auto[col] || (v != nil && fmt.Sprint(v) != "")) {
+				insertCols = append(insertCols, col)
+				break
+			}
+		}
+	}
+	return insertCols
+}
+
+// insertLiteral renders v for an INSERT VALUES list. A missing auto-increment value becomes DEFAULT
+// (NULL on SQLite, which assigns the rowid) so mixed batches still get server-assigned keys.
+func insertLiteral(v interface{}, auto bool, driver string) string {
+	if auto && (v == nil || fmt.Sprint(v) == "") {
+		if driver == "sqlite" {
+			return "NULL"
+		}
+		return "DEFAULT"
+	}
+	if v == nil {
+		return "NULL"
+	}
+	if b, ok := db.DecodeBlob(v); ok {
+		return blobLiteral(b, driver)
+	}
+	switch x := v.(type) {
+	case []byte:
+		return blobLiteral(x, driver)
+	case bool, int64:
+		// from db.BooleanArg; quoting would turn 1 into the character '1' for a BIT column
+		return displayLiteral(driver, x)
+	}
+	return escapeSQLValue(fmt.Sprint(v), driver)
+}
+
 func importError(msg string) string {
 	result := map[string]interface{}{
 		"success": false,
@@ -3016,16 +8898,13 @@ func importError(msg string) string {
 func getTableColumns(g *gorm.DB, driver, database, tableName string) ([]string, error) {
 	var columns []string
 	if driver == "mysql" {
+		database, err := db.CurrentMySQLDatabase(g, database)
+		if err != nil {
+			return nil, err
+		}
 		query := "SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION"
-		if database == "" {
-			query = "SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_NAME = ? ORDER BY ORDINAL_POSITION"
-			if err := g.Raw(query, tableName).Scan(&columns).Error; err != nil {
-				return nil, err
-			}
-		} else {
-			if err := g.Raw(query, database, tableName).Scan(&columns).Error; err != nil {
-				return nil, err
-			}
+		if err := g.Raw(query, database, tableName).Scan(&columns).Error; err != nil {
+			return nil, err
 		}
 	} else if driver == "postgresql" || driver == "postgres" {
 		schema := "public"
@@ -3057,26 +8936,125 @@ func getTableColumns(g *gorm.DB, driver, database, tableName string) ([]string,
 			columns[i] = info.Name
 		}
 	}
+	if len(columns) == 0 {
+		return nil, errTableNotFound(tableName)
+	}
 	return columns, nil
 }
 
-// GenerateCreateTableSQL generates CREATE TABLE SQL from TableSchema
-func (a *App) GenerateCreateTableSQL(schemaJSON, driver string) string {
+// errTableNotFound is classified NOT_FOUND by userFacingError.
+func errTableNotFound(tableName string) error {
+	return fmt.Errorf("table %s does not exist", tableName)
+}
+
+// requireTable fails with errTableNotFound unless tableName is a table or view of database, so a typo is reported
+// as such rather than as the driver's error for the SQL built around it.
+func requireTable(g *gorm.DB, driver, database, tableName string) error {
+	ok, err := db.TableExists(g, driver, database, tableName)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errTableNotFound(tableName)
+	}
+	return nil
+}
+
+// CreateTableOptions are the table options GenerateCreateTableSQL adds. Engine, Charset and Collation are
+// MySQL's, With holds PostgreSQL storage parameters (e.g. "fillfactor=70") and Tablespace applies to both;
+// options the driver does not have are ignored.
+type CreateTableOptions struct {
+	IfNotExists bool   `json:"ifNotExists,omitempty"`
+	Engine      string `json:"engine,omitempty"`
+	Charset     string `json:"charset,omitempty"`
+	Collation   string `json:"collation,omitempty"`
+	Tablespace  string `json:"tablespace,omitempty"`
+	With        string `json:"with,omitempty"`
+}
+
+var (
+	tableOptionNameRegex = regexp.MustCompile(`^\w+$`)
+	storageParamRegex    = regexp.MustCompile(`^\s*\w+(?:\.\w+)?\s*=\s*[\w.]+\s*$`)
+)
+
+// tableOptionsClause returns the options that follow the closing parenthesis of CREATE TABLE, with a leading
+// space, or "" when there are none. Names must be plain identifiers since they cannot be quoted everywhere.
+func tableOptionsClause(driver string, opts CreateTableOptions) (string, error) {
+	for _, name := range []string{opts.Engine, opts.Charset, opts.Collation, opts.Tablespace} {
+		if name != "" && !tableOptionNameRegex.MatchString(name) {
+			return "", fmt.Errorf("invalid table option %q", name)
+		}
+	}
+	var parts []string
+	switch driver {
+	case "mysql":
+		if opts.Engine != "" {
+			parts = append(parts, "ENGINE="+opts.Engine)
+		}
+		if opts.Charset != "" {
+			parts = append(parts, "DEFAULT CHARSET="+opts.Charset)
+		}
+		if opts.Collation != "" {
+			parts = append(parts, "COLLATE="+opts.Collation)
+		}
+		if opts.Tablespace != "" {
+			parts = append(parts, "TABLESPACE "+quoteIdent(driver, opts.Tablespace))
+		}
+	case "postgresql", "postgres":
+		if opts.With != "" {
+			params := strings.Split(opts.With, ",")
+			for i, p := range params {
+				if !storageParamRegex.MatchString(p) {
+					return "", fmt.Errorf("invalid storage parameter %q", strings.TrimSpace(p))
+				}
+				params[i] = strings.TrimSpace(p)
+			}
+			parts = append(parts, "WITH ("+strings.Join(params, ", ")+")")
+		}
+		if opts.Tablespace != "" {
+			parts = append(parts, "TABLESPACE "+quoteIdent(driver, opts.Tablespace))
+		}
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return " " + strings.Join(parts, " "), nil
+}
+
+// GenerateCreateTableSQL generates CREATE TABLE SQL from TableSchema. optionsJSON is CreateTableOptions JSON;
+// "" generates the plain statement.
+func (a *App) GenerateCreateTableSQL(schemaJSON, driver, optionsJSON string) string {
 	var schema TableSchema
 	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
 		return fmt.Sprintf("-- Error: %v", err)
 	}
+	var opts CreateTableOptions
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			return fmt.Sprintf("-- Error: %v", err)
+		}
+	}
+	tableOptions, err := tableOptionsClause(driver, opts)
+	if err != nil {
+		return fmt.Sprintf("-- Error: %v", err)
+	}
+	ifNotExists := ""
+	if opts.IfNotExists {
+		ifNotExists = "IF NOT EXISTS "
+	}
 
 	var sql strings.Builder
-	sql.WriteString("CREATE TABLE ")
-	if driver == "mysql" && schema.Name != "" {
-		sql.WriteString(quoteIdent(driver, schema.Name))
-	} else {
-		sql.WriteString(quoteIdent(driver, schema.Name))
-	}
+	sql.WriteString("CREATE TABLE " + ifNotExists)
+	sql.WriteString(quoteIdent(driver, schema.Name))
 	sql.WriteString(" (\n")
 
 	// Columns
+	pkCount := 0
+	for _, col := range schema.Columns {
+		if col.IsPrimaryKey {
+			pkCount++
+		}
+	}
 	columnDefs := make([]string, 0, len(schema.Columns))
 	for _, col := range schema.Columns {
 		colDef := "  " + quoteIdent(driver, col.Name) + " " + col.Type
@@ -3086,7 +9064,7 @@ func (a *App) GenerateCreateTableSQL(schemaJSON, driver string) string {
 		if col.DefaultValue != "" {
 			colDef += " DEFAULT " + col.DefaultValue
 		}
-		if col.IsPrimaryKey {
+		if col.IsPrimaryKey && pkCount == 1 {
 			colDef += " PRIMARY KEY"
 		}
 		if col.IsUnique && !col.IsPrimaryKey {
@@ -3136,9 +9114,13 @@ func (a *App) GenerateCreateTableSQL(schemaJSON, driver string) string {
 		sql.WriteString(strings.Join(fkDefs, ",\n"))
 	}
 
-	sql.WriteString("\n);\n")
+	sql.WriteString("\n)" + tableOptions + ";\n")
 
-	// Indexes (CREATE INDEX statements)
+	// Indexes (CREATE INDEX statements); MySQL has no CREATE INDEX IF NOT EXISTS
+	indexIfNotExists := ifNotExists
+	if driver == "mysql" {
+		indexIfNotExists = ""
+	}
 	if len(schema.Indexes) > 0 {
 		sql.WriteString("\n")
 		for _, idx := range schema.Indexes {
@@ -3147,63 +9129,377 @@ func (a *App) GenerateCreateTableSQL(schemaJSON, driver string) string {
 				idxCols[i] = quoteIdent(driver, col)
 			}
 			if idx.IsUnique {
-				sql.WriteString(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s);\n",
+				sql.WriteString(fmt.Sprintf("CREATE UNIQUE INDEX %s%s ON %s (%s);\n",
+					indexIfNotExists,
 					quoteIdent(driver, idx.Name),
 					quoteIdent(driver, schema.Name),
 					strings.Join(idxCols, ", ")))
 			} else {
-				sql.WriteString(fmt.Sprintf("CREATE INDEX %s ON %s (%s);\n",
+				sql.WriteString(fmt.Sprintf("CREATE INDEX %s%s ON %s (%s);\n",
+					indexIfNotExists,
 					quoteIdent(driver, idx.Name),
 					quoteIdent(driver, schema.Name),
 					strings.Join(idxCols, ", ")))
 			}
 		}
 	}
-
-	return sql.String()
+
+	return sql.String()
+}
+
+// analyzeSQLMessages holds the AnalyzeSQL texts per language; see analyzeSQLText.
+var analyzeSQLMessages = map[string]map[string]string{
+	"zh": {
+		"selectStar":       "使用 SELECT * 可能影响性能，建议明确指定需要的列",
+		"noWhereOrLimit":   "查询没有 WHERE 条件或 LIMIT，可能返回大量数据",
+		"leadingWildcard":  "LIKE '%...' 无法使用索引，考虑使用全文搜索或前缀匹配",
+		"orderByNoLimit":   "ORDER BY 没有 LIMIT，可能影响性能",
+		"notInSubquery":    "NOT IN (子查询) 在子查询返回 NULL 时结果为空且难以优化，建议改用 NOT EXISTS 或 LEFT JOIN ... IS NULL",
+		"functionOnColumn": "WHERE 中对列 %s 使用函数会导致无法使用该列上的索引，建议改写条件或使用函数索引",
+		"singleRowInsert":  "单行 INSERT：插入多行时考虑使用批量插入 (VALUES (...), (...)) 以提高性能",
+		"updateNoWhere":    "UPDATE 语句缺少 WHERE 条件，将更新所有行！",
+		"deleteNoWhere":    "DELETE 语句缺少 WHERE 条件，将删除所有行！",
+		"joinIndex":        "建议确保 JOIN 的列上有索引",
+		"cartesianProduct": "可能产生笛卡尔积：%s 之间没有连接条件",
+		"distinctOrderBy":  "SELECT DISTINCT 按不在选择列表中的 %s 排序：PostgreSQL 会报错，MySQL 的排序结果不确定",
+	},
+	"en": {
+		"selectStar":       "SELECT * may hurt performance; list only the columns you need",
+		"noWhereOrLimit":   "The query has no WHERE condition or LIMIT and may return a lot of rows",
+		"leadingWildcard":  "LIKE '%...' cannot use an index; consider full-text search or a prefix match",
+		"orderByNoLimit":   "ORDER BY without LIMIT may hurt performance",
+		"notInSubquery":    "NOT IN (subquery) returns no rows when the subquery yields a NULL and is hard to optimize; use NOT EXISTS or LEFT JOIN ... IS NULL",
+		"functionOnColumn": "A function applied to column %s in WHERE prevents using an index on it; rewrite the condition or use a functional index",
+		"singleRowInsert":  "Single-row INSERT: when inserting many rows, batch them in one statement (VALUES (...), (...))",
+		"updateNoWhere":    "UPDATE has no WHERE condition and will update every row!",
+		"deleteNoWhere":    "DELETE has no WHERE condition and will delete every row!",
+		"joinIndex":        "Make sure the JOIN columns are indexed",
+		"cartesianProduct": "Possible cartesian product: no join condition links %s",
+		"distinctOrderBy":  "SELECT DISTINCT ordered by %s, which is not in the select list: PostgreSQL rejects it and MySQL's order is undefined",
+	},
+}
+
+// analyzeSQLText returns the message for key in lang ("en", "en-US", ... or Chinese, the default).
+func analyzeSQLText(lang, key string, args ...interface{}) string {
+	msgs := analyzeSQLMessages["zh"]
+	if strings.HasPrefix(strings.ToLower(lang), "en") {
+		msgs = analyzeSQLMessages["en"]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msgs[key], args...)
+	}
+	return msgs[key]
+}
+
+var (
+	analyzeWhereRegex           = regexp.MustCompile(`\bwhere\b`)
+	analyzeValuesRegex          = regexp.MustCompile(`\bvalues?\s*\(`)
+	analyzeLeadingWildcardRegex = regexp.MustCompile(`\blike\s+'%`)
+	analyzeNotInSubqueryRegex   = regexp.MustCompile(`\bnot\s+in\s*\(\s*select\b`)
+	// a function call on a bare column directly compared with something: LOWER(email) = ..., YEAR(created_at) > ...
+	analyzeFunctionOnColumnRegex = regexp.MustCompile(`\b([a-z_]\w*)\s*\(\s*([a-z_][\w.]*)\s*(?:,[^()]*)?\)\s*(?:=|<>|!=|<=|>=|<|>|\blike\b|\bin\b|\bbetween\b)`)
+)
+
+// analyzeWhereClause returns the text after the first WHERE of the lower-cased statement, or "".
+func analyzeWhereClause(sqlLower string) string {
+	loc := analyzeWhereRegex.FindStringIndex(sqlLower)
+	if loc == nil {
+		return ""
+	}
+	return sqlLower[loc[1]:]
+}
+
+// insertValuesRowCount counts the row tuples of an INSERT ... VALUES statement, ignoring parentheses inside
+// string literals; 0 when there is no VALUES clause (e.g. INSERT ... SELECT).
+func insertValuesRowCount(sqlLower string) int {
+	loc := analyzeValuesRegex.FindStringIndex(sqlLower)
+	if loc == nil {
+		return 0
+	}
+	rows, depth := 0, 0
+	var quote byte
+	for i := loc[1] - 1; i < len(sqlLower); i++ {
+		c := sqlLower[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			if depth == 0 {
+				rows++
+			}
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && c != ',' && c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			return rows // ON DUPLICATE KEY UPDATE, RETURNING, ...
+		}
+	}
+	return rows
+}
+
+var (
+	analyzeStringRegex     = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	analyzeFromRegex       = regexp.MustCompile(`\bfrom\b`)
+	analyzeFromEndRegex    = regexp.MustCompile(`\b(?:where|group\s+by|order\s+by|having|limit|union|window|for\s+update)\b`)
+	analyzeJoinRegex       = regexp.MustCompile(`\bjoin\b`)
+	analyzeJoinCondRegex   = regexp.MustCompile(`\b(?:on|using)\b`)
+	analyzeColumnPairRegex = regexp.MustCompile(`\b([a-z_]\w*)\.[a-z_]\w*\s*(?:=|<>|!=|<=|>=|<|>)\s*([a-z_]\w*)\.[a-z_]\w*`)
+	analyzeDistinctRegex   = regexp.MustCompile(`^select\s+distinct\s+`)
+	analyzeOrderByRegex    = regexp.MustCompile(`\border\s+by\s+`)
+	analyzeOrderEndRegex   = regexp.MustCompile(`\b(?:limit|offset|fetch|for)\b`)
+	analyzeOrderDirRegex   = regexp.MustCompile(`\s+(?:asc|desc)\b.*$|\s+nulls\s+(?:first|last)$`)
+	analyzeAsAliasRegex    = regexp.MustCompile(`\s+(?:as\s+)?([a-z_]\w*)$`)
+)
+
+// blankParenthesized replaces everything inside parentheses with spaces, keeping offsets, so regexes over the
+// result only see the top level of the statement (not subqueries or function arguments).
+func blankParenthesized(s string) string {
+	b := []byte(s)
+	depth := 0
+	for i, c := range b {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case depth > 0:
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
+
+// splitTopLevel splits s at the sep bytes that flat (s with parentheses blanked) still contains.
+func splitTopLevel(s, flat string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(flat); i++ {
+		if flat[i] == sep {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// trimJoinKeywords drops the join type (LEFT OUTER, CROSS, ...) that precedes the next JOIN from a FROM item.
+func trimJoinKeywords(ref string) string {
+	f := strings.Fields(ref)
+	for len(f) > 0 {
+		switch f[len(f)-1] {
+		case "left", "right", "full", "outer", "inner", "cross", "natural", "straight_join":
+			f = f[:len(f)-1]
+			continue
+		}
+		break
+	}
+	return strings.Join(f, " ")
+}
+
+// tableRefAlias returns the name a FROM item is referred to by: its alias, else the unqualified table name.
+func tableRefAlias(ref string) string {
+	f := strings.Fields(blankParenthesized(ref))
+	if len(f) == 0 {
+		return ""
+	}
+	name := f[len(f)-1]
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "`\"()")
+}
+
+// analyzeCartesianProduct returns the FROM items of a lower-cased SELECT that are neither joined with ON/USING
+// nor linked by a column comparison in WHERE, e.g. "a, b" for SELECT * FROM a, b; "" when everything is linked.
+func analyzeCartesianProduct(sqlLower string) string {
+	sqlLower = analyzeStringRegex.ReplaceAllString(sqlLower, "''")
+	flat := blankParenthesized(sqlLower)
+	from := analyzeFromRegex.FindStringIndex(flat)
+	if from == nil {
+		return ""
+	}
+	end := len(flat)
+	if loc := analyzeFromEndRegex.FindStringIndex(flat[from[1]:]); loc != nil {
+		end = from[1] + loc[0]
+	}
+	parent := map[string]string{}
+	var find func(string) string
+	find = func(x string) string {
+		if p, ok := parent[x]; ok && p != x {
+			r := find(p)
+			parent[x] = r
+			return r
+		}
+		return x
+	}
+	union := func(x, y string) {
+		if _, ok := parent[x]; !ok {
+			return
+		}
+		if _, ok := parent[y]; !ok {
+			return
+		}
+		parent[find(x)] = find(y)
+	}
+	var items []string
+	for _, ref := range splitTopLevel(sqlLower[from[1]:end], flat[from[1]:end], ',') {
+		refFlat := blankParenthesized(ref)
+		bounds := analyzeJoinRegex.FindAllStringIndex(refFlat, -1)
+		var first string
+		for k := 0; k <= len(bounds); k++ {
+			prev, segEnd := 0, len(ref)
+			if k > 0 {
+				prev = bounds[k-1][1]
+			}
+			if k < len(bounds) {
+				segEnd = bounds[k][0]
+			}
+			table := ref[prev:segEnd]
+			linked := k == 0 || strings.HasSuffix(strings.TrimSpace(ref[:prev-len("join")]), "natural")
+			if loc := analyzeJoinCondRegex.FindStringIndex(refFlat[prev:segEnd]); loc != nil {
+				table, linked = table[:loc[0]], true
+			}
+			alias := tableRefAlias(trimJoinKeywords(table))
+			if alias == "" {
+				continue
+			}
+			parent[alias] = alias
+			switch {
+			case first == "":
+				first = alias
+				items = append(items, alias)
+			case linked:
+				union(alias, first)
+			default:
+				// JOIN without ON/USING (CROSS JOIN, or MySQL's bare JOIN): only WHERE can still link it
+				items = append(items, alias)
+			}
+		}
+	}
+	if len(items) < 2 {
+		return ""
+	}
+	for _, m := range analyzeColumnPairRegex.FindAllStringSubmatch(sqlLower[end:], -1) {
+		union(m[1], m[2])
+	}
+	unlinked := []string{items[0]}
+	for _, it := range items[1:] {
+		if find(it) != find(items[0]) {
+			unlinked = append(unlinked, it)
+		}
+	}
+	if len(unlinked) == 1 {
+		return ""
+	}
+	return strings.Join(unlinked, ", ")
+}
+
+// analyzeDistinctOrderBy returns the ORDER BY expressions of a lower-cased SELECT DISTINCT that are not in its
+// select list (by expression, alias or column name), joined with ", ".
+func analyzeDistinctOrderBy(sqlLower string) string {
+	sqlLower = analyzeStringRegex.ReplaceAllString(sqlLower, "''")
+	flat := blankParenthesized(sqlLower)
+	sel := analyzeDistinctRegex.FindStringIndex(flat)
+	from := analyzeFromRegex.FindStringIndex(flat)
+	order := analyzeOrderByRegex.FindStringIndex(flat)
+	if sel == nil || from == nil || order == nil || from[0] < sel[1] {
+		return ""
+	}
+	selected := map[string]bool{}
+	for _, item := range splitTopLevel(sqlLower[sel[1]:from[0]], flat[sel[1]:from[0]], ',') {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.HasSuffix(item, ".*") {
+			return ""
+		}
+		selected[item] = true
+		if m := analyzeAsAliasRegex.FindStringSubmatch(item); m != nil {
+			selected[m[1]] = true
+			item = strings.TrimSpace(strings.TrimSuffix(item, m[0]))
+			selected[item] = true
+		}
+		if i := strings.LastIndex(item, "."); i >= 0 && !strings.Contains(item, "(") {
+			selected[item[i+1:]] = true
+		}
+	}
+	end := len(flat)
+	if loc := analyzeOrderEndRegex.FindStringIndex(flat[order[1]:]); loc != nil {
+		end = order[1] + loc[0]
+	}
+	var missing []string
+	for _, item := range splitTopLevel(sqlLower[order[1]:end], flat[order[1]:end], ',') {
+		item = strings.TrimSpace(analyzeOrderDirRegex.ReplaceAllString(strings.TrimSpace(item), ""))
+		if item == "" || strings.Trim(item, "0123456789") == "" || selected[item] {
+			continue
+		}
+		if i := strings.LastIndex(item, "."); i >= 0 && !strings.Contains(item, "(") && selected[item[i+1:]] {
+			continue
+		}
+		missing = append(missing, item)
+	}
+	return strings.Join(missing, ", ")
 }
 
-// AnalyzeSQL provides basic SQL analysis and optimization suggestions
-func (a *App) AnalyzeSQL(sql, driver string) string {
+// AnalyzeSQL provides basic SQL analysis and optimization suggestions. lang selects the language of the
+// messages: "en"/"en-US" for English, anything else for Chinese.
+func (a *App) AnalyzeSQL(sql, driver, lang string) string {
 	sqlLower := strings.ToLower(strings.TrimSpace(sql))
-	analysis := map[string]interface{}{
-		"queryType":   "unknown",
-		"suggestions": []string{},
-		"warnings":    []string{},
-		"performance": map[string]interface{}{},
+	warnings := []string{}
+	suggestions := []string{}
+	queryType := db.StatementType(sql)
+	if queryType == db.StatementOther {
+		queryType = "unknown"
 	}
 
-	// Detect query type
 	if strings.HasPrefix(sqlLower, "select") {
-		analysis["queryType"] = "SELECT"
 		// Check for common issues
 		if strings.Contains(sqlLower, "select *") {
-			analysis["warnings"] = append(analysis["warnings"].([]string), "使用 SELECT * 可能影响性能，建议明确指定需要的列")
+			warnings = append(warnings, analyzeSQLText(lang, "selectStar"))
 		}
 		if !strings.Contains(sqlLower, "where") && !strings.Contains(sqlLower, "limit") {
-			analysis["warnings"] = append(analysis["warnings"].([]string), "查询没有 WHERE 条件或 LIMIT，可能返回大量数据")
-		}
-		if strings.Contains(sqlLower, "like '%") {
-			analysis["suggestions"] = append(analysis["suggestions"].([]string), "LIKE '%...' 无法使用索引，考虑使用全文搜索或前缀匹配")
+			warnings = append(warnings, analyzeSQLText(lang, "noWhereOrLimit"))
 		}
 		if strings.Contains(sqlLower, "order by") && !strings.Contains(sqlLower, "limit") {
-			analysis["warnings"] = append(analysis["warnings"].([]string), "ORDER BY 没有 LIMIT，可能影响性能")
+			warnings = append(warnings, analyzeSQLText(lang, "orderByNoLimit"))
+		}
+		if tables := analyzeCartesianProduct(sqlLower); tables != "" {
+			warnings = append(warnings, analyzeSQLText(lang, "cartesianProduct", tables))
+		}
+		if cols := analyzeDistinctOrderBy(sqlLower); cols != "" {
+			warnings = append(warnings, analyzeSQLText(lang, "distinctOrderBy", cols))
 		}
 	} else if strings.HasPrefix(sqlLower, "insert") {
-		analysis["queryType"] = "INSERT"
-		if strings.Contains(sqlLower, "values") && !strings.Contains(sqlLower, "values") {
-			analysis["suggestions"] = append(analysis["suggestions"].([]string), "考虑使用批量插入以提高性能")
+		if insertValuesRowCount(sqlLower) == 1 {
+			suggestions = append(suggestions, analyzeSQLText(lang, "singleRowInsert"))
 		}
 	} else if strings.HasPrefix(sqlLower, "update") {
-		analysis["queryType"] = "UPDATE"
 		if !strings.Contains(sqlLower, "where") {
-			analysis["warnings"] = append(analysis["warnings"].([]string), "UPDATE 语句缺少 WHERE 条件，将更新所有行！")
+			warnings = append(warnings, analyzeSQLText(lang, "updateNoWhere"))
 		}
 	} else if strings.HasPrefix(sqlLower, "delete") {
-		analysis["queryType"] = "DELETE"
 		if !strings.Contains(sqlLower, "where") {
-			analysis["warnings"] = append(analysis["warnings"].([]string), "DELETE 语句缺少 WHERE 条件，将删除所有行！")
+			warnings = append(warnings, analyzeSQLText(lang, "deleteNoWhere"))
+		}
+	}
+
+	// Predicates that defeat indexes, in whichever statement filters rows
+	if analyzeLeadingWildcardRegex.MatchString(sqlLower) {
+		suggestions = append(suggestions, analyzeSQLText(lang, "leadingWildcard"))
+	}
+	if analyzeNotInSubqueryRegex.MatchString(sqlLower) {
+		suggestions = append(suggestions, analyzeSQLText(lang, "notInSubquery"))
+	}
+	for _, m := range analyzeFunctionOnColumnRegex.FindAllStringSubmatch(analyzeWhereClause(sqlLower), -1) {
+		switch m[1] {
+		case "and", "or", "not", "in", "exists", "select":
+			continue
 		}
+		suggestions = append(suggestions, analyzeSQLText(lang, "functionOnColumn", m[2]))
 	}
 
 	// Performance tips
@@ -3213,14 +9509,18 @@ func (a *App) AnalyzeSQL(sql, driver string) string {
 	}
 	if strings.Contains(sqlLower, "join") {
 		perf["estimatedComplexity"] = "medium"
-		perf["indexUsage"] = "建议确保 JOIN 的列上有索引"
+		perf["indexUsage"] = analyzeSQLText(lang, "joinIndex")
 	}
 	if strings.Contains(sqlLower, "group by") || strings.Contains(sqlLower, "having") {
 		perf["estimatedComplexity"] = "high"
 	}
-	analysis["performance"] = perf
 
-	data, _ := json.Marshal(analysis)
+	data, _ := json.Marshal(map[string]interface{}{
+		"queryType":   queryType,
+		"suggestions": suggestions,
+		"warnings":    warnings,
+		"performance": perf,
+	})
 	return string(data)
 }
 
@@ -3234,6 +9534,11 @@ func (a *App) GetTableSchema(connectionID, database, tableName, sessionID string
 	if conn == nil {
 		return `{"name":"","columns":[],"indexes":[],"foreignKeys":[]}`
 	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		apiErr := userFacingError(err)
+		data, _ := json.Marshal(TableSchema{Columns: []Column{}, Indexes: []Index{}, ForeignKeys: []ForeignKey{}, Error: &apiErr})
+		return string(data)
+	}
 	info, err := db.TableSchema(g, conn.Type, database, tableName)
 	if err != nil {
 		return `{"name":"","columns":[],"indexes":[],"foreignKeys":[]}`
@@ -3246,12 +9551,14 @@ func (a *App) GetTableSchema(connectionID, database, tableName, sessionID string
 	}
 	for _, c := range info.Columns {
 		schema.Columns = append(schema.Columns, Column{
-			Name:         c.Name,
-			Type:         c.Type,
-			Nullable:     c.Nullable,
-			DefaultValue: c.DefaultValue,
-			IsPrimaryKey: c.IsPrimaryKey,
-			IsUnique:     c.IsUnique,
+			Name:          c.Name,
+			Type:          c.Type,
+			Nullable:      c.Nullable,
+			DefaultValue:  c.DefaultValue,
+			IsPrimaryKey:  c.IsPrimaryKey,
+			IsUnique:      c.IsUnique,
+			AutoIncrement: c.AutoIncrement,
+			Generated:     c.Generated,
 		})
 	}
 	for _, f := range info.ForeignKeys {
@@ -3268,6 +9575,190 @@ func (a *App) GetTableSchema(connectionID, database, tableName, sessionID string
 	return string(data)
 }
 
+// columnProfileTimeout caps the aggregate queries behind GetColumnStats and GetDistinctValues, which scan the
+// whole table.
+const columnProfileTimeout = 30 * time.Second
+
+var (
+	// numericTypeRegex matches column types AVG makes sense for.
+	numericTypeRegex = regexp.MustCompile(`(?i)^(?:(?:tiny|small|medium|big)?int(?:eger|[248])?|decimal|numeric|dec|real|float[48]?|double|money|(?:small|big)?serial)\b`)
+	// unorderedTypeRegex matches column types MIN/MAX cannot (or should not) be taken of.
+	unorderedTypeRegex = regexp.MustCompile(`(?i)blob|binary|bytea|json|xml|bool|geometry|point|polygon|line|circle|box|path|tsvector|\[\]`)
+)
+
+// tableColumn returns the schema of column in tableName, failing with NOT_FOUND for a missing table or column.
+func tableColumn(g *gorm.DB, driver, database, tableName, column string) (db.SchemaColumn, error) {
+	if err := requireTable(g, driver, database, tableName); err != nil {
+		return db.SchemaColumn{}, err
+	}
+	info, err := db.TableSchema(g, driver, database, tableName)
+	if err != nil {
+		return db.SchemaColumn{}, err
+	}
+	for _, c := range info.Columns {
+		if c.Name == column {
+			return c, nil
+		}
+	}
+	return db.SchemaColumn{}, fmt.Errorf("column %s does not exist in table %s", column, tableName)
+}
+
+// countValue reads a COUNT(*) result, which drivers return as int64, []byte or string.
+func countValue(v interface{}) int64 {
+	switch x := v.(type) {
+	case int64:
+		return x
+	case float64:
+		return int64(x)
+	case []byte:
+		n, _ := strconv.ParseInt(string(x), 10, 64)
+		return n
+	case string:
+		n, _ := strconv.ParseInt(x, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// groupableType reports whether values of a column type can be counted distinct or grouped: PostgreSQL json, xml
+// and geometric types have no equality operator.
+func groupableType(driver, colType string) bool {
+	return driver != "postgresql" && driver != "postgres" || !unorderedTypeRegex.MatchString(colType) ||
+		strings.EqualFold(colType, "jsonb")
+}
+
+// ColumnStats profiles one column (see GetColumnStats). Min and Max are omitted for types without an order
+// (binary, JSON, booleans, geometry), Avg for non-numeric types.
+type ColumnStats struct {
+	Column   string      `json:"column"`
+	Type     string      `json:"type"`
+	Rows     int64       `json:"rows"`
+	Nulls    int64       `json:"nulls"`
+	Distinct int64       `json:"distinct"`
+	Min      interface{} `json:"min,omitempty"`
+	Max      interface{} `json:"max,omitempty"`
+	Avg      interface{} `json:"avg,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// GetColumnStats returns ColumnStats JSON for a column: row, NULL and distinct counts plus MIN/MAX/AVG where the
+// type allows, computed with one aggregate query that is cancelled after 30 seconds.
+func (a *App) GetColumnStats(connectionID, database, tableName, columnName, sessionID string) string {
+	out := ColumnStats{Column: columnName}
+	fail := func(err error) string {
+		out.Error = userFacingError(err).Message
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fail(fmt.Errorf("connection not found: %s", connectionID))
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return fail(err)
+	}
+	col, err := tableColumn(g, conn.Type, database, tableName, columnName)
+	if err != nil {
+		return fail(err)
+	}
+	out.Type = col.Type
+
+	qc := quoteIdent(conn.Type, columnName)
+	exprs := []string{"COUNT(*) AS total_rows", "COUNT(" + qc + ") AS non_null"}
+	ordered := !unorderedTypeRegex.MatchString(col.Type)
+	if groupableType(conn.Type, col.Type) {
+		exprs = append(exprs, "COUNT(DISTINCT "+qc+") AS distinct_values")
+	}
+	if ordered {
+		exprs = append(exprs, "MIN("+qc+") AS min_value", "MAX("+qc+") AS max_value")
+	}
+	if numericTypeRegex.MatchString(strings.TrimSpace(col.Type)) {
+		exprs = append(exprs, "AVG("+qc+") AS avg_value")
+	}
+	q := "SELECT " + strings.Join(exprs, ", ") + " FROM " + db.QualTable(conn.Type, database, tableName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), columnProfileTimeout)
+	defer cancel()
+	set, err := db.RawSelectResult(g.WithContext(ctx), q, 1)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("column statistics timed out after %s", columnProfileTimeout)
+		}
+		return fail(err)
+	}
+	if len(set.Rows) == 1 {
+		row := set.Rows[0]
+		out.Rows = countValue(row["total_rows"])
+		out.Nulls = out.Rows - countValue(row["non_null"])
+		out.Distinct = countValue(row["distinct_values"])
+		out.Min, out.Max, out.Avg = row["min_value"], row["max_value"], row["avg_value"]
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// ValueCount is one distinct value of a column and how many rows hold it.
+type ValueCount struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+// GetDistinctValues returns the limit (default 20, at most 1000) most frequent values of a column, NULL
+// included, as JSON {"values": [{value, count}]} ordered by count descending, for filter dropdowns. The query is
+// cancelled after 30 seconds.
+func (a *App) GetDistinctValues(connectionID, database, tableName, columnName string, limit int, sessionID string) string {
+	var out struct {
+		Values []ValueCount `json:"values"`
+		Error  string       `json:"error,omitempty"`
+	}
+	out.Values = []ValueCount{}
+	fail := func(err error) string {
+		out.Error = userFacingError(err).Message
+		data, _ := json.Marshal(out)
+		return string(data)
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return fail(fmt.Errorf("connection not found: %s", connectionID))
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return fail(err)
+	}
+	col, err := tableColumn(g, conn.Type, database, tableName, columnName)
+	if err != nil {
+		return fail(err)
+	}
+	if !groupableType(conn.Type, col.Type) {
+		return fail(fmt.Errorf("values of type %s cannot be grouped", col.Type))
+	}
+
+	qc := quoteIdent(conn.Type, columnName)
+	q := fmt.Sprintf("SELECT %s AS value, COUNT(*) AS value_count FROM %s GROUP BY %s ORDER BY 2 DESC, 1 LIMIT %d",
+		qc, db.QualTable(conn.Type, database, tableName), qc, limit)
+	ctx, cancel := context.WithTimeout(context.Background(), columnProfileTimeout)
+	defer cancel()
+	set, err := db.RawSelectResult(g.WithContext(ctx), q, limit)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("distinct values timed out after %s", columnProfileTimeout)
+		}
+		return fail(err)
+	}
+	for _, row := range set.Rows {
+		out.Values = append(out.Values, ValueCount{Value: row["value"], Count: countValue(row["value_count"])})
+	}
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
 // GenerateSchemaSyncScript generates ALTER/CREATE SQL to sync target table to match source.
 // direction: "a_to_b" (change B to match A) or "b_to_a" (change A to match B).
 // Returns SQL string; supports MySQL and PostgreSQL. SQLite returns a comment (limited ALTER support).
@@ -3372,8 +9863,14 @@ func (a *App) GenerateSchemaSyncScript(connA, dbA, tableA, connB, dbB, tableB, d
 	return b.String()
 }
 
+// exportMaxRows caps ExportData when no row limit is given.
+const exportMaxRows = 1 << 20
+
 // ExportData exports data from a table. database is optional (MySQL: qualify db.table). sessionID optional for tab isolation.
-func (a *App) ExportData(connectionID, database, tableName, format, sessionID string) string {
+// columnsJSON is an optional JSON array of the columns to export, in that order (default: every column);
+// filtersJSON an optional TableFilter array restricting the rows. limit and offset select a row range; limit <= 0
+// exports up to exportMaxRows rows.
+func (a *App) ExportData(connectionID, database, tableName, format, columnsJSON, filtersJSON string, limit, offset int, sessionID string) string {
 	g, err := getOrOpenDB(connectionID, sessionID)
 	if err != nil {
 		return exportError(err.Error())
@@ -3382,7 +9879,30 @@ func (a *App) ExportData(connectionID, database, tableName, format, sessionID st
 	if conn == nil {
 		return exportError("connection not found")
 	}
-	cols, rows, _, err := db.TableData(g, conn.Type, database, tableName, 1<<20, 0)
+	var columns []string
+	if strings.TrimSpace(columnsJSON) != "" {
+		if err := json.Unmarshal([]byte(columnsJSON), &columns); err != nil {
+			return exportError(err.Error())
+		}
+	}
+	var filters []TableFilter
+	if strings.TrimSpace(filtersJSON) != "" {
+		if err := json.Unmarshal([]byte(filtersJSON), &filters); err != nil {
+			return exportError(err.Error())
+		}
+	}
+	if limit <= 0 || limit > exportMaxRows {
+		limit = exportMaxRows
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	q, args, err := exportSelect(g, conn.Type, database, tableName, columns, filters)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	q += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	cols, rows, _, err := db.RawSelectLimit(g, q, 0, args...)
 	if err != nil {
 		return exportError(err.Error())
 	}
@@ -3408,7 +9928,9 @@ func (a *App) ExportData(connectionID, database, tableName, format, sessionID st
 			rec := make([]string, len(cols))
 			for i, c := range cols {
 				v := r[c]
-				if v != nil {
+				if b, ok := db.DecodeBlob(v); ok {
+					rec[i] = base64.StdEncoding.EncodeToString(b)
+				} else if v != nil {
 					rec[i] = fmt.Sprint(v)
 				}
 			}
@@ -3438,21 +9960,11 @@ func (a *App) ExportData(connectionID, database, tableName, format, sessionID st
 		tbl := db.QualTable(conn.Type, database, tableName)
 		// Generate INSERT statements
 		for _, r := range rows {
-			colNames := make([]string, 0, len(cols))
 			values := make([]string, 0, len(cols))
 			for _, col := range cols {
-				colNames = append(colNames, quoteIdent(conn.Type, col))
-				val := r[col]
-				if val == nil {
-					values = append(values, "NULL")
-				} else {
-					valStr := escapeSQLValue(fmt.Sprint(val), conn.Type)
-					values = append(values, valStr)
-				}
+				values = append(values, insertLiteral(r[col], false, conn.Type))
 			}
-			insertSQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);\n",
-				tbl, strings.Join(colNames, ", "), strings.Join(values, ", "))
-			_, _ = f.WriteString(insertSQL)
+			_, _ = f.WriteString(insertStatement(conn.Type, tbl, cols, values))
 		}
 	default:
 		return exportError("unsupported format: " + format)
@@ -3467,6 +9979,335 @@ func (a *App) ExportData(connectionID, database, tableName, format, sessionID st
 	return string(data)
 }
 
+// insertStatement renders one "INSERT INTO tbl (cols) VALUES (values);" line; values are SQL literals.
+func insertStatement(driver, tbl string, cols, values []string) string {
+	colNames := make([]string, 0, len(cols))
+	for _, col := range cols {
+		colNames = append(colNames, quoteIdent(driver, col))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);\n", tbl, strings.Join(colNames, ", "), strings.Join(values, ", "))
+}
+
+// GenerateInsertForRows returns INSERT statements that recreate rowsJSON (rows as loaded in the grid), one per
+// line, for copying rows to another database. Columns follow the table's order; generated columns, columns
+// absent from every row and row keys that are not table columns are left out, and a column missing from one row
+// is NULL there. Blob cells are written as hex literals.
+func (a *App) GenerateInsertForRows(connectionID, database, tableName, rowsJSON, sessionID string) (string, error) {
+	var rows []map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(rowsJSON))
+	dec.UseNumber() // keep large integers exact
+	if err := dec.Decode(&rows); err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return "", fmt.Errorf("connection not found")
+	}
+	if err := requireTable(g, conn.Type, database, tableName); err != nil {
+		return "", err
+	}
+	info, err := db.TableSchema(g, conn.Type, database, tableName)
+	if err != nil {
+		return "", err
+	}
+	present := make(map[string]bool)
+	for _, row := range rows {
+		for k := range row {
+			present[k] = true
+		}
+	}
+	var cols []string
+	for _, c := range info.Columns {
+		if present[c.Name] && !c.Generated {
+			cols = append(cols, c.Name)
+		}
+	}
+	if len(cols) == 0 {
+		return "", fmt.Errorf("rows have no insertable columns")
+	}
+	tbl := db.QualTable(conn.Type, database, tableName)
+	var b strings.Builder
+	for _, row := range rows {
+		values := make([]string, 0, len(cols))
+		for _, c := range cols {
+			values = append(values, displayLiteral(conn.Type, decodeCellValue(row[c])))
+		}
+		b.WriteString(insertStatement(conn.Type, tbl, cols, values))
+	}
+	return b.String(), nil
+}
+
+// exportSelect builds the SELECT of ExportData without its LIMIT: SELECT * unless columns are given, which must
+// exist in the table, and a WHERE clause from filters.
+func exportSelect(g *gorm.DB, driver, database, tableName string, columns []string, filters []TableFilter) (string, []interface{}, error) {
+	tbl := db.QualTable(driver, database, tableName)
+	if len(columns) == 0 && len(filters) == 0 {
+		return "SELECT * FROM " + tbl, nil, nil
+	}
+	if err := requireTable(g, driver, database, tableName); err != nil {
+		return "", nil, err
+	}
+	info, err := db.TableSchema(g, driver, database, tableName)
+	if err != nil {
+		return "", nil, err
+	}
+	list := "*"
+	if len(columns) > 0 {
+		known := make(map[string]bool, len(info.Columns))
+		for _, c := range info.Columns {
+			known[c.Name] = true
+		}
+		quoted := make([]string, 0, len(columns))
+		for _, c := range columns {
+			if !known[c] {
+				return "", nil, fmt.Errorf("unknown column %q", c)
+			}
+			quoted = append(quoted, quoteIdent(driver, c))
+		}
+		list = strings.Join(quoted, ", ")
+	}
+	q := "SELECT " + list + " FROM " + tbl
+	where, args, err := buildFilterWhere(driver, info, filters)
+	if err != nil {
+		return "", nil, err
+	}
+	if where != "" {
+		q += " WHERE " + where
+	}
+	return q, args, nil
+}
+
+// DumpTable writes a self-contained SQL file for one table: its CREATE TABLE statement followed by INSERTs for
+// every row. It runs over the normal connection (including SSH tunnels) and needs no external dump tools.
+// When path is empty a save dialog is shown. Returns JSON { "success", "path", "rows" } or { "success": false, "error" }.
+func (a *App) DumpTable(connectionID, database, tableName, path, sessionID string) string {
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return exportError("connection not found")
+	}
+	if path == "" {
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Dump table",
+			DefaultFilename: safeFileName(tableName + ".sql"),
+			Filters: []runtime.FileFilter{
+				{DisplayName: "SQL (*.sql)", Pattern: "*.sql"},
+				{DisplayName: "All Files", Pattern: "*"},
+			},
+		})
+		if err != nil {
+			return exportError(err.Error())
+		}
+		if path == "" {
+			return exportError("cancelled")
+		}
+	}
+
+	ddl, err := a.tableDDL(g, conn.Type, connectionID, database, tableName, sessionID)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	cols, rows, err := db.TableRows(g, conn.Type, database, tableName, 1<<20, 0)
+	if err != nil {
+		return exportError(err.Error())
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "-- Table %s dumped %s\n\n", tableName, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "%s;\n\n", ddl)
+	tbl := quoteIdent(conn.Type, tableName)
+	colNames := make([]string, len(cols))
+	for i, col := range cols {
+		colNames[i] = quoteIdent(conn.Type, col)
+	}
+	for _, r := range rows {
+		values := make([]string, len(cols))
+		for i, col := range cols {
+			values[i] = insertLiteral(r[col], false, conn.Type)
+		}
+		fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES (%s);\n", tbl, strings.Join(colNames, ", "), strings.Join(values, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return exportError(err.Error())
+	}
+	appendAuditLog("export", fmt.Sprintf("format=dump path=%s", path), connectionID, database, tableName)
+	data, _ := json.Marshal(map[string]interface{}{"success": true, "path": path, "rows": len(rows)})
+	return string(data)
+}
+
+// tableDDL returns the statement(s) that create a table, without a trailing ';': the server's own DDL where it
+// has one (MySQL SHOW CREATE TABLE, SQLite), otherwise DDL generated from the table schema (PostgreSQL).
+func (a *App) tableDDL(g *gorm.DB, driver, connectionID, database, tableName, sessionID string) (string, error) {
+	ddl, err := db.CreateTableDDL(g, driver, database, tableName)
+	if err != nil {
+		return "", err
+	}
+	if ddl == "" {
+		ddl = strings.TrimSuffix(strings.TrimSpace(a.GenerateCreateTableSQL(a.GetTableSchema(connectionID, database, tableName, sessionID), driver, "")), ";")
+	}
+	return ddl, nil
+}
+
+// ExportDatabaseSchema writes the DDL of every table of database, and then of its views, to one .sql file. Tables
+// are ordered so that each comes after the tables its foreign keys reference, making the script runnable top to
+// bottom; tables caught in a foreign-key cycle follow in name order (MySQL scripts disable FOREIGN_KEY_CHECKS
+// around them). Like DumpTable it needs no external tools and works over SSH tunnels. When path is empty a save
+// dialog is shown. Returns JSON { "success", "path", "tables", "views" } or { "success": false, "error" }.
+func (a *App) ExportDatabaseSchema(connectionID, database, path, sessionID string) string {
+	g, err := getOrOpenDB(connectionID, sessionID)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	conn := getConnByID(connectionID)
+	if conn == nil {
+		return exportError("connection not found")
+	}
+	if path == "" {
+		name := database
+		if name == "" {
+			name = conn.Name
+		}
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "Export database schema",
+			DefaultFilename: safeFileName(name + "_schema.sql"),
+			Filters: []runtime.FileFilter{
+				{DisplayName: "SQL (*.sql)", Pattern: "*.sql"},
+				{DisplayName: "All Files", Pattern: "*"},
+			},
+		})
+		if err != nil {
+			return exportError(err.Error())
+		}
+		if path == "" {
+			return exportError("cancelled")
+		}
+	}
+
+	views, err := db.ViewDDLs(g, conn.Type, database)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	isView := make(map[string]bool, len(views))
+	for _, v := range views {
+		isView[v.Name] = true
+	}
+	names, err := db.TableNames(g, conn.Type, database)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	var tables []string
+	for _, n := range names {
+		// MySQL's SHOW TABLES lists views too
+		if !isView[n] {
+			tables = append(tables, n)
+		}
+	}
+	inSet := make(map[string]bool, len(tables))
+	for _, n := range tables {
+		inSet[n] = true
+	}
+	deps := make(map[string][]string, len(tables))
+	for _, n := range tables {
+		info, err := db.TableSchema(g, conn.Type, database, n)
+		if err != nil {
+			return exportError(err.Error())
+		}
+		for _, fk := range info.ForeignKeys {
+			if fk.ReferencedTable != n && inSet[fk.ReferencedTable] {
+				deps[n] = append(deps[n], fk.ReferencedTable)
+			}
+		}
+	}
+	ordered, cyclic := orderByForeignKeys(tables, deps)
+	ddls := make([]string, len(ordered))
+	for i, n := range ordered {
+		if ddls[i], err = a.tableDDL(g, conn.Type, connectionID, database, n, sessionID); err != nil {
+			return exportError(err.Error())
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return exportError(err.Error())
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	title := database
+	if title == "" {
+		title = conn.Name
+	}
+	fmt.Fprintf(w, "-- Schema of %s exported %s\n\n", title, time.Now().Format(time.RFC3339))
+	firstCyclic := len(ordered) - len(cyclic)
+	for i, ddl := range ddls {
+		if i == firstCyclic {
+			fmt.Fprintf(w, "-- Foreign keys form a cycle between the remaining tables: %s\n", strings.Join(cyclic, ", "))
+			if conn.Type == "mysql" {
+				fmt.Fprint(w, "SET FOREIGN_KEY_CHECKS = 0;\n\n")
+			}
+		}
+		fmt.Fprintf(w, "%s;\n\n", ddl)
+	}
+	if len(cyclic) > 0 && conn.Type == "mysql" {
+		fmt.Fprint(w, "SET FOREIGN_KEY_CHECKS = 1;\n\n")
+	}
+	for _, v := range views {
+		fmt.Fprintf(w, "%s;\n\n", v.DDL)
+	}
+	if err := w.Flush(); err != nil {
+		return exportError(err.Error())
+	}
+	appendAuditLog("export", fmt.Sprintf("format=schema path=%s tables=%d views=%d", path, len(ordered), len(views)), connectionID, database, "")
+	data, _ := json.Marshal(map[string]interface{}{"success": true, "path": path, "tables": len(ordered), "views": len(views)})
+	return string(data)
+}
+
+// orderByForeignKeys orders tables so that each follows the tables it depends on (deps: table -> referenced
+// tables), breaking ties by name. Tables that cannot be placed because of a cycle are appended in name order
+// and also returned as cyclic.
+func orderByForeignKeys(tables []string, deps map[string][]string) (ordered, cyclic []string) {
+	remaining := append([]string(nil), tables...)
+	sort.Strings(remaining)
+	placed := make(map[string]bool, len(remaining))
+	for len(remaining) > 0 {
+		var next []string
+		for _, n := range remaining {
+			ready := true
+			for _, d := range deps[n] {
+				if !placed[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, n)
+				placed[n] = true
+			} else {
+				next = append(next, n)
+			}
+		}
+		if len(next) == len(remaining) {
+			return append(ordered, next...), next
+		}
+		remaining = next
+	}
+	return ordered, nil
+}
+
 func exportError(msg string) string {
 	data, _ := json.Marshal(map[string]interface{}{"success": false, "error": msg})
 	return string(data)
@@ -1,11 +1,52 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"topology/internal/backup"
+	"topology/internal/db"
+	"topology/internal/sshtunnel"
 )
 
+// TestMain points the user config dir at a temp dir, so that no store (connections, history, audit log,
+// settings, snippets, favorites, drafts, table views, backup records and schedules) ever reaches the user's
+// files, including the delayed LastConnectedAt save of a test that swaps in its own connections.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "topology-test")
+	if err != nil {
+		panic(err)
+	}
+	// os.UserConfigDir reads XDG_CONFIG_HOME or HOME on Unix, HOME on macOS and AppData on Windows
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+		os.Setenv(env, dir)
+	}
+	if cfg, err := os.UserConfigDir(); err != nil || !strings.HasPrefix(cfg, dir) {
+		panic(fmt.Sprintf("user config dir %q is outside the test dir %q: %v", cfg, dir, err))
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestUserFacingError(t *testing.T) {
 	tests := []struct {
 		err  error
@@ -39,6 +80,67 @@ func TestUserFacingError(t *testing.T) {
 	}
 }
 
+func TestUserFacingSyntaxErrorLocation(t *testing.T) {
+	tests := []struct {
+		err      error
+		position int
+		line     int
+		near     string
+	}{
+		{&pgconn.PgError{Severity: "ERROR", Code: "42601", Message: `syntax error at or near "FRM"`, Position: 10},
+			10, 0, "FRM"},
+		{fmt.Errorf("Error 1064 (42000): You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near 'FRM t\nWHERE id = 1' at line 2"),
+			0, 2, "FRM t\nWHERE id = 1"},
+		{fmt.Errorf("Error 1064 (42000): You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near '' at line 3"),
+			0, 3, ""},
+		{fmt.Errorf(`near "FRM": syntax error`), 0, 0, "FRM"},
+	}
+	for _, tt := range tests {
+		out := userFacingError(tt.err)
+		if out.Code != "SYNTAX_ERROR" || !strings.Contains(out.Message, "SQL syntax error") {
+			t.Errorf("%v: got %+v", tt.err, out)
+		}
+		if out.Position != tt.position || out.Line != tt.line || out.Near != tt.near {
+			t.Errorf("%v: position %d line %d near %q, want %d %d %q", tt.err, out.Position, out.Line, out.Near, tt.position, tt.line, tt.near)
+		}
+	}
+
+	withTestConnection(t, Connection{ID: "synerr", Type: "sqlite", Database: filepath.Join(t.TempDir(), "synerr.db")})
+	var r QueryResult
+	json.Unmarshal([]byte((&App{}).ExecuteQuery("synerr", "", "SELECT 1 FRM x")), &r)
+	if r.ErrorDetail == nil || r.ErrorDetail.Near != "x" || r.Error == "" {
+		t.Errorf("result = %+v, detail %+v", r, r.ErrorDetail)
+	}
+}
+
+func TestIsTunnelDrop(t *testing.T) {
+	tests := []struct {
+		err    error
+		expect bool
+	}{
+		{fmt.Errorf("dial tcp 127.0.0.1:50123: connect: connection refused"), true},
+		{fmt.Errorf("read tcp 127.0.0.1:50123: connection reset by peer"), true},
+		{fmt.Errorf("unexpected EOF"), true},
+		{fmt.Errorf("driver: bad connection"), true},
+		{fmt.Errorf("Error 1045: Access denied for user 'x'"), false},
+		{fmt.Errorf("Error 1049: Unknown database 'x'"), false},
+		{fmt.Errorf("Error 1205: Lock wait timeout exceeded; try restarting transaction"), false},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{fmt.Errorf("dial tcp 127.0.0.1:50123: i/o timeout"), true},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isTunnelDrop(tt.err); got != tt.expect {
+			t.Errorf("isTunnelDrop(%v) = %v, want %v", tt.err, got, tt.expect)
+		}
+	}
+	// No tunnel is running for an unknown connection, so a drop would trigger a rebuild.
+	if sshtunnel.Alive("no-such-connection", time.Second) {
+		t.Error("Alive reported an unknown tunnel as up")
+	}
+}
+
 func TestParsePGExplainJSON(t *testing.T) {
 	json := `[{"Plan":{"Node Type":"Seq Scan","Relation Name":"foo","Plan Rows":100,"Total Cost":10.5}}]`
 	nodes, warnings, err := parsePGExplainJSON(json)
@@ -128,3 +230,3158 @@ func TestExtractIndexHintTablesAndCols(t *testing.T) {
 		t.Errorf("expected id and x in cols, got %v", cols)
 	}
 }
+
+func TestPruneBackupFiles(t *testing.T) {
+	dir := t.TempDir()
+	savedPath, savedRecords := backupsFilePath, backupRecords
+	t.Cleanup(func() { backupsFilePath, backupRecords = savedPath, savedRecords })
+	backupsFilePath = filepath.Join(dir, "backups.json")
+	backupRecords = []BackupRecord{}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var files []backupFile
+	for i := 0; i < 5; i++ {
+		p := filepath.Join(dir, fmt.Sprintf("b%d.sql", i))
+		_ = os.WriteFile(p, []byte("-- dump"), 0o644)
+		files = append(files, backupFile{path: p, at: now.AddDate(0, 0, -i)})
+	}
+	removed := pruneBackupFiles(files, 3, 0, now)
+	if len(removed) != 2 {
+		t.Fatalf("keepLast=3: removed %v", removed)
+	}
+	for _, p := range removed {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should be deleted", p)
+		}
+	}
+	removed = pruneBackupFiles(files[:3], 0, 1, now)
+	if len(removed) != 1 || removed[0] != files[2].path {
+		t.Errorf("keepDays=1: removed %v", removed)
+	}
+	if got := pruneBackupFiles(files[:2], 0, 0, now); got != nil {
+		t.Errorf("no rules: removed %v", got)
+	}
+}
+
+func TestConnectionBackupFilesScopedByID(t *testing.T) {
+	dir := t.TempDir()
+	savedPath, savedRecords := backupsFilePath, backupRecords
+	t.Cleanup(func() { backupsFilePath, backupRecords = savedPath, savedRecords })
+	backupsFilePath = filepath.Join(dir, "backups.json")
+	backupRecords = []BackupRecord{}
+	// two connections named "prod" share the directory; each must only see its own scheduler files
+	for _, name := range []string{"prod-c1-20260301-030000.sql", "prod-c2-20260301-030000.sql", "prod-c2-20260302-030000.sql"} {
+		_ = os.WriteFile(filepath.Join(dir, name), []byte("-- dump"), 0o644)
+	}
+	if got := connectionBackupFiles("c1", dir, safeFileName("prod-c1")); len(got) != 1 || filepath.Base(got[0].path) != "prod-c1-20260301-030000.sql" {
+		t.Errorf("c1 files = %+v", got)
+	}
+	if got := connectionBackupFiles("c2", dir, safeFileName("prod-c2")); len(got) != 2 {
+		t.Errorf("c2 files = %+v", got)
+	}
+}
+
+func TestPruneBackupsRejectsKeepNone(t *testing.T) {
+	dir := t.TempDir()
+	savedPath, savedRecords := backupsFilePath, backupRecords
+	t.Cleanup(func() { backupsFilePath, backupRecords = savedPath, savedRecords })
+	backupsFilePath = filepath.Join(dir, "backups.json")
+	p := filepath.Join(dir, "b.sql")
+	_ = os.WriteFile(p, []byte("-- dump"), 0o644)
+	backupRecords = []BackupRecord{{ConnectionID: "c1", Path: p, At: time.Now().Format(time.RFC3339)}}
+
+	a := &App{}
+	for _, keep := range []int{0, -1} {
+		var res struct {
+			Success bool `json:"success"`
+		}
+		_ = json.Unmarshal([]byte(a.PruneBackups("c1", keep)), &res)
+		if res.Success {
+			t.Errorf("keepLast=%d accepted", keep)
+		}
+	}
+	if _, err := os.Stat(p); err != nil {
+		t.Errorf("backup deleted: %v", err)
+	}
+}
+
+func TestSnippetExpand(t *testing.T) {
+	s := Snippet{SQL: "SELECT * FROM ${table} WHERE id = ${id} AND owner = ${id}"}
+	if got := s.Placeholders(); len(got) != 2 || got[0] != "table" || got[1] != "id" {
+		t.Fatalf("Placeholders = %v", got)
+	}
+	out, err := s.Expand(map[string]string{"table": "users", "id": "7"})
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if out != "SELECT * FROM users WHERE id = 7 AND owner = 7" {
+		t.Errorf("Expand = %q", out)
+	}
+	if _, err := s.Expand(map[string]string{"table": "users"}); err == nil || !strings.Contains(err.Error(), "id") {
+		t.Errorf("expected unfilled placeholder error, got %v", err)
+	}
+}
+
+func TestSnippetMatches(t *testing.T) {
+	s := Snippet{Alias: "daily", SQL: "SELECT 1", Category: "Reports", Tags: []string{"kpi"}}
+	for _, term := range []string{"DAI", "select", "report", "KPI"} {
+		if !s.matches(term) {
+			t.Errorf("matches(%q) = false", term)
+		}
+	}
+	if s.matches("orders") {
+		t.Error("matches(orders) = true")
+	}
+}
+
+func TestSQLTableAliases(t *testing.T) {
+	got := sqlTableAliases("SELECT u.id FROM shop.users u JOIN orders AS o ON o.user_id = u.id WHERE")
+	if got["u"] != "users" || got["o"] != "orders" || got["users"] != "users" {
+		t.Errorf("aliases = %v", got)
+	}
+	if _, ok := got["where"]; ok {
+		t.Errorf("keyword treated as alias: %v", got)
+	}
+}
+
+func TestRankCompletions(t *testing.T) {
+	items := []CompletionItem{
+		{Label: "user_roles", Kind: "table"},
+		{Label: "Users", Kind: "table"},
+		{Label: "users", Kind: "table"},
+		{Label: "superusers", Kind: "table"},
+		{Label: "orders", Kind: "table"},
+		{Label: "users", Kind: "table"},
+	}
+	got := rankCompletions(items, "user")
+	var labels []string
+	for _, it := range got {
+		labels = append(labels, it.Label)
+	}
+	want := "user_roles,users,Users,superusers"
+	if strings.Join(labels, ",") != want {
+		t.Errorf("rank = %v, want %s", labels, want)
+	}
+}
+
+func TestMySQLSupportsExplainAnalyze(t *testing.T) {
+	cases := map[string]bool{
+		"8.0.18":                true,
+		"8.0.35-0ubuntu0.22.04": true,
+		"8.4.0":                 true,
+		"9.0.1":                 true,
+		"8.0.17":                false,
+		"5.7.44-log":            false,
+		"10.11.6-MariaDB":       false,
+		"":                      false,
+	}
+	for v, want := range cases {
+		if got := mysqlSupportsExplainAnalyze(v); got != want {
+			t.Errorf("mysqlSupportsExplainAnalyze(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+func TestParseMySQLExplainAnalyze(t *testing.T) {
+	text := "-> Nested loop inner join  (cost=4.50 rows=10) (actual time=0.050..1.250 rows=8 loops=1)\n" +
+		"    -> Table scan on u  (cost=1.25 rows=10) (actual time=0.030..0.100 rows=10 loops=1)\n" +
+		"    -> Index lookup on o using idx_user (user_id=u.id)  (cost=0.25 rows=1) (actual time=0.010..0.020 rows=1 loops=10)\n"
+	nodes, total, warnings := parseMySQLExplainAnalyze(text)
+	if len(nodes) != 3 {
+		t.Fatalf("nodes = %d, want 3", len(nodes))
+	}
+	if nodes[0].ParentID != nil || nodes[0].Type != "Join" || nodes[0].Cost != "4.50" || nodes[0].Rows != 8 {
+		t.Errorf("root = %+v", nodes[0])
+	}
+	if nodes[1].ParentID == nil || *nodes[1].ParentID != "1" || !nodes[1].FullTableScan || nodes[1].Label != "u" {
+		t.Errorf("scan = %+v", nodes[1])
+	}
+	if nodes[2].ParentID == nil || *nodes[2].ParentID != "1" || !nodes[2].IndexUsed {
+		t.Errorf("lookup = %+v", nodes[2])
+	}
+	if total != 1.25 {
+		t.Errorf("total = %v, want 1.25", total)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestParseMySQLExplainJSON(t *testing.T) {
+	js := `{"query_block": {"select_id": 1, "cost_info": {"query_cost": "12.50"},
+		"ordering_operation": {"using_filesort": true,
+			"nested_loop": [
+				{"table": {"table_name": "u", "access_type": "ALL", "rows_examined_per_scan": 10,
+					"cost_info": {"prefix_cost": "1.25"}, "attached_condition": "(u.active = 1)"}},
+				{"table": {"table_name": "o", "access_type": "ref", "key": "idx_user", "rows_examined_per_scan": 2,
+					"cost_info": {"prefix_cost": "8.00"}}}
+			]}}}`
+	nodes, warnings, err := parseMySQLExplainJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 5 {
+		t.Fatalf("nodes = %d, want 5: %+v", len(nodes), nodes)
+	}
+	wantParents := []string{"", "1", "2", "3", "3"}
+	for i, n := range nodes {
+		got := ""
+		if n.ParentID != nil {
+			got = *n.ParentID
+		}
+		if got != wantParents[i] {
+			t.Errorf("node %d (%s) parent = %q, want %q", i, n.Label, got, wantParents[i])
+		}
+	}
+	if nodes[0].Cost != "12.50" || nodes[1].Type != "Sort" || nodes[2].Type != "Join" {
+		t.Errorf("unexpected top nodes: %+v", nodes[:3])
+	}
+	if !nodes[3].FullTableScan || nodes[3].Rows != 10 || !nodes[4].IndexUsed {
+		t.Errorf("unexpected table nodes: %+v", nodes[3:])
+	}
+	if len(warnings) != 1 {
+		t.Errorf("warnings = %v", warnings)
+	}
+	if _, _, err := parseMySQLExplainJSON(`{"foo": 1}`); err == nil {
+		t.Error("expected error for missing query_block")
+	}
+}
+
+func TestBuildCreateIndexDDL(t *testing.T) {
+	ddl, cols := buildCreateIndexDDL("mysql", "shop", "orders", []string{"user_id", "status", "USER_ID"})
+	want := "CREATE INDEX `idx_orders_user_id_status` ON `shop`.`orders` (`user_id`, `status`);"
+	if ddl != want {
+		t.Errorf("ddl = %s, want %s", ddl, want)
+	}
+	if len(cols) != 2 {
+		t.Errorf("cols = %v", cols)
+	}
+	ddl, _ = buildCreateIndexDDL("postgresql", "", "orders", []string{"id"})
+	if ddl != `CREATE INDEX "idx_orders_id" ON "public"."orders" ("id");` {
+		t.Errorf("pg ddl = %s", ddl)
+	}
+	long := strings.Repeat("c", 80)
+	ddl, _ = buildCreateIndexDDL("mysql", "", "t", []string{long})
+	name := ddl[len("CREATE INDEX `"):strings.Index(ddl, "` ON")]
+	if len(name) != 64 {
+		t.Errorf("index name length = %d, want 64", len(name))
+	}
+	ddl, _ = buildCreateIndexDDL("postgresql", "", "订单", []string{strings.Repeat("列", 30)})
+	name = ddl[len(`CREATE INDEX "`):strings.Index(ddl, `" ON`)]
+	if !utf8.ValidString(name) || len(name) > 63 || len(name) < 60 {
+		t.Errorf("multibyte index name %q (%d bytes)", name, len(name))
+	}
+	if ddl, cols = buildCreateIndexDDL("mysql", "shop", "t", nil); cols != nil || !strings.HasPrefix(ddl, "-- ") {
+		t.Errorf("no-column ddl = %s", ddl)
+	}
+}
+
+func TestExtractIndexColumnsByTable(t *testing.T) {
+	got := extractIndexColumnsByTable("SELECT u.name FROM users u JOIN orders o ON o.user_id = u.id WHERE o.status = 'paid' GROUP BY u.country ORDER BY o.created_at DESC")
+	if strings.Join(got["orders"], ",") != "user_id,status,created_at" {
+		t.Errorf("orders cols = %v", got["orders"])
+	}
+	if strings.Join(got["users"], ",") != "id,country" {
+		t.Errorf("users cols = %v", got["users"])
+	}
+	single := extractIndexColumnsByTable("SELECT * FROM logs WHERE level = 'error' ORDER BY ts")
+	if strings.Join(single["logs"], ",") != "level,ts" {
+		t.Errorf("single-table cols = %v", single)
+	}
+}
+
+func TestIndexColumnRefsKeywordSuffix(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM t WHERE admin = 1 AND domain LIKE 'x%'", "admin,domain"},
+		{"SELECT * FROM t WHERE twin IN (1, 2) AND slike BETWEEN 1 AND 5", "twin,slike"},
+		{"SELECT * FROM t WHERE status NOT IN ('a') AND name NOT LIKE 'b%'", "status,name"},
+		{"SELECT * FROM a JOIN b ON a.id = b.a_id INNER JOIN c ON c.b_id = b.id", "id,b_id,a_id,id"},
+	}
+	for _, tt := range tests {
+		var cols []string
+		for _, ref := range indexColumnRefs(tt.sql) {
+			cols = append(cols, ref[1])
+		}
+		if got := strings.Join(cols, ","); got != tt.want {
+			t.Errorf("indexColumnRefs(%q) = %s, want %s", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestBuildOptimisticUpdate(t *testing.T) {
+	orig := map[string]interface{}{"id": float64(7), "name": "a", "note": nil}
+	all := map[string]bool{"id": true, "name": true, "note": true}
+	q, args, err := buildOptimisticUpdate("mysql", "`t`", []UpdateRecord{
+		{Column: "name", OldValue: "a", NewValue: "b", Original: orig},
+		{Column: "note", OldValue: nil, NewValue: "x", Original: orig},
+	}, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "UPDATE `t` SET `name` = ?, `note` = ? WHERE `id` = ? AND `name` = ? AND `note` IS NULL LIMIT 1"
+	if q != want {
+		t.Errorf("sql = %s\nwant %s", q, want)
+	}
+	if len(args) != 4 || args[0] != "b" || args[2] != float64(7) || args[3] != "a" {
+		t.Errorf("args = %v", args)
+	}
+	if q, _, _ := buildOptimisticUpdate("postgresql", `"t"`, []UpdateRecord{{Column: "name", OldValue: "a", NewValue: "a", Original: orig}}, all); q != "" {
+		t.Errorf("no-op update produced %s", q)
+	}
+	if _, _, err := buildOptimisticUpdate("mysql", "`t`", []UpdateRecord{{Column: "name", OldValue: "a", NewValue: "b", Original: orig}}, nil); err == nil {
+		t.Error("expected error without compare columns")
+	}
+}
+
+func TestOptimisticCompareColumnsSkipsLossyTypes(t *testing.T) {
+	info := &db.TableSchemaInfo{Columns: []db.SchemaColumn{
+		{Name: "id", Type: "bigint(20)", IsPrimaryKey: true},
+		{Name: "name", Type: "varchar(64)"},
+		{Name: "price", Type: "double"},
+		{Name: "ratio", Type: "real"},
+		{Name: "views", Type: "bigint"},
+		{Name: "created_at", Type: "timestamp"},
+		{Name: "updated_at", Type: "timestamp without time zone"},
+		{Name: "born", Type: "datetime"},
+		{Name: "meta", Type: "json"},
+		{Name: "qty", Type: "int(11)"},
+	}}
+	compare := optimisticCompareColumns(info)
+	var got []string
+	for _, c := range info.Columns {
+		if compare[c.Name] {
+			got = append(got, c.Name)
+		}
+	}
+	if strings.Join(got, ",") != "id,name,qty" {
+		t.Fatalf("compare columns = %v", got)
+	}
+
+	// A float and a timestamp rendered in another zone must not end up in the WHERE clause.
+	orig := map[string]interface{}{"id": float64(1), "name": "a", "price": 0.1 + 0.2, "created_at": "2026-01-02T08:00:00+08:00", "qty": float64(3)}
+	q, args, err := buildOptimisticUpdate("mysql", "`t`", []UpdateRecord{{Column: "name", OldValue: "a", NewValue: "b", Original: orig}}, compare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "UPDATE `t` SET `name` = ? WHERE `id` = ? AND `name` = ? AND `qty` = ? LIMIT 1"; q != want {
+		t.Errorf("sql = %s\nwant %s", q, want)
+	}
+	if len(args) != 4 {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestSelectInsertColumns(t *testing.T) {
+	tableCols := []string{"id", "name", "full_name"}
+	auto := map[string]bool{"id": true}
+	generated := map[string]bool{"full_name": true}
+	batch := []map[string]interface{}{
+		{"id": float64(5), "name": "a", "full_name": "x"},
+		{"id": nil, "name": "b"},
+	}
+	if got := strings.Join(selectInsertColumns(tableCols, batch, auto, generated, false), ","); got != "name" {
+		t.Errorf("without keepIdentity = %s", got)
+	}
+	if got := strings.Join(selectInsertColumns(tableCols, batch, auto, generated, true), ","); got != "id,name" {
+		t.Errorf("with keepIdentity = %s", got)
+	}
+	empty := []map[string]interface{}{{"id": "", "name": "c"}}
+	if got := strings.Join(selectInsertColumns(tableCols, empty, auto, generated, true), ","); got != "name" {
+		t.Errorf("empty identity values = %s", got)
+	}
+	if insertLiteral(nil, true, "mysql") != "DEFAULT" || insertLiteral(nil, true, "sqlite") != "NULL" || insertLiteral("o'k", false, "mysql") != "'o''k'" {
+		t.Error("unexpected insertLiteral output")
+	}
+}
+
+func TestApplyRowCap(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"SELECT * FROM t;", "SELECT * FROM t\nLIMIT 11"},
+		{"select * from t -- all", "select * from t -- all\nLIMIT 11"},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", "WITH x AS (SELECT 1) SELECT * FROM x\nLIMIT 11"},
+		{"SELECT * FROM t LIMIT 5", "SELECT * FROM t LIMIT 5"},
+		{"SELECT * FROM t FOR UPDATE", "SELECT * FROM t FOR UPDATE"},
+		{"SHOW TABLES", "SHOW TABLES"},
+	}
+	for _, c := range cases {
+		if got := applyRowCap(c.in, 10); got != c.want {
+			t.Errorf("applyRowCap(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	if got := applyRowCap("SELECT 1", 0); got != "SELECT 1" {
+		t.Errorf("disabled cap rewrote SQL: %q", got)
+	}
+}
+
+func TestUpsertTableMeta(t *testing.T) {
+	meta := SchemaMetadata{ConnectionID: "c", Databases: []SchemaDBMeta{
+		{Name: "shop", Tables: []SchemaTableMeta{{Name: "users"}, {Name: "orders"}}},
+	}}
+	users := SchemaTableMeta{Name: "users", Columns: []SchemaColumnMeta{{Name: "id"}}}
+	got := upsertTableMeta(meta, "shop", users, false)
+	if len(got.Databases[0].Tables) != 2 || len(got.Databases[0].Tables[0].Columns) != 1 {
+		t.Errorf("replace: %+v", got.Databases[0].Tables)
+	}
+	if len(meta.Databases[0].Tables[0].Columns) != 0 {
+		t.Error("original metadata was modified")
+	}
+	got = upsertTableMeta(got, "shop", SchemaTableMeta{Name: "items"}, false)
+	if n := len(got.Databases[0].Tables); n != 3 {
+		t.Errorf("add: %d tables", n)
+	}
+	got = upsertTableMeta(got, "shop", SchemaTableMeta{Name: "orders"}, true)
+	for _, tb := range got.Databases[0].Tables {
+		if tb.Name == "orders" {
+			t.Error("remove: orders still present")
+		}
+	}
+	got = upsertTableMeta(got, "crm", SchemaTableMeta{Name: "leads"}, false)
+	if len(got.Databases) != 2 || got.Databases[1].Tables[0].Name != "leads" {
+		t.Errorf("new database: %+v", got.Databases)
+	}
+}
+
+func TestStoreSchemaMetadataAfterCancel(t *testing.T) {
+	const id = "meta-cancel"
+	defer func() {
+		schemaMetaMu.Lock()
+		delete(schemaMetaCache, id)
+		schemaMetaMu.Unlock()
+	}()
+	stopCh := make(chan struct{})
+	schemaLoadMu.Lock()
+	schemaLoadStop[id] = stopCh
+	schemaLoadMu.Unlock()
+
+	if !storeSchemaMetadata(id, SchemaMetadata{ConnectionID: id}, stopCh) {
+		t.Fatal("store before cancel was rejected")
+	}
+	cancelSchemaMetadataLoad(id)
+	schemaMetaMu.Lock()
+	delete(schemaMetaCache, id)
+	schemaMetaMu.Unlock()
+	if storeSchemaMetadata(id, SchemaMetadata{ConnectionID: id}, stopCh) {
+		t.Error("store after cancel succeeded")
+	}
+	schemaMetaMu.RLock()
+	_, ok := schemaMetaCache[id]
+	schemaMetaMu.RUnlock()
+	if ok {
+		t.Error("canceled load resurrected the cache entry")
+	}
+}
+
+func TestReadOnlyConnectionRejectsWrites(t *testing.T) {
+	withTestConnection(t, Connection{ID: "ro", Type: "sqlite", Database: filepath.Join(t.TempDir(), "ro.db"), ReadOnly: true})
+
+	a := &App{}
+	if got := a.ExecuteQuery("ro", "", "DELETE FROM users"); !strings.Contains(got, "connection is read-only") {
+		t.Errorf("ExecuteQuery: %s", got)
+	}
+	if got := a.RestoreBackup("ro", "/nonexistent.sql"); !strings.Contains(got, "connection is read-only") {
+		t.Errorf("RestoreBackup: %s", got)
+	}
+	if _, err := a.UpdateTableData("ro", "", "users", "[]", "", false); err == nil || err.Error() != "connection is read-only" {
+		t.Errorf("UpdateTableData: %v", err)
+	}
+}
+
+func TestReadOnlyRejectsMultiStatementBypass(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ro.db")
+	withTestConnection(t, Connection{ID: "rw", Type: "sqlite", Database: path}, Connection{ID: "ro", Type: "sqlite", Database: path, ReadOnly: true})
+
+	a := &App{}
+	for _, q := range []string{"CREATE TABLE t (id INTEGER)", "INSERT INTO t VALUES (1)"} {
+		if got := a.ExecuteQuery("rw", "", q); strings.Contains(got, `"error"`) {
+			t.Fatalf("%s: %s", q, got)
+		}
+	}
+	if got := a.ExecuteQuery("ro", "", "SELECT 1; DELETE FROM t"); !strings.Contains(got, errMultipleStatements) {
+		t.Errorf("ExecuteQuery: %s", got)
+	}
+	if got := a.ExecuteQueryPaged("ro", "", "SELECT id FROM t; DELETE FROM t", `{"column":"id"}`, 10); !strings.Contains(got, errMultipleStatements) {
+		t.Errorf("ExecuteQueryPaged: %s", got)
+	}
+	if got := a.GetExecutionPlan("ro", "", "SELECT 1; DELETE FROM t", false); !strings.Contains(got, errMultipleStatements) {
+		t.Errorf("GetExecutionPlan: %s", got)
+	}
+	if got := a.GetIndexSuggestions("ro", "", "", "SELECT id FROM t; DELETE FROM t"); !strings.Contains(got, errMultipleStatements) {
+		t.Errorf("GetIndexSuggestions: %s", got)
+	}
+	if got := a.ExecuteQuery("ro", "", "SELECT COUNT(*) AS n FROM t"); !strings.Contains(got, `"n":1`) {
+		t.Errorf("rows were deleted: %s", got)
+	}
+}
+
+// useTempStores points the history, audit, settings and other per-user stores at an empty temp dir for the test
+// and resets their in-memory copies, so queries run by the test never touch the user's files.
+func useTempStores(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	// resolve the sync.Once paths first, so they are not computed again after the swap
+	getHistoryFilePath()
+	getSnippetsFilePath()
+	getFavoritesFilePath()
+	getTableViewsFilePath()
+	getDraftsFilePath()
+	getAuditFilePath()
+
+	historyMu.Lock()
+	savedHistoryPath, savedHistory := historyFilePath, queryHistory
+	historyFilePath, queryHistory = filepath.Join(dir, historyFileName), nil
+	historyMu.Unlock()
+	auditMu.Lock()
+	savedAuditPath := auditPath
+	auditPath = filepath.Join(dir, auditFileName)
+	auditMu.Unlock()
+	snippetsMu.Lock()
+	savedSnippetsPath, savedSnippets := snippetsFilePath, snippets
+	snippetsFilePath, snippets = filepath.Join(dir, snippetsFileName), nil
+	snippetsMu.Unlock()
+	favoritesMu.Lock()
+	savedFavoritesPath, savedFavorites := favoritesFilePath, favorites
+	favoritesFilePath, favorites = filepath.Join(dir, favoritesFileName), nil
+	favoritesMu.Unlock()
+	tableViewsMu.Lock()
+	savedViewsPath, savedViews := tableViewsFilePath, tableViews
+	tableViewsFilePath, tableViews = filepath.Join(dir, viewsFileName), nil
+	tableViewsMu.Unlock()
+	draftsMu.Lock()
+	savedDraftsPath, savedDrafts := draftsFilePath, editorDrafts
+	draftsFilePath, editorDrafts = filepath.Join(dir, draftsFileName), nil
+	draftsMu.Unlock()
+	settingsMu.Lock()
+	savedSettingsPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath, appSettings, settingsLoaded = filepath.Join(dir, settingsFileName), AppSettings{}, false
+	settingsMu.Unlock()
+	backupMu.Lock()
+	savedBackupsPath, savedRecords := backupsFilePath, backupRecords
+	backupsFilePath, backupRecords = filepath.Join(dir, backupsFileName), nil
+	backupMu.Unlock()
+	scheduleMu.Lock()
+	savedSchedulesPath, savedSchedules := schedulesFilePath, backupSchedules
+	schedulesFilePath, backupSchedules = filepath.Join(dir, schedulesFileName), nil
+	scheduleMu.Unlock()
+
+	t.Cleanup(func() {
+		historyMu.Lock()
+		historyFilePath, queryHistory = savedHistoryPath, savedHistory
+		historyMu.Unlock()
+		auditMu.Lock()
+		auditPath = savedAuditPath
+		auditMu.Unlock()
+		snippetsMu.Lock()
+		snippetsFilePath, snippets = savedSnippetsPath, savedSnippets
+		snippetsMu.Unlock()
+		favoritesMu.Lock()
+		favoritesFilePath, favorites = savedFavoritesPath, savedFavorites
+		favoritesMu.Unlock()
+		tableViewsMu.Lock()
+		tableViewsFilePath, tableViews = savedViewsPath, savedViews
+		tableViewsMu.Unlock()
+		draftsMu.Lock()
+		draftsFilePath, editorDrafts = savedDraftsPath, savedDrafts
+		draftsMu.Unlock()
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedSettingsPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+		backupMu.Lock()
+		backupsFilePath, backupRecords = savedBackupsPath, savedRecords
+		backupMu.Unlock()
+		scheduleMu.Lock()
+		schedulesFilePath, backupSchedules = savedSchedulesPath, savedSchedules
+		scheduleMu.Unlock()
+	})
+}
+
+// withTestConnection makes conns the only configured connections for the test; cleanup closes their pools and
+// restores the previous list.
+func withTestConnection(t *testing.T, conns ...Connection) {
+	t.Helper()
+	useTempStores(t)
+	connMu.Lock()
+	saved := connections
+	connections = conns
+	connMu.Unlock()
+	t.Cleanup(func() {
+		for _, c := range conns {
+			db.CloseConnection(c.ID)
+		}
+		connMu.Lock()
+		connections = saved
+		connMu.Unlock()
+	})
+}
+
+// useTempConnectionsFile points the connection store at an empty file in a temp dir for the test.
+func useTempConnectionsFile(t *testing.T) {
+	useTempStores(t)
+	savedPath := getConnectionsFilePath()
+	savedConns, savedKey := connections, vaultKey
+	connFilePath = filepath.Join(t.TempDir(), "connections.json")
+	connections, vaultKey, vaultConfLoaded = nil, nil, false
+	connectionsLoadOnce = sync.Once{}
+	connectionsLoadOnce.Do(func() {})
+	t.Cleanup(func() {
+		connFilePath, connections, vaultKey, vaultConfLoaded = savedPath, savedConns, savedKey, false
+		connectionsLoadOnce = sync.Once{}
+		connectionsLoadOnce.Do(func() {})
+	})
+}
+
+func TestConnectionDSN(t *testing.T) {
+	useTempConnectionsFile(t)
+	a := &App{}
+	file := filepath.Join(t.TempDir(), "dsn.db")
+	if err := a.CreateConnection(`{"name":"lite","dsn":"sqlite://` + file + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CreateConnection(`{"name":"pg","dsn":"postgres://u:s3cret@h:5433/app?sslmode=require"}`); err != nil {
+		t.Fatal(err)
+	}
+	lite, pg := connections[0], connections[1]
+	defer db.CloseConnection(lite.ID)
+	if lite.Type != "sqlite" || lite.Database != file {
+		t.Errorf("sqlite connection: %+v", lite)
+	}
+	if pg.Type != "postgresql" || pg.Host != "h" || pg.Port != 5433 || pg.Database != "app" {
+		t.Errorf("postgres connection: %+v", pg)
+	}
+	if got := a.ExecuteQuery(lite.ID, "", "SELECT 1 AS n"); !strings.Contains(got, `"n":1`) {
+		t.Errorf("query over DSN: %s", got)
+	}
+	data, _ := os.ReadFile(getConnectionsFilePath())
+	if strings.Contains(string(data), "s3cret") {
+		t.Error("DSN password written in clear")
+	}
+	saved, _ := loadConnectionsFromFile()
+	if len(saved) != 2 || saved[1].DSN != pg.DSN {
+		t.Errorf("reloaded: %+v", saved)
+	}
+	if err := a.UseDatabase(pg.ID, "", "other"); err != errDSNDatabase {
+		t.Errorf("UseDatabase = %v", err)
+	}
+	if err := a.CreateConnection(`{"name":"bad","dsn":"redis://h"}`); err == nil {
+		t.Error("unknown scheme accepted")
+	}
+
+	var form Connection
+	out, err := a.ParseDSN("mysql://root:pw@10.0.0.1:3307/shop?tls=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal([]byte(out), &form)
+	if form.Type != "mysql" || form.Host != "10.0.0.1" || form.Port != 3307 || form.Username != "root" ||
+		form.Password != "pw" || form.Database != "shop" || !form.UseSSL || form.DSN != "" {
+		t.Errorf("ParseDSN = %+v", form)
+	}
+}
+
+func TestLastConnectedAt(t *testing.T) {
+	useTempConnectionsFile(t)
+	a := &App{}
+	if err := a.CreateConnection(`{"name":"lite","type":"sqlite","database":"` + filepath.Join(t.TempDir(), "last.db") + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	id := connections[0].ID
+	defer db.CloseConnection(id)
+	t.Cleanup(flushLastConnected)
+	if got := a.ExecuteQuery(id, "", "SELECT 1"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	at := getConnByID(id).LastConnectedAt
+	if _, err := time.Parse(time.RFC3339, at); err != nil {
+		t.Fatalf("LastConnectedAt = %q", at)
+	}
+	data, _ := os.ReadFile(getConnectionsFilePath())
+	if strings.Contains(string(data), "lastConnectedAt") {
+		t.Error("saved before the delay")
+	}
+	// an edit from a form loaded earlier keeps the recorded time
+	if err := a.UpdateConnection(`{"id":"` + id + `","name":"renamed","type":"sqlite","database":"x.db"}`); err != nil {
+		t.Fatal(err)
+	}
+	if got := getConnByID(id).LastConnectedAt; got != at {
+		t.Errorf("after update LastConnectedAt = %q", got)
+	}
+	flushLastConnected()
+	saved, _ := loadConnectionsFromFile()
+	if len(saved) != 1 || saved[0].LastConnectedAt != at {
+		t.Errorf("saved: %+v", saved)
+	}
+}
+
+func TestStatementLogging(t *testing.T) {
+	useTempConnectionsFile(t)
+	statementLogMu.Lock()
+	savedDir := statementLogDir
+	statementLogDir = t.TempDir()
+	statementLogMu.Unlock()
+	t.Cleanup(func() {
+		statementLogMu.Lock()
+		statementLogDir = savedDir
+		statementLogMu.Unlock()
+	})
+	a := &App{}
+	if err := a.CreateConnection(`{"name":"lite","type":"sqlite","database":"` + filepath.Join(t.TempDir(), "log.db") + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	id := connections[0].ID
+	defer db.CloseConnection(id)
+	a.ExecuteQuery(id, "", "CREATE TABLE t (id INTEGER PRIMARY KEY)")
+	if err := a.SetStatementLogging(id, true); err != nil {
+		t.Fatal(err)
+	}
+	a.ExecuteQuery(id, "tab-1", "INSERT INTO t VALUES (1);")
+	a.ExecuteQuery(id, "tab-1", "SELECT * FROM missing")
+	a.ExecuteParamQuery(id, "", "DELETE FROM t WHERE id = :id", `{"id":1}`)
+	if err := a.SetStatementLogging(id, false); err != nil {
+		t.Fatal(err)
+	}
+	a.ExecuteQuery(id, "", "SELECT 1")
+
+	data, err := os.ReadFile(a.GetStatementLogPath(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("log:\n%s", data)
+	}
+	if !strings.Contains(lines[0], " session=tab-1 ") || !strings.HasSuffix(lines[0], " ok") || lines[1] != "INSERT INTO t VALUES (1);" {
+		t.Errorf("insert logged as %q %q", lines[0], lines[1])
+	}
+	if !strings.Contains(lines[2], " error: ") || !strings.Contains(lines[2], "missing") {
+		t.Errorf("failure logged as %q", lines[2])
+	}
+	if lines[5] != "DELETE FROM t WHERE id = 1;" {
+		t.Errorf("parameters not inlined: %q", lines[5])
+	}
+	if saved, _ := loadConnectionsFromFile(); len(saved) != 1 || saved[0].LogStatements {
+		t.Errorf("saved: %+v", saved)
+	}
+}
+
+func TestImportNavicatConnections(t *testing.T) {
+	useTempConnectionsFile(t)
+	ncx := filepath.Join(t.TempDir(), "connections.ncx")
+	if err := os.WriteFile(ncx, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Connections Ver="1.5">
+  <Connection ConnectionName="my" ConnType="MYSQL" Host="10.0.0.1" Port="" Database="app" UserName="root" Password="B75D320B6211468D63EB3B67C9E85933"/>
+  <Connection ConnectionName="pg" ConnType="POSTGRESQL" Host="10.0.0.2" Port="" Database="shop" UserName="postgres" SSL="true"/>
+  <Connection ConnectionName="ora" ConnType="ORACLE" Host="10.0.0.3" Port="1521"/>
+</Connections>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var result ImportNavicatResult
+	if err := json.Unmarshal([]byte((&App{}).ImportNavicatConnections(ncx, true)), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 2 || result.Skipped != 1 || len(result.Errors) != 0 {
+		t.Fatalf("result: %+v", result)
+	}
+	var my, pg *Connection
+	for i := range connections {
+		switch connections[i].Name {
+		case "my":
+			my = &connections[i]
+		case "pg":
+			pg = &connections[i]
+		}
+	}
+	if my == nil || my.Password != "This is a test" {
+		t.Errorf("mysql connection: %+v", my)
+	}
+	if pg == nil || pg.Type != "postgresql" || pg.Port != 5432 || pg.Database != "shop" || !pg.UseSSL {
+		t.Errorf("postgres connection: %+v", pg)
+	}
+}
+
+func TestImportConnectionsFromDBeaver(t *testing.T) {
+	useTempConnectionsFile(t)
+	dir := t.TempDir()
+	sources := `{"folders": {}, "connections": {
+  "mysql8-1": {"provider": "mysql", "driver": "mysql8", "name": "prod",
+    "configuration": {"host": "10.0.0.1", "port": "3307", "database": "app",
+      "handlers": {"ssh_tunnel": {"enabled": true, "properties": {"host": "jump", "port": 2222, "authType": "PASSWORD"}}}}},
+  "postgres-jdbc-2": {"provider": "postgresql", "driver": "postgres-jdbc", "name": "pg",
+    "configuration": {"host": "10.0.0.2", "database": "shop", "user": "postgres"}},
+  "sqlite_jdbc-3": {"provider": "generic", "driver": "sqlite_jdbc", "name": "local",
+    "configuration": {"url": "jdbc:sqlite:/data/app.db"}},
+  "oracle-4": {"provider": "oracle", "driver": "oracle_thin", "name": "ora", "configuration": {}}
+}}`
+	if err := os.WriteFile(filepath.Join(dir, "data-sources.json"), []byte(sources), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// credentials-config.json as DBeaver writes it: AES-128-CBC, IV first, PKCS#5 padding.
+	plain := []byte(`{"mysql8-1": {"#connection": {"user": "root", "password": "s3cret"}, "network/ssh_tunnel": {"user": "ops", "password": "jump"}}}`)
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+	key, _ := hex.DecodeString(dbeaverCredentialsKey)
+	block, _ := aes.NewCipher(key)
+	iv := bytes.Repeat([]byte{7}, aes.BlockSize)
+	enc := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(enc, plain)
+	if err := os.WriteFile(filepath.Join(dir, "credentials-config.json"), append(iv, enc...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var result ImportNavicatResult
+	if err := json.Unmarshal([]byte((&App{}).ImportConnectionsFromDBeaver(filepath.Join(dir, "data-sources.json"))), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 3 || result.Skipped != 1 || len(result.Errors) != 0 {
+		t.Fatalf("result: %+v", result)
+	}
+	byName := map[string]Connection{}
+	for _, c := range connections {
+		byName[c.Name] = c
+	}
+	if c := byName["prod"]; c.Type != "mysql" || c.Port != 3307 || c.Username != "root" || c.Password != "s3cret" ||
+		c.SSHTunnel == nil || c.SSHTunnel.Host != "jump" || c.SSHTunnel.Port != 2222 || c.SSHTunnel.Username != "ops" {
+		t.Errorf("mysql: %+v %+v", c, c.SSHTunnel)
+	}
+	if c := byName["pg"]; c.Type != "postgresql" || c.Port != 5432 || c.Username != "postgres" || c.Database != "shop" {
+		t.Errorf("postgres: %+v", c)
+	}
+	if c := byName["local"]; c.Type != "sqlite" || c.Database != "/data/app.db" {
+		t.Errorf("sqlite: %+v", c)
+	}
+}
+
+func TestDecodeNavicatPassword(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want string
+		ok   bool
+	}{
+		{"B75D320B6211468D63EB3B67C9E85933", "This is a test", true}, // Navicat 12+
+		{"0EA71F51DD37BFB60CCBA219BE3A", "This is a test", true},     // Navicat 11
+		{"", "", false},
+		{"not hex", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := decodeNavicatPassword(tt.hex); got != tt.want || ok != tt.ok {
+			t.Errorf("decodeNavicatPassword(%q) = %q, %v; want %q, %v", tt.hex, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMasterPasswordVault(t *testing.T) {
+	dir := t.TempDir()
+	// Resolve the real path first so restoring it leaves later tests pointed at the right file.
+	savedPath := getConnectionsFilePath()
+	savedConns, savedKey := connections, vaultKey
+	connFilePath = filepath.Join(dir, "connections.json")
+	vaultConfLoaded = false
+	t.Cleanup(func() {
+		connFilePath, connections, vaultKey, vaultConfLoaded = savedPath, savedConns, savedKey, false
+		// connections now holds the restored list; keep the loader from replacing it with a file read.
+		connectionsLoadOnce = sync.Once{}
+		connectionsLoadOnce.Do(func() {})
+	})
+	connections = []Connection{{ID: "1", Type: "mysql", Password: "s3cret"}}
+	if err := saveConnectionsToFile(connections); err != nil {
+		t.Fatal(err)
+	}
+	connectionsLoadOnce = sync.Once{}
+	connectionsLoadOnce.Do(func() {})
+
+	a := &App{}
+	if err := a.SetMasterPassword("", "master"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(connFilePath)
+	if strings.Contains(string(data), "s3cret") {
+		t.Fatal("password stored in plain text")
+	}
+
+	// New session: locked until the master password is entered.
+	vaultKey, vaultConfLoaded, connections, connectionsLoadOnce = nil, false, nil, sync.Once{}
+	if !vaultLocked() {
+		t.Fatal("expected vault to be locked")
+	}
+	if err := saveConnectionsToFile(nil); err == nil {
+		t.Error("save while locked should fail")
+	}
+	if err := a.UnlockVault("wrong"); err == nil {
+		t.Error("wrong password accepted")
+	}
+	if err := a.UnlockVault("master"); err != nil {
+		t.Fatal(err)
+	}
+	if len(connections) != 1 || connections[0].Password != "s3cret" {
+		t.Fatalf("after unlock: %+v", connections)
+	}
+
+	// A failed save must keep the old vault, so the file stays readable with the old password.
+	vaultData, _ := os.ReadFile(getVaultFilePath())
+	connData, _ := os.ReadFile(connFilePath)
+	os.Remove(connFilePath)
+	os.MkdirAll(filepath.Join(connFilePath, "blocked"), 0o755)
+	if err := a.SetMasterPassword("master", "changed"); err == nil {
+		t.Error("change with an unwritable connections file succeeded")
+	}
+	if err := a.SetMasterPassword("master", ""); err == nil {
+		t.Error("removal with an unwritable connections file succeeded")
+	}
+	if data, _ := os.ReadFile(getVaultFilePath()); !bytes.Equal(data, vaultData) {
+		t.Error("vault changed by a failed save")
+	}
+	os.RemoveAll(connFilePath)
+	os.WriteFile(connFilePath, connData, 0o600)
+	vaultKey, vaultConfLoaded, connections, connectionsLoadOnce = nil, false, nil, sync.Once{}
+	if err := a.UnlockVault("master"); err != nil || len(connections) != 1 || connections[0].Password != "s3cret" {
+		t.Fatalf("unlock after failed change: %v, %+v", err, connections)
+	}
+
+	if err := a.SetMasterPassword("master", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(getVaultFilePath()); !os.IsNotExist(err) {
+		t.Error("vault file not removed")
+	}
+	loaded, _ := loadConnectionsFromFile()
+	if len(loaded) != 1 || loaded[0].Password != "s3cret" {
+		t.Errorf("after removing master password: %+v", loaded)
+	}
+}
+
+func TestBuildDSNDefaultSchema(t *testing.T) {
+	pg := &Connection{Type: "postgresql", Username: "u", Password: "p", Database: "app", DefaultSchema: "sales,public"}
+	dsn, err := buildDSN(pg, "db", 5432)
+	if err != nil || !strings.Contains(dsn, " search_path='sales,public'") {
+		t.Errorf("postgres: %q, %v", dsn, err)
+	}
+	my := &Connection{Type: "mysql", Username: "u", Password: "p", Database: "app", DefaultSchema: "shop"}
+	dsn, err = buildDSN(my, "db", 3306)
+	if err != nil || !strings.Contains(dsn, "/shop?") {
+		t.Errorf("mysql: %q, %v", dsn, err)
+	}
+	my.DefaultSchema = ""
+	if dsn, _ = buildDSN(my, "db", 3306); !strings.Contains(dsn, "/app?") {
+		t.Errorf("mysql without default schema: %q", dsn)
+	}
+}
+
+func TestBuildDSNApplicationName(t *testing.T) {
+	pg := &Connection{Type: "postgresql", Name: "prod", Username: "u", Password: "p", Database: "app"}
+	if dsn, err := buildDSN(pg, "db", 5432); err != nil || !strings.HasSuffix(dsn, " application_name='topology - prod'") {
+		t.Errorf("postgres default: %q, %v", dsn, err)
+	}
+	pg.Options = map[string]string{"application_name": "etl"}
+	if dsn, _ := buildDSN(pg, "db", 5432); strings.Count(dsn, "application_name=") != 1 || !strings.HasSuffix(dsn, " application_name='etl'") {
+		t.Errorf("postgres option: %q", dsn)
+	}
+	my := &Connection{Type: "mysql", Name: "a,b:c", Username: "u", Password: "p", Database: "app", ApplicationName: "reports"}
+	if dsn, err := buildDSN(my, "db", 3306); err != nil || !strings.HasSuffix(dsn, "&connectionAttributes=program_name%3Areports") {
+		t.Errorf("mysql: %q, %v", dsn, err)
+	}
+	my.ApplicationName = ""
+	if dsn, _ := buildDSN(my, "db", 3306); !strings.HasSuffix(dsn, "&connectionAttributes=program_name%3Atopology+-+a+b+c") {
+		t.Errorf("mysql default: %q", dsn)
+	}
+	lite := &Connection{Type: "sqlite", Name: "x", Database: filepath.Join(t.TempDir(), "x.db")}
+	if dsn, _ := buildDSN(lite, "", 0); strings.Contains(dsn, "topology") {
+		t.Errorf("sqlite: %q", dsn)
+	}
+}
+
+func TestConnectionCharset(t *testing.T) {
+	my := &Connection{Type: "mysql", Username: "u", Password: "p", Database: "app", Charset: "latin1"}
+	if dsn, err := buildDSN(my, "db", 3306); err != nil || !strings.Contains(dsn, "?charset=latin1&") {
+		t.Errorf("mysql: %q, %v", dsn, err)
+	}
+	pg := &Connection{Type: "postgresql", Username: "u", Password: "p", Database: "app", Charset: "LATIN1"}
+	if dsn, err := buildDSN(pg, "db", 5432); err != nil || !strings.Contains(dsn, " client_encoding=LATIN1") {
+		t.Errorf("postgres: %q, %v", dsn, err)
+	}
+	my.Charset = "latin1&allowAllFiles=true"
+	if _, err := buildDSN(my, "db", 3306); err == nil {
+		t.Error("charset with DSN syntax accepted")
+	}
+
+	if w := charsetWarning("mysql", map[string]string{"character_set_client": "utf8mb4", "character_set_connection": "utf8mb4", "character_set_results": ""}); w != "" {
+		t.Errorf("utf8mb4 warned: %s", w)
+	}
+	w := charsetWarning("mysql", map[string]string{"character_set_client": "latin1", "character_set_connection": "utf8mb4", "character_set_results": "latin1", "character_set_database": "latin1"})
+	if w != "character_set_client=latin1, character_set_results=latin1 is not utf8mb4; text outside that character set may be garbled" {
+		t.Errorf("mysql warning = %q", w)
+	}
+	if w := charsetWarning("postgresql", map[string]string{"client_encoding": "SQL_ASCII"}); !strings.Contains(w, "client_encoding=SQL_ASCII") {
+		t.Errorf("postgres warning = %q", w)
+	}
+
+	withTestConnection(t, Connection{ID: "cs", Type: "sqlite", Database: filepath.Join(t.TempDir(), "cs.db")})
+	if got := (&App{}).GetConnectionCharset("cs", ""); got != `{"type":"sqlite","charset":{"encoding":"UTF-8"}}` {
+		t.Errorf("sqlite: %s", got)
+	}
+}
+
+func TestBuildDSNSQLiteOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "opts.db")
+	c := &Connection{Type: "sqlite", Database: path}
+	dsn, err := buildDSN(c, "", 0)
+	if err != nil || dsn != "file:"+path+"?_busy_timeout=5000" {
+		t.Errorf("defaults: %q, %v", dsn, err)
+	}
+	c.SQLiteBusyTimeout, c.SQLiteJournalMode = 250, "wal"
+	if dsn, err = buildDSN(c, "", 0); err != nil || !strings.HasSuffix(dsn, "?_busy_timeout=250&_journal_mode=WAL") {
+		t.Errorf("options: %q, %v", dsn, err)
+	}
+	c.SQLiteJournalMode = "fast"
+	if _, err = buildDSN(c, "", 0); err == nil {
+		t.Error("invalid journal mode accepted")
+	}
+	c.SQLiteJournalMode, c.Options = "wal", map[string]string{"_foreign_keys": "1", "_busy_timeout": "100"}
+	if dsn, err = buildDSN(c, "", 0); err != nil || !strings.HasSuffix(dsn, "?_busy_timeout=100&_journal_mode=WAL&_foreign_keys=1") {
+		t.Errorf("custom options: %q, %v", dsn, err)
+	}
+	c.Options = nil
+
+	c.SQLiteJournalMode = "WAL"
+	wal := *c
+	wal.ID = "sqlite-wal"
+	withTestConnection(t, wal)
+	g, err := getOrOpenDB("sqlite-wal", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mode string
+	if err := g.Raw("PRAGMA journal_mode").Scan(&mode).Error; err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v", mode, err)
+	}
+}
+
+func TestReadOnlySQLiteOpensReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ro file.db")
+	withTestConnection(t, Connection{ID: "ro-file", Type: "sqlite", Database: path})
+	g, err := getOrOpenDB("ro-file", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	db.CloseConnection("ro-file")
+
+	connMu.Lock()
+	connections[0].ReadOnly = true
+	connMu.Unlock()
+	if g, err = getOrOpenDB("ro-file", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := db.RawSelect(g, "SELECT * FROM t"); err != nil {
+		t.Errorf("read: %v", err)
+	}
+	if err := g.Exec("INSERT INTO t VALUES (1)").Error; err == nil || !strings.Contains(err.Error(), "readonly") {
+		t.Errorf("write on read-only file: %v", err)
+	}
+}
+
+func TestSwitchPgDatabaseRejectsOtherDrivers(t *testing.T) {
+	withTestConnection(t, Connection{ID: "lite", Type: "sqlite", Database: filepath.Join(t.TempDir(), "lite.db")})
+	a := &App{}
+	if got := a.GetPgDatabases("lite", ""); got != "[]" {
+		t.Errorf("GetPgDatabases = %s", got)
+	}
+	if err := a.SwitchPgDatabase("lite", "other"); err == nil {
+		t.Error("switch on sqlite succeeded")
+	}
+	if getConnByID("lite").Database == "other" {
+		t.Error("connection was modified")
+	}
+}
+
+func TestUseDatabase(t *testing.T) {
+	withTestConnection(t, Connection{ID: "use-lite", Type: "sqlite", Database: filepath.Join(t.TempDir(), "use.db")})
+	if err := (&App{}).UseDatabase("use-lite", "tab", "other"); err == nil {
+		t.Error("UseDatabase on sqlite succeeded")
+	}
+
+	sessionDBMu.Lock()
+	sessionDatabase[txKey("c1", "")] = "a"
+	sessionDatabase[txKey("c1", "tab")] = "b"
+	sessionDatabase[txKey("c10", "tab")] = "c"
+	sessionDBMu.Unlock()
+	clearSessionDatabases("c1")
+	sessionDBMu.Lock()
+	defer sessionDBMu.Unlock()
+	if len(sessionDatabase) != 1 || sessionDatabase[txKey("c10", "tab")] != "c" {
+		t.Errorf("after clear: %v", sessionDatabase)
+	}
+	delete(sessionDatabase, txKey("c10", "tab"))
+}
+
+func TestDeleteRowsByCondition(t *testing.T) {
+	info := &db.TableSchemaInfo{Columns: []db.SchemaColumn{{Name: "id"}, {Name: "status"}}}
+	where, args, err := buildFilterWhere("postgresql", info, []TableFilter{
+		{Column: "status", Op: "in", Value: []interface{}{"a", "b"}},
+		{Column: "id", Op: "is  not null"},
+	})
+	if err != nil || where != `"status" IN (?, ?) AND "id" IS NOT NULL` || len(args) != 2 {
+		t.Errorf("where = %q, args = %v, err = %v", where, args, err)
+	}
+	if _, _, err := buildFilterWhere("mysql", info, []TableFilter{{Column: "x; DROP", Op: "="}}); err == nil {
+		t.Error("unknown column accepted")
+	}
+
+	withTestConnection(t, Connection{ID: "del", Type: "sqlite", Database: filepath.Join(t.TempDir(), "del.db")})
+	g, err := getOrOpenDB("del", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, status TEXT)")
+	g.Exec("INSERT INTO items (status) VALUES ('open'), ('done'), ('done')")
+
+	a := &App{}
+	if _, err := a.DeleteRowsByCondition("del", "", "items", "[]", "", false); err == nil {
+		t.Error("delete without condition accepted")
+	}
+	n, err := a.DeleteRowsByCondition("del", "", "items", `[{"column":"status","op":"=","value":"done"}]`, "", false)
+	if err != nil || n != 2 {
+		t.Fatalf("deleted %d, %v", n, err)
+	}
+	if n, err = a.DeleteRowsByCondition("del", "", "items", "", "", true); err != nil || n != 1 {
+		t.Errorf("allowAll: deleted %d, %v", n, err)
+	}
+}
+
+func TestMissingTableNotFound(t *testing.T) {
+	withTestConnection(t, Connection{ID: "nf", Type: "sqlite", Database: filepath.Join(t.TempDir(), "nf.db")})
+
+	a := &App{}
+	if got := a.ExecuteQuery("nf", "", "CREATE VIEW v AS SELECT 1 AS id"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	var data TableData
+	if err := json.Unmarshal([]byte(a.GetTableData("nf", "", "usres", 10, 0, "", true)), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Error == nil || data.Error.Code != "NOT_FOUND" || !strings.Contains(data.Error.Message, "usres") {
+		t.Errorf("GetTableData: %+v", data.Error)
+	}
+	var schema TableSchema
+	if err := json.Unmarshal([]byte(a.GetTableSchema("nf", "", "usres", "")), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Error == nil || schema.Error.Code != "NOT_FOUND" {
+		t.Errorf("GetTableSchema: %+v", schema.Error)
+	}
+	if _, err := a.DeleteRowsByCondition("nf", "", "usres", "", "", true); userFacingError(err).Code != "NOT_FOUND" {
+		t.Errorf("DeleteRowsByCondition: %v", err)
+	}
+	if _, err := a.InsertTableRows("nf", "", "usres", `[{"id":1}]`, "", false); userFacingError(err).Code != "NOT_FOUND" {
+		t.Errorf("InsertTableRows: %v", err)
+	}
+	var view TableData
+	if err := json.Unmarshal([]byte(a.GetTableData("nf", "", "v", 10, 0, "", true)), &view); err != nil || view.Error != nil || view.TotalRows != 1 {
+		t.Errorf("view: %+v, %v", view, err)
+	}
+}
+
+func TestExecuteQueryColumnTypes(t *testing.T) {
+	withTestConnection(t, Connection{ID: "ct", Type: "sqlite", Database: filepath.Join(t.TempDir(), "ct.db")})
+	t.Cleanup(func() { clearQueryCacheForConnection("ct") })
+
+	a := &App{}
+	for _, q := range []string{"CREATE TABLE t (id INTEGER NOT NULL, name VARCHAR(20))", "INSERT INTO t VALUES (1, 'a')"} {
+		if got := a.ExecuteQuery("ct", "", q); strings.Contains(got, `"error"`) {
+			t.Fatalf("%s: %s", q, got)
+		}
+	}
+	for _, wantCached := range []bool{false, true} {
+		var r QueryResult
+		if err := json.Unmarshal([]byte(a.ExecuteQuery("ct", "", "SELECT id, name FROM t")), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Cached != wantCached || len(r.ColumnTypes) != 2 {
+			t.Fatalf("cached=%v: %+v", wantCached, r)
+		}
+		if id := r.ColumnTypes[0]; id.Name != "id" || id.DatabaseType != "INTEGER" {
+			t.Errorf("id column: %+v", id)
+		}
+		if name := r.ColumnTypes[1]; name.Name != "name" || !strings.HasPrefix(name.DatabaseType, "VARCHAR") {
+			t.Errorf("name column: %+v", name)
+		}
+	}
+}
+
+func TestExecuteQueryStatementType(t *testing.T) {
+	withTestConnection(t, Connection{ID: "st", Type: "sqlite", Database: filepath.Join(t.TempDir(), "st.db")})
+	t.Cleanup(func() { clearQueryCacheForConnection("st") })
+
+	a := &App{}
+	tests := []struct {
+		sql, wantType, wantMessage string
+		wantAffected               int
+	}{
+		{"CREATE TABLE t (id INTEGER)", "DDL", "Table created", 0},
+		{"INSERT INTO t VALUES (1), (2)", "INSERT", "", 2},
+		{"UPDATE t SET id = id + 10", "UPDATE", "", 2},
+		{"SELECT id FROM t", "SELECT", "", 0},
+		{"DELETE FROM t WHERE id = 11", "DELETE", "", 1},
+		{"CREATE INDEX t_id ON t (id)", "DDL", "Index created", 0},
+		{"DROP TABLE t", "DDL", "Table dropped", 0},
+	}
+	for _, tt := range tests {
+		var r QueryResult
+		if err := json.Unmarshal([]byte(a.ExecuteQuery("st", "", tt.sql)), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Error != "" {
+			t.Fatalf("%s: %s", tt.sql, r.Error)
+		}
+		if r.StatementType != tt.wantType || r.Message != tt.wantMessage || r.AffectedRows != tt.wantAffected {
+			t.Errorf("%s: got type=%q message=%q affected=%d", tt.sql, r.StatementType, r.Message, r.AffectedRows)
+		}
+	}
+}
+
+func TestExecuteQueryPrettyJSONFallback(t *testing.T) {
+	withTestConnection(t, Connection{ID: "pj", Type: "sqlite", Database: filepath.Join(t.TempDir(), "pj.db")})
+	t.Cleanup(func() { clearQueryCacheForConnection("pj") })
+
+	a := &App{}
+	for _, q := range []string{
+		"CREATE TABLE t (id INTEGER, doc TEXT)",
+		`INSERT INTO t VALUES (1, '{"k":[1,2]}'), (2, '42'), (3, 'plain')`,
+	} {
+		if got := a.ExecuteQuery("pj", "", q); strings.Contains(got, `"error"`) {
+			t.Fatalf("%s: %s", q, got)
+		}
+	}
+	var r QueryResult
+	if err := json.Unmarshal([]byte(a.ExecuteQuery("pj", "", "SELECT id, doc FROM t ORDER BY id")), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Rows[0]["doc"] != `{"k":[1,2]}` {
+		t.Errorf("stored value changed: %v", r.Rows[0]["doc"])
+	}
+	if got := r.PrettyJSON[0]["doc"]; got != "{\n  \"k\": [\n    1,\n    2\n  ]\n}" {
+		t.Errorf("pretty JSON = %q", got)
+	}
+	if len(r.PrettyJSON) != 1 {
+		t.Errorf("only the JSON row should be pretty-printed: %v", r.PrettyJSON)
+	}
+	if len(r.ColumnTypes) != 2 || r.ColumnTypes[0].JSON || !r.ColumnTypes[1].JSON {
+		t.Errorf("column types: %+v", r.ColumnTypes)
+	}
+}
+
+func TestInsertBatchSizeWideTable(t *testing.T) {
+	settingsMu.Lock()
+	savedPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath = filepath.Join(t.TempDir(), "settings.json")
+	appSettings, settingsLoaded = AppSettings{}, false
+	settingsMu.Unlock()
+	withTestConnection(t, Connection{ID: "wide", Type: "sqlite", Database: filepath.Join(t.TempDir(), "wide.db")})
+	t.Cleanup(func() {
+		insertBatchSizeMu.Lock()
+		insertBatchSize = 0
+		insertBatchSizeMu.Unlock()
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+	})
+
+	// 500 columns: SQLite's 32766-value limit allows 65 rows per INSERT, below the default of 100
+	const numCols, numRows = 500, 150
+	defs := make([]string, numCols)
+	for i := range defs {
+		defs[i] = fmt.Sprintf("c%d INTEGER", i)
+	}
+	a := &App{}
+	if got := a.ExecuteQuery("wide", "", "CREATE TABLE w ("+strings.Join(defs, ", ")+")"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	rows := make([]map[string]interface{}, numRows)
+	for r := range rows {
+		rows[r] = make(map[string]interface{}, numCols)
+		for c := 0; c < numCols; c++ {
+			rows[r][fmt.Sprintf("c%d", c)] = r*numCols + c
+		}
+	}
+	rowsJSON, _ := json.Marshal(rows)
+
+	used, err := a.InsertTableRows("wide", "", "w", string(rowsJSON), "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 32766 / numCols; used != want {
+		t.Errorf("batch size = %d, want %d", used, want)
+	}
+
+	if err := a.SetInsertBatchSize(maxInsertBatchSize + 1); err == nil {
+		t.Error("SetInsertBatchSize accepted a value above the limit")
+	}
+	if err := a.SetInsertBatchSize(40); err != nil {
+		t.Fatal(err)
+	}
+	if used, err = a.InsertTableRows("wide", "", "w", string(rowsJSON), "", false); err != nil || used != 40 {
+		t.Errorf("batch size = %d, %v; want 40", used, err)
+	}
+	if got := getSettings().InsertBatchSize; got != 40 {
+		t.Errorf("persisted batch size = %d", got)
+	}
+
+	var r QueryResult
+	if err := json.Unmarshal([]byte(a.ExecuteQuery("wide", "", "SELECT COUNT(*) AS n, SUM(c499) AS s FROM w")), &r); err != nil {
+		t.Fatal(err)
+	}
+	if n := fmt.Sprint(r.Rows[0]["n"]); n != fmt.Sprint(2*numRows) {
+		t.Errorf("inserted %s rows, want %d", n, 2*numRows)
+	}
+	if err := a.SetInsertBatchSize(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := effectiveInsertBatchSize("mysql", 10); got != mysqlInsertBatchSize {
+		t.Errorf("MySQL default batch = %d, want %d", got, mysqlInsertBatchSize)
+	}
+	if got := effectiveInsertBatchSize("mysql", 1000); got != 65 {
+		t.Errorf("MySQL batch for 1000 columns = %d, want 65", got)
+	}
+}
+
+func TestDestructiveOperationsDryRun(t *testing.T) {
+	withTestConnection(t, Connection{ID: "dry", Type: "sqlite", Database: filepath.Join(t.TempDir(), "dry.db")})
+	t.Cleanup(func() { clearQueryCacheForConnection("dry") })
+
+	a := &App{}
+	for _, q := range []string{
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO t VALUES (1, 'a'), (2, 'it''s'), (3, 'c')",
+	} {
+		if got := a.ExecuteQuery("dry", "", q); strings.Contains(got, `"error"`) {
+			t.Fatalf("%s: %s", q, got)
+		}
+	}
+	count := func() string {
+		var r QueryResult
+		_ = json.Unmarshal([]byte(a.ExecuteQuery("dry", "", "SELECT COUNT(*) AS n FROM t")), &r)
+		if len(r.Rows) == 0 {
+			return "missing"
+		}
+		return fmt.Sprint(r.Rows[0]["n"])
+	}
+	preview := func(out string, err error) DryRunResult {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r DryRunResult
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+		return r
+	}
+
+	r := preview(a.DeleteTableRows("dry", "", "t", `[{"id":2,"name":"it's"},{"id":9,"name":"x"}]`, "", true))
+	want := []string{`DELETE FROM "t" WHERE "id" = 2`, `DELETE FROM "t" WHERE "id" = 9`}
+	if strings.Join(r.Statements, "\n") != strings.Join(want, "\n") || r.EstimatedRows != 1 {
+		t.Errorf("delete preview: %+v", r)
+	}
+	r = preview(a.UpdateTableData("dry", "", "t", `[{"rowIndex":1,"column":"name","oldValue":"it's","newValue":"b","original":{"id":2,"name":"it's"}}]`, "", true))
+	if len(r.Statements) != 1 || r.Statements[0] != `UPDATE "t" SET "name" = 'b' WHERE "id" = 2 AND "name" = 'it''s'` || r.EstimatedRows != 1 {
+		t.Errorf("update preview: %+v", r)
+	}
+	r = preview(a.TruncateTable("dry", "", "t", "", true))
+	if len(r.Statements) != 1 || r.Statements[0] != `DELETE FROM "t"` || r.EstimatedRows != 3 {
+		t.Errorf("truncate preview: %+v", r)
+	}
+	r = preview(a.DropTable("dry", "", "t", "", true))
+	if len(r.Statements) != 1 || r.Statements[0] != `DROP TABLE "t"` || r.EstimatedRows != 3 {
+		t.Errorf("drop preview: %+v", r)
+	}
+	if n := count(); n != "3" {
+		t.Fatalf("dry runs changed the table: %s rows", n)
+	}
+
+	if out, err := a.TruncateTable("dry", "", "t", "", false); err != nil || out != "" {
+		t.Fatalf("truncate: %q, %v", out, err)
+	}
+	if n := count(); n != "0" {
+		t.Errorf("after truncate: %s rows", n)
+	}
+	if _, err := a.DropTable("dry", "", "t", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.DropTable("dry", "", "t", "", true); userFacingError(err).Code != "NOT_FOUND" {
+		t.Errorf("drop of a dropped table: %v", err)
+	}
+}
+
+func TestReadOnlyTxRejectsWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rotx.db")
+	withTestConnection(t, Connection{ID: "rotx", Type: "sqlite", Database: path}, Connection{ID: "rotx-ro", Type: "sqlite", Database: path, ReadOnly: true})
+	t.Cleanup(func() {
+		txMu.Lock()
+		for _, k := range []string{txKey("rotx", "s1"), txKey("rotx-ro", "")} {
+			if tx := activeTx[k]; tx != nil {
+				tx.Rollback()
+			}
+			forgetTxLocked(k)
+		}
+		txMu.Unlock()
+		clearQueryCacheForConnection("rotx")
+	})
+
+	a := &App{}
+	if got := a.ExecuteQuery("rotx", "", "CREATE TABLE t (id INTEGER)"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	if err := a.BeginReadOnlyTx("rotx", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.GetTransactionStatus("rotx", "s1"); got != `{"active":true,"readOnly":true}` {
+		t.Errorf("status = %s", got)
+	}
+	if err := a.BeginTx("rotx", "s1"); err == nil {
+		t.Error("BeginTx inside a read-only transaction succeeded")
+	}
+	if got := a.ExecuteQuery("rotx", "s1", "INSERT INTO t VALUES (1)"); !strings.Contains(got, errReadOnlyTx) {
+		t.Errorf("ExecuteQuery write: %s", got)
+	}
+	if got := a.ExecuteMultiResult("rotx", "s1", "DELETE FROM t"); !strings.Contains(got, errReadOnlyTx) {
+		t.Errorf("ExecuteMultiResult write: %s", got)
+	}
+	if _, err := a.InsertTableRows("rotx", "", "t", `[{"id":1}]`, "s1", false); err == nil || err.Error() != errReadOnlyTx {
+		t.Errorf("InsertTableRows: %v", err)
+	}
+	if got := a.ExecuteQuery("rotx", "s1", "SELECT COUNT(*) AS n FROM t"); strings.Contains(got, `"error"`) {
+		t.Errorf("SELECT in read-only transaction: %s", got)
+	}
+	if err := a.RollbackTx("rotx", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.ExecuteQuery("rotx", "s1", "INSERT INTO t VALUES (3)"); strings.Contains(got, `"error"`) {
+		t.Errorf("write after rollback: %s", got)
+	}
+	if err := a.BeginReadOnlyTx("rotx-ro", ""); err != nil {
+		t.Errorf("read-only connection: %v", err)
+	}
+}
+
+func TestReapIdleTx(t *testing.T) {
+	withTestConnection(t, Connection{ID: "txr", Type: "sqlite", Database: filepath.Join(t.TempDir(), "txr.db")})
+
+	a := &App{}
+	if err := a.BeginTx("txr", "tab1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := reapIdleTx(time.Now(), time.Minute); len(got) != 0 {
+		t.Errorf("fresh transaction reaped: %v", got)
+	}
+	key := txKey("txr", "tab1")
+	g, err := getOrOpenDB("txr", "tab1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	txMu.Lock()
+	txLastUsed[key] = time.Now().Add(-time.Hour)
+	txMu.Unlock()
+	if err := g.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	txMu.Lock()
+	busy, used := txBusy[key], txLastUsed[key]
+	txBusy[key] = 1 // a statement still running
+	txMu.Unlock()
+	if busy != 0 || time.Since(used) > time.Minute {
+		t.Errorf("after statement: busy=%d lastUsed=%v", busy, used)
+	}
+	if got := reapIdleTx(time.Now().Add(2*time.Minute), time.Minute); len(got) != 0 {
+		t.Errorf("busy transaction reaped: %v", got)
+	}
+	txMu.Lock()
+	delete(txBusy, key)
+	txMu.Unlock()
+	got := reapIdleTx(time.Now().Add(2*time.Minute), time.Minute)
+	if len(got) != 1 || got[0] != txKey("txr", "tab1") {
+		t.Fatalf("reaped %v", got)
+	}
+	if !strings.Contains(a.GetTransactionStatus("txr", "tab1"), `"active":false`) {
+		t.Error("transaction still active after reaping")
+	}
+}
+
+func TestShutdownRollsBackTx(t *testing.T) {
+	withTestConnection(t, Connection{ID: "shut", Type: "sqlite", Database: filepath.Join(t.TempDir(), "shut.db")})
+
+	a := &App{}
+	if err := a.BeginTx("shut", "tab"); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.ExecuteQuery("shut", "tab", "CREATE TABLE t (id INTEGER)"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	stopCh := make(chan struct{})
+	monitorMu.Lock()
+	monitorStop["shut"] = stopCh
+	monitorMu.Unlock()
+
+	a.shutdown(context.Background())
+	if !strings.Contains(a.GetTransactionStatus("shut", "tab"), `"active":false`) {
+		t.Error("transaction still active after shutdown")
+	}
+	select {
+	case <-stopCh:
+	default:
+		t.Error("monitor not stopped")
+	}
+	if got := a.ExecuteQuery("shut", "", "SELECT COUNT(*) AS n FROM sqlite_master WHERE name = 't'"); !strings.Contains(got, `"n":0`) {
+		t.Errorf("CREATE TABLE was not rolled back: %s", got)
+	}
+}
+
+func TestExecuteQueryInTxBypassesCache(t *testing.T) {
+	withTestConnection(t, Connection{ID: "txq", Type: "sqlite", Database: filepath.Join(t.TempDir(), "txq.db")})
+	t.Cleanup(clearQueryCache)
+
+	a := &App{}
+	a.ExecuteQuery("txq", "tab", "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	a.ExecuteQuery("txq", "tab", "INSERT INTO t (v) VALUES ('old')")
+	const sel = "SELECT v FROM t WHERE id = 1"
+	if got := a.ExecuteQuery("txq", "tab", sel); !strings.Contains(got, `"old"`) {
+		t.Fatalf("before tx: %s", got)
+	}
+	if err := a.BeginTx("txq", "tab"); err != nil {
+		t.Fatal(err)
+	}
+	defer a.RollbackTx("txq", "tab")
+	if got := a.ExecuteQuery("txq", "tab", "UPDATE t SET v = 'new' WHERE id = 1"); strings.Contains(got, `"error"`) {
+		t.Fatalf("update: %s", got)
+	}
+	got := a.ExecuteQuery("txq", "tab", sel)
+	if !strings.Contains(got, `"new"`) || strings.Contains(got, `"cached":true`) {
+		t.Errorf("select in tx: %s", got)
+	}
+
+	// Another session caches the committed value while the transaction is open; the commit must drop it.
+	if got := a.ExecuteQuery("txq", "other", sel); !strings.Contains(got, `"old"`) {
+		t.Fatalf("other session in tx: %s", got)
+	}
+	if err := a.CommitTx("txq", "tab"); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.ExecuteQuery("txq", "other", sel); !strings.Contains(got, `"new"`) {
+		t.Errorf("after commit: %s", got)
+	}
+	a.ExecuteQuery("txq", "tab", "UPDATE t SET v = 'newer' WHERE id = 1")
+	if got := a.ExecuteQuery("txq", "other", sel); !strings.Contains(got, `"newer"`) {
+		t.Errorf("after write outside tx: %s", got)
+	}
+}
+
+func TestExecuteMultiResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "multi.db")
+	if err := os.WriteFile(path, nil, 0o644); err != nil { // read-only connections do not create the file
+		t.Fatal(err)
+	}
+	withTestConnection(t, Connection{ID: "multi", Type: "sqlite", Database: path, ReadOnly: true})
+
+	a := &App{}
+	var results []QueryResult
+	if err := json.Unmarshal([]byte(a.ExecuteMultiResult("multi", "", "SELECT 1; DROP TABLE t")), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error != "connection is read-only" {
+		t.Errorf("read-only script: %+v", results)
+	}
+	results = nil
+	if err := json.Unmarshal([]byte(a.ExecuteMultiResult("multi", "", "SELECT 1 AS a, 2 AS b;")), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error != "" || len(results[0].Columns) != 2 || results[0].RowCount != 1 {
+		t.Errorf("select: %+v", results)
+	}
+}
+
+func TestDumpTableSQLite(t *testing.T) {
+	dir := t.TempDir()
+	withTestConnection(t,
+		Connection{ID: "dump-src", Type: "sqlite", Database: filepath.Join(dir, "src.db")},
+		Connection{ID: "dump-dst", Type: "sqlite", Database: filepath.Join(dir, "dst.db")},
+	)
+	src, err := getOrOpenDB("dump-src", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.Exec("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+	src.Exec("INSERT INTO notes (body) VALUES ('it''s'), (NULL)")
+
+	a := &App{}
+	out := filepath.Join(dir, "notes.sql")
+	if got := a.DumpTable("dump-src", "", "notes", out, ""); !strings.Contains(got, `"rows":2`) {
+		t.Fatalf("DumpTable: %s", got)
+	}
+	script, _ := os.ReadFile(out)
+	dst, err := getOrOpenDB("dump-dst", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range strings.Split(string(script), ";\n") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			if err := dst.Exec(stmt).Error; err != nil {
+				t.Fatalf("replay %q: %v", stmt, err)
+			}
+		}
+	}
+	var body string
+	dst.Raw("SELECT body FROM notes WHERE id = 1").Scan(&body)
+	if body != "it's" {
+		t.Errorf("replayed body = %q", body)
+	}
+}
+
+func TestExportDataColumnsAndRange(t *testing.T) {
+	withTestConnection(t, Connection{ID: "exp", Type: "sqlite", Database: filepath.Join(t.TempDir(), "exp.db")})
+	// ExportData writes under ./build/export
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	a := &App{}
+	a.ExecuteQuery("exp", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, payload BLOB)")
+	a.ExecuteQuery("exp", "", "INSERT INTO t (name, payload) VALUES ('a', x'00'), ('b', x'01'), ('c', x'02'), ('d', x'03')")
+
+	export := func(columns, filters string, limit, offset int) string {
+		var res struct {
+			Success bool   `json:"success"`
+			Path    string `json:"path"`
+			Error   string `json:"error"`
+		}
+		json.Unmarshal([]byte(a.ExportData("exp", "", "t", "csv", columns, filters, limit, offset, "")), &res)
+		if !res.Success {
+			return "error: " + res.Error
+		}
+		data, _ := os.ReadFile(res.Path)
+		return string(data)
+	}
+	if got := export(`["name","id"]`, "", 2, 1); got != "name,id\nb,2\nc,3\n" {
+		t.Errorf("columns with range = %q", got)
+	}
+	if got := export(`["name"]`, `[{"column":"id","op":">=","value":3}]`, 0, 0); got != "name\nc\nd\n" {
+		t.Errorf("filtered = %q", got)
+	}
+	if got := export("", "", 0, 0); !strings.HasPrefix(got, "id,name,payload\n") || strings.Count(got, "\n") != 5 {
+		t.Errorf("default = %q", got)
+	}
+	if got := export(`["id","secret"]`, "", 0, 0); !strings.Contains(got, "unknown column") {
+		t.Errorf("unknown column = %q", got)
+	}
+}
+
+func TestExportDatabaseSchemaSQLite(t *testing.T) {
+	dir := t.TempDir()
+	withTestConnection(t,
+		Connection{ID: "schema-src", Type: "sqlite", Database: filepath.Join(dir, "src.db")},
+		Connection{ID: "schema-dst", Type: "sqlite", Database: filepath.Join(dir, "dst.db")},
+	)
+	src, err := getOrOpenDB("schema-src", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{
+		"CREATE TABLE z_parent (id INTEGER PRIMARY KEY, up INTEGER REFERENCES z_parent(id))",
+		"CREATE TABLE a_child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES z_parent(id))",
+		"CREATE TABLE m_plain (id INTEGER)",
+		"CREATE VIEW v_children AS SELECT c.id FROM a_child c JOIN z_parent p ON p.id = c.parent_id",
+	} {
+		if err := src.Exec(q).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := &App{}
+	out := filepath.Join(dir, "schema.sql")
+	if got := a.ExportDatabaseSchema("schema-src", "", out, ""); !strings.Contains(got, `"tables":3`) || !strings.Contains(got, `"views":1`) {
+		t.Fatalf("ExportDatabaseSchema: %s", got)
+	}
+	data, _ := os.ReadFile(out)
+	script := string(data)
+	parent, child, view := strings.Index(script, "CREATE TABLE z_parent"), strings.Index(script, "CREATE TABLE a_child"), strings.Index(script, "CREATE VIEW")
+	if parent < 0 || child < parent || view < child {
+		t.Fatalf("statement order wrong:\n%s", script)
+	}
+	dst, err := getOrOpenDB("schema-dst", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range strings.Split(script, ";\n") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			if err := dst.Exec(stmt).Error; err != nil {
+				t.Fatalf("replay %q: %v", stmt, err)
+			}
+		}
+	}
+	var n int64
+	dst.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view')").Scan(&n)
+	if n != 4 {
+		t.Errorf("replayed %d tables and views, want 4", n)
+	}
+}
+
+func TestOrderByForeignKeys(t *testing.T) {
+	ordered, cyclic := orderByForeignKeys([]string{"orders", "items", "users", "a", "b"}, map[string][]string{
+		"orders": {"users"},
+		"items":  {"orders", "users"},
+		"a":      {"b"},
+		"b":      {"a"},
+	})
+	if got := strings.Join(ordered, ","); got != "users,orders,items,a,b" {
+		t.Errorf("ordered = %s", got)
+	}
+	if got := strings.Join(cyclic, ","); got != "a,b" {
+		t.Errorf("cyclic = %s", got)
+	}
+}
+
+func TestExecuteQueryPaged(t *testing.T) {
+	q, args := buildKeysetQuery("mysql", "SELECT id FROM t;", QueryCursor{Column: "id", Desc: true, After: 7.0}, 10)
+	if q != "SELECT * FROM (\nSELECT id FROM t\n) AS _page WHERE _page.`id` < ? ORDER BY _page.`id` DESC LIMIT 11" || len(args) != 1 {
+		t.Errorf("query = %q, args = %v", q, args)
+	}
+
+	withTestConnection(t, Connection{ID: "pg", Type: "sqlite", Database: filepath.Join(t.TempDir(), "pg.db")})
+	g, err := getOrOpenDB("pg", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Exec("CREATE TABLE n (id INTEGER PRIMARY KEY)")
+	g.Exec("INSERT INTO n (id) VALUES (1), (2), (3), (4), (5)")
+
+	a := &App{}
+	cursor := `{"column":"id"}`
+	var seen []string
+	for page := 0; page < 5; page++ {
+		var res PagedQueryResult
+		if err := json.Unmarshal([]byte(a.ExecuteQueryPaged("pg", "", "SELECT id FROM n", cursor, 2)), &res); err != nil || res.Error != "" {
+			t.Fatalf("page %d: %v %s", page, err, res.Error)
+		}
+		for _, r := range res.Rows {
+			seen = append(seen, fmt.Sprint(r["id"]))
+		}
+		if res.NextCursor == nil {
+			break
+		}
+		b, _ := json.Marshal(res.NextCursor)
+		cursor = string(b)
+	}
+	if strings.Join(seen, ",") != "1,2,3,4,5" {
+		t.Errorf("pages returned %v", seen)
+	}
+
+	// keys beyond 2^53 must come back exactly, and keys that cannot are refused
+	g.Exec("CREATE TABLE big (id INTEGER PRIMARY KEY, r REAL, b BLOB)")
+	g.Exec("INSERT INTO big VALUES (9007199254740993, 0.5, X'01'), (9007199254740994, 1.5, X'02'), (9007199254740995, 2.5, X'03')")
+	var res PagedQueryResult
+	json.Unmarshal([]byte(a.ExecuteQueryPaged("pg", "", "SELECT id FROM big", `{"column":"id"}`, 1)), &res)
+	if res.NextCursor == nil || res.NextCursor.After != "9007199254740993" || res.NextCursor.AfterKind != "int" {
+		t.Fatalf("first page: %+v", res)
+	}
+	b, _ := json.Marshal(res.NextCursor)
+	res = PagedQueryResult{}
+	json.Unmarshal([]byte(a.ExecuteQueryPaged("pg", "", "SELECT id FROM big", string(b), 1)), &res)
+	if len(res.Rows) != 1 || res.NextCursor == nil || res.NextCursor.After != "9007199254740994" {
+		t.Errorf("second page: %+v", res)
+	}
+	for _, col := range []string{"r", "b"} {
+		res = PagedQueryResult{}
+		json.Unmarshal([]byte(a.ExecuteQueryPaged("pg", "", "SELECT r, b FROM big", `{"column":"`+col+`"}`, 1)), &res)
+		if !strings.Contains(res.Error, "must hold integer, text or date values") {
+			t.Errorf("paging on %s: %+v", col, res)
+		}
+	}
+	for _, cursor := range []string{`{"column":"id","after":1.5}`, `{"column":"id","after":{"__blob__":"AQ=="}}`, `{"column":"id","after":"x","afterKind":"int"}`} {
+		res = PagedQueryResult{}
+		json.Unmarshal([]byte(a.ExecuteQueryPaged("pg", "", "SELECT id FROM big", cursor, 1)), &res)
+		if !strings.HasPrefix(res.Error, "invalid cursor") {
+			t.Errorf("%s: %+v", cursor, res)
+		}
+	}
+}
+
+func TestExecuteQueryCursor(t *testing.T) {
+	withTestConnection(t, Connection{ID: "cur", Type: "sqlite", Database: filepath.Join(t.TempDir(), "cur.db")})
+	t.Cleanup(func() { closeCursors("cur", "*") })
+	g, err := getOrOpenDB("cur", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Exec("CREATE TABLE n (id INTEGER PRIMARY KEY)")
+	g.Exec("WITH RECURSIVE s(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM s WHERE i < 25) INSERT INTO n SELECT i FROM s")
+
+	a := &App{}
+	var res CursorResult
+	json.Unmarshal([]byte(a.ExecuteQueryCursor("cur", "tab", "SELECT id FROM n ORDER BY id", 10)), &res)
+	if res.Error != "" || res.RowCount != 10 || !res.HasMore || res.Handle == "" {
+		t.Fatalf("first page: %+v", res)
+	}
+	handle, total := res.Handle, res.RowCount
+	for res.HasMore {
+		res = CursorResult{}
+		json.Unmarshal([]byte(a.FetchMore(handle, 10)), &res)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+		total += res.RowCount
+	}
+	if total != 25 || res.RowCount != 5 || res.Handle != "" || fmt.Sprint(res.Rows[4]["id"]) != "25" {
+		t.Errorf("read %d rows, last page %+v", total, res)
+	}
+	res = CursorResult{}
+	if json.Unmarshal([]byte(a.FetchMore(handle, 10)), &res); res.Error == "" {
+		t.Error("exhausted cursor still fetchable")
+	}
+
+	// a new query replaces the session's cursor; idle cursors are reaped
+	json.Unmarshal([]byte(a.ExecuteQueryCursor("cur", "tab", "SELECT id FROM n", 10)), &res)
+	first := res.Handle
+	json.Unmarshal([]byte(a.ExecuteQueryCursor("cur", "tab", "SELECT id FROM n", 10)), &res)
+	cursorMu.Lock()
+	_, stale := openCursors[first]
+	cursorMu.Unlock()
+	if stale || res.Handle == "" || res.Handle == first {
+		t.Errorf("previous cursor kept (%v) or no new handle %q", stale, res.Handle)
+	}
+	if n := reapIdleCursors(time.Now().Add(cursorIdleTimeout+time.Second), cursorIdleTimeout); n != 1 {
+		t.Errorf("reaped %d cursors, want 1", n)
+	}
+
+	if res = (CursorResult{}); json.Unmarshal([]byte(a.ExecuteQueryCursor("cur", "", "DELETE FROM n", 10)), &res) == nil && res.Error == "" {
+		t.Error("DELETE accepted")
+	}
+}
+
+func TestExecuteParamQuery(t *testing.T) {
+	withTestConnection(t, Connection{ID: "prm", Type: "sqlite", Database: filepath.Join(t.TempDir(), "prm.db")})
+	a := &App{}
+	a.ExecuteQuery("prm", "", "CREATE TABLE u (id INTEGER PRIMARY KEY, name TEXT)")
+
+	var res QueryResult
+	json.Unmarshal([]byte(a.ExecuteParamQuery("prm", "", "INSERT INTO u (id, name) VALUES (?, ?), (?, ?)", `[1, "O'Brien", 2, "x'; DROP TABLE u; --"]`)), &res)
+	if res.Error != "" || res.AffectedRows != 2 {
+		t.Fatalf("insert: %+v", res)
+	}
+	res = QueryResult{}
+	json.Unmarshal([]byte(a.ExecuteParamQuery("prm", "", "SELECT name FROM u WHERE id = :id OR name = :name ORDER BY id", `{"id": 1, "name": "x'; DROP TABLE u; --"}`)), &res)
+	if res.Error != "" || res.RowCount != 2 || res.Rows[0]["name"] != "O'Brien" {
+		t.Errorf("select: %+v", res)
+	}
+	res = QueryResult{}
+	if json.Unmarshal([]byte(a.ExecuteParamQuery("prm", "", "SELECT * FROM u WHERE id = :id", `{}`)), &res); res.Error == "" {
+		t.Error("missing parameter accepted")
+	}
+}
+
+func TestExecuteQueryDuplicateColumns(t *testing.T) {
+	withTestConnection(t, Connection{ID: "dup", Type: "sqlite", Database: filepath.Join(t.TempDir(), "dup.db")})
+	a := &App{}
+	for _, q := range []string{
+		"CREATE TABLE a (id INTEGER PRIMARY KEY, b_id INTEGER)",
+		"CREATE TABLE b (id INTEGER PRIMARY KEY)",
+		"INSERT INTO a VALUES (1, 7)",
+		"INSERT INTO b VALUES (7)",
+	} {
+		a.ExecuteQuery("dup", "", q)
+	}
+	q := "SELECT a.id, b.id FROM a JOIN b ON a.b_id = b.id"
+	var res QueryResult
+	json.Unmarshal([]byte(a.ExecuteQuery("dup", "", q)), &res)
+	if res.Error != "" || len(res.Columns) != 2 || res.Columns[1] != "id_2" {
+		t.Fatalf("columns: %+v", res)
+	}
+	if res.Rows[0]["id"] != float64(1) || res.Rows[0]["id_2"] != float64(7) {
+		t.Errorf("row = %v", res.Rows[0])
+	}
+	var page CursorResult
+	json.Unmarshal([]byte(a.ExecuteQueryCursor("dup", "", q, 10)), &page)
+	if len(page.Rows) != 1 || page.Rows[0]["id_2"] != float64(7) {
+		t.Errorf("cursor page = %+v", page)
+	}
+}
+
+func TestAttachDatabase(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.db")
+	withTestConnection(t,
+		Connection{ID: "att", Type: "sqlite", Database: filepath.Join(dir, "main.db")},
+		Connection{ID: "oth", Type: "sqlite", Database: other},
+	)
+	t.Cleanup(func() { clearSessionDatabases("att") })
+	a := &App{}
+	a.ExecuteQuery("oth", "", "CREATE TABLE items (id INTEGER PRIMARY KEY)")
+	a.ExecuteQuery("oth", "", "INSERT INTO items VALUES (1), (2)")
+	a.ExecuteQuery("att", "s1", "CREATE TABLE local (id INTEGER)")
+
+	for _, alias := range []string{"main", "bad-name", ""} {
+		if a.AttachDatabase("att", "s1", other, alias) == nil {
+			t.Errorf("alias %q accepted", alias)
+		}
+	}
+	if a.AttachDatabase("att", "s1", filepath.Join(dir, "missing.db"), "x") == nil {
+		t.Error("missing file accepted")
+	}
+	if err := a.AttachDatabase("att", "s1", other, "ext"); err != nil {
+		t.Fatalf("AttachDatabase: %v", err)
+	}
+	if a.AttachDatabase("att", "s1", other, "EXT") == nil {
+		t.Error("duplicate alias accepted")
+	}
+	// every pooled connection of the session sees the attachment
+	g, _ := getOrOpenDB("att", "s1")
+	sqlDB, _ := g.DB()
+	c1, _ := sqlDB.Conn(context.Background())
+	c2, _ := sqlDB.Conn(context.Background())
+	for _, c := range []*sql.Conn{c1, c2} {
+		var n int
+		if err := c.QueryRowContext(context.Background(), "SELECT count(*) FROM ext.items").Scan(&n); err != nil || n != 2 {
+			t.Errorf("count = %d, %v", n, err)
+		}
+		c.Close()
+	}
+
+	var names []string
+	json.Unmarshal([]byte(a.GetDatabases("att", "s1")), &names)
+	if strings.Join(names, ",") != "main,ext" {
+		t.Errorf("GetDatabases = %v", names)
+	}
+	var tables []Table
+	json.Unmarshal([]byte(a.GetTables("att", "ext", "s1")), &tables)
+	if len(tables) != 1 || tables[0].Name != "items" {
+		t.Errorf("GetTables(ext) = %+v", tables)
+	}
+	json.Unmarshal([]byte(a.GetTables("att", "main", "s1")), &tables)
+	if len(tables) != 1 || tables[0].Name != "local" {
+		t.Errorf("GetTables(main) = %+v", tables)
+	}
+	// other sessions are not affected
+	if got := a.ListAttachedDatabases("att", "s2"); got != "[]" {
+		t.Errorf("s2 attachments = %s", got)
+	}
+	var list []db.Attachment
+	json.Unmarshal([]byte(a.ListAttachedDatabases("att", "s1")), &list)
+	if len(list) != 1 || list[0].Alias != "ext" || list[0].Path != other {
+		t.Errorf("ListAttachedDatabases = %+v", list)
+	}
+
+	if err := a.DetachDatabase("att", "s1", "ext"); err != nil {
+		t.Fatalf("DetachDatabase: %v", err)
+	}
+	var res QueryResult
+	json.Unmarshal([]byte(a.ExecuteQuery("att", "s1", "SELECT count(*) FROM ext.items")), &res)
+	if res.Error == "" {
+		t.Error("detached database still reachable")
+	}
+	if a.DetachDatabase("att", "s1", "ext") == nil {
+		t.Error("detaching twice succeeded")
+	}
+}
+
+func TestResetConnectionPool(t *testing.T) {
+	withTestConnection(t, Connection{ID: "rst", Type: "sqlite", Database: filepath.Join(t.TempDir(), "rst.db")})
+	t.Cleanup(func() { clearActiveTxForConnection("rst") })
+	a := &App{}
+	a.ExecuteQuery("rst", "s1", "CREATE TABLE t (id INTEGER)")
+	before, _ := db.Get("rst", "s1")
+	if err := a.ResetConnectionPool("rst", "s1"); err != nil {
+		t.Fatalf("ResetConnectionPool: %v", err)
+	}
+	if after, _ := db.Get("rst", "s1"); after == before {
+		t.Error("pool not replaced")
+	}
+	var res QueryResult
+	json.Unmarshal([]byte(a.ExecuteQuery("rst", "s1", "INSERT INTO t VALUES (1)")), &res)
+	if res.Error != "" {
+		t.Errorf("query after reset: %s", res.Error)
+	}
+	if err := a.BeginTx("rst", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if a.ResetConnectionPool("rst", "s1") == nil {
+		t.Error("reset allowed with a transaction open")
+	}
+	if a.SetPoolTimeouts(-1, 0) == nil {
+		t.Error("negative pool timeout accepted")
+	}
+}
+
+func TestGetDeleteImpact(t *testing.T) {
+	withTestConnection(t, Connection{ID: "fk", Type: "sqlite", Database: filepath.Join(t.TempDir(), "fk.db")})
+	a := &App{}
+	for _, q := range []string{
+		"CREATE TABLE parent (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE child (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id) ON DELETE CASCADE)",
+		"CREATE TABLE grandchild (id INTEGER PRIMARY KEY, child_id INTEGER REFERENCES child ON DELETE CASCADE)",
+		"CREATE TABLE note (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id) ON DELETE SET NULL)",
+		"INSERT INTO parent VALUES (1), (2)",
+		"INSERT INTO child VALUES (10, 1), (11, 1), (12, 2)",
+		"INSERT INTO grandchild VALUES (100, 10), (101, 11), (102, 12)",
+		"INSERT INTO note VALUES (1, 2)",
+	} {
+		a.ExecuteQuery("fk", "", q)
+	}
+	var imp DeleteImpact
+	json.Unmarshal([]byte(a.GetDeleteImpact("fk", "", "parent", `[{"id":1}]`, "")), &imp)
+	if imp.Error != "" || imp.Blocked || len(imp.Tables) != 2 {
+		t.Fatalf("impact = %+v", imp)
+	}
+	if c := imp.Tables[0]; c.Table != "child" || c.Action != "CASCADE" || c.Rows != 2 || c.Depth != 1 {
+		t.Errorf("child = %+v", c)
+	}
+	if g := imp.Tables[1]; g.Table != "grandchild" || g.ReferencedTable != "child" || g.Rows != 2 || g.Depth != 2 {
+		t.Errorf("grandchild = %+v", g)
+	}
+
+	a.ExecuteQuery("fk", "", "CREATE TABLE lock (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parent(id) ON DELETE RESTRICT)")
+	a.ExecuteQuery("fk", "", "INSERT INTO lock VALUES (1, 2)")
+	imp = DeleteImpact{}
+	json.Unmarshal([]byte(a.GetDeleteImpact("fk", "", "parent", `[{"id":2}]`, "")), &imp)
+	if imp.Error != "" || !imp.Blocked || len(imp.Tables) != 4 {
+		t.Fatalf("impact = %+v", imp)
+	}
+}
+
+func TestGetTableSize(t *testing.T) {
+	withTestConnection(t, Connection{ID: "size", Type: "sqlite", Database: filepath.Join(t.TempDir(), "size.db")})
+	a := &App{}
+	a.ExecuteQuery("size", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	out, err := a.GetTableSize("size", "", "t", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size db.TableSize
+	json.Unmarshal([]byte(out), &size)
+	// without the dbstat table SQLite cannot measure a table
+	if size.Available != (size.TotalBytes > 0) || size.TotalBytes != size.DataBytes+size.IndexBytes {
+		t.Errorf("size = %+v", size)
+	}
+	if _, err := a.GetTableSize("size", "", "missing", ""); err == nil {
+		t.Error("missing table accepted")
+	}
+}
+
+func TestGetColumnAllowedValues(t *testing.T) {
+	withTestConnection(t, Connection{ID: "enum", Type: "sqlite", Database: filepath.Join(t.TempDir(), "enum.db")})
+	a := &App{}
+	a.ExecuteQuery("enum", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, status TEXT CHECK (status IN ('a', 'b')))")
+	out, err := a.GetColumnAllowedValues("enum", "", "t", "status", "")
+	if err != nil || out != "[]" {
+		t.Errorf("GetColumnAllowedValues = %q, %v", out, err)
+	}
+	if _, err := a.GetColumnAllowedValues("enum", "", "t", "missing", ""); err == nil {
+		t.Error("missing column accepted")
+	}
+}
+
+func TestRunMaintenance(t *testing.T) {
+	withTestConnection(t, Connection{ID: "maint", Type: "sqlite", Database: filepath.Join(t.TempDir(), "maint.db")})
+	a := &App{}
+	a.ExecuteQuery("maint", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)")
+	a.ExecuteQuery("maint", "", "CREATE INDEX t_v ON t (v)")
+	a.ExecuteQuery("maint", "", "INSERT INTO t (v) VALUES ('a')")
+	for op, want := range map[string]string{"analyze": `ANALYZE "t"`, " VACUUM ": "VACUUM"} {
+		out, err := a.RunMaintenance("maint", "", "t", op, "")
+		if err != nil {
+			t.Fatalf("%s: %v", op, err)
+		}
+		var res MaintenanceResult
+		json.Unmarshal([]byte(out), &res)
+		if res.SQL != want {
+			t.Errorf("%s ran %q", op, res.SQL)
+		}
+	}
+	if got := a.ExecuteQuery("maint", "", "SELECT COUNT(*) AS n FROM sqlite_stat1"); !strings.Contains(got, `"n":1`) {
+		t.Errorf("no statistics after ANALYZE: %s", got)
+	}
+	for _, op := range []string{"optimize", "reindex"} {
+		if _, err := a.RunMaintenance("maint", "", "t", op, ""); err == nil {
+			t.Errorf("%s accepted", op)
+		}
+	}
+	if err := a.BeginTx("maint", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer a.RollbackTx("maint", "")
+	if _, err := a.RunMaintenance("maint", "", "t", "vacuum", ""); err == nil {
+		t.Error("VACUUM accepted inside a transaction")
+	}
+}
+
+func TestUpdateRowsByKeys(t *testing.T) {
+	withTestConnection(t, Connection{ID: "bulk", Type: "sqlite", Database: filepath.Join(t.TempDir(), "bulk.db")})
+	a := &App{}
+	a.ExecuteQuery("bulk", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, status TEXT, n INTEGER, twice INTEGER GENERATED ALWAYS AS (n * 2))")
+	var values []string
+	for i := 1; i <= updateKeysBatch+10; i++ {
+		values = append(values, fmt.Sprintf("(%d, 'new', %d)", i, i))
+	}
+	a.ExecuteQuery("bulk", "", "INSERT INTO t (id, status, n) VALUES "+strings.Join(values, ", "))
+
+	keys := []int{1, 2, updateKeysBatch + 5, 999999}
+	keysJSON, _ := json.Marshal(keys)
+	n, err := a.UpdateRowsByKeys("bulk", "", "t", "id", string(keysJSON), `{"status":"archived","n":0}`, "")
+	if err != nil || n != 3 {
+		t.Fatalf("UpdateRowsByKeys = %d, %v", n, err)
+	}
+	if got := a.ExecuteQuery("bulk", "", "SELECT COUNT(*) AS c FROM t WHERE status = 'archived' AND n = 0"); !strings.Contains(got, `"c":3`) {
+		t.Errorf("updated rows: %s", got)
+	}
+	// keys beyond one batch run as several statements in the same transaction
+	var all []int
+	for i := 1; i <= updateKeysBatch+10; i++ {
+		all = append(all, i)
+	}
+	keysJSON, _ = json.Marshal(all)
+	if n, err := a.UpdateRowsByKeys("bulk", "", "t", "id", string(keysJSON), `{"status":"done"}`, ""); err != nil || n != int64(len(all)) {
+		t.Errorf("batched update = %d, %v", n, err)
+	}
+	for _, c := range []struct{ key, set string }{
+		{"id", `{"missing":1}`},
+		{"id", `{"twice":1}`},
+		{"missing", `{"status":"x"}`},
+		{"id", `{}`},
+	} {
+		if _, err := a.UpdateRowsByKeys("bulk", "", "t", c.key, "[1]", c.set, ""); err == nil {
+			t.Errorf("key %s set %s accepted", c.key, c.set)
+		}
+	}
+	if _, err := a.UpdateRowsByKeys("bulk", "", "t", "id", "[1, null]", `{"status":"x"}`, ""); err == nil {
+		t.Error("null key accepted")
+	}
+}
+
+func TestMutationStatements(t *testing.T) {
+	got := mutationStatements("sqlite", []plannedStatement{
+		{sql: "UPDATE t SET name = ? WHERE id = ?", args: []interface{}{"O'Brien", int64(3)}},
+		{sql: "INSERT INTO t (id) VALUES (" + strings.Repeat("1", mutationSQLMax) + ")"},
+	})
+	if len(got) != 2 || got[0] != "UPDATE t SET name = 'O''Brien' WHERE id = 3" {
+		t.Fatalf("statements = %q", got)
+	}
+	if n := len([]rune(got[1])); n != mutationSQLMax+1 || !strings.HasSuffix(got[1], "…") {
+		t.Errorf("long statement kept %d runes", n)
+	}
+
+	settingsMu.Lock()
+	savedPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath = filepath.Join(t.TempDir(), "settings.json")
+	appSettings, settingsLoaded = AppSettings{}, false
+	settingsMu.Unlock()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+	})
+	a := &App{}
+	if err := a.SetMutationSQLEvents(true); err != nil || !getSettings().MutationSQLEvents {
+		t.Errorf("SetMutationSQLEvents: %v", err)
+	}
+}
+
+func TestSlowQueryHistory(t *testing.T) {
+	settingsMu.Lock()
+	savedPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath = filepath.Join(t.TempDir(), "settings.json")
+	appSettings, settingsLoaded = AppSettings{}, false
+	settingsMu.Unlock()
+	getHistoryFilePath()
+	historyMu.Lock()
+	savedHistoryPath, savedHistory := historyFilePath, queryHistory
+	historyFilePath, queryHistory = filepath.Join(t.TempDir(), historyFileName), []QueryHistory{
+		{ID: "old", ConnectionID: "c1", SQL: "SELECT old", Duration: 4000}, // recorded before the slow flag existed
+	}
+	historyMu.Unlock()
+	slowQueryMu.Lock()
+	savedThreshold := slowQueryThreshold
+	slowQueryMu.Unlock()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+		historyMu.Lock()
+		historyFilePath, queryHistory = savedHistoryPath, savedHistory
+		historyMu.Unlock()
+		slowQueryMu.Lock()
+		slowQueryThreshold = savedThreshold
+		slowQueryMu.Unlock()
+	})
+
+	a := &App{}
+	if err := a.SetSlowQueryThreshold(500); err != nil {
+		t.Fatal(err)
+	}
+	if a.SetSlowQueryThreshold(-1) == nil {
+		t.Error("negative threshold accepted")
+	}
+	saveQueryHistory("c1", "SELECT fast", true, 500, 1)
+	saveQueryHistory("c1", "SELECT slow", true, 501, 1)
+	saveQueryHistory("c2", "SELECT other", true, 2000, 1)
+
+	ids := func(js string) string {
+		var list []QueryHistory
+		if err := json.Unmarshal([]byte(js), &list); err != nil {
+			t.Fatal(err)
+		}
+		var sqls []string
+		for _, h := range list {
+			sqls = append(sqls, h.SQL)
+		}
+		return strings.Join(sqls, ",")
+	}
+	if got := ids(a.GetSlowQueries("c1", 0)); got != "SELECT slow" {
+		t.Errorf("flagged slow = %q", got)
+	}
+	if got := ids(a.GetSlowQueries("", 1000)); got != "SELECT other,SELECT old" {
+		t.Errorf("over 1000ms = %q", got)
+	}
+	if got := a.GetSlowQueries("none", 0); got != "[]" {
+		t.Errorf("no matches = %s", got)
+	}
+	if settings := getSettings(); settings.SlowQueryThresholdMs != 500 {
+		t.Errorf("persisted threshold = %d", settings.SlowQueryThresholdMs)
+	}
+}
+
+func TestGetColumnStats(t *testing.T) {
+	withTestConnection(t, Connection{ID: "stats", Type: "sqlite", Database: filepath.Join(t.TempDir(), "stats.db")})
+	g, err := getOrOpenDB("stats", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Exec("CREATE TABLE p (id INTEGER PRIMARY KEY, price REAL, name TEXT, img BLOB)")
+	g.Exec("INSERT INTO p (price, name, img) VALUES (1, 'b', x'00'), (2, 'a', NULL), (NULL, 'b', NULL), (3, NULL, NULL)")
+
+	a := &App{}
+	var st ColumnStats
+	json.Unmarshal([]byte(a.GetColumnStats("stats", "", "p", "price", "")), &st)
+	if st.Error != "" || st.Rows != 4 || st.Nulls != 1 || st.Distinct != 3 || fmt.Sprint(st.Min, st.Max, st.Avg) != "1 3 2" {
+		t.Errorf("price: %+v", st)
+	}
+	st = ColumnStats{}
+	json.Unmarshal([]byte(a.GetColumnStats("stats", "", "p", "name", "")), &st)
+	if st.Nulls != 1 || st.Distinct != 2 || st.Min != "a" || st.Max != "b" || st.Avg != nil {
+		t.Errorf("name: %+v", st)
+	}
+	st = ColumnStats{}
+	json.Unmarshal([]byte(a.GetColumnStats("stats", "", "p", "img", "")), &st)
+	if st.Nulls != 3 || st.Min != nil || st.Max != nil {
+		t.Errorf("img: %+v", st)
+	}
+	st = ColumnStats{}
+	if json.Unmarshal([]byte(a.GetColumnStats("stats", "", "p", "nope", "")), &st); st.Error == "" {
+		t.Error("missing column accepted")
+	}
+
+	var dv struct {
+		Values []ValueCount `json:"values"`
+		Error  string       `json:"error"`
+	}
+	json.Unmarshal([]byte(a.GetDistinctValues("stats", "", "p", "name", 2, "")), &dv)
+	if dv.Error != "" || fmt.Sprint(dv.Values) != "[{b 2} {<nil> 1}]" {
+		t.Errorf("distinct names: %+v", dv)
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	before := `{"columns":["id","name","qty"],"rows":[
+		{"id":1,"name":"a","qty":5},{"id":2,"name":"b","qty":1},{"id":3,"name":"c","qty":9007199254740993}]}`
+	after := `{"columns":["id","qty","note"],"rows":[
+		{"id":3,"qty":9007199254740993,"note":"x"},{"id":1,"qty":6,"note":null},{"id":4,"qty":0,"note":null}]}`
+	var d ResultDiff
+	if err := json.Unmarshal([]byte((&App{}).DiffResults(before, after, `["id"]`)), &d); err != nil || d.Error != "" {
+		t.Fatalf("%v %s", err, d.Error)
+	}
+	if fmt.Sprint(d.Columns, d.OnlyInA, d.OnlyInB) != "[id qty] [name] [note]" {
+		t.Errorf("columns: %v %v %v", d.Columns, d.OnlyInA, d.OnlyInB)
+	}
+	if len(d.Changed) != 1 || fmt.Sprint(d.Changed[0].Key["id"], d.Changed[0].Cells["qty"]) != "1 {5 6}" || len(d.Changed[0].Cells) != 1 {
+		t.Errorf("changed: %+v", d.Changed)
+	}
+	if len(d.Removed) != 1 || fmt.Sprint(d.Removed[0]["id"]) != "2" || len(d.Added) != 1 || fmt.Sprint(d.Added[0]["id"]) != "4" || d.Unchanged != 1 {
+		t.Errorf("removed %v added %v unchanged %d", d.Removed, d.Added, d.Unchanged)
+	}
+
+	d = ResultDiff{}
+	json.Unmarshal([]byte((&App{}).DiffResults(before, after, "")), &d)
+	if len(d.Changed) != 0 || len(d.Removed) != 2 || len(d.Added) != 2 || d.Unchanged != 1 {
+		t.Errorf("without keys: %+v", d)
+	}
+	d = ResultDiff{}
+	if json.Unmarshal([]byte((&App{}).DiffResults(before, after, `["name"]`)), &d); d.Error == "" {
+		t.Error("key column missing from B accepted")
+	}
+}
+
+func TestTableView(t *testing.T) {
+	getTableViewsFilePath()
+	tableViewsMu.Lock()
+	savedPath, savedViews := tableViewsFilePath, tableViews
+	tableViewsFilePath, tableViews = filepath.Join(t.TempDir(), viewsFileName), nil
+	tableViewsMu.Unlock()
+	t.Cleanup(func() {
+		tableViewsMu.Lock()
+		tableViewsFilePath, tableViews = savedPath, savedViews
+		tableViewsMu.Unlock()
+	})
+
+	a := &App{}
+	if got := a.GetTableView("c1", "users"); got != "{}" {
+		t.Errorf("unsaved view = %s", got)
+	}
+	view := `{"columnWidths":{"id":60,"name":220},"visibleColumns":["name","id"],"sortColumn":"name","sortDesc":true,"pageSize":200}`
+	if err := a.SaveTableView("c1", "users", view); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SaveTableView("c1", "users", `{"pageSize":-1}`); err == nil {
+		t.Error("negative page size accepted")
+	}
+	// reload from disk
+	tableViewsMu.Lock()
+	tableViews = nil
+	tableViewsMu.Unlock()
+	if got := a.GetTableView("c1", "users"); got != view {
+		t.Errorf("reloaded view = %s", got)
+	}
+	if got := a.GetTableView("c2", "users"); got != "{}" {
+		t.Errorf("view leaked to another connection: %s", got)
+	}
+	if err := a.SaveTableView("c1", "users", ""); err != nil || a.GetTableView("c1", "users") != "{}" {
+		t.Errorf("clearing view: %v", err)
+	}
+}
+
+func TestEditorDrafts(t *testing.T) {
+	getDraftsFilePath()
+	draftsMu.Lock()
+	savedPath, savedDrafts := draftsFilePath, editorDrafts
+	draftsFilePath, editorDrafts = filepath.Join(t.TempDir(), draftsFileName), nil
+	draftsMu.Unlock()
+	t.Cleanup(func() {
+		draftsMu.Lock()
+		draftsFilePath, editorDrafts = savedPath, savedDrafts
+		draftsMu.Unlock()
+	})
+	stale, _ := json.Marshal([]EditorDraft{
+		{SessionID: "old", SQL: "SELECT 1", SavedAt: time.Now().Add(-draftMaxAge - time.Hour).Format(time.RFC3339)},
+		{SessionID: "tab-1", SQL: "SELECT 2", SavedAt: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	})
+	if err := os.WriteFile(draftsFilePath, stale, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &App{}
+	if err := a.SaveEditorDraft("tab-2", "SELECT * FROM t WHERE"); err != nil {
+		t.Fatal(err)
+	}
+	var list []EditorDraft
+	json.Unmarshal([]byte(a.ListEditorDrafts()), &list)
+	if len(list) != 2 || list[0].SessionID != "tab-2" || list[1].SessionID != "tab-1" {
+		t.Errorf("drafts = %+v", list)
+	}
+	var d EditorDraft
+	json.Unmarshal([]byte(a.GetEditorDraft("tab-2")), &d)
+	if d.SQL != "SELECT * FROM t WHERE" {
+		t.Errorf("draft = %+v", d)
+	}
+	if got := a.GetEditorDraft("old"); got != "{}" {
+		t.Errorf("expired draft returned: %s", got)
+	}
+	if err := a.SaveEditorDraft("tab-2", "  "); err != nil || a.GetEditorDraft("tab-2") != "{}" {
+		t.Errorf("blank content kept the draft: %v", err)
+	}
+	// what was written survives a restart
+	draftsMu.Lock()
+	editorDrafts = nil
+	draftsMu.Unlock()
+	if list = nil; json.Unmarshal([]byte(a.ListEditorDrafts()), &list) != nil || len(list) != 1 || list[0].SessionID != "tab-1" {
+		t.Errorf("reloaded drafts = %+v", list)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	if got := truncateRunes("SELECT 1", 20); got != "SELECT 1" {
+		t.Errorf("short text changed: %q", got)
+	}
+	if got := truncateRunes("SELECT '数据库'", 9); got != "SELECT '数…" {
+		t.Errorf("truncateRunes = %q", got)
+	}
+	// without a frontend the lifecycle events are skipped, not fatal
+	a := &App{}
+	a.emitQueryEvent("query-start", QueryEvent{SQL: "SELECT 1"})
+}
+
+func TestCancelBackup(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("uses a shell script as the dump tool")
+	}
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "sqlite3")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\necho partial\nsleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	savedTools := backup.CurrentToolPaths()
+	backup.SetToolPaths(backup.ToolPaths{SQLite3: tool})
+	withTestConnection(t, Connection{ID: "bk", Type: "sqlite", Database: filepath.Join(dir, "bk.db")})
+	t.Cleanup(func() { backup.SetToolPaths(savedTools) })
+
+	a := &App{}
+	if a.CancelBackup("bk") {
+		t.Error("cancelled a backup that was not running")
+	}
+	out := filepath.Join(dir, "bk.sql")
+	done := make(chan error, 1)
+	go func() { done <- backupToPath("bk", out, "") }()
+	for i := 0; !a.CancelBackup("bk"); i++ {
+		if i > 200 {
+			t.Fatal("backup never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		if err != errBackupCancelled {
+			t.Errorf("err = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("cancel did not stop the dump")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("partial backup file left behind: %v", err)
+	}
+	if a.SetBackupTimeout(maxBackupTimeoutMinutes+1) == nil {
+		t.Error("timeout above the limit accepted")
+	}
+}
+
+func TestUpdateSettingsConcurrent(t *testing.T) {
+	settingsMu.Lock()
+	savedPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath = filepath.Join(t.TempDir(), "settings.json")
+	appSettings, settingsLoaded = AppSettings{}, false
+	settingsMu.Unlock()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = updateSettings(func(s *AppSettings) { s.MaxResultRows++ })
+		}()
+		go func() {
+			defer wg.Done()
+			_ = updateSettings(func(s *AppSettings) { s.MaxHistorySize++ })
+		}()
+	}
+	wg.Wait()
+	if s := getSettings(); s.MaxResultRows != 20 || s.MaxHistorySize != 20 {
+		t.Errorf("lost updates: maxResultRows=%d maxHistorySize=%d", s.MaxResultRows, s.MaxHistorySize)
+	}
+}
+
+func TestAnalyzeSQLJoins(t *testing.T) {
+	for _, sql := range []string{
+		"SELECT a.id FROM a, b WHERE a.id = b.a_id",
+		"SELECT a.id FROM a LEFT OUTER JOIN b ON a.id = b.a_id, c WHERE c.b_id = b.id",
+		"SELECT a.id FROM a NATURAL JOIN b",
+		"SELECT a.id FROM a JOIN b WHERE a.id = b.a_id",
+		"SELECT a.id FROM a JOIN (SELECT x, y FROM c, d) s USING (x)",
+		"SELECT a.id FROM a WHERE a.name = 'x, y'",
+	} {
+		if got := analyzeCartesianProduct(strings.ToLower(sql)); got != "" {
+			t.Errorf("analyzeCartesianProduct(%q) = %q, want none", sql, got)
+		}
+	}
+}
+
+func TestAnalyzeSQL(t *testing.T) {
+	tests := []struct {
+		sql, lang string
+		field     string // "warnings" or "suggestions"
+		want      string // substring of one message; "" = field must be empty
+	}{
+		{"SELECT * FROM t WHERE id = 1", "en", "warnings", "SELECT *"},
+		{"SELECT id FROM t", "en", "warnings", "no WHERE condition or LIMIT"},
+		{"SELECT id FROM t WHERE a = 1 ORDER BY id", "en", "warnings", "ORDER BY without LIMIT"},
+		{"SELECT id FROM t WHERE name LIKE '%abc'", "en", "suggestions", "cannot use an index"},
+		{"SELECT id FROM t WHERE name LIKE 'abc%'", "en", "suggestions", ""},
+		{"SELECT id FROM t WHERE id NOT IN (SELECT t_id FROM u)", "en", "suggestions", "NOT EXISTS"},
+		{"SELECT id FROM t WHERE id NOT IN (1, 2)", "en", "suggestions", ""},
+		{"SELECT id FROM t WHERE LOWER(email) = 'a@b.c'", "en", "suggestions", "column email"},
+		{"SELECT id FROM t WHERE YEAR(t.created_at) >= 2024 LIMIT 5", "en", "suggestions", "column t.created_at"},
+		{"SELECT LOWER(email) FROM t WHERE id = 1", "en", "suggestions", ""},
+		{"INSERT INTO t (a, b) VALUES (1, 'x(')", "en", "suggestions", "Single-row INSERT"},
+		{"INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y')", "en", "suggestions", ""},
+		{"INSERT INTO t SELECT * FROM u WHERE id = 1", "en", "suggestions", ""},
+		{"UPDATE t SET a = 1", "en", "warnings", "will update every row"},
+		{"DELETE FROM t", "zh", "warnings", "DELETE 语句缺少 WHERE 条件"},
+		{"SELECT id FROM t", "zh-CN", "warnings", "查询没有 WHERE 条件"},
+		{"SELECT * FROM a, b", "en", "warnings", "no join condition links a, b"},
+		{"SELECT * FROM a x, b y WHERE x.id = 1", "en", "warnings", "links x, y"},
+		{"SELECT * FROM a CROSS JOIN b LIMIT 5", "en", "warnings", "links a, b"},
+		{"SELECT * FROM a JOIN b WHERE a.id = b.a_id LIMIT 5", "en", "warnings", "SELECT *"},
+		{"SELECT DISTINCT name FROM t WHERE id > 1 ORDER BY created_at LIMIT 5", "en", "warnings", "ordered by created_at"},
+		{"SELECT DISTINCT t.name AS n FROM t WHERE id > 1 ORDER BY t.name DESC, n LIMIT 5", "en", "warnings", ""},
+	}
+	for _, tt := range tests {
+		var out map[string]interface{}
+		if err := json.Unmarshal([]byte((&App{}).AnalyzeSQL(tt.sql, "mysql", tt.lang)), &out); err != nil {
+			t.Fatal(err)
+		}
+		msgs, _ := out[tt.field].([]interface{})
+		if tt.want == "" {
+			if len(msgs) != 0 {
+				t.Errorf("AnalyzeSQL(%q) %s = %v, want none", tt.sql, tt.field, msgs)
+			}
+			continue
+		}
+		found := false
+		for _, m := range msgs {
+			found = found || strings.Contains(fmt.Sprint(m), tt.want)
+		}
+		if !found {
+			t.Errorf("AnalyzeSQL(%q) %s = %v, want one containing %q", tt.sql, tt.field, msgs, tt.want)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(path, []byte("old contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("contents = %q", data)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, %v", fi.Mode(), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %d entries", len(entries))
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "x.json"), []byte("x"), 0o600); err == nil {
+		t.Error("write into a missing directory succeeded")
+	}
+}
+
+func TestCorruptStoreIsQuarantined(t *testing.T) {
+	useTempConnectionsFile(t)
+	a := &App{}
+	a.GetStoreWarnings()
+	if err := os.WriteFile(connFilePath, []byte(`[{"id":"c1","name":`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if conns, existed := loadConnectionsFromFile(); conns != nil || existed {
+		t.Errorf("loaded corrupt file: %v, %v", conns, existed)
+	}
+	if data, err := os.ReadFile(connFilePath + ".bak"); err != nil || string(data) != `[{"id":"c1","name":` {
+		t.Errorf("backup = %q, %v", data, err)
+	}
+	if _, err := os.Stat(connFilePath); !os.IsNotExist(err) {
+		t.Errorf("corrupt file left in place: %v", err)
+	}
+
+	historyMu.Lock()
+	savedPath, savedHistory := historyFilePath, queryHistory
+	historyFilePath = filepath.Join(t.TempDir(), "history.json")
+	os.WriteFile(historyFilePath, []byte("{not json"), 0o600)
+	loadQueryHistory()
+	n := len(queryHistory)
+	historyFilePath, queryHistory = savedPath, savedHistory
+	historyMu.Unlock()
+	if n != 0 {
+		t.Errorf("history entries = %d", n)
+	}
+
+	warnings := a.GetStoreWarnings()
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "connections.json.bak") || !strings.Contains(warnings[1], "history.json") {
+		t.Errorf("warnings = %q", warnings)
+	}
+	if again := a.GetStoreWarnings(); len(again) != 0 {
+		t.Errorf("warnings repeated: %q", again)
+	}
+}
+
+func TestDeleteTableRowsCompositeAndRowidKeys(t *testing.T) {
+	withTestConnection(t, Connection{ID: "pkdel", Type: "sqlite", Database: filepath.Join(t.TempDir(), "pkdel.db")})
+	a := &App{}
+	a.ExecuteQuery("pkdel", "", "CREATE TABLE m (a TEXT, b INTEGER, c TEXT, PRIMARY KEY (c, a)) WITHOUT ROWID")
+	a.ExecuteQuery("pkdel", "", "INSERT INTO m VALUES ('x', 1, 'k'), ('y', 1, 'k'), ('x', 2, 'l')")
+	a.ExecuteQuery("pkdel", "", "CREATE TABLE dup (v TEXT)")
+	a.ExecuteQuery("pkdel", "", "INSERT INTO dup VALUES ('same'), ('same'), ('other')")
+	count := func(table string) int64 {
+		g, _ := getOrOpenDB("pkdel", "")
+		var n int64
+		g.Raw("SELECT COUNT(*) FROM " + table).Scan(&n)
+		return n
+	}
+
+	// b is not part of the key, so a stale value must not prevent the delete
+	out, err := a.DeleteTableRows("pkdel", "", "m", `[{"a":"x","b":99,"c":"k"}]`, "", true)
+	if err != nil || !strings.Contains(out, `WHERE \"c\" = 'k' AND \"a\" = 'x'`) {
+		t.Fatalf("dry run = %s, %v", out, err)
+	}
+	if _, err := a.DeleteTableRows("pkdel", "", "m", `[{"a":"x","b":99,"c":"k"}]`, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("m"); n != 2 {
+		t.Errorf("m rows = %d, want 2", n)
+	}
+
+	out, err = a.DeleteTableRows("pkdel", "", "dup", `[{"v":"same"}]`, "", true)
+	if err != nil || !strings.Contains(out, `"estimatedRows":1`) {
+		t.Fatalf("dry run = %s, %v", out, err)
+	}
+	if _, err := a.DeleteTableRows("pkdel", "", "dup", `[{"v":"same"}]`, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if n := count("dup"); n != 2 {
+		t.Errorf("dup rows = %d, want 2 (one duplicate kept)", n)
+	}
+}
+
+func TestHistoryDisabledAndIncognito(t *testing.T) {
+	settingsMu.Lock()
+	savedPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath = filepath.Join(t.TempDir(), "settings.json")
+	appSettings, settingsLoaded = AppSettings{}, false
+	settingsMu.Unlock()
+	getHistoryFilePath()
+	historyMu.Lock()
+	savedHistoryPath, savedHistory, savedEnabled := historyFilePath, queryHistory, historyEnabled
+	historyFilePath, queryHistory = filepath.Join(t.TempDir(), historyFileName), []QueryHistory{}
+	historyMu.Unlock()
+	withTestConnection(t, Connection{ID: "prod", Type: "mysql", Incognito: true}, Connection{ID: "dev", Type: "mysql"})
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+		historyMu.Lock()
+		historyFilePath, queryHistory, historyEnabled = savedHistoryPath, savedHistory, savedEnabled
+		historyMu.Unlock()
+	})
+	count := func() int {
+		historyMu.RLock()
+		defer historyMu.RUnlock()
+		return len(queryHistory)
+	}
+
+	a := &App{}
+	saveQueryHistory("prod", "SELECT secret", true, 1, 1)
+	saveQueryHistory("dev", "SELECT 1", true, 1, 1)
+	if n := count(); n != 1 {
+		t.Fatalf("history = %d entries, want only the non-incognito one", n)
+	}
+	if err := a.SetHistoryEnabled(false, false); err != nil {
+		t.Fatal(err)
+	}
+	if a.GetHistoryEnabled() || !getSettings().HistoryDisabled {
+		t.Error("history still enabled")
+	}
+	saveQueryHistory("dev", "SELECT 2", true, 1, 1)
+	if n := count(); n != 1 {
+		t.Errorf("history = %d entries while disabled", n)
+	}
+	if err := a.SetHistoryEnabled(false, true); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("history = %d entries after clear", n)
+	}
+	if err := a.SetHistoryEnabled(true, false); err != nil || !a.GetHistoryEnabled() || getSettings().HistoryDisabled {
+		t.Errorf("re-enable: %v", err)
+	}
+	saveQueryHistory("dev", "SELECT 3", true, 1, 1)
+	if n := count(); n != 1 {
+		t.Errorf("history = %d entries after re-enabling", n)
+	}
+}
+
+func TestMySQLSocketConnection(t *testing.T) {
+	dir, err := os.MkdirTemp("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "mysqld.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+	tunnel := &SSHTunnel{Enabled: true, Host: "jump", Username: "u"}
+	c := &Connection{Type: "mysql", Host: "db.example.com", Port: 3306, Username: "root", Socket: sock, SSHTunnel: tunnel}
+	if usesSSHTunnel(c) {
+		t.Error("socket connection uses the SSH tunnel")
+	}
+	dsn, err := buildDSN(c, c.Host, c.Port)
+	if err != nil || !strings.HasPrefix(dsn, "root:@unix("+sock+")/mysql?") {
+		t.Errorf("dsn = %q, %v", dsn, err)
+	}
+	if got := backupHost(c); got != sock {
+		t.Errorf("backup host = %q", got)
+	}
+	c = &Connection{Type: "mysql", Host: sock, Username: "root", SSHTunnel: tunnel}
+	if usesSSHTunnel(c) || mysqlSocket(c) != sock {
+		t.Error("host path not treated as a socket")
+	}
+	c.Host, c.Socket = "db.example.com", ""
+	if !usesSSHTunnel(c) || backupHost(c) != "db.example.com" {
+		t.Error("TCP connection changed")
+	}
+}
+
+func TestGenerateInsertForRows(t *testing.T) {
+	withTestConnection(t, Connection{ID: "geninsert", Type: "sqlite", Database: filepath.Join(t.TempDir(), "geninsert.db")})
+	a := &App{}
+	a.ExecuteQuery("geninsert", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, data BLOB, big INTEGER, twice INTEGER GENERATED ALWAYS AS (id * 2))")
+	rows := `[{"id":1,"name":"it's","data":{"__blob__":"AAE="},"big":9007199254740993,"twice":2},{"name":null,"id":2}]`
+	got, err := a.GenerateInsertForRows("geninsert", "", "t", rows, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `INSERT INTO "t" ("id", "name", "data", "big") VALUES (1, 'it''s', X'0001', 9007199254740993);` + "\n" +
+		`INSERT INTO "t" ("id", "name", "data", "big") VALUES (2, NULL, NULL, NULL);` + "\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	// the statements run as generated
+	for _, stmt := range strings.SplitAfter(strings.TrimSpace(got), ";") {
+		if r := a.ExecuteQuery("geninsert", "", strings.TrimSpace(stmt)); strings.Contains(r, `"error"`) {
+			t.Errorf("%s: %s", stmt, r)
+		}
+	}
+	if _, err := a.GenerateInsertForRows("geninsert", "", "t", `[{"nope":1}]`, ""); err == nil {
+		t.Error("row without table columns accepted")
+	}
+}
+
+func TestGenerateUpdateForChanges(t *testing.T) {
+	withTestConnection(t, Connection{ID: "genupd", Type: "sqlite", Database: filepath.Join(t.TempDir(), "genupd.db")})
+	a := &App{}
+	a.ExecuteQuery("genupd", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, data BLOB)")
+	a.ExecuteQuery("genupd", "", "INSERT INTO t VALUES (2, 'it''s', x'00')")
+	updates := `[{"rowIndex":0,"column":"name","oldValue":"it's","newValue":"b","original":{"id":2,"name":"it's","data":{"__blob__":"AA=="}}},
+		{"rowIndex":0,"column":"data","oldValue":{"__blob__":"AA=="},"newValue":{"__blob__":"AQI="},"original":{"id":2,"name":"it's","data":{"__blob__":"AA=="}}}]`
+	out, err := a.GenerateUpdateForChanges("genupd", "", "t", updates, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stmts []GeneratedStatement
+	if err := json.Unmarshal([]byte(out), &stmts); err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 {
+		t.Fatalf("statements = %+v", stmts)
+	}
+	st := stmts[0]
+	if st.SQL != `UPDATE "t" SET "name" = ?, "data" = ? WHERE "data" = ? AND "id" = ? AND "name" = ?` || len(st.Args) != 5 {
+		t.Errorf("sql = %s args = %v", st.SQL, st.Args)
+	}
+	if st.Display != `UPDATE "t" SET "name" = 'b', "data" = X'0102' WHERE "data" = X'00' AND "id" = 2 AND "name" = 'it''s';` {
+		t.Errorf("display = %s", st.Display)
+	}
+	if b, ok := db.DecodeBlob(st.Args[1]); !ok || string(b) != "\x01\x02" {
+		t.Errorf("blob arg = %v", st.Args[1])
+	}
+	// nothing was changed
+	var r QueryResult
+	json.Unmarshal([]byte(a.ExecuteQuery("genupd", "", "SELECT name FROM t")), &r)
+	if len(r.Rows) != 1 || r.Rows[0]["name"] != "it's" {
+		t.Errorf("rows = %v", r.Rows)
+	}
+	if out, err := a.GenerateUpdateForChanges("genupd", "", "t", "[]", ""); err != nil || out != "[]" {
+		t.Errorf("empty = %q, %v", out, err)
+	}
+}
+
+func TestBooleanColumns(t *testing.T) {
+	withTestConnection(t, Connection{ID: "bools", Type: "sqlite", Database: filepath.Join(t.TempDir(), "bools.db")})
+	a := &App{}
+	a.ExecuteQuery("bools", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, flag BOOLEAN)")
+	if _, err := a.InsertTableRows("bools", "", "t", `[{"id":1,"flag":"true"},{"id":2,"flag":false}]`, "", true); err != nil {
+		t.Fatal(err)
+	}
+	var r QueryResult
+	json.Unmarshal([]byte(a.ExecuteQuery("bools", "", "SELECT flag, typeof(flag) AS kind FROM t ORDER BY id")), &r)
+	if len(r.Rows) != 2 || r.Rows[0]["flag"] != true || r.Rows[1]["flag"] != false || r.Rows[0]["kind"] != "integer" {
+		t.Fatalf("rows = %v", r.Rows)
+	}
+	updates := `[{"rowIndex":0,"column":"flag","oldValue":true,"newValue":"false","original":{"id":1,"flag":true}}]`
+	if _, err := a.UpdateTableData("bools", "", "t", updates, "", false); err != nil {
+		t.Fatal(err)
+	}
+	var td TableData
+	json.Unmarshal([]byte(a.GetTableData("bools", "", "t", 10, 0, "", false)), &td)
+	if len(td.Rows) != 2 || td.Rows[0]["flag"] != false {
+		t.Fatalf("table data = %v", td.Rows)
+	}
+
+	settingsMu.Lock()
+	savedPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath = filepath.Join(t.TempDir(), "settings.json")
+	appSettings, settingsLoaded = AppSettings{}, false
+	settingsMu.Unlock()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+	})
+	if err := a.SetNumericBooleans(true); err != nil {
+		t.Fatal(err)
+	}
+	defer db.SetNumericBooleans(false)
+	json.Unmarshal([]byte(a.GetTableData("bools", "", "t", 10, 0, "", false)), &td)
+	if td.Rows[0]["flag"] != float64(0) || !a.GetNumericBooleans() {
+		t.Errorf("numeric rows = %v", td.Rows)
+	}
+}
+
+func TestSetKeepAliveInterval(t *testing.T) {
+	settingsMu.Lock()
+	savedPath, savedSettings, savedLoaded := settingsFilePath, appSettings, settingsLoaded
+	settingsFilePath = filepath.Join(t.TempDir(), "settings.json")
+	appSettings, settingsLoaded = AppSettings{}, false
+	settingsMu.Unlock()
+	t.Cleanup(func() {
+		settingsMu.Lock()
+		settingsFilePath, appSettings, settingsLoaded = savedPath, savedSettings, savedLoaded
+		settingsMu.Unlock()
+		keepAliveMu.Lock()
+		keepAliveInterval = 0
+		keepAliveMu.Unlock()
+	})
+	a := &App{}
+	for _, bad := range []int{-1, 10, maxKeepAliveSeconds + 1} {
+		if err := a.SetKeepAliveInterval(bad); err == nil {
+			t.Errorf("SetKeepAliveInterval(%d) accepted", bad)
+		}
+	}
+	if err := a.SetKeepAliveInterval(120); err != nil {
+		t.Fatal(err)
+	}
+	keepAliveMu.Lock()
+	interval := keepAliveInterval
+	keepAliveMu.Unlock()
+	if interval != 2*time.Minute || a.GetKeepAliveInterval() != 120 {
+		t.Errorf("interval = %s, setting = %d", interval, a.GetKeepAliveInterval())
+	}
+	if err := a.SetKeepAliveInterval(0); err != nil || a.GetKeepAliveInterval() != 0 {
+		t.Errorf("disabling keep-alive: %v", err)
+	}
+}
+
+func TestDisconnectConnection(t *testing.T) {
+	useTempConnectionsFile(t)
+	a := &App{}
+	if err := a.CreateConnection(`{"name":"lite","type":"sqlite","database":"` + filepath.ToSlash(filepath.Join(t.TempDir(), "disc.db")) + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	id := connections[0].ID
+	defer db.CloseConnection(id)
+	if err := a.BeginTx(id, "tab"); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.ExecuteQuery(id, "tab", "CREATE TABLE t (id INTEGER)"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	if got := a.ExecuteQuery(id, "", "SELECT 1"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	if getConnByID(id).Status != "connected" {
+		t.Errorf("status after query = %q", getConnByID(id).Status)
+	}
+
+	if err := a.DisconnectConnection(id); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(a.GetTransactionStatus(id, "tab"), `"active":false`) {
+		t.Error("transaction still active")
+	}
+	for _, session := range []string{"", "tab"} {
+		if _, ok := db.Get(id, session); ok {
+			t.Errorf("pool of session %q still open", session)
+		}
+	}
+	saved, _ := loadConnectionsFromFile()
+	if len(saved) != 1 || getConnByID(id).Status != "disconnected" {
+		t.Errorf("connection = %+v, saved = %+v", getConnByID(id), saved)
+	}
+	if err := a.DisconnectConnection("missing"); err == nil {
+		t.Error("unknown connection accepted")
+	}
+}
+
+func TestGetConnectionStatus(t *testing.T) {
+	withTestConnection(t, Connection{ID: "status", Type: "sqlite", Database: filepath.Join(t.TempDir(), "status.db"), Status: "disconnected"})
+	a := &App{}
+	status := func() ConnectionStatus {
+		var st ConnectionStatus
+		if err := json.Unmarshal([]byte(a.GetConnectionStatus("status")), &st); err != nil {
+			t.Fatal(err)
+		}
+		return st
+	}
+	if st := status(); st.Status != "disconnected" {
+		t.Errorf("before opening: %+v", st)
+	}
+	if _, ok := db.Get("status", ""); ok {
+		t.Fatal("GetConnectionStatus opened a pool")
+	}
+	if got := a.ExecuteQuery("status", "tab", "SELECT 1"); strings.Contains(got, `"error"`) {
+		t.Fatal(got)
+	}
+	if st := status(); st.Status != "connected" || getConnByID("status").Status != "connected" {
+		t.Errorf("after query: %+v", st)
+	}
+	g, _ := db.Get("status", "tab")
+	sqlDB, _ := g.DB()
+	sqlDB.Close()
+	if st := status(); st.Status != "error" || st.Message == "" || getConnByID("status").Status != "error" {
+		t.Errorf("after the pool died: %+v", st)
+	}
+	if !strings.Contains(a.GetConnectionStatus("missing"), `"error"`) {
+		t.Error("unknown connection not reported")
+	}
+}
+
+func TestGenerateCreateTableSQLOptions(t *testing.T) {
+	a := &App{}
+	schema := `{"name":"t","columns":[{"name":"id","type":"INT","isPrimaryKey":true}],"indexes":[{"name":"ix","columns":["id"]}]}`
+	if got := a.GenerateCreateTableSQL(schema, "mysql", ""); !strings.HasPrefix(got, "CREATE TABLE `t` (\n  `id` INT NOT NULL PRIMARY KEY\n);\n") {
+		t.Errorf("without options:\n%s", got)
+	}
+	got := a.GenerateCreateTableSQL(schema, "mysql", `{"ifNotExists":true,"engine":"InnoDB","charset":"utf8mb4","collation":"utf8mb4_bin"}`)
+	if !strings.HasPrefix(got, "CREATE TABLE IF NOT EXISTS `t` (") ||
+		!strings.Contains(got, "\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;\n") ||
+		!strings.Contains(got, "CREATE INDEX `ix` ON") {
+		t.Errorf("mysql:\n%s", got)
+	}
+	got = a.GenerateCreateTableSQL(schema, "postgresql", `{"ifNotExists":true,"engine":"InnoDB","with":"fillfactor=70, autovacuum_enabled=false","tablespace":"fast"}`)
+	if !strings.Contains(got, `) WITH (fillfactor=70, autovacuum_enabled=false) TABLESPACE "fast";`) ||
+		!strings.Contains(got, `CREATE INDEX IF NOT EXISTS "ix" ON "t"`) || strings.Contains(got, "ENGINE") {
+		t.Errorf("postgresql:\n%s", got)
+	}
+	for _, bad := range []string{`{"engine":"InnoDB; DROP TABLE x"}`, `{"collation":"a b"}`} {
+		if got := a.GenerateCreateTableSQL(schema, "mysql", bad); !strings.HasPrefix(got, "-- Error:") {
+			t.Errorf("%s accepted:\n%s", bad, got)
+		}
+	}
+	if got := a.GenerateCreateTableSQL(schema, "postgresql", `{"with":"fillfactor=70); DROP TABLE x; --"}`); !strings.HasPrefix(got, "-- Error:") {
+		t.Errorf("bad storage parameter accepted:\n%s", got)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	// 2024-03-06 is a Wednesday
+	at := func(day, h, m int) time.Time { return time.Date(2024, 3, day, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		s    BackupSchedule
+		base time.Time
+		want time.Time
+	}{
+		{"daily later today", BackupSchedule{Schedule: "daily", Time: "14:30"}, at(6, 9, 0), at(6, 14, 30)},
+		{"daily already passed", BackupSchedule{Schedule: "daily", Time: "14:30"}, at(6, 15, 0), at(7, 14, 30)},
+		{"daily exactly due", BackupSchedule{Schedule: "daily", Time: "14:30"}, at(6, 14, 30), at(7, 14, 30)},
+		{"daily 23:59", BackupSchedule{Schedule: "daily", Time: "23:59"}, at(6, 23, 58), at(6, 23, 59)},
+		{"daily midnight rollover", BackupSchedule{Schedule: "daily", Time: "00:00"}, at(6, 23, 59), at(7, 0, 0)},
+		{"daily month rollover", BackupSchedule{Schedule: "daily", Time: "0:05"}, time.Date(2024, 2, 29, 1, 0, 0, 0, time.UTC), at(1, 0, 5)},
+		{"weekly later this week", BackupSchedule{Schedule: "weekly", Time: "08:00", Day: 5}, at(6, 9, 0), at(8, 8, 0)},
+		{"weekly today", BackupSchedule{Schedule: "weekly", Time: "10:00", Day: 3}, at(6, 9, 0), at(6, 10, 0)},
+		{"weekly today passed", BackupSchedule{Schedule: "weekly", Time: "10:00", Day: 3}, at(6, 10, 1), at(13, 10, 0)},
+		{"weekly Sunday after Saturday", BackupSchedule{Schedule: "weekly", Time: "00:00", Day: 0}, at(9, 23, 59), at(10, 0, 0)},
+	}
+	for _, tt := range tests {
+		got, ok := nextRun(&tt.s, tt.base)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("%s: nextRun = %v, %v; want %v", tt.name, got, ok, tt.want)
+		}
+	}
+	for _, bad := range []BackupSchedule{
+		{Schedule: "daily", Time: "24:00"},
+		{Schedule: "daily", Time: "ab:cd"},
+		{Schedule: "daily", Time: "12"},
+		{Schedule: "weekly", Time: "12:00", Day: 7},
+		{Schedule: "hourly", Time: "12:00"},
+	} {
+		if got, ok := nextRun(&bad, at(6, 9, 0)); ok {
+			t.Errorf("nextRun(%+v) = %v for a malformed schedule", bad, got)
+		}
+	}
+}
+
+func TestNextRunMultipleDays(t *testing.T) {
+	// 2024-03-06 is a Wednesday; Mon/Wed/Fri at 02:00
+	at := func(day, h, m int) time.Time { return time.Date(2024, 3, day, h, m, 0, 0, time.UTC) }
+	s := &BackupSchedule{Schedule: "weekly", Time: "02:00", Day: 6, Days: []int{5, 1, 3}}
+	tests := []struct {
+		base, want time.Time
+	}{
+		{at(6, 1, 0), at(6, 2, 0)},   // Wednesday before the slot
+		{at(6, 2, 0), at(8, 2, 0)},   // Wednesday's slot taken: Friday
+		{at(8, 3, 0), at(11, 2, 0)},  // Friday after the slot: Monday
+		{at(9, 12, 0), at(11, 2, 0)}, // Saturday: Monday, not the legacy Day
+	}
+	for _, tt := range tests {
+		if got, ok := nextRun(s, tt.base); !ok || !got.Equal(tt.want) {
+			t.Errorf("nextRun(%v) = %v, %v; want %v", tt.base, got, ok, tt.want)
+		}
+	}
+	if _, ok := nextRun(&BackupSchedule{Schedule: "weekly", Time: "02:00", Days: []int{1, 9}}, at(6, 1, 0)); ok {
+		t.Error("day 9 accepted")
+	}
+	// schedules saved before Days existed keep using Day
+	if got, _ := nextRun(&BackupSchedule{Schedule: "weekly", Time: "02:00", Day: 6}, at(6, 1, 0)); !got.Equal(at(9, 2, 0)) {
+		t.Errorf("legacy Day: %v", got)
+	}
+}
+
+func TestSetBackupSchedulesValidates(t *testing.T) {
+	scheduleMu.Lock()
+	savedPath, savedSchedules := schedulesFilePath, backupSchedules
+	schedulesFilePath = filepath.Join(t.TempDir(), "schedules.json")
+	scheduleMu.Unlock()
+	t.Cleanup(func() {
+		scheduleMu.Lock()
+		schedulesFilePath, backupSchedules = savedPath, savedSchedules
+		scheduleMu.Unlock()
+	})
+	a := &App{}
+	for _, bad := range []string{
+		`[{"connectionId":"c","schedule":"daily","time":"25:00"}]`,
+		`[{"connectionId":"c","schedule":"weekly","time":"10:00","day":-1}]`,
+		`[{"connectionId":"c","schedule":"weekly","time":"10:00","days":[1,3,7]}]`,
+		`[{"connectionId":"c","schedule":"monthly","time":"10:00"}]`,
+		`[{"connectionId":"c","schedule":"daily","time":"10:00","mode":"everything"}]`,
+		`[{"schedule":"daily","time":"10:00"}]`,
+	} {
+		if err := a.SetBackupSchedules(bad); err == nil {
+			t.Errorf("%s accepted", bad)
+		}
+	}
+	if _, err := os.Stat(getSchedulesFilePath()); !os.IsNotExist(err) {
+		t.Error("invalid schedules were saved")
+	}
+	if err := a.SetBackupSchedules(`[{"connectionId":"c","enabled":true,"schedule":"weekly","time":" 7:05 ","day":6}]`); err != nil {
+		t.Fatal(err)
+	}
+	if s := loadBackupSchedules(); len(s) != 1 || s[0].Time != "7:05" {
+		t.Errorf("saved = %+v", s)
+	}
+}
+
+func TestScheduleDue(t *testing.T) {
+	// 2024-03-06 is a Wednesday
+	at := func(day, h, m int) time.Time { return time.Date(2024, 3, day, h, m, 30, 0, time.Local) }
+	weekly := &BackupSchedule{Schedule: "weekly", Time: "10:00", Day: 3}
+	tests := []struct {
+		name         string
+		lastRun, now time.Time
+		due, missed  bool
+	}{
+		{"never ran, before the slot", time.Time{}, at(6, 9, 59), false, false},
+		{"never ran, at the slot", time.Time{}, at(6, 10, 0), true, false},
+		{"never ran, next tick", time.Time{}, at(6, 10, 1), true, false},
+		{"ran this slot", at(6, 10, 0), at(6, 10, 1), false, false},
+		{"ran this slot, later that week", at(6, 10, 0), at(9, 12, 0), false, false},
+		{"app closed over the slot", at(6, 10, 0), at(15, 8, 0), true, true},
+		{"app closed for weeks", at(6, 10, 0), at(29, 8, 0), true, true},
+		{"caught up", at(15, 8, 0), at(15, 9, 0), false, false},
+		{"next slot after catching up", at(15, 8, 0), at(20, 10, 0), true, false},
+		{"clock set back", at(27, 10, 0), at(13, 10, 0), true, false},
+	}
+	for _, tt := range tests {
+		lastRun := tt.lastRun
+		if !lastRun.IsZero() {
+			// LastRun is stored in RFC 3339, to the second
+			lastRun, _ = time.Parse(time.RFC3339, lastRun.Format(time.RFC3339))
+		}
+		due, missed := scheduleDue(weekly, lastRun, tt.now)
+		if due != tt.due || missed != tt.missed {
+			t.Errorf("%s: scheduleDue = %v, %v; want %v, %v", tt.name, due, missed, tt.due, tt.missed)
+		}
+	}
+	if due, _ := scheduleDue(&BackupSchedule{Schedule: "weekly", Time: "10:00", Day: 9}, time.Time{}, at(6, 10, 0)); due {
+		t.Error("malformed schedule is due")
+	}
+}
+
+func TestRunScheduleNow(t *testing.T) {
+	dir := t.TempDir()
+	backupMu.Lock()
+	savedBackupsPath, savedRecords := backupsFilePath, backupRecords
+	backupsFilePath, backupRecords = filepath.Join(dir, "backups.json"), nil
+	backupMu.Unlock()
+	scheduleMu.Lock()
+	savedPath, savedSchedules := schedulesFilePath, backupSchedules
+	schedulesFilePath, backupSchedules = filepath.Join(dir, "schedules.json"), nil
+	scheduleMu.Unlock()
+	withTestConnection(t, Connection{ID: "sched", Name: "nightly db", Type: "sqlite", Database: filepath.Join(dir, "sched.db")})
+	t.Cleanup(func() {
+		scheduleMu.Lock()
+		schedulesFilePath, backupSchedules = savedPath, savedSchedules
+		scheduleMu.Unlock()
+		backupMu.Lock()
+		backupsFilePath, backupRecords = savedBackupsPath, savedRecords
+		backupMu.Unlock()
+	})
+	a := &App{}
+	a.ExecuteQuery("sched", "", "CREATE TABLE t (id INTEGER)")
+
+	var res BackupResult
+	json.Unmarshal([]byte(a.RunScheduleNow("sched")), &res)
+	if res.Success || res.Error == "" {
+		t.Errorf("without a schedule: %+v", res)
+	}
+	outDir := filepath.Join(dir, "out", "nested")
+	if err := a.SetBackupSchedules(fmt.Sprintf(`[{"connectionId":"sched","enabled":false,"schedule":"daily","time":"03:00","outputDir":%q}]`, outDir)); err != nil {
+		t.Fatal(err)
+	}
+	res = BackupResult{}
+	json.Unmarshal([]byte(a.RunScheduleNow("sched")), &res)
+	if !res.Success || filepath.Dir(res.Path) != outDir || !strings.HasPrefix(filepath.Base(res.Path), "nightly-db-sched-") {
+		t.Fatalf("result = %+v", res)
+	}
+	if data, err := os.ReadFile(res.Path); err != nil || !strings.Contains(string(data), "CREATE TABLE") {
+		t.Errorf("backup file: %v %q", err, data)
+	}
+	if s := loadBackupSchedules(); len(s) != 1 || s[0].LastRun == "" || s[0].Enabled {
+		t.Errorf("schedules after the run = %+v", s)
+	}
+}
+
+func TestGetCellValueAndBlob(t *testing.T) {
+	withTestConnection(t, Connection{ID: "cell", Type: "sqlite", Database: filepath.Join(t.TempDir(), "cell.db")})
+	a := &App{}
+	a.ExecuteQuery("cell", "", "CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, data BLOB)")
+	a.ExecuteQuery("cell", "", "INSERT INTO t VALUES (1, 'alice', X'00ff')")
+
+	if b, err := a.GetCellBlob("cell", "", "t", "data", `{"id":1}`, ""); err != nil || !bytes.Equal(b, []byte{0x00, 0xff}) {
+		t.Errorf("GetCellBlob = %v, %v", b, err)
+	}
+	if v, err := a.GetCellValue("cell", "", "t", "name", `{"id":1}`, "", ""); err != nil || v != "alice" {
+		t.Errorf("GetCellValue = %v, %v", v, err)
+	}
+	if _, err := a.GetCellValue("cell", "", "t", "name", `{"id":2}`, "", ""); err == nil || !strings.Contains(err.Error(), "row not found") {
+		t.Errorf("missing row: %v", err)
+	}
+	if _, err := a.GetCellValue("cell", "", "t", "name", `{"id":1}`, "", "Mars/Base"); err == nil {
+		t.Error("unknown timezone accepted")
+	}
+}
@@ -3,16 +3,30 @@ import { EventsOn } from '../../wailsjs/runtime/runtime'
 import { schemaService, type SchemaMetadata } from '../services/schemaService'
 
 const SCHEMA_READY_EVENT = 'schema-metadata-ready'
+const SCHEMA_PROGRESS_EVENT = 'schema-metadata-progress'
+const DDL_TABLE_RE = /^\s*(?:CREATE|ALTER|DROP)\s+TABLE\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?(?:[`"]?(\w+)[`"]?\.)?[`"]?(\w+)/i
 
 /** Per-connection schema metadata cache for SQL completion. */
 const cache = ref<Record<string, SchemaMetadata>>({})
+/** Per-connection load progress (tables fetched / total) while metadata is loading. */
+const progress = ref<Record<string, { done: number; total: number }>>({})
 let unsubscribe: (() => void) | null = null
 
 export function useSchemaMetadata() {
   const ensureListener = () => {
     if (unsubscribe) return
+    EventsOn(
+      SCHEMA_PROGRESS_EVENT,
+      (p: { connectionId: string; done: number; total: number }) => {
+        if (!p?.connectionId) return
+        progress.value = { ...progress.value, [p.connectionId]: { done: p.done, total: p.total } }
+      }
+    )
     unsubscribe = EventsOn(SCHEMA_READY_EVENT, async (connectionId: string) => {
       if (!connectionId) return
+      const rest = { ...progress.value }
+      delete rest[connectionId]
+      progress.value = rest
       try {
         const meta = await schemaService.getSchemaMetadata(connectionId)
         cache.value = { ...cache.value, [connectionId]: meta }
@@ -35,6 +49,14 @@ export function useSchemaMetadata() {
     }).catch(() => {})
   }
 
+  /** After CREATE/ALTER/DROP TABLE in sql, refresh just that table's metadata. */
+  const refreshAfterDDL = (connectionId: string, database: string, sql: string) => {
+    const m = DDL_TABLE_RE.exec(sql)
+    if (!connectionId || !m) return
+    ensureListener()
+    schemaService.refreshTableMetadata(connectionId, m[1] || database, m[2]).catch(() => {})
+  }
+
   /** Get cached metadata for connection (may be empty if not loaded yet). */
   const get = (connectionId: string): SchemaMetadata | undefined => {
     return cache.value[connectionId]
@@ -90,7 +112,9 @@ export function useSchemaMetadata() {
 
   return {
     schemaCache: cache,
+    schemaProgress: progress,
     load,
+    refreshAfterDDL,
     get,
     getAllTableNames,
     getColumnsForTable,
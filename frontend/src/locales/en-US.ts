@@ -45,6 +45,22 @@ export default {
     database: 'Database',
     useSSL: 'Use SSL/TLS',
     readOnly: 'Read-only connection',
+    logStatements: 'Log statements to file',
+    logStatementsHint: 'Append every statement run on this connection, with its time, duration and outcome, to a SQL log file',
+    incognito: 'Incognito (no query history)',
+    incognitoHint: 'Statements run on this connection are not recorded in the query history',
+    defaultSchema: 'Default schema (search_path)',
+    sqliteBusyTimeout: 'Lock wait timeout (ms)',
+    sqliteJournalMode: 'Journal mode',
+    sqliteJournalDefault: 'Keep file setting',
+    dsnOptions: 'DSN parameters (key=value per line)',
+    applicationName: 'Application name',
+    socket: 'Unix socket',
+    socketHint: 'Connect through the server socket instead of host and port; SSH tunnels do not apply',
+    dsn: 'Connection string',
+    fillFromDsn: 'Fill fields',
+    dsnHint: 'Used as is when set, and the fields below are ignored. Fill fields copies it into them instead.',
+    lastUsed: 'Last used {time}',
     testConnection: 'Test Connection',
     connect: 'Connect',
     update: 'Update',
@@ -53,6 +69,8 @@ export default {
     yes: 'Yes',
     no: 'No',
     refresh: 'Refresh Connection',
+    disconnect: 'Disconnect',
+    switchDatabase: 'Switch to this database',
     noSavedConnections: 'No saved connections',
     status: {
       connected: 'Connected',
@@ -76,7 +94,10 @@ export default {
   sidebar: {
     newConnection: 'NEW CONNECTION',
     importNavicat: 'Import Navicat',
+    importDBeaver: 'Import DBeaver',
     newTable: 'NEW TABLE',
+    exportSchema: 'Export schema (SQL)',
+    schemaExported: 'Exported {tables} tables and {views} views to {path}',
     filter: 'Filter...',
     more: 'More',
     expand: 'Expand sidebar',
@@ -86,9 +107,14 @@ export default {
     result: 'Imported {imported} connection(s), skipped {skipped}',
     cancelled: 'Cancelled',
     error: 'Import failed',
+    decodePasswords: 'Also import passwords saved in the file? (Only files exported with "Export password" contain them.)',
   },
   query: {
     title: 'SQL Query',
+    recoveredDraft: 'Recovered - {name}',
+    draftsRecovered: 'Reopened {n} unsaved query tab(s) from the last session',
+    longQueryFinished: 'Query finished in {seconds}s',
+    longQueryFailed: 'Query failed after {seconds}s: {error}',
     execute: 'EXECUTE',
     running: 'RUNNING',
     stop: 'STOP',
@@ -98,6 +124,8 @@ export default {
     history: 'History',
     analyzeSQL: 'Analyze SQL',
     noResults: 'No results yet',
+    resultSet: 'Result {n}',
+    rowsAffected: '{n} rows affected',
     executeQuery: 'Execute a query to see results',
   },
   paramModal: {
@@ -109,6 +137,13 @@ export default {
     query: 'Query',
     import: 'Import',
     export: 'Export',
+    dump: 'Dump SQL (schema + data)',
+    dumped: 'Dumped {rows} rows to {path}',
+    analyze: 'Analyze (update statistics)',
+    vacuum: 'Vacuum (reclaim space)',
+    optimize: 'Optimize table',
+    maintenanceRunning: 'Running {sql}…',
+    maintenanceDone: '{sql} finished in {ms} ms',
   },
   table: {
     title: 'Table Data',
@@ -123,12 +158,19 @@ export default {
     noDataInTable: 'No data in current table',
     loading: 'Loading data...',
     beginTx: 'Begin transaction',
+    beginReadOnlyTx: 'Begin read-only',
+    copyExecutedSql: 'Copy executed SQL',
     commitTx: 'Commit',
     rollbackTx: 'Rollback',
     inTransaction: 'In transaction',
+    inReadOnlyTransaction: 'In read-only transaction',
     txBegun: 'Transaction started',
     txCommitted: 'Committed',
     txRolledBack: 'Rolled back',
+    txTimedOut: 'Transaction was idle too long and has been rolled back',
+    approxRowsHint: 'Estimated from table statistics; exact counting is skipped for very large tables',
+    size: 'Size',
+    sizeHint: 'Data {data}, indexes {index}',
     validationNonNull: 'Column "{column}" cannot be null',
   },
   dataGrid: {
@@ -143,8 +185,11 @@ export default {
     sql: 'SQL Insert',
     copiedToClipboard: 'Copied to clipboard',
     cacheHit: 'From cache',
+    truncated: 'Showing first {n} rows; add a LIMIT to see others',
     cacheStats: 'Cache H:{h} M:{m}',
     batchDelete: 'Batch delete',
+    copyAsInsert: 'Copy as INSERT',
+    copyAsUpdate: 'Copy as UPDATE',
     batchEdit: 'Batch edit',
     addFromPaste: 'Add from paste',
     selectRowsFirst: 'Select rows first',
@@ -166,6 +211,11 @@ export default {
     noHistory: 'No query history',
     clear: 'Clear History',
     clearConfirm: 'Are you sure you want to clear all query history?',
+    slow: 'slow',
+    slowOnly: 'Show slow queries only',
+    recordingOn: 'Recording history; click to stop',
+    recordingOff: 'History recording is off; click to resume',
+    disableConfirm: 'Stop recording query history? Choose OK to also delete the history recorded so far.',
     timeAgo: {
       justNow: 'Just now',
       minutesAgo: '{n} minutes ago',
@@ -202,11 +252,17 @@ export default {
     previewFailed: 'Preview failed',
     imported: 'Successfully imported: {inserted} / {total} rows',
     back: 'Back',
+    keepIdentity: 'Keep explicit auto-increment values',
     complete: 'Complete',
   },
   designer: {
     title: 'Table Designer',
     tableName: 'Table Name',
+    engine: 'Storage engine',
+    charset: 'Default character set',
+    collation: 'Collation',
+    storageParameters: 'Storage parameters',
+    tablespace: 'Tablespace',
     columns: 'Column Definitions',
     addColumn: 'Add Column',
     columnName: 'Column Name',
@@ -256,6 +312,7 @@ export default {
     warnings: 'Suggestions',
     mysqlOnly: 'MySQL only',
     unsupportedDriver: 'Execution plan is supported for MySQL and PostgreSQL only.',
+    analyze: 'Actual (ANALYZE)',
     saveAsA: 'Save as A',
     runB: 'Run B',
     clearCompare: 'Clear compare',
@@ -295,6 +352,21 @@ export default {
     copy: 'Copy',
     copied: 'Copied',
   },
+  vault: {
+    masterPassword: 'Master Password',
+    unlockTitle: 'Unlock Connections',
+    unlockHint: 'Saved connection passwords are protected by a master password. Enter it to continue.',
+    setHint: 'The master password encrypts saved connection passwords. It cannot be recovered if forgotten.',
+    currentPassword: 'Current password',
+    newPassword: 'New password',
+    confirmPassword: 'Confirm password',
+    mismatch: 'Passwords do not match',
+    unlock: 'Unlock',
+    remove: 'Remove',
+    saved: 'Master password saved',
+    removed: 'Master password removed',
+    useKeychain: 'Store passwords in the OS keychain',
+  },
   audit: {
     title: 'Audit Log',
     allOps: 'All',
@@ -336,6 +408,7 @@ export default {
     noBackups: 'No backups yet',
     backupSuccess: 'Backup saved',
     backupFailed: 'Backup failed',
+    running: 'Backing up… close to cancel',
     restoreSuccess: 'Restore completed',
     restoreFailed: 'Restore failed',
     manage: 'Backup Manager',
@@ -345,6 +418,7 @@ export default {
     verify: 'Verify',
     verified: 'Verified',
     addSchedule: 'Add Schedule',
+    runNow: 'Run Now',
     daily: 'Daily',
     weekly: 'Weekly',
     at: 'Time',
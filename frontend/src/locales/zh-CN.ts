@@ -45,6 +45,22 @@ export default {
     database: '数据库',
     useSSL: '使用 SSL/TLS',
     readOnly: '只读连接',
+    logStatements: '记录语句到文件',
+    logStatementsHint: '将此连接执行的每条语句及其时间、耗时和结果追加到 SQL 日志文件',
+    incognito: '隐身模式（不记录查询历史）',
+    incognitoHint: '此连接执行的语句不会记录到查询历史中',
+    defaultSchema: '默认模式 (search_path)',
+    sqliteBusyTimeout: '锁等待超时（毫秒）',
+    sqliteJournalMode: '日志模式',
+    sqliteJournalDefault: '保持文件设置',
+    dsnOptions: 'DSN 参数（每行一个 key=value）',
+    applicationName: '应用名称',
+    socket: 'Unix 套接字',
+    socketHint: '通过服务器套接字连接而不是主机和端口；SSH 隧道不适用',
+    dsn: '连接字符串',
+    fillFromDsn: '填充字段',
+    dsnHint: '填写后将原样使用，并忽略下方字段。点击“填充字段”可将其拆分到下方字段中。',
+    lastUsed: '上次使用：{time}',
     testConnection: '测试连接',
     connect: '连接',
     update: '更新',
@@ -53,6 +69,8 @@ export default {
     yes: '是',
     no: '否',
     refresh: '刷新连接',
+    disconnect: '断开连接',
+    switchDatabase: '切换到此数据库',
     noSavedConnections: '暂无已保存连接',
     status: {
       connected: '已连接',
@@ -76,7 +94,10 @@ export default {
   sidebar: {
     newConnection: '新建连接',
     importNavicat: '导入 Navicat',
+    importDBeaver: '导入 DBeaver',
     newTable: '新建表',
+    exportSchema: '导出结构（SQL）',
+    schemaExported: '已导出 {tables} 张表和 {views} 个视图到 {path}',
     filter: '筛选...',
     more: '更多',
     expand: '展开侧边栏',
@@ -86,9 +107,14 @@ export default {
     result: '已导入 {imported} 个连接，跳过 {skipped} 个',
     cancelled: '已取消',
     error: '导入失败',
+    decodePasswords: '是否同时导入文件中保存的密码？（仅在导出时勾选了「导出密码」的文件包含密码）',
   },
   query: {
     title: 'SQL 查询',
+    recoveredDraft: '已恢复 - {name}',
+    draftsRecovered: '已重新打开上次会话中 {n} 个未保存的查询标签页',
+    longQueryFinished: '查询已完成，耗时 {seconds} 秒',
+    longQueryFailed: '查询在 {seconds} 秒后失败：{error}',
     execute: '执行',
     running: '运行中',
     stop: '停止',
@@ -98,6 +124,8 @@ export default {
     history: '历史',
     analyzeSQL: '分析 SQL',
     noResults: '暂无结果',
+    resultSet: '结果 {n}',
+    rowsAffected: '影响 {n} 行',
     executeQuery: '执行查询以查看结果',
   },
   paramModal: {
@@ -109,6 +137,13 @@ export default {
     query: '查询',
     import: '导入',
     export: '导出',
+    dump: '导出 SQL（结构 + 数据）',
+    dumped: '已导出 {rows} 行到 {path}',
+    analyze: '分析（更新统计信息）',
+    vacuum: '清理（回收空间）',
+    optimize: '优化表',
+    maintenanceRunning: '正在执行 {sql}…',
+    maintenanceDone: '{sql} 已完成，耗时 {ms} 毫秒',
   },
   table: {
     title: '表数据',
@@ -123,12 +158,19 @@ export default {
     noDataInTable: '当前表中无数据',
     loading: '正在加载数据...',
     beginTx: '开启事务',
+    beginReadOnlyTx: '开启只读事务',
+    copyExecutedSql: '复制已执行的 SQL',
     commitTx: '提交事务',
     rollbackTx: '回滚事务',
     inTransaction: '事务中',
+    inReadOnlyTransaction: '只读事务中',
     txBegun: '已开启事务',
     txCommitted: '已提交',
     txRolledBack: '已回滚',
+    txTimedOut: '事务空闲时间过长，已自动回滚',
+    approxRowsHint: '根据表统计信息估算；超大表不做精确计数',
+    size: '大小',
+    sizeHint: '数据 {data}，索引 {index}',
     validationNonNull: '列「{column}」不允许为空',
   },
   dataGrid: {
@@ -143,8 +185,11 @@ export default {
     sql: 'SQL Insert',
     copiedToClipboard: '已复制到粘贴板',
     cacheHit: '来自缓存',
+    truncated: '仅显示前 {n} 行，请添加 LIMIT 查看其余数据',
     cacheStats: '缓存 H:{h} M:{m}',
     batchDelete: '批量删除',
+    copyAsInsert: '复制为 INSERT',
+    copyAsUpdate: '复制为 UPDATE',
     batchEdit: '批量修改',
     addFromPaste: '粘贴新增',
     selectRowsFirst: '请先勾选要操作的行',
@@ -166,6 +211,11 @@ export default {
     noHistory: '暂无查询历史',
     clear: '清除历史',
     clearConfirm: '确定要清除所有查询历史吗？',
+    slow: '慢查询',
+    slowOnly: '只显示慢查询',
+    recordingOn: '正在记录历史，点击停止',
+    recordingOff: '已停止记录历史，点击恢复',
+    disableConfirm: '停止记录查询历史？点击“确定”将同时删除已记录的历史。',
     timeAgo: {
       justNow: '刚刚',
       minutesAgo: '{n} 分钟前',
@@ -202,11 +252,17 @@ export default {
     previewFailed: '预览失败',
     imported: '成功导入: {inserted} / {total} 行',
     back: '返回',
+    keepIdentity: '保留显式的自增列值',
     complete: '完成',
   },
   designer: {
     title: '表结构设计器',
     tableName: '表名',
+    engine: '存储引擎',
+    charset: '默认字符集',
+    collation: '排序规则',
+    storageParameters: '存储参数',
+    tablespace: '表空间',
     columns: '列定义',
     addColumn: '添加列',
     columnName: '列名',
@@ -256,6 +312,7 @@ export default {
     warnings: '优化建议',
     mysqlOnly: '仅支持 MySQL',
     unsupportedDriver: '执行计划仅支持 MySQL 与 PostgreSQL',
+    analyze: '实际执行 (ANALYZE)',
     saveAsA: '保存为 A',
     runB: '运行 B',
     clearCompare: '清除对比',
@@ -295,6 +352,21 @@ export default {
     copy: '复制',
     copied: '已复制',
   },
+  vault: {
+    masterPassword: '主密码',
+    unlockTitle: '解锁连接',
+    unlockHint: '已保存的连接密码受主密码保护，请输入主密码以继续。',
+    setHint: '主密码用于加密已保存的连接密码，遗忘后无法找回。',
+    currentPassword: '当前密码',
+    newPassword: '新密码',
+    confirmPassword: '确认密码',
+    mismatch: '两次输入的密码不一致',
+    unlock: '解锁',
+    remove: '移除',
+    saved: '主密码已保存',
+    removed: '主密码已移除',
+    useKeychain: '将密码保存到系统钥匙串',
+  },
   audit: {
     title: '审计日志',
     allOps: '全部',
@@ -336,6 +408,7 @@ export default {
     noBackups: '暂无备份记录',
     backupSuccess: '备份已保存',
     backupFailed: '备份失败',
+    running: '正在备份…关闭以取消',
     restoreSuccess: '恢复完成',
     restoreFailed: '恢复失败',
     manage: '备份管理',
@@ -345,6 +418,7 @@ export default {
     verify: '验证',
     verified: '已验证',
     addSchedule: '添加定时',
+    runNow: '立即运行',
     daily: '每日',
     weekly: '每周',
     at: '时间',
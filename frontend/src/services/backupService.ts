@@ -1,12 +1,18 @@
 import {
   BackupNow,
+  CancelBackup,
+  SetBackupTimeout,
   ListBackups,
   RestoreBackup,
   PickBackupFile,
   GetBackupSchedules,
   SetBackupSchedules,
+  RunScheduleNow,
   DeleteBackup,
   VerifyBackup,
+  PruneBackups,
+  GetToolPaths,
+  SetToolPaths,
 } from '../../wailsjs/go/main/App'
 
 export interface BackupRecord {
@@ -15,13 +21,21 @@ export interface BackupRecord {
   at: string
 }
 
+export type BackupMode = 'full' | 'schema' | 'data'
+
 export interface BackupSchedule {
   connectionId: string
   enabled: boolean
   schedule: 'daily' | 'weekly'
   time: string
+  /** Single weekly day (0 = Sunday); superseded by days when that is set. */
   day: number
+  /** Weekly on each of these days (0 = Sunday .. 6 = Saturday). */
+  days?: number[]
   outputDir?: string
+  mode?: BackupMode
+  keepLast?: number
+  keepDays?: number
   lastRun?: string
 }
 
@@ -36,15 +50,25 @@ export interface RestoreResult {
   error?: string
 }
 
+export interface ToolPaths {
+  mysqldump?: string
+  mysql?: string
+  pgdump?: string
+  psql?: string
+  sqlite3?: string
+}
+
 export interface VerifyResult {
   exists: boolean
   size: number
+  looksValid: boolean
+  detectedType?: string
 }
 
 export const backupService = {
-  async backupNow(connectionId: string): Promise<BackupResult> {
+  async backupNow(connectionId: string, mode: BackupMode = 'full'): Promise<BackupResult> {
     try {
-      const json = await BackupNow(connectionId)
+      const json = await BackupNow(connectionId, mode)
       return JSON.parse(json) as BackupResult
     } catch (e) {
       return {
@@ -54,6 +78,18 @@ export const backupService = {
     }
   },
 
+  async cancelBackup(connectionId: string): Promise<boolean> {
+    try {
+      return await CancelBackup(connectionId)
+    } catch {
+      return false
+    }
+  },
+
+  async setBackupTimeout(minutes: number): Promise<void> {
+    await SetBackupTimeout(minutes)
+  },
+
   async listBackups(connectionId: string): Promise<BackupRecord[]> {
     try {
       const json = await ListBackups(connectionId)
@@ -96,6 +132,18 @@ export const backupService = {
     await SetBackupSchedules(JSON.stringify(schedules))
   },
 
+  /** Run the connection's (saved) schedule at once, to check its output directory and tools. */
+  async runScheduleNow(connectionId: string): Promise<BackupResult> {
+    try {
+      return JSON.parse(await RunScheduleNow(connectionId)) as BackupResult
+    } catch (e) {
+      return {
+        success: false,
+        error: e instanceof Error ? e.message : 'Backup failed',
+      }
+    }
+  },
+
   async deleteBackup(path: string): Promise<{ success: boolean; error?: string }> {
     try {
       const json = await DeleteBackup(path)
@@ -105,12 +153,37 @@ export const backupService = {
     }
   },
 
-  async verifyBackup(path: string): Promise<VerifyResult> {
+  async verifyBackup(connectionId: string, path: string): Promise<VerifyResult> {
     try {
-      const json = await VerifyBackup(path)
+      const json = await VerifyBackup(connectionId, path)
       return JSON.parse(json) as VerifyResult
     } catch {
-      return { exists: false, size: 0 }
+      return { exists: false, size: 0, looksValid: false }
+    }
+  },
+
+  async pruneBackups(
+    connectionId: string,
+    keepLast: number
+  ): Promise<{ success: boolean; removed?: string[]; error?: string }> {
+    try {
+      const json = await PruneBackups(connectionId, keepLast)
+      return JSON.parse(json) as { success: boolean; removed?: string[]; error?: string }
+    } catch (e) {
+      return { success: false, error: e instanceof Error ? e.message : 'Prune failed' }
     }
   },
+
+  async getToolPaths(): Promise<ToolPaths> {
+    try {
+      const json = await GetToolPaths()
+      return JSON.parse(json) as ToolPaths
+    } catch {
+      return {}
+    }
+  },
+
+  async setToolPaths(paths: ToolPaths): Promise<void> {
+    await SetToolPaths(JSON.stringify(paths))
+  },
 }
@@ -1,21 +1,58 @@
-import type { Connection } from '../types'
+import type { Connection, ConnectionStatusResult } from '../types'
 
 import {
   GetConnections,
   CreateConnection,
   TestConnection,
+  ParseDSN,
+  SetStatementLogging,
+  GetStatementLogPath,
   DeleteConnection,
   UpdateConnection,
   ReconnectConnection,
+  DisconnectConnection,
+  GetConnectionStatus,
+  ResetConnectionPool,
+  SetPoolTimeouts,
+  SetConnectionRetry,
+  GetKeepAliveInterval,
+  SetKeepAliveInterval,
   ImportNavicatConnectionsFromDialog,
+  ImportConnectionsFromDBeaverDialog,
+  GetVaultStatus,
+  GetStoreWarnings,
+  UnlockVault,
+  SetMasterPassword,
+  GetKeychainStatus,
+  SetUseKeychain,
+  GetConnectionCharset,
 } from '../../wailsjs/go/main/App'
 
+export interface ConnectionCharset {
+  type?: string
+  /** Session character-set variables as reported by the server. */
+  charset?: Record<string, string>
+  /** Set when the client encoding cannot carry all Unicode text. */
+  warning?: string
+  error?: string
+}
+
 export interface ImportNavicatResult {
   imported: number
   skipped: number
   errors?: string[]
 }
 
+export interface VaultStatus {
+  enabled: boolean
+  locked: boolean
+}
+
+export interface KeychainStatus {
+  available: boolean
+  enabled: boolean
+}
+
 export const connectionService = {
   async getConnections(): Promise<Connection[]> {
     try {
@@ -53,21 +90,135 @@ export const connectionService = {
     }
   },
 
+  /** Splits a pasted connection string into connection fields, for pre-filling the form. */
+  async parseDsn(dsn: string): Promise<Connection> {
+    return JSON.parse(await ParseDSN(dsn))
+  },
+
+  async setStatementLogging(id: string, enabled: boolean): Promise<void> {
+    await SetStatementLogging(id, enabled)
+  },
+
+  /** Path of the connection's SQL statement log. */
+  async getStatementLogPath(id: string): Promise<string> {
+    return GetStatementLogPath(id)
+  },
+
   async reconnectConnection(id: string): Promise<void> {
     await ReconnectConnection(id)
   },
 
+  /** Ping the connection's open pools without opening new ones. */
+  async getConnectionStatus(id: string): Promise<ConnectionStatusResult> {
+    try {
+      return JSON.parse(await GetConnectionStatus(id))
+    } catch (error) {
+      return { status: 'error', message: error instanceof Error ? error.message : String(error) }
+    }
+  },
+
+  /** Roll back transactions and close sessions and the SSH tunnel; the connection is kept, marked disconnected. */
+  async disconnectConnection(id: string): Promise<void> {
+    await DisconnectConnection(id)
+  },
+
+  /** Replace the session's pooled connections with fresh ones, keeping its database choice and attachments. */
+  async resetConnectionPool(id: string, sessionId: string = ''): Promise<void> {
+    await ResetConnectionPool(id, sessionId)
+  },
+
+  /** Idle and maximum lifetime of pooled connections in seconds; 0 restores the default. */
+  async setPoolTimeouts(idleSeconds: number, lifetimeSeconds: number): Promise<void> {
+    await SetPoolTimeouts(idleSeconds, lifetimeSeconds)
+  },
+
+  /** Attempts to open a MySQL/PostgreSQL connection (1 fails fast) and the delay in ms before the first retry, doubled per retry. */
+  async setConnectionRetry(retries: number, baseDelayMs: number): Promise<void> {
+    await SetConnectionRetry(retries, baseDelayMs)
+  },
+
+  /** Seconds between keep-alive pings of open connections; 0 when keep-alive is off. */
+  async getKeepAliveInterval(): Promise<number> {
+    return await GetKeepAliveInterval()
+  },
+
+  /** 0 turns keep-alive off; otherwise 30-3600 seconds. */
+  async setKeepAliveInterval(seconds: number): Promise<void> {
+    await SetKeepAliveInterval(seconds)
+  },
+
   async deleteConnection(id: string): Promise<void> {
     await DeleteConnection(id)
   },
 
-  /** Opens file dialog for .ncx, imports Navicat connections (MySQL/SQLite) and creates them. */
-  async importNavicatFromDialog(): Promise<ImportNavicatResult> {
-    const raw = await ImportNavicatConnectionsFromDialog()
+  /**
+   * Opens file dialog for .ncx, imports Navicat connections (MySQL/PostgreSQL/SQLite) and creates them.
+   * decodePasswords decodes passwords the file carries (only exports made with Navicat's "export password").
+   */
+  async importNavicatFromDialog(decodePasswords = false): Promise<ImportNavicatResult> {
+    const raw = await ImportNavicatConnectionsFromDialog(decodePasswords)
     try {
       return JSON.parse(raw || '{}') as ImportNavicatResult
     } catch {
       return { imported: 0, skipped: 0, errors: [] }
     }
   },
+
+  /** Opens file dialog for DBeaver's data-sources.json and imports its MySQL/PostgreSQL/SQLite data sources. */
+  async importDBeaverFromDialog(): Promise<ImportNavicatResult> {
+    const raw = await ImportConnectionsFromDBeaverDialog()
+    try {
+      return JSON.parse(raw || '{}') as ImportNavicatResult
+    } catch {
+      return { imported: 0, skipped: 0, errors: [] }
+    }
+  },
+
+  async getConnectionCharset(id: string, sessionId = ''): Promise<ConnectionCharset> {
+    try {
+      return JSON.parse(await GetConnectionCharset(id, sessionId)) as ConnectionCharset
+    } catch (error) {
+      return { error: String(error) }
+    }
+  },
+
+  /** Warnings about corrupt data files set aside since the last call. */
+  async getStoreWarnings(): Promise<string[]> {
+    try {
+      return await GetStoreWarnings()
+    } catch {
+      return []
+    }
+  },
+
+  async getVaultStatus(): Promise<VaultStatus> {
+    try {
+      return JSON.parse(await GetVaultStatus()) as VaultStatus
+    } catch {
+      return { enabled: false, locked: false }
+    }
+  },
+
+  /** Enters the master password for this session; rejects when it is wrong. */
+  async unlockVault(password: string): Promise<void> {
+    await UnlockVault(password)
+  },
+
+  /** Sets, changes or (with an empty password) removes the master password. */
+  async setMasterPassword(current: string, password: string): Promise<void> {
+    await SetMasterPassword(current, password)
+  },
+
+  async getKeychainStatus(): Promise<KeychainStatus> {
+    try {
+      return JSON.parse(await GetKeychainStatus()) as KeychainStatus
+    } catch {
+      return { available: false, enabled: false }
+    }
+  },
+
+  /** Moves saved passwords into the OS keychain, or back into the encrypted connections file. */
+  async setUseKeychain(enabled: boolean): Promise<void> {
+    await SetUseKeychain(enabled)
+  },
 }
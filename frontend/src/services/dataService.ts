@@ -1,4 +1,11 @@
-import type { Table, TableData, TableSchema, UpdateRecord } from '../types'
+import type { ColumnStats, DeleteImpact, DryRunResult, GeneratedStatement, MaintenanceOperation, MaintenanceResult, SQLiteAttachment, TableSize, TableView, ValueCount, Table, TableData, TableSchema, UpdateRecord } from '../types'
+
+/** One predicate of a row filter; value is an array for IN / NOT IN and omitted for IS [NOT] NULL. */
+export interface TableFilter {
+  column: string
+  op: '=' | '!=' | '<' | '<=' | '>' | '>=' | 'LIKE' | 'NOT LIKE' | 'IN' | 'NOT IN' | 'IS NULL' | 'IS NOT NULL'
+  value?: unknown
+}
 
 export interface ERMetadataResult {
   tables: TableSchema[]
@@ -7,19 +14,48 @@ export interface ERMetadataResult {
 
 import {
   GetDatabases,
+  GetPgDatabases,
+  SwitchPgDatabase,
+  UseDatabase,
+  AttachDatabase,
+  DetachDatabase,
+  ListAttachedDatabases,
   GetTables,
   GetTableData,
+  GetApproxRowCount,
+  GetTableSize,
+  GetColumnAllowedValues,
+  GenerateInsertForRows,
+  GenerateUpdateForChanges,
   UpdateTableData,
   GetTableSchema,
   ExportData,
+  DumpTable,
+  ExportDatabaseSchema,
   DeleteTableRows,
+  GetDeleteImpact,
+  DeleteRowsByCondition,
+  UpdateRowsByKeys,
   InsertTableRows,
+  DropTable,
+  TruncateTable,
+  RunMaintenance,
+  SetInsertBatchSize,
+  GetCellBlob,
+  GetCellValue,
   BeginTx,
+  BeginReadOnlyTx,
   CommitTx,
   RollbackTx,
   GetTransactionStatus,
+  SetTxIdleTimeout,
+  SetMutationSQLEvents,
   GetERMetadata,
   GenerateSchemaSyncScript,
+  GetColumnStats,
+  GetDistinctValues,
+  SaveTableView,
+  GetTableView,
 } from '../../wailsjs/go/main/App'
 
 /** Optional sessionId for per-tab DB session isolation; pass '' for shared connection. */
@@ -36,6 +72,50 @@ export const dataService = {
     }
   },
 
+  /** PostgreSQL: databases on the server (getDatabases returns the schemas of the connected one). */
+  async getPgDatabases(connectionId: string, sessionId: string = defaultSession): Promise<string[]> {
+    try {
+      const result = await GetPgDatabases(connectionId, sessionId)
+      return JSON.parse(result)
+    } catch (error) {
+      console.error('Failed to get databases:', error)
+      return []
+    }
+  },
+
+  /** PostgreSQL: reconnect to another database on the same server; saved with the connection. */
+  async switchPgDatabase(connectionId: string, database: string): Promise<void> {
+    await SwitchPgDatabase(connectionId, database)
+  },
+
+  /**
+   * Make database current for the session (MySQL USE semantics; PostgreSQL reopens on that dbname).
+   * Pass '' to go back to the connection's configured database. Fails while a transaction is open.
+   */
+  async useDatabase(connectionId: string, sessionId: string, database: string): Promise<void> {
+    await UseDatabase(connectionId, sessionId, database)
+  },
+
+  /**
+   * SQLite: attach another database file to the session as alias, so its tables are reachable as alias.table
+   * and listed by getDatabases/getTables. Fails while a transaction is open.
+   */
+  async attachDatabase(connectionId: string, sessionId: string, filePath: string, alias: string): Promise<void> {
+    await AttachDatabase(connectionId, sessionId, filePath, alias)
+  },
+
+  async detachDatabase(connectionId: string, sessionId: string, alias: string): Promise<void> {
+    await DetachDatabase(connectionId, sessionId, alias)
+  },
+
+  async listAttachedDatabases(connectionId: string, sessionId: string = defaultSession): Promise<SQLiteAttachment[]> {
+    try {
+      return JSON.parse(await ListAttachedDatabases(connectionId, sessionId)) as SQLiteAttachment[]
+    } catch {
+      return []
+    }
+  },
+
   async getTables(connectionId: string, database: string, sessionId: string = defaultSession): Promise<Table[]> {
     try {
       const result = await GetTables(connectionId, database, sessionId)
@@ -52,10 +132,11 @@ export const dataService = {
     tableName: string,
     limit: number = 100,
     offset: number = 0,
-    sessionId: string = defaultSession
+    sessionId: string = defaultSession,
+    withCount = true
   ): Promise<TableData> {
     try {
-      const result = await GetTableData(connectionId, database, tableName, limit, offset, sessionId)
+      const result = await GetTableData(connectionId, database, tableName, limit, offset, sessionId, withCount)
       return JSON.parse(result)
     } catch (error) {
       console.error('Failed to get table data:', error)
@@ -69,6 +150,64 @@ export const dataService = {
     }
   },
 
+  /** Fast row-count estimate from table statistics; -1 when unknown. */
+  async getApproxRowCount(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    sessionId: string = defaultSession
+  ): Promise<number> {
+    try {
+      return await GetApproxRowCount(connectionId, database, tableName, sessionId)
+    } catch {
+      return -1
+    }
+  },
+
+  /** Bytes taken by the table's data and indexes; available is false where the server cannot tell. */
+  async getTableSize(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    sessionId: string = defaultSession
+  ): Promise<TableSize> {
+    return JSON.parse(await GetTableSize(connectionId, database, tableName, sessionId))
+  },
+
+  /** INSERT statements recreating rows (as loaded in the grid), one per line. */
+  async generateInsertForRows(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    rows: Record<string, unknown>[],
+    sessionId: string = defaultSession
+  ): Promise<string> {
+    return await GenerateInsertForRows(connectionId, database, tableName, JSON.stringify(rows), sessionId)
+  },
+
+  /** UPDATE statements the pending edits would run, without executing them. */
+  async generateUpdateForChanges(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    updates: UpdateRecord[],
+    sessionId: string = defaultSession
+  ): Promise<GeneratedStatement[]> {
+    const result = await GenerateUpdateForChanges(connectionId, database, tableName, JSON.stringify(updates), sessionId)
+    return JSON.parse(result) as GeneratedStatement[]
+  },
+
+  /** Values an ENUM/SET column accepts, in declaration order; empty for unrestricted columns. */
+  async getColumnAllowedValues(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    columnName: string,
+    sessionId: string = defaultSession
+  ): Promise<string[]> {
+    return JSON.parse(await GetColumnAllowedValues(connectionId, database, tableName, columnName, sessionId))
+  },
+
   async updateTableData(
     connectionId: string,
     database: string,
@@ -78,13 +217,24 @@ export const dataService = {
   ): Promise<void> {
     try {
       const updatesJSON = JSON.stringify(updates)
-      await UpdateTableData(connectionId, database, tableName, updatesJSON, sessionId)
+      await UpdateTableData(connectionId, database, tableName, updatesJSON, sessionId, false)
     } catch (error) {
       console.error('Failed to update table data:', error)
       throw error
     }
   },
 
+  /** Statements updateTableData would run, with values inlined, and the rows they would touch. */
+  async previewUpdateTableData(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    updates: UpdateRecord[],
+    sessionId: string = defaultSession
+  ): Promise<DryRunResult> {
+    return JSON.parse(await UpdateTableData(connectionId, database, tableName, JSON.stringify(updates), sessionId, true))
+  },
+
   async getTableSchema(
     connectionId: string,
     database: string,
@@ -105,15 +255,72 @@ export const dataService = {
     }
   },
 
+  /** Row, NULL and distinct counts plus MIN/MAX/AVG (where the type allows) for one column. */
+  async getColumnStats(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    column: string,
+    sessionId: string = defaultSession
+  ): Promise<ColumnStats> {
+    try {
+      return JSON.parse(await GetColumnStats(connectionId, database, tableName, column, sessionId)) as ColumnStats
+    } catch (error) {
+      return { column, type: '', rows: 0, nulls: 0, distinct: 0, error: String(error) }
+    }
+  },
+
+  /** Saved grid layout for a table; {} when none was saved. */
+  async getTableView(connectionId: string, tableName: string): Promise<TableView> {
+    try {
+      return JSON.parse(await GetTableView(connectionId, tableName)) as TableView
+    } catch {
+      return {}
+    }
+  },
+
+  /** Persist the grid layout for a table; null forgets it. */
+  async saveTableView(connectionId: string, tableName: string, view: TableView | null): Promise<void> {
+    await SaveTableView(connectionId, tableName, view ? JSON.stringify(view) : '')
+  },
+
+  /** The most frequent values of a column with their row counts, for a filter dropdown. */
+  async getDistinctValues(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    column: string,
+    limit = 20,
+    sessionId: string = defaultSession
+  ): Promise<{ values: ValueCount[]; error?: string }> {
+    try {
+      return JSON.parse(await GetDistinctValues(connectionId, database, tableName, column, limit, sessionId))
+    } catch (error) {
+      return { values: [], error: String(error) }
+    }
+  },
+
+  /** Exports a table; options narrow it to some columns (in order), filtered rows and a limit/offset range. */
   async exportData(
     connectionId: string,
     database: string,
     tableName: string,
     format: string,
-    sessionId: string = defaultSession
+    sessionId: string = defaultSession,
+    options: { columns?: string[]; filters?: TableFilter[]; limit?: number; offset?: number } = {}
   ): Promise<{ success: boolean; filename?: string; error?: string }> {
     try {
-      const result = await ExportData(connectionId, database, tableName, format, sessionId)
+      const result = await ExportData(
+        connectionId,
+        database,
+        tableName,
+        format,
+        options.columns?.length ? JSON.stringify(options.columns) : '',
+        options.filters?.length ? JSON.stringify(options.filters) : '',
+        options.limit ?? 0,
+        options.offset ?? 0,
+        sessionId
+      )
       return JSON.parse(result)
     } catch (error) {
       console.error('Failed to export data:', error)
@@ -124,6 +331,35 @@ export const dataService = {
     }
   },
 
+  /** Writes CREATE TABLE plus INSERTs for one table to path (a save dialog is shown when path is empty). */
+  async dumpTable(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    path = '',
+    sessionId: string = defaultSession
+  ): Promise<{ success: boolean; path?: string; rows?: number; error?: string }> {
+    try {
+      return JSON.parse(await DumpTable(connectionId, database, tableName, path, sessionId))
+    } catch (error) {
+      return { success: false, error: error instanceof Error ? error.message : 'Unknown error' }
+    }
+  },
+
+  /** Writes the DDL of every table (in foreign-key order) and view of database to one .sql file. */
+  async exportDatabaseSchema(
+    connectionId: string,
+    database: string,
+    path = '',
+    sessionId: string = defaultSession
+  ): Promise<{ success: boolean; path?: string; tables?: number; views?: number; error?: string }> {
+    try {
+      return JSON.parse(await ExportDatabaseSchema(connectionId, database, path, sessionId))
+    } catch (error) {
+      return { success: false, error: error instanceof Error ? error.message : 'Unknown error' }
+    }
+  },
+
   async deleteTableRows(
     connectionId: string,
     database: string,
@@ -132,24 +368,151 @@ export const dataService = {
     sessionId: string = defaultSession
   ): Promise<void> {
     const rowsJSON = JSON.stringify(rows)
-    await DeleteTableRows(connectionId, database, tableName, rowsJSON, sessionId)
+    await DeleteTableRows(connectionId, database, tableName, rowsJSON, sessionId, false)
   },
 
-  async insertTableRows(
+  /** Sets the same column values on every row whose keyColumn is in keys, in one transaction; returns rows affected. */
+  async updateRowsByKeys(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    keyColumn: string,
+    keys: unknown[],
+    values: Record<string, unknown>,
+    sessionId: string = defaultSession
+  ): Promise<number> {
+    return UpdateRowsByKeys(connectionId, database, tableName, keyColumn, JSON.stringify(keys), JSON.stringify(values), sessionId)
+  },
+
+  /** Counts the rows in other tables that deleting rows would cascade to or be blocked by. */
+  async getDeleteImpact(
     connectionId: string,
     database: string,
     tableName: string,
     rows: Record<string, unknown>[],
     sessionId: string = defaultSession
-  ): Promise<void> {
+  ): Promise<DeleteImpact> {
+    const result = await GetDeleteImpact(connectionId, database, tableName, JSON.stringify(rows), sessionId)
+    const impact: DeleteImpact = JSON.parse(result)
+    if (impact.error) throw new Error(impact.error)
+    return impact
+  },
+
+  /** Statements deleteTableRows would run, with values inlined, and the rows they would delete. */
+  async previewDeleteTableRows(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    rows: Record<string, unknown>[],
+    sessionId: string = defaultSession
+  ): Promise<DryRunResult> {
+    return JSON.parse(await DeleteTableRows(connectionId, database, tableName, JSON.stringify(rows), sessionId, true))
+  },
+
+  /** Drops a table; with dryRun resolves to the DROP statement and the table's row count instead. */
+  async dropTable(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    sessionId: string = defaultSession,
+    dryRun = false
+  ): Promise<DryRunResult | null> {
+    const result = await DropTable(connectionId, database, tableName, sessionId, dryRun)
+    return result ? JSON.parse(result) : null
+  },
+
+  /** Removes every row of a table; with dryRun resolves to the statement and the row count instead. */
+  /** Runs ANALYZE, VACUUM (PostgreSQL, SQLite) or OPTIMIZE TABLE (MySQL) on a table. */
+  async runMaintenance(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    operation: MaintenanceOperation,
+    sessionId: string = defaultSession
+  ): Promise<MaintenanceResult> {
+    return JSON.parse(await RunMaintenance(connectionId, database, tableName, operation, sessionId))
+  },
+
+  async truncateTable(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    sessionId: string = defaultSession,
+    dryRun = false
+  ): Promise<DryRunResult | null> {
+    const result = await TruncateTable(connectionId, database, tableName, sessionId, dryRun)
+    return result ? JSON.parse(result) : null
+  },
+
+  /** Deletes all rows matching filters (ANDed); returns the number deleted. Empty filters require allowAll. */
+  async deleteRowsByCondition(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    filters: TableFilter[],
+    allowAll = false,
+    sessionId: string = defaultSession
+  ): Promise<number> {
+    return await DeleteRowsByCondition(connectionId, database, tableName, JSON.stringify(filters), sessionId, allowAll)
+  },
+
+  async insertTableRows(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    rows: Record<string, unknown>[],
+    sessionId: string = defaultSession,
+    keepIdentity = false
+  ): Promise<number> {
     const rowsJSON = JSON.stringify(rows)
-    await InsertTableRows(connectionId, database, tableName, rowsJSON, sessionId)
+    // resolves to the rows per INSERT that were used
+    return await InsertTableRows(connectionId, database, tableName, rowsJSON, sessionId, keepIdentity)
+  },
+
+  /** Rows per INSERT for inserts and imports (1..10000); 0 restores the per-driver default. */
+  async setInsertBatchSize(n: number): Promise<void> {
+    await SetInsertBatchSize(n)
+  },
+
+  /** Raw bytes of a binary cell; row is the grid row used to locate it by primary key. */
+  async getCellBlob(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    column: string,
+    row: Record<string, unknown>,
+    sessionId: string = defaultSession
+  ): Promise<Uint8Array> {
+    // Wails serializes []byte as a base64 string despite the generated Array<number> type.
+    const res = (await GetCellBlob(connectionId, database, tableName, column, JSON.stringify(row), sessionId)) as unknown
+    if (typeof res === 'string') {
+      return Uint8Array.from(atob(res), (c) => c.charCodeAt(0))
+    }
+    return Uint8Array.from((res as number[]) ?? [])
+  },
+
+  /** One cell of a grid row; timezone ('UTC', 'Local', IANA) overrides the display timezone for this call. */
+  async getCellValue(
+    connectionId: string,
+    database: string,
+    tableName: string,
+    column: string,
+    row: Record<string, unknown>,
+    timezone = '',
+    sessionId: string = defaultSession
+  ): Promise<unknown> {
+    return await GetCellValue(connectionId, database, tableName, column, JSON.stringify(row), sessionId, timezone)
   },
 
   async beginTx(connectionId: string, sessionId: string = defaultSession): Promise<void> {
     await BeginTx(connectionId, sessionId)
   },
 
+  /** Starts a READ ONLY transaction; write statements in the session are refused until it ends. */
+  async beginReadOnlyTx(connectionId: string, sessionId: string = defaultSession): Promise<void> {
+    await BeginReadOnlyTx(connectionId, sessionId)
+  },
+
   async commitTx(connectionId: string, sessionId: string = defaultSession): Promise<void> {
     await CommitTx(connectionId, sessionId)
   },
@@ -161,10 +524,20 @@ export const dataService = {
   async getTransactionStatus(
     connectionId: string,
     sessionId: string = defaultSession
-  ): Promise<{ active: boolean }> {
+  ): Promise<{ active: boolean; readOnly: boolean }> {
     const raw = await GetTransactionStatus(connectionId, sessionId)
-    const o = JSON.parse(raw) as { active?: boolean }
-    return { active: o.active ?? false }
+    const o = JSON.parse(raw) as { active?: boolean; readOnly?: boolean }
+    return { active: o.active ?? false, readOnly: o.readOnly ?? false }
+  },
+
+  /** Minutes after which an unused transaction is rolled back (default 30); <= 0 disables it. */
+  async setTxIdleTimeout(minutes: number): Promise<void> {
+    await SetTxIdleTimeout(minutes)
+  },
+
+  /** Report the SQL run by grid edits through "data-mutation" events (off by default). */
+  async setMutationSqlEvents(enabled: boolean): Promise<void> {
+    await SetMutationSQLEvents(enabled)
   },
 
   async getERMetadata(
@@ -0,0 +1,22 @@
+import type { FavoriteQuery } from '../types'
+import { AddFavoriteQuery, GetFavoriteQueries, DeleteFavoriteQuery } from '../../wailsjs/go/main/App'
+
+export const favoriteService = {
+  async getFavorites(connectionId: string = ''): Promise<FavoriteQuery[]> {
+    try {
+      const json = await GetFavoriteQueries(connectionId)
+      return JSON.parse(json) as FavoriteQuery[]
+    } catch (error) {
+      console.error('Failed to get favorite queries:', error)
+      return []
+    }
+  },
+
+  async addFavorite(connectionId: string, name: string, sql: string): Promise<void> {
+    await AddFavoriteQuery(connectionId, name, sql)
+  },
+
+  async deleteFavorite(id: string): Promise<void> {
+    await DeleteFavoriteQuery(id)
+  },
+}
@@ -3,6 +3,12 @@ import type { QueryHistory } from '../types'
 import {
   GetQueryHistory,
   ClearQueryHistory,
+  SetMaxHistorySize,
+  ExportQueryHistory,
+  GetSlowQueries,
+  SetSlowQueryThreshold,
+  SetHistoryEnabled,
+  GetHistoryEnabled,
 } from '../../wailsjs/go/main/App'
 
 export const historyService = {
@@ -28,4 +34,45 @@ export const historyService = {
       throw error
     }
   },
+
+  /** Entries slower than thresholdMs; 0 returns those flagged slow when they ran. */
+  async getSlowQueries(connectionId = '', thresholdMs = 0): Promise<QueryHistory[]> {
+    try {
+      return JSON.parse(await GetSlowQueries(connectionId, thresholdMs))
+    } catch (error) {
+      console.error('Failed to get slow queries:', error)
+      return []
+    }
+  },
+
+  /** Duration in ms above which new entries are flagged slow; 0 restores the default (1000). */
+  async setSlowQueryThreshold(ms: number): Promise<void> {
+    await SetSlowQueryThreshold(ms)
+  },
+
+  /** Turn history recording on or off; clear also deletes the recorded history when turning it off. */
+  async setHistoryEnabled(enabled: boolean, clear = false): Promise<void> {
+    await SetHistoryEnabled(enabled, clear)
+  },
+
+  async getHistoryEnabled(): Promise<boolean> {
+    try {
+      return await GetHistoryEnabled()
+    } catch {
+      return true
+    }
+  },
+
+  async setMaxHistorySize(n: number): Promise<void> {
+    await SetMaxHistorySize(n)
+  },
+
+  /** Export the full history; a path ending in .csv writes CSV, anything else JSON. */
+  async exportQueryHistory(path: string): Promise<{ success: boolean; path?: string; count?: number; error?: string }> {
+    try {
+      return JSON.parse(await ExportQueryHistory(path))
+    } catch (error) {
+      return { success: false, error: error instanceof Error ? error.message : 'Export failed' }
+    }
+  },
 }
@@ -30,7 +30,8 @@ export const importService = {
     filePath: string,
     format: ImportFormat,
     columnMapping: Record<string, string>,
-    sessionId: string = ''
+    sessionId: string = '',
+    keepIdentity = false
   ): Promise<ImportResult> {
     try {
       const mappingJSON = JSON.stringify(columnMapping)
@@ -41,7 +42,8 @@ export const importService = {
         filePath,
         format,
         mappingJSON,
-        sessionId
+        sessionId,
+        keepIdentity
       )
       return JSON.parse(result)
     } catch (error) {
@@ -1,6 +1,26 @@
-import type { QueryResult, ExecutionPlanResult, IndexSuggestion } from '../types'
+import type { EditorDraft, QueryResult, ExecutionPlanResult, IndexSuggestion, QueryCursor, PagedQueryResult, CursorResult, ResultDiff } from '../types'
 
-import { ExecuteQuery, FormatSQL, GetExecutionPlan, GetQueryCacheStats, GetIndexSuggestions } from '../../wailsjs/go/main/App'
+import {
+  ExecuteQuery,
+  ExecuteParamQuery,
+  ExecuteQueryPaged,
+  ExecuteQueryCursor,
+  FetchMore,
+  CloseCursor,
+  DiffResults,
+  SaveEditorDraft,
+  ListEditorDrafts,
+  ExecuteMultiResult,
+  FormatSQL,
+  GetExecutionPlan,
+  GetQueryCacheStats,
+  GetIndexSuggestions,
+  GetDisplayTimezone,
+  SetDisplayTimezone,
+  GetNumericBooleans,
+  SetNumericBooleans,
+  SetMaxResultRows,
+} from '../../wailsjs/go/main/App'
 
 const QUERY_TIMEOUT_MS = 120000 // 2 minutes
 
@@ -34,6 +54,146 @@ export const queryService = {
     }
   },
 
+  /**
+   * Execute one statement with bound parameters: an array for ? placeholders or an object for :name ones.
+   * Values are bound by the driver, never concatenated into the SQL.
+   */
+  async executeParamQuery(
+    connectionId: string,
+    sessionId: string,
+    sql: string,
+    params: unknown[] | Record<string, unknown>
+  ): Promise<QueryResult> {
+    try {
+      const result = await withTimeout(
+        ExecuteParamQuery(connectionId, sessionId, sql, JSON.stringify(params)),
+        QUERY_TIMEOUT_MS,
+        'Query timeout (exceeded ' + QUERY_TIMEOUT_MS / 1000 + 's)'
+      )
+      return JSON.parse(result) as QueryResult
+    } catch (error) {
+      return {
+        columns: [],
+        rows: [],
+        rowCount: 0,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      }
+    }
+  },
+
+  /**
+   * Fetch one page of a SELECT using keyset pagination on cursor.column, which must be unique, non-null and
+   * ideally indexed. Pass the returned nextCursor to get the following page.
+   */
+  async executeQueryPaged(
+    connectionId: string,
+    sessionId: string,
+    sql: string,
+    cursor: QueryCursor,
+    pageSize = 100
+  ): Promise<PagedQueryResult> {
+    try {
+      const result = await withTimeout(
+        ExecuteQueryPaged(connectionId, sessionId, sql, JSON.stringify(cursor), pageSize),
+        QUERY_TIMEOUT_MS,
+        'Query timeout (exceeded ' + QUERY_TIMEOUT_MS / 1000 + 's)'
+      )
+      return JSON.parse(result) as PagedQueryResult
+    } catch (error) {
+      return {
+        columns: [],
+        rows: [],
+        rowCount: 0,
+        hasMore: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      }
+    }
+  },
+
+  /**
+   * Run a SELECT and return its first pageSize rows, keeping the rest open on the server. Pass the returned
+   * handle to fetchMore for further pages, or to closeCursor when the user stops scrolling. A new cursor on the
+   * same session closes the previous one.
+   */
+  async executeQueryCursor(connectionId: string, sessionId: string, sql: string, pageSize = 500): Promise<CursorResult> {
+    try {
+      const result = await withTimeout(
+        ExecuteQueryCursor(connectionId, sessionId, sql, pageSize),
+        QUERY_TIMEOUT_MS,
+        'Query timeout (exceeded ' + QUERY_TIMEOUT_MS / 1000 + 's)'
+      )
+      return JSON.parse(result) as CursorResult
+    } catch (error) {
+      return {
+        columns: [],
+        rows: [],
+        rowCount: 0,
+        hasMore: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      }
+    }
+  },
+
+  async fetchMore(handle: string, n = 500): Promise<CursorResult> {
+    try {
+      return JSON.parse(await FetchMore(handle, n)) as CursorResult
+    } catch (error) {
+      return {
+        columns: [],
+        rows: [],
+        rowCount: 0,
+        hasMore: false,
+        error: error instanceof Error ? error.message : 'Unknown error',
+      }
+    }
+  },
+
+  async closeCursor(handle: string): Promise<void> {
+    await CloseCursor(handle)
+  },
+
+  /** Persist unsaved editor content of a tab for crash recovery; blank sql drops the draft. */
+  async saveEditorDraft(tabId: string, sql: string): Promise<void> {
+    await SaveEditorDraft(tabId, sql)
+  },
+
+  /** Drafts left from earlier runs (at most three days old), newest first. */
+  async listEditorDrafts(): Promise<EditorDraft[]> {
+    try {
+      return JSON.parse(await ListEditorDrafts()) as EditorDraft[]
+    } catch {
+      return []
+    }
+  },
+
+  /** Compare two results row by row, matching rows on keyColumns (all shared columns when empty). */
+  async diffResults(a: QueryResult, b: QueryResult, keyColumns: string[] = []): Promise<ResultDiff> {
+    const empty = { keyColumns, columns: [], added: [], removed: [], changed: [], unchanged: 0 }
+    try {
+      return JSON.parse(await DiffResults(JSON.stringify(a), JSON.stringify(b), JSON.stringify(keyColumns))) as ResultDiff
+    } catch (error) {
+      return { ...empty, error: error instanceof Error ? error.message : 'Unknown error' }
+    }
+  },
+
+  /**
+   * Run a stored procedure CALL or multi-statement script and return one QueryResult per result set.
+   * Only MySQL returns more than one set. On failure the array holds a single result with error set.
+   */
+  async executeMultiResult(connectionId: string, sessionId: string, sql: string): Promise<QueryResult[]> {
+    try {
+      const result = await withTimeout(
+        ExecuteMultiResult(connectionId, sessionId, sql),
+        QUERY_TIMEOUT_MS,
+        'Query timeout (exceeded ' + QUERY_TIMEOUT_MS / 1000 + 's)'
+      )
+      return JSON.parse(result) as QueryResult[]
+    } catch (error) {
+      console.error('Failed to execute query:', error)
+      return [{ columns: [], rows: [], rowCount: 0, error: error instanceof Error ? error.message : 'Unknown error' }]
+    }
+  },
+
   async formatSQL(sql: string): Promise<string> {
     try {
       return await FormatSQL(sql)
@@ -43,10 +203,15 @@ export const queryService = {
     }
   },
 
-  /** Get structured execution plan (EXPLAIN) for visualization. MySQL and PostgreSQL supported. */
-  async getExecutionPlan(connectionId: string, sessionId: string, sql: string): Promise<ExecutionPlanResult> {
+  /** Get structured execution plan (EXPLAIN) for visualization. MySQL and PostgreSQL supported. analyze uses EXPLAIN ANALYZE on MySQL 8.0.18+. */
+  async getExecutionPlan(
+    connectionId: string,
+    sessionId: string,
+    sql: string,
+    analyze = false
+  ): Promise<ExecutionPlanResult> {
     try {
-      const result = await GetExecutionPlan(connectionId, sessionId, sql)
+      const result = await GetExecutionPlan(connectionId, sessionId, sql, analyze)
       return JSON.parse(result) as ExecutionPlanResult
     } catch (error) {
       console.error('Failed to get execution plan:', error)
@@ -71,10 +236,11 @@ export const queryService = {
   async getIndexSuggestions(
     connectionId: string,
     sessionId: string,
-    sql: string
+    sql: string,
+    database = ''
   ): Promise<{ suggestions: IndexSuggestion[]; error?: string }> {
     try {
-      const raw = await GetIndexSuggestions(connectionId, sessionId, sql)
+      const raw = await GetIndexSuggestions(connectionId, database, sessionId, sql)
       const o = JSON.parse(raw) as { suggestions?: IndexSuggestion[]; error?: string }
       return { suggestions: o.suggestions ?? [], error: o.error }
     } catch (e) {
@@ -84,4 +250,35 @@ export const queryService = {
       }
     }
   },
+
+  /** Zone TIMESTAMP/DATETIME cells are shown in: '' (as returned), 'UTC', 'Local' or an IANA name. */
+  async getDisplayTimezone(): Promise<string> {
+    try {
+      return await GetDisplayTimezone()
+    } catch {
+      return ''
+    }
+  },
+
+  async setDisplayTimezone(tz: string): Promise<void> {
+    await SetDisplayTimezone(tz)
+  },
+
+  /** Whether boolean columns (BOOL, TINYINT(1), BIT(1)) are shown as 0/1 instead of false/true. */
+  async getNumericBooleans(): Promise<boolean> {
+    try {
+      return await GetNumericBooleans()
+    } catch {
+      return false
+    }
+  },
+
+  async setNumericBooleans(numeric: boolean): Promise<void> {
+    await SetNumericBooleans(numeric)
+  },
+
+  /** Row cap for ad-hoc SELECTs without LIMIT; n <= 0 disables it. */
+  async setMaxResultRows(n: number): Promise<void> {
+    await SetMaxResultRows(n)
+  },
 }
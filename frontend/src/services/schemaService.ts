@@ -1,10 +1,13 @@
 import {
   LoadSchemaMetadata,
   GetSchemaMetadata,
+  RefreshTableMetadata,
+  GetCompletions,
   AnalyzeSQL,
   GenerateCreateTableSQL,
 } from '../../wailsjs/go/main/App'
-import type { SQLAnalysis } from '../types'
+import type { CreateTableOptions, SQLAnalysis } from '../types'
+import { getLocale } from '../locales'
 
 export interface SchemaColumnMeta {
   name: string
@@ -26,12 +29,23 @@ export interface SchemaMetadata {
   databases: SchemaDBMeta[]
 }
 
+export interface CompletionItem {
+  label: string
+  kind: 'table' | 'column' | 'keyword'
+  detail?: string
+}
+
 export const schemaService = {
   /** Trigger async metadata fetch for the connection. Listen for 'schema-metadata-ready' then call getSchemaMetadata. */
   loadSchemaMetadata(connectionId: string): void {
     LoadSchemaMetadata(connectionId)
   },
 
+  /** Update one table in the cached metadata after DDL; 'schema-metadata-ready' fires when done. */
+  async refreshTableMetadata(connectionId: string, database: string, tableName: string): Promise<void> {
+    await RefreshTableMetadata(connectionId, database, tableName)
+  },
+
   async getSchemaMetadata(connectionId: string): Promise<SchemaMetadata> {
     const json = await GetSchemaMetadata(connectionId)
     try {
@@ -41,12 +55,22 @@ export const schemaService = {
     }
   },
 
+  /** Ranked completions for the word being typed; context is the statement text (for alias resolution). */
+  async getCompletions(connectionId: string, prefix: string, context: string): Promise<CompletionItem[]> {
+    try {
+      return JSON.parse(await GetCompletions(connectionId, prefix, context)) as CompletionItem[]
+    } catch {
+      return []
+    }
+  },
+
+  /** Messages come back in the current UI language. */
   async analyzeSQL(sql: string, driver: string): Promise<SQLAnalysis> {
-    const json = await AnalyzeSQL(sql, driver)
+    const json = await AnalyzeSQL(sql, driver, getLocale())
     return JSON.parse(json) as SQLAnalysis
   },
 
-  async generateCreateTableSQL(schema: object, driver: string): Promise<string> {
-    return GenerateCreateTableSQL(JSON.stringify(schema), driver)
+  async generateCreateTableSQL(schema: object, driver: string, options: CreateTableOptions = {}): Promise<string> {
+    return GenerateCreateTableSQL(JSON.stringify(schema), driver, JSON.stringify(options))
   },
 }
@@ -1,5 +1,12 @@
 import type { Snippet } from '../types'
-import { GetSnippets, SaveSnippet, DeleteSnippet } from '../../wailsjs/go/main/App'
+import {
+  GetSnippets,
+  SaveSnippet,
+  DeleteSnippet,
+  SearchSnippets,
+  GetSnippetPlaceholders,
+  ExpandSnippet,
+} from '../../wailsjs/go/main/App'
 
 export const snippetService = {
   async getSnippets(): Promise<Snippet[]> {
@@ -12,11 +19,34 @@ export const snippetService = {
     }
   },
 
-  async saveSnippet(alias: string, sql: string): Promise<void> {
-    await SaveSnippet(alias, sql)
+  async saveSnippet(alias: string, sql: string, category = '', tags: string[] = []): Promise<void> {
+    await SaveSnippet(alias, sql, category, JSON.stringify(tags))
+  },
+
+  async searchSnippets(term: string, category = ''): Promise<Snippet[]> {
+    try {
+      const json = await SearchSnippets(term, category)
+      return JSON.parse(json) as Snippet[]
+    } catch (error) {
+      console.error('Failed to search snippets:', error)
+      return []
+    }
   },
 
   async deleteSnippet(id: string): Promise<void> {
     await DeleteSnippet(id)
   },
+
+  async getPlaceholders(id: string): Promise<string[]> {
+    try {
+      const json = await GetSnippetPlaceholders(id)
+      return JSON.parse(json) as string[]
+    } catch {
+      return []
+    }
+  },
+
+  async expandSnippet(id: string, params: Record<string, string>): Promise<string> {
+    return await ExpandSnippet(id, JSON.stringify(params))
+  },
 }
@@ -13,11 +13,40 @@ export interface Connection {
   status: ConnectionStatus;
   createdAt?: string;
   readOnly?: boolean;
+  defaultSchema?: string;
+  /** Client character set (MySQL charset / PostgreSQL client_encoding); empty uses the driver default. */
+  charset?: string;
+  /** SQLite: ms to wait on a locked database (default 5000). */
+  sqliteBusyTimeout?: number;
+  /** SQLite: PRAGMA journal_mode applied on open, e.g. 'WAL'; empty keeps the file's mode. */
+  sqliteJournalMode?: string;
+  /** Extra driver parameters merged into the DSN, e.g. { readTimeout: '30s' }. */
+  options?: Record<string, string>;
+  /** MySQL: Unix socket path; when set host and port are ignored and SSH tunnels do not apply. */
+  socket?: string;
+  /** Client name shown in pg_stat_activity / MySQL connection attributes; empty uses 'topology - <name>'. */
+  applicationName?: string;
+  /** Pasted connection string used as is instead of the fields above; it also decides the type. */
+  dsn?: string;
+  /** When a pool for the connection was last opened (RFC 3339). */
+  lastConnectedAt?: string;
+  /** Append every statement run on the connection to its SQL log file. */
+  logStatements?: boolean;
+  /** Keep this connection's statements out of the query history. */
+  incognito?: boolean;
+  /** Why the last status check failed; set with status 'error'. */
+  statusMessage?: string;
 }
 
 export type DatabaseType = 'mysql' | 'postgresql' | 'sqlite';
 export type ConnectionStatus = 'connected' | 'disconnected' | 'connecting' | 'error';
 
+/** Result of a status check on a connection's open pools; message explains 'error'. */
+export interface ConnectionStatusResult {
+  status: ConnectionStatus;
+  message?: string;
+}
+
 export interface SSHTunnel {
   enabled: boolean;
   host?: string;
@@ -47,7 +76,23 @@ export interface Column {
   nullable: boolean;
   defaultValue?: string;
   isPrimaryKey: boolean;
+  /** 1-based position in a composite primary key (SQLite). */
+  primaryKeyOrdinal?: number;
   isUnique: boolean;
+  autoIncrement?: boolean;
+  generated?: boolean;
+}
+
+/** User-facing error of a failed call; code is e.g. NOT_FOUND or ACCESS_DENIED. */
+export interface ApiError {
+  code?: string;
+  message: string;
+  /** 1-based character offset of a syntax error in the statement (PostgreSQL). */
+  position?: number;
+  /** 1-based line of a syntax error in the statement (MySQL). */
+  line?: number;
+  /** Text the server quoted at the error. */
+  near?: string;
 }
 
 export interface TableSchema {
@@ -55,6 +100,7 @@ export interface TableSchema {
   columns: Column[];
   indexes: Index[];
   foreignKeys: ForeignKey[];
+  error?: ApiError;
 }
 
 export interface Index {
@@ -82,15 +128,195 @@ export interface QueryResult {
   affectedRows?: number;
   error?: string;
   cached?: boolean;
+  truncated?: boolean;
+  /** Informational note, e.g. a CALL that returned more than one result set. */
+  message?: string;
+  /** Driver column metadata, same order as columns; absent when the driver reports no types. */
+  columnTypes?: ColumnMeta[];
+  /** Detected statement kind: SELECT, INSERT, UPDATE, DELETE, DDL or OTHER. */
+  statementType?: 'SELECT' | 'INSERT' | 'UPDATE' | 'DELETE' | 'DDL' | 'OTHER';
+  /** Pretty-printed JSON found in TEXT/BLOB/untyped columns, by row index then column; rows keep the raw value. */
+  prettyJson?: Record<number, Record<string, string>>;
+  /** Location of a syntax error, when the server reported one. */
+  errorDetail?: ApiError;
+}
+
+export interface ColumnMeta {
+  name: string;
+  databaseType: string;
+  scanType?: string;
+  nullable?: boolean;
+  length?: number;
+  /** JSON/JSONB column, or one whose TEXT/BLOB values were recognized as JSON. */
+  json?: boolean;
+}
+
+/** Keyset pagination position for ExecuteQueryPaged; omit `after` for the first page. */
+export interface QueryCursor {
+  column: string;
+  desc?: boolean;
+  after?: unknown;
+  /** "int" or "time" when `after` is an integer or date sent as a string; pass it back unchanged. */
+  afterKind?: 'int' | 'time';
+}
+
+export interface PagedQueryResult extends QueryResult {
+  nextCursor?: QueryCursor;
+  hasMore: boolean;
+}
+
+/** One page read through a server-side result cursor; handle is set while more rows remain. */
+export interface CursorResult extends QueryResult {
+  handle?: string;
+  hasMore: boolean;
+}
+
+/** Payload of the "query-start" and "query-finish" events; durationMs, rowCount and error are set on finish. */
+export interface QueryEvent {
+  connectionId: string;
+  sessionId: string;
+  sql: string;
+  durationMs: number;
+  rowCount: number;
+  error?: string;
+}
+
+/** A SQLite database file attached to a session under alias. */
+export interface SQLiteAttachment {
+  alias: string;
+  path: string;
+}
+
+/** Payload of the "data-mutation" event: the statements a grid edit ran, with values inlined. */
+export interface DataMutation {
+  connectionId: string;
+  sessionId: string;
+  database: string;
+  table: string;
+  operation: 'update' | 'delete' | 'insert';
+  statements: string[];
+}
+
+/** Rows of one referencing table a delete would cascade to, null out or be blocked by. */
+export interface DeleteImpactTable {
+  table: string;
+  foreignKey: string;
+  referencedTable: string;
+  action: 'CASCADE' | 'SET NULL' | 'SET DEFAULT' | 'RESTRICT' | 'NO ACTION';
+  rows: number;
+  depth: number;
+}
+
+/** Result of GetDeleteImpact. blocked means a RESTRICT / NO ACTION reference would make the delete fail. */
+export interface DeleteImpact {
+  tables: DeleteImpactTable[];
+  blocked: boolean;
+  truncated?: boolean;
+  error?: string;
+}
+
+/** Storage taken by a table, in bytes (see GetTableSize). */
+export interface TableSize {
+  dataBytes: number;
+  indexBytes: number;
+  totalBytes: number;
+  available: boolean;
+}
+
+export type MaintenanceOperation = 'analyze' | 'vacuum' | 'optimize';
+
+/** Result of RunMaintenance; messages are MySQL's status rows. */
+export interface MaintenanceResult {
+  operation: MaintenanceOperation;
+  sql: string;
+  messages: string[];
+  durationMs: number;
+}
+
+/** Unsaved query editor content kept for crash recovery. */
+export interface EditorDraft {
+  sessionId: string;
+  sql: string;
+  savedAt: string;
+}
+
+/** Row-level difference between two query results (see queryService.diffResults). */
+export interface ResultDiff {
+  keyColumns: string[];
+  /** Columns present in both results; only these are compared */
+  columns: string[];
+  onlyInA?: string[];
+  onlyInB?: string[];
+  /** Rows only in the second result */
+  added: Record<string, unknown>[];
+  /** Rows only in the first result */
+  removed: Record<string, unknown>[];
+  changed: { key: Record<string, unknown>; cells: Record<string, { old: unknown; new: unknown }> }[];
+  unchanged: number;
+  error?: string;
 }
 
 // Table data types
 export interface TableData {
   columns: string[];
   rows: Record<string, any>[];
+  /** -1 when the data was requested without a count */
   totalRows: number;
   page: number;
   pageSize: number;
+  hasMore?: boolean;
+  error?: ApiError;
+}
+
+/** Preview returned by the destructive table operations when run with dryRun. */
+/** Profile of one column; min/max are absent for unordered types, avg for non-numeric ones. */
+export interface ColumnStats {
+  column: string;
+  type: string;
+  rows: number;
+  nulls: number;
+  distinct: number;
+  min?: unknown;
+  max?: unknown;
+  avg?: unknown;
+  error?: string;
+}
+
+/** Data grid layout remembered per connection and table. */
+export interface TableView {
+  /** Column name -> width in px */
+  columnWidths?: Record<string, number>;
+  /** Columns to show, in display order; absent shows all */
+  visibleColumns?: string[];
+  sortColumn?: string;
+  sortDesc?: boolean;
+  pageSize?: number;
+}
+
+/** One distinct column value and the number of rows holding it. */
+export interface ValueCount {
+  value: unknown;
+  count: number;
+}
+
+export interface DryRunResult {
+  /** Statements that would run, with bound values inlined for display */
+  statements: string[];
+  /** Rows they would touch, from a matching SELECT COUNT(*) */
+  estimatedRows: number;
+}
+
+/** Table options appended to generated CREATE TABLE; those the driver does not have are ignored. */
+export interface CreateTableOptions {
+  ifNotExists?: boolean;
+  /** MySQL */
+  engine?: string;
+  charset?: string;
+  collation?: string;
+  /** MySQL and PostgreSQL */
+  tablespace?: string;
+  /** PostgreSQL storage parameters, e.g. 'fillfactor=70' */
+  with?: string;
 }
 
 export interface UpdateRecord {
@@ -98,6 +324,15 @@ export interface UpdateRecord {
   column: string;
   oldValue: any;
   newValue: any;
+  /** Full original row; enables optimistic locking on the backend. */
+  original?: Record<string, any>;
+}
+
+/** A statement generated for review; display has its arguments inlined. */
+export interface GeneratedStatement {
+  sql: string;
+  args: any[];
+  display: string;
 }
 
 // Tab types
@@ -128,6 +363,8 @@ export interface QueryHistory {
   success: boolean
   duration?: number
   rowCount?: number
+  /** Duration exceeded the slow-query threshold when it ran. */
+  slow?: boolean
 }
 
 // SQL snippet (saved fragment with alias)
@@ -135,6 +372,17 @@ export interface Snippet {
   id: string
   alias: string
   sql: string
+  category?: string
+  tags?: string[]
+  createdAt: string
+}
+
+// Pinned full query tied to a connection
+export interface FavoriteQuery {
+  id: string
+  connectionId: string
+  name: string
+  sql: string
   createdAt: string
 }
 
@@ -151,6 +399,8 @@ export interface ImportResult {
   success: boolean
   inserted?: number
   totalRows?: number
+  /** Rows per INSERT that were used */
+  batchSize?: number
   error?: string
 }
 
@@ -1,14 +1,24 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function AnalyzeSQL(arg1:string,arg2:string):Promise<string>;
+export function AddFavoriteQuery(arg1:string,arg2:string,arg3:string):Promise<void>;
 
-export function BackupNow(arg1:string):Promise<string>;
+export function AnalyzeSQL(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function AttachDatabase(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+
+export function BackupNow(arg1:string,arg2:string):Promise<string>;
+
+export function BeginReadOnlyTx(arg1:string,arg2:string):Promise<void>;
 
 export function BeginTx(arg1:string,arg2:string):Promise<void>;
 
+export function CancelBackup(arg1:string):Promise<boolean>;
+
 export function ClearQueryHistory():Promise<void>;
 
+export function CloseCursor(arg1:string):Promise<void>;
+
 export function CommitTx(arg1:string,arg2:string):Promise<void>;
 
 export function CreateConnection(arg1:string):Promise<void>;
@@ -17,33 +27,103 @@ export function DeleteBackup(arg1:string):Promise<string>;
 
 export function DeleteConnection(arg1:string):Promise<void>;
 
+export function DeleteFavoriteQuery(arg1:string):Promise<void>;
+
+export function DeleteRowsByCondition(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:boolean):Promise<number>;
+
 export function DeleteSnippet(arg1:string):Promise<void>;
 
-export function DeleteTableRows(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<void>;
+export function DeleteTableRows(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:boolean):Promise<string>;
+
+export function DetachDatabase(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function DiffResults(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function DisconnectConnection(arg1:string):Promise<void>;
+
+export function DropTable(arg1:string,arg2:string,arg3:string,arg4:string,arg5:boolean):Promise<string>;
+
+export function DumpTable(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+
+export function ExecuteMultiResult(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function ExecuteParamQuery(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
 export function ExecuteQuery(arg1:string,arg2:string,arg3:string):Promise<string>;
 
+export function ExecuteQueryCursor(arg1:string,arg2:string,arg3:string,arg4:number):Promise<string>;
+
+export function ExecuteQueryPaged(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number):Promise<string>;
+
+export function ExpandSnippet(arg1:string,arg2:string):Promise<string>;
+
 export function ExportAuditLog(arg1:string):Promise<string>;
 
-export function ExportData(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+export function ExportData(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:number,arg8:number,arg9:string):Promise<string>;
+
+export function ExportDatabaseSchema(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function ExportQueryHistory(arg1:string):Promise<string>;
+
+export function FetchMore(arg1:string,arg2:number):Promise<string>;
 
 export function FormatSQL(arg1:string):Promise<string>;
 
-export function GenerateCreateTableSQL(arg1:string,arg2:string):Promise<string>;
+export function GenerateCreateTableSQL(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function GenerateInsertForRows(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
 
 export function GenerateSchemaSyncScript(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string):Promise<string>;
 
+export function GenerateUpdateForChanges(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+
+export function GetApproxRowCount(arg1:string,arg2:string,arg3:string,arg4:string):Promise<number>;
+
 export function GetBackupSchedules():Promise<string>;
 
+export function GetCellBlob(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string):Promise<Array<number>>;
+
+export function GetCellValue(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string):Promise<any>;
+
+export function GetColumnAllowedValues(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+
+export function GetColumnStats(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+
+export function GetCompletions(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function GetConnectionCharset(arg1:string,arg2:string):Promise<string>;
+
+export function GetConnectionStatus(arg1:string):Promise<string>;
+
 export function GetConnections():Promise<string>;
 
 export function GetDatabases(arg1:string,arg2:string):Promise<string>;
 
+export function GetDeleteImpact(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+
+export function GetDisplayTimezone():Promise<string>;
+
+export function GetDistinctValues(arg1:string,arg2:string,arg3:string,arg4:string,arg5:number,arg6:string):Promise<string>;
+
 export function GetERMetadata(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
-export function GetExecutionPlan(arg1:string,arg2:string,arg3:string):Promise<string>;
+export function GetEditorDraft(arg1:string):Promise<string>;
+
+export function GetExecutionPlan(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<string>;
+
+export function GetFavoriteQueries(arg1:string):Promise<string>;
+
+export function GetHistoryEnabled():Promise<boolean>;
+
+export function GetIndexSuggestions(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
-export function GetIndexSuggestions(arg1:string,arg2:string,arg3:string):Promise<string>;
+export function GetKeepAliveInterval():Promise<number>;
+
+export function GetKeychainStatus():Promise<string>;
+
+export function GetNumericBooleans():Promise<boolean>;
+
+export function GetPgDatabases(arg1:string,arg2:string):Promise<string>;
 
 export function GetQueryCacheStats():Promise<string>;
 
@@ -51,54 +131,142 @@ export function GetQueryHistory(arg1:string,arg2:string,arg3:number):Promise<str
 
 export function GetSchemaMetadata(arg1:string):Promise<string>;
 
+export function GetServerInfo(arg1:string):Promise<string>;
+
+export function GetSlowQueries(arg1:string,arg2:number):Promise<string>;
+
+export function GetSnippetPlaceholders(arg1:string):Promise<string>;
+
 export function GetSnippets():Promise<string>;
 
-export function GetTableData(arg1:string,arg2:string,arg3:string,arg4:number,arg5:number,arg6:string):Promise<string>;
+export function GetStatementLogPath(arg1:string):Promise<string>;
+
+export function GetStoreWarnings():Promise<Array<string>>;
+
+export function GetTableData(arg1:string,arg2:string,arg3:string,arg4:number,arg5:number,arg6:string,arg7:boolean):Promise<string>;
 
 export function GetTableSchema(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
 
+export function GetTableSize(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function GetTableView(arg1:string,arg2:string):Promise<string>;
+
 export function GetTables(arg1:string,arg2:string,arg3:string):Promise<string>;
 
+export function GetToolPaths():Promise<string>;
+
 export function GetTransactionStatus(arg1:string,arg2:string):Promise<string>;
 
-export function ImportData(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string):Promise<string>;
+export function GetVaultStatus():Promise<string>;
+
+export function ImportConnectionsFromDBeaver(arg1:string):Promise<string>;
+
+export function ImportConnectionsFromDBeaverDialog():Promise<string>;
+
+export function ImportData(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:boolean):Promise<string>;
 
 export function ImportDataPreview(arg1:string,arg2:string):Promise<string>;
 
-export function ImportNavicatConnections(arg1:string):Promise<string>;
+export function ImportNavicatConnections(arg1:string,arg2:boolean):Promise<string>;
 
-export function ImportNavicatConnectionsFromDialog():Promise<string>;
+export function ImportNavicatConnectionsFromDialog(arg1:boolean):Promise<string>;
 
-export function InsertTableRows(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<void>;
+export function InsertTableRows(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:boolean):Promise<number>;
+
+export function ListAttachedDatabases(arg1:string,arg2:string):Promise<string>;
 
 export function ListBackups(arg1:string):Promise<string>;
 
+export function ListEditorDrafts():Promise<string>;
+
 export function LoadSchemaMetadata(arg1:string):Promise<void>;
 
+export function ParseDSN(arg1:string):Promise<string>;
+
 export function PickBackupFile():Promise<string>;
 
+export function PruneBackups(arg1:string,arg2:number):Promise<string>;
+
 export function QueryAuditLog(arg1:number,arg2:string,arg3:string):Promise<string>;
 
 export function ReconnectConnection(arg1:string):Promise<void>;
 
+export function RefreshTableMetadata(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function ReleaseSession(arg1:string,arg2:string):Promise<void>;
 
+export function ResetConnectionPool(arg1:string,arg2:string):Promise<void>;
+
 export function RestoreBackup(arg1:string,arg2:string):Promise<string>;
 
 export function RollbackTx(arg1:string,arg2:string):Promise<void>;
 
-export function SaveSnippet(arg1:string,arg2:string):Promise<void>;
+export function RunMaintenance(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<string>;
+
+export function RunScheduleNow(arg1:string):Promise<string>;
+
+export function SaveEditorDraft(arg1:string,arg2:string):Promise<void>;
+
+export function SaveSnippet(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+
+export function SaveTableView(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SearchSnippets(arg1:string,arg2:string):Promise<string>;
 
 export function SetBackupSchedules(arg1:string):Promise<void>;
 
+export function SetBackupTimeout(arg1:number):Promise<void>;
+
+export function SetConnectionRetry(arg1:number,arg2:number):Promise<void>;
+
+export function SetDisplayTimezone(arg1:string):Promise<void>;
+
+export function SetHistoryEnabled(arg1:boolean,arg2:boolean):Promise<void>;
+
+export function SetInsertBatchSize(arg1:number):Promise<void>;
+
+export function SetKeepAliveInterval(arg1:number):Promise<void>;
+
+export function SetMasterPassword(arg1:string,arg2:string):Promise<void>;
+
+export function SetMaxHistorySize(arg1:number):Promise<void>;
+
+export function SetMaxResultRows(arg1:number):Promise<void>;
+
+export function SetMutationSQLEvents(arg1:boolean):Promise<void>;
+
+export function SetNumericBooleans(arg1:boolean):Promise<void>;
+
+export function SetPoolTimeouts(arg1:number,arg2:number):Promise<void>;
+
+export function SetSlowQueryThreshold(arg1:number):Promise<void>;
+
+export function SetStatementLogging(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetToolPaths(arg1:string):Promise<void>;
+
+export function SetTxIdleTimeout(arg1:number):Promise<void>;
+
+export function SetUseKeychain(arg1:boolean):Promise<void>;
+
 export function StartMonitor(arg1:string):Promise<string>;
 
 export function StopMonitor(arg1:string):Promise<void>;
 
+export function SwitchPgDatabase(arg1:string,arg2:string):Promise<void>;
+
 export function TestConnection(arg1:string):Promise<boolean>;
 
+export function TruncateTable(arg1:string,arg2:string,arg3:string,arg4:string,arg5:boolean):Promise<string>;
+
+export function UnlockVault(arg1:string):Promise<void>;
+
 export function UpdateConnection(arg1:string):Promise<void>;
 
-export function UpdateTableData(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<void>;
+export function UpdateRowsByKeys(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string):Promise<number>;
+
+export function UpdateTableData(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:boolean):Promise<string>;
+
+export function UseDatabase(arg1:string,arg2:string,arg3:string):Promise<void>;
 
-export function VerifyBackup(arg1:string):Promise<string>;
+export function VerifyBackup(arg1:string,arg2:string):Promise<string>;
@@ -2,22 +2,42 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function AnalyzeSQL(arg1, arg2) {
-  return window['go']['main']['App']['AnalyzeSQL'](arg1, arg2);
+export function AddFavoriteQuery(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddFavoriteQuery'](arg1, arg2, arg3);
 }
 
-export function BackupNow(arg1) {
-  return window['go']['main']['App']['BackupNow'](arg1);
+export function AnalyzeSQL(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AnalyzeSQL'](arg1, arg2, arg3);
+}
+
+export function AttachDatabase(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['AttachDatabase'](arg1, arg2, arg3, arg4);
+}
+
+export function BackupNow(arg1, arg2) {
+  return window['go']['main']['App']['BackupNow'](arg1, arg2);
+}
+
+export function BeginReadOnlyTx(arg1, arg2) {
+  return window['go']['main']['App']['BeginReadOnlyTx'](arg1, arg2);
 }
 
 export function BeginTx(arg1, arg2) {
   return window['go']['main']['App']['BeginTx'](arg1, arg2);
 }
 
+export function CancelBackup(arg1) {
+  return window['go']['main']['App']['CancelBackup'](arg1);
+}
+
 export function ClearQueryHistory() {
   return window['go']['main']['App']['ClearQueryHistory']();
 }
 
+export function CloseCursor(arg1) {
+  return window['go']['main']['App']['CloseCursor'](arg1);
+}
+
 export function CommitTx(arg1, arg2) {
   return window['go']['main']['App']['CommitTx'](arg1, arg2);
 }
@@ -34,42 +54,142 @@ export function DeleteConnection(arg1) {
   return window['go']['main']['App']['DeleteConnection'](arg1);
 }
 
+export function DeleteFavoriteQuery(arg1) {
+  return window['go']['main']['App']['DeleteFavoriteQuery'](arg1);
+}
+
+export function DeleteRowsByCondition(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['DeleteRowsByCondition'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
 export function DeleteSnippet(arg1) {
   return window['go']['main']['App']['DeleteSnippet'](arg1);
 }
 
-export function DeleteTableRows(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['main']['App']['DeleteTableRows'](arg1, arg2, arg3, arg4, arg5);
+export function DeleteTableRows(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['DeleteTableRows'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function DetachDatabase(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DetachDatabase'](arg1, arg2, arg3);
+}
+
+export function DiffResults(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiffResults'](arg1, arg2, arg3);
+}
+
+export function DisconnectConnection(arg1) {
+  return window['go']['main']['App']['DisconnectConnection'](arg1);
+}
+
+export function DropTable(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['DropTable'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function DumpTable(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['DumpTable'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function ExecuteMultiResult(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExecuteMultiResult'](arg1, arg2, arg3);
+}
+
+export function ExecuteParamQuery(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ExecuteParamQuery'](arg1, arg2, arg3, arg4);
 }
 
 export function ExecuteQuery(arg1, arg2, arg3) {
   return window['go']['main']['App']['ExecuteQuery'](arg1, arg2, arg3);
 }
 
+export function ExecuteQueryCursor(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ExecuteQueryCursor'](arg1, arg2, arg3, arg4);
+}
+
+export function ExecuteQueryPaged(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['ExecuteQueryPaged'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function ExpandSnippet(arg1, arg2) {
+  return window['go']['main']['App']['ExpandSnippet'](arg1, arg2);
+}
+
 export function ExportAuditLog(arg1) {
   return window['go']['main']['App']['ExportAuditLog'](arg1);
 }
 
-export function ExportData(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['main']['App']['ExportData'](arg1, arg2, arg3, arg4, arg5);
+export function ExportData(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9) {
+  return window['go']['main']['App']['ExportData'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9);
+}
+
+export function ExportDatabaseSchema(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ExportDatabaseSchema'](arg1, arg2, arg3, arg4);
+}
+
+export function ExportQueryHistory(arg1) {
+  return window['go']['main']['App']['ExportQueryHistory'](arg1);
+}
+
+export function FetchMore(arg1, arg2) {
+  return window['go']['main']['App']['FetchMore'](arg1, arg2);
 }
 
 export function FormatSQL(arg1) {
   return window['go']['main']['App']['FormatSQL'](arg1);
 }
 
-export function GenerateCreateTableSQL(arg1, arg2) {
-  return window['go']['main']['App']['GenerateCreateTableSQL'](arg1, arg2);
+export function GenerateCreateTableSQL(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GenerateCreateTableSQL'](arg1, arg2, arg3);
+}
+
+export function GenerateInsertForRows(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['GenerateInsertForRows'](arg1, arg2, arg3, arg4, arg5);
 }
 
 export function GenerateSchemaSyncScript(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
   return window['go']['main']['App']['GenerateSchemaSyncScript'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
+export function GenerateUpdateForChanges(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['GenerateUpdateForChanges'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function GetApproxRowCount(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetApproxRowCount'](arg1, arg2, arg3, arg4);
+}
+
 export function GetBackupSchedules() {
   return window['go']['main']['App']['GetBackupSchedules']();
 }
 
+export function GetCellBlob(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['GetCellBlob'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function GetCellValue(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
+  return window['go']['main']['App']['GetCellValue'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
+}
+
+export function GetColumnAllowedValues(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['GetColumnAllowedValues'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function GetColumnStats(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['GetColumnStats'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function GetCompletions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetCompletions'](arg1, arg2, arg3);
+}
+
+export function GetConnectionCharset(arg1, arg2) {
+  return window['go']['main']['App']['GetConnectionCharset'](arg1, arg2);
+}
+
+export function GetConnectionStatus(arg1) {
+  return window['go']['main']['App']['GetConnectionStatus'](arg1);
+}
+
 export function GetConnections() {
   return window['go']['main']['App']['GetConnections']();
 }
@@ -78,16 +198,56 @@ export function GetDatabases(arg1, arg2) {
   return window['go']['main']['App']['GetDatabases'](arg1, arg2);
 }
 
+export function GetDeleteImpact(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['GetDeleteImpact'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function GetDisplayTimezone() {
+  return window['go']['main']['App']['GetDisplayTimezone']();
+}
+
+export function GetDistinctValues(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['GetDistinctValues'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
 export function GetERMetadata(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['GetERMetadata'](arg1, arg2, arg3, arg4);
 }
 
-export function GetExecutionPlan(arg1, arg2, arg3) {
-  return window['go']['main']['App']['GetExecutionPlan'](arg1, arg2, arg3);
+export function GetEditorDraft(arg1) {
+  return window['go']['main']['App']['GetEditorDraft'](arg1);
+}
+
+export function GetExecutionPlan(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetExecutionPlan'](arg1, arg2, arg3, arg4);
+}
+
+export function GetFavoriteQueries(arg1) {
+  return window['go']['main']['App']['GetFavoriteQueries'](arg1);
+}
+
+export function GetHistoryEnabled() {
+  return window['go']['main']['App']['GetHistoryEnabled']();
+}
+
+export function GetIndexSuggestions(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetIndexSuggestions'](arg1, arg2, arg3, arg4);
 }
 
-export function GetIndexSuggestions(arg1, arg2, arg3) {
-  return window['go']['main']['App']['GetIndexSuggestions'](arg1, arg2, arg3);
+export function GetKeepAliveInterval() {
+  return window['go']['main']['App']['GetKeepAliveInterval']();
+}
+
+export function GetKeychainStatus() {
+  return window['go']['main']['App']['GetKeychainStatus']();
+}
+
+export function GetNumericBooleans() {
+  return window['go']['main']['App']['GetNumericBooleans']();
+}
+
+export function GetPgDatabases(arg1, arg2) {
+  return window['go']['main']['App']['GetPgDatabases'](arg1, arg2);
 }
 
 export function GetQueryCacheStats() {
@@ -102,58 +262,118 @@ export function GetSchemaMetadata(arg1) {
   return window['go']['main']['App']['GetSchemaMetadata'](arg1);
 }
 
+export function GetServerInfo(arg1) {
+  return window['go']['main']['App']['GetServerInfo'](arg1);
+}
+
+export function GetSlowQueries(arg1, arg2) {
+  return window['go']['main']['App']['GetSlowQueries'](arg1, arg2);
+}
+
+export function GetSnippetPlaceholders(arg1) {
+  return window['go']['main']['App']['GetSnippetPlaceholders'](arg1);
+}
+
 export function GetSnippets() {
   return window['go']['main']['App']['GetSnippets']();
 }
 
-export function GetTableData(arg1, arg2, arg3, arg4, arg5, arg6) {
-  return window['go']['main']['App']['GetTableData'](arg1, arg2, arg3, arg4, arg5, arg6);
+export function GetStatementLogPath(arg1) {
+  return window['go']['main']['App']['GetStatementLogPath'](arg1);
+}
+
+export function GetStoreWarnings() {
+  return window['go']['main']['App']['GetStoreWarnings']();
+}
+
+export function GetTableData(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
+  return window['go']['main']['App']['GetTableData'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
 export function GetTableSchema(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['GetTableSchema'](arg1, arg2, arg3, arg4);
 }
 
+export function GetTableSize(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetTableSize'](arg1, arg2, arg3, arg4);
+}
+
+export function GetTableView(arg1, arg2) {
+  return window['go']['main']['App']['GetTableView'](arg1, arg2);
+}
+
 export function GetTables(arg1, arg2, arg3) {
   return window['go']['main']['App']['GetTables'](arg1, arg2, arg3);
 }
 
+export function GetToolPaths() {
+  return window['go']['main']['App']['GetToolPaths']();
+}
+
 export function GetTransactionStatus(arg1, arg2) {
   return window['go']['main']['App']['GetTransactionStatus'](arg1, arg2);
 }
 
-export function ImportData(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
-  return window['go']['main']['App']['ImportData'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
+export function GetVaultStatus() {
+  return window['go']['main']['App']['GetVaultStatus']();
+}
+
+export function ImportConnectionsFromDBeaver(arg1) {
+  return window['go']['main']['App']['ImportConnectionsFromDBeaver'](arg1);
+}
+
+export function ImportConnectionsFromDBeaverDialog() {
+  return window['go']['main']['App']['ImportConnectionsFromDBeaverDialog']();
+}
+
+export function ImportData(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
+  return window['go']['main']['App']['ImportData'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
 }
 
 export function ImportDataPreview(arg1, arg2) {
   return window['go']['main']['App']['ImportDataPreview'](arg1, arg2);
 }
 
-export function ImportNavicatConnections(arg1) {
-  return window['go']['main']['App']['ImportNavicatConnections'](arg1);
+export function ImportNavicatConnections(arg1, arg2) {
+  return window['go']['main']['App']['ImportNavicatConnections'](arg1, arg2);
 }
 
-export function ImportNavicatConnectionsFromDialog() {
-  return window['go']['main']['App']['ImportNavicatConnectionsFromDialog']();
+export function ImportNavicatConnectionsFromDialog(arg1) {
+  return window['go']['main']['App']['ImportNavicatConnectionsFromDialog'](arg1);
 }
 
-export function InsertTableRows(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['main']['App']['InsertTableRows'](arg1, arg2, arg3, arg4, arg5);
+export function InsertTableRows(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['InsertTableRows'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function ListAttachedDatabases(arg1, arg2) {
+  return window['go']['main']['App']['ListAttachedDatabases'](arg1, arg2);
 }
 
 export function ListBackups(arg1) {
   return window['go']['main']['App']['ListBackups'](arg1);
 }
 
+export function ListEditorDrafts() {
+  return window['go']['main']['App']['ListEditorDrafts']();
+}
+
 export function LoadSchemaMetadata(arg1) {
   return window['go']['main']['App']['LoadSchemaMetadata'](arg1);
 }
 
+export function ParseDSN(arg1) {
+  return window['go']['main']['App']['ParseDSN'](arg1);
+}
+
 export function PickBackupFile() {
   return window['go']['main']['App']['PickBackupFile']();
 }
 
+export function PruneBackups(arg1, arg2) {
+  return window['go']['main']['App']['PruneBackups'](arg1, arg2);
+}
+
 export function QueryAuditLog(arg1, arg2, arg3) {
   return window['go']['main']['App']['QueryAuditLog'](arg1, arg2, arg3);
 }
@@ -162,10 +382,18 @@ export function ReconnectConnection(arg1) {
   return window['go']['main']['App']['ReconnectConnection'](arg1);
 }
 
+export function RefreshTableMetadata(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RefreshTableMetadata'](arg1, arg2, arg3);
+}
+
 export function ReleaseSession(arg1, arg2) {
   return window['go']['main']['App']['ReleaseSession'](arg1, arg2);
 }
 
+export function ResetConnectionPool(arg1, arg2) {
+  return window['go']['main']['App']['ResetConnectionPool'](arg1, arg2);
+}
+
 export function RestoreBackup(arg1, arg2) {
   return window['go']['main']['App']['RestoreBackup'](arg1, arg2);
 }
@@ -174,14 +402,102 @@ export function RollbackTx(arg1, arg2) {
   return window['go']['main']['App']['RollbackTx'](arg1, arg2);
 }
 
-export function SaveSnippet(arg1, arg2) {
-  return window['go']['main']['App']['SaveSnippet'](arg1, arg2);
+export function RunMaintenance(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['RunMaintenance'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function RunScheduleNow(arg1) {
+  return window['go']['main']['App']['RunScheduleNow'](arg1);
+}
+
+export function SaveEditorDraft(arg1, arg2) {
+  return window['go']['main']['App']['SaveEditorDraft'](arg1, arg2);
+}
+
+export function SaveSnippet(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SaveSnippet'](arg1, arg2, arg3, arg4);
+}
+
+export function SaveTableView(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveTableView'](arg1, arg2, arg3);
+}
+
+export function SearchSnippets(arg1, arg2) {
+  return window['go']['main']['App']['SearchSnippets'](arg1, arg2);
 }
 
 export function SetBackupSchedules(arg1) {
   return window['go']['main']['App']['SetBackupSchedules'](arg1);
 }
 
+export function SetBackupTimeout(arg1) {
+  return window['go']['main']['App']['SetBackupTimeout'](arg1);
+}
+
+export function SetConnectionRetry(arg1, arg2) {
+  return window['go']['main']['App']['SetConnectionRetry'](arg1, arg2);
+}
+
+export function SetDisplayTimezone(arg1) {
+  return window['go']['main']['App']['SetDisplayTimezone'](arg1);
+}
+
+export function SetHistoryEnabled(arg1, arg2) {
+  return window['go']['main']['App']['SetHistoryEnabled'](arg1, arg2);
+}
+
+export function SetInsertBatchSize(arg1) {
+  return window['go']['main']['App']['SetInsertBatchSize'](arg1);
+}
+
+export function SetKeepAliveInterval(arg1) {
+  return window['go']['main']['App']['SetKeepAliveInterval'](arg1);
+}
+
+export function SetMasterPassword(arg1, arg2) {
+  return window['go']['main']['App']['SetMasterPassword'](arg1, arg2);
+}
+
+export function SetMaxHistorySize(arg1) {
+  return window['go']['main']['App']['SetMaxHistorySize'](arg1);
+}
+
+export function SetMaxResultRows(arg1) {
+  return window['go']['main']['App']['SetMaxResultRows'](arg1);
+}
+
+export function SetMutationSQLEvents(arg1) {
+  return window['go']['main']['App']['SetMutationSQLEvents'](arg1);
+}
+
+export function SetNumericBooleans(arg1) {
+  return window['go']['main']['App']['SetNumericBooleans'](arg1);
+}
+
+export function SetPoolTimeouts(arg1, arg2) {
+  return window['go']['main']['App']['SetPoolTimeouts'](arg1, arg2);
+}
+
+export function SetSlowQueryThreshold(arg1) {
+  return window['go']['main']['App']['SetSlowQueryThreshold'](arg1);
+}
+
+export function SetStatementLogging(arg1, arg2) {
+  return window['go']['main']['App']['SetStatementLogging'](arg1, arg2);
+}
+
+export function SetToolPaths(arg1) {
+  return window['go']['main']['App']['SetToolPaths'](arg1);
+}
+
+export function SetTxIdleTimeout(arg1) {
+  return window['go']['main']['App']['SetTxIdleTimeout'](arg1);
+}
+
+export function SetUseKeychain(arg1) {
+  return window['go']['main']['App']['SetUseKeychain'](arg1);
+}
+
 export function StartMonitor(arg1) {
   return window['go']['main']['App']['StartMonitor'](arg1);
 }
@@ -190,18 +506,38 @@ export function StopMonitor(arg1) {
   return window['go']['main']['App']['StopMonitor'](arg1);
 }
 
+export function SwitchPgDatabase(arg1, arg2) {
+  return window['go']['main']['App']['SwitchPgDatabase'](arg1, arg2);
+}
+
 export function TestConnection(arg1) {
   return window['go']['main']['App']['TestConnection'](arg1);
 }
 
+export function TruncateTable(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['TruncateTable'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function UnlockVault(arg1) {
+  return window['go']['main']['App']['UnlockVault'](arg1);
+}
+
 export function UpdateConnection(arg1) {
   return window['go']['main']['App']['UpdateConnection'](arg1);
 }
 
-export function UpdateTableData(arg1, arg2, arg3, arg4, arg5) {
-  return window['go']['main']['App']['UpdateTableData'](arg1, arg2, arg3, arg4, arg5);
+export function UpdateRowsByKeys(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
+  return window['go']['main']['App']['UpdateRowsByKeys'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
+}
+
+export function UpdateTableData(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['UpdateTableData'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function UseDatabase(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UseDatabase'](arg1, arg2, arg3);
 }
 
-export function VerifyBackup(arg1) {
-  return window['go']['main']['App']['VerifyBackup'](arg1);
+export function VerifyBackup(arg1, arg2) {
+  return window['go']['main']['App']['VerifyBackup'](arg1, arg2);
 }
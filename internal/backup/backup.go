@@ -1,26 +1,127 @@
 package backup
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"gorm.io/gorm"
 )
 
 // Conn holds connection params for backup/restore.
 type Conn struct {
 	Type     string // mysql, postgresql, sqlite
-	Host     string
+	Host     string // MySQL: a path starting with "/" is the server's Unix socket
 	Port     int
 	Username string
 	Password string
 	Database string
+	Mode     string // full (default), schema, data
+	// DB is the open SQLite connection, used to dump and restore in Go when the sqlite3 CLI is unavailable.
+	DB *gorm.DB
+}
+
+// Backup modes for Conn.Mode. Empty Mode is treated as ModeFull.
+const (
+	ModeFull   = "full"
+	ModeSchema = "schema"
+	ModeData   = "data"
+)
+
+// ToolPaths holds explicit paths to the client binaries used for backup/restore. Empty fields fall back to PATH lookup.
+type ToolPaths struct {
+	MySQLDump string `json:"mysqldump,omitempty"`
+	MySQL     string `json:"mysql,omitempty"`
+	PGDump    string `json:"pgdump,omitempty"`
+	PSQL      string `json:"psql,omitempty"`
+	SQLite3   string `json:"sqlite3,omitempty"`
+}
+
+var (
+	toolMu sync.RWMutex
+	// envTools are the defaults from TOPOLOGY_MYSQLDUMP, TOPOLOGY_MYSQL, TOPOLOGY_PG_DUMP, TOPOLOGY_PSQL, TOPOLOGY_SQLITE3.
+	envTools = ToolPaths{
+		MySQLDump: os.Getenv("TOPOLOGY_MYSQLDUMP"),
+		MySQL:     os.Getenv("TOPOLOGY_MYSQL"),
+		PGDump:    os.Getenv("TOPOLOGY_PG_DUMP"),
+		PSQL:      os.Getenv("TOPOLOGY_PSQL"),
+		SQLite3:   os.Getenv("TOPOLOGY_SQLITE3"),
+	}
+	tools = envTools
+)
+
+// SetToolPaths sets the binary paths used by RunBackup/RunRestore. Empty fields revert to the env default (or PATH).
+func SetToolPaths(p ToolPaths) {
+	pick := func(v, def string) string {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+		return def
+	}
+	toolMu.Lock()
+	defer toolMu.Unlock()
+	tools = ToolPaths{
+		MySQLDump: pick(p.MySQLDump, envTools.MySQLDump),
+		MySQL:     pick(p.MySQL, envTools.MySQL),
+		PGDump:    pick(p.PGDump, envTools.PGDump),
+		PSQL:      pick(p.PSQL, envTools.PSQL),
+		SQLite3:   pick(p.SQLite3, envTools.SQLite3),
+	}
+}
+
+// CurrentToolPaths returns the configured binary paths (empty means PATH lookup).
+func CurrentToolPaths() ToolPaths {
+	toolMu.RLock()
+	defer toolMu.RUnlock()
+	return tools
+}
+
+// toolPath resolves the binary for name ("mysqldump", "mysql", "pg_dump", "psql", "sqlite3").
+// A configured path must exist; otherwise the name is looked up on PATH.
+func toolPath(name string) (string, error) {
+	t := CurrentToolPaths()
+	var configured string
+	switch name {
+	case "mysqldump":
+		configured = t.MySQLDump
+	case "mysql":
+		configured = t.MySQL
+	case "pg_dump":
+		configured = t.PGDump
+	case "psql":
+		configured = t.PSQL
+	case "sqlite3":
+		configured = t.SQLite3
+	}
+	if configured != "" {
+		if _, err := os.Stat(configured); err != nil {
+			return "", fmt.Errorf("%s not found at %s; check its path in settings", name, configured)
+		}
+		return configured, nil
+	}
+	p, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found; set its path in settings", name)
+	}
+	return p, nil
 }
 
 // RunBackup runs mysqldump (MySQL), pg_dump (PostgreSQL), or sqlite3 .dump (SQLite). outputPath must be absolute. SSH not supported.
+// SQLite falls back to a pure-Go dump through c.DB when the sqlite3 CLI is not installed.
+// c.Mode selects a full dump, schema only (no rows), or data only (INSERTs, no DDL).
 func RunBackup(ctx context.Context, c *Conn, outputPath string) error {
+	switch c.Mode {
+	case "", ModeFull, ModeSchema, ModeData:
+	default:
+		return fmt.Errorf("unsupported backup mode: %s", c.Mode)
+	}
 	switch c.Type {
 	case "mysql":
 		return runMySQLBackup(ctx, c, outputPath)
@@ -33,8 +134,17 @@ func RunBackup(ctx context.Context, c *Conn, outputPath string) error {
 	}
 }
 
+// mysqlHostArgs addresses the server for mysqldump and mysql: --socket when Host is a socket path (starts
+// with "/"), otherwise -h and -P.
+func mysqlHostArgs(c *Conn) []string {
+	if strings.HasPrefix(c.Host, "/") {
+		return []string{"--socket=" + c.Host}
+	}
+	return []string{"-h", c.Host, "-P", fmt.Sprintf("%d", c.Port)}
+}
+
 func runMySQLBackup(ctx context.Context, c *Conn, out string) error {
-	args := []string{"-h", c.Host, "-P", fmt.Sprintf("%d", c.Port), "-u", c.Username}
+	args := append(mysqlHostArgs(c), "-u", c.Username)
 	if c.Password != "" {
 		args = append(args, "-p"+c.Password)
 	}
@@ -43,9 +153,21 @@ func runMySQLBackup(ctx context.Context, c *Conn, out string) error {
 	} else {
 		args = append(args, "--all-databases")
 	}
-	args = append(args, "--single-transaction", "--routines", "--triggers", "--events")
+	args = append(args, "--single-transaction")
+	switch c.Mode {
+	case ModeSchema:
+		args = append(args, "--no-data", "--routines", "--triggers", "--events")
+	case ModeData:
+		args = append(args, "--no-create-info", "--skip-triggers")
+	default:
+		args = append(args, "--routines", "--triggers", "--events")
+	}
 
-	cmd := exec.CommandContext(ctx, "mysqldump", args...)
+	bin, err := toolPath("mysqldump")
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
 	f, err := os.Create(out)
 	if err != nil {
 		return fmt.Errorf("create backup file: %w", err)
@@ -66,7 +188,17 @@ func runPGBackup(ctx context.Context, c *Conn, out string) error {
 		db = "postgres"
 	}
 	args := []string{"-h", c.Host, "-p", fmt.Sprintf("%d", c.Port), "-U", c.Username, "-d", db, "-f", out}
-	cmd := exec.CommandContext(ctx, "pg_dump", args...)
+	switch c.Mode {
+	case ModeSchema:
+		args = append(args, "--schema-only")
+	case ModeData:
+		args = append(args, "--data-only")
+	}
+	bin, err := toolPath("pg_dump")
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Env = append(os.Environ(), "PGPASSWORD="+c.Password)
 	cmd.Stderr = nil
 	if err := cmd.Run(); err != nil {
@@ -84,12 +216,49 @@ func runSQLiteBackup(ctx context.Context, c *Conn, out string) error {
 	if !filepath.IsAbs(dbPath) && !strings.HasPrefix(dbPath, "file:") {
 		// treat as relative to cwd
 	}
-	cmd := exec.CommandContext(ctx, "sqlite3", dbPath, ".dump")
+	dot := ".dump"
+	if c.Mode == ModeSchema {
+		dot = ".schema"
+	}
+	bin, err := toolPath("sqlite3")
+	if err != nil {
+		if c.DB != nil {
+			return dumpSQLite(ctx, c.DB, c.Mode, out)
+		}
+		return err
+	}
+	cmd := exec.CommandContext(ctx, bin, dbPath, dot)
 	f, err := os.Create(out)
 	if err != nil {
 		return fmt.Errorf("create backup file: %w", err)
 	}
 	defer f.Close()
+	if c.Mode == ModeData {
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			_ = os.Remove(out)
+			return fmt.Errorf("sqlite3 dump: %w", err)
+		}
+		cmd.Stderr = nil
+		if err := cmd.Start(); err != nil {
+			_ = os.Remove(out)
+			return fmt.Errorf("sqlite3 dump: %w", err)
+		}
+		ferr := filterSQLiteInserts(stdout, f)
+		if ferr != nil {
+			// drain so sqlite3 is not left blocked on a full pipe
+			_, _ = io.Copy(io.Discard, stdout)
+		}
+		if err := cmd.Wait(); err != nil {
+			_ = os.Remove(out)
+			return fmt.Errorf("sqlite3 dump: %w", err)
+		}
+		if ferr != nil {
+			_ = os.Remove(out)
+			return fmt.Errorf("sqlite3 dump: %w", ferr)
+		}
+		return nil
+	}
 	cmd.Stdout = f
 	cmd.Stderr = nil
 	if err := cmd.Run(); err != nil {
@@ -99,7 +268,64 @@ func runSQLiteBackup(ctx context.Context, c *Conn, out string) error {
 	return nil
 }
 
+// filterSQLiteInserts streams a sqlite3 .dump and copies only its INSERT statements (wrapped in a transaction).
+// Statements end at a ';' outside quotes, so text values containing ");" or newlines are kept whole.
+func filterSQLiteInserts(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("BEGIN TRANSACTION;\n")
+	var stmt bytes.Buffer
+	var quote byte // ' or " while inside a quoted value or identifier; '' escapes re-enter the quote
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		stmt.WriteByte(c)
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';':
+			s := bytes.TrimLeft(stmt.Bytes(), " \t\r\n")
+			if bytes.HasPrefix(s, []byte("INSERT INTO ")) {
+				_, _ = bw.Write(s)
+				_ = bw.WriteByte('\n')
+			}
+			stmt.Reset()
+		}
+	}
+	_, _ = bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
+
+// DetectDumpType inspects the beginning of a dump file and returns "mysql", "postgresql", "sqlite",
+// "sql" (plain SQL of unknown origin, e.g. sqlite3 .schema output), or "" when it does not look like a dump.
+func DetectDumpType(head []byte) string {
+	s := string(head)
+	upper := strings.ToUpper(s)
+	switch {
+	case strings.Contains(s, "-- MySQL dump") || strings.Contains(s, "/*!40101 SET"):
+		return "mysql"
+	case strings.Contains(s, "-- PostgreSQL database dump") || strings.Contains(s, "pg_catalog.set_config"):
+		return "postgresql"
+	case strings.Contains(upper, "PRAGMA FOREIGN_KEYS") || strings.HasPrefix(strings.TrimSpace(upper), "BEGIN TRANSACTION;"):
+		return "sqlite"
+	case strings.Contains(upper, "CREATE TABLE") || strings.Contains(upper, "INSERT INTO"):
+		return "sql"
+	default:
+		return ""
+	}
+}
+
 // RunRestore runs mysql (MySQL), psql (PostgreSQL), or sqlite3 (SQLite) to restore from backupPath. SSH not supported.
+// Like RunBackup, SQLite executes the script through c.DB when the sqlite3 CLI is not installed.
 func RunRestore(ctx context.Context, c *Conn, backupPath string) error {
 	switch c.Type {
 	case "mysql":
@@ -114,7 +340,7 @@ func RunRestore(ctx context.Context, c *Conn, backupPath string) error {
 }
 
 func runMySQLRestore(ctx context.Context, c *Conn, fpath string) error {
-	args := []string{"-h", c.Host, "-P", fmt.Sprintf("%d", c.Port), "-u", c.Username}
+	args := append(mysqlHostArgs(c), "-u", c.Username)
 	if c.Password != "" {
 		args = append(args, "-p"+c.Password)
 	}
@@ -124,7 +350,11 @@ func runMySQLRestore(ctx context.Context, c *Conn, fpath string) error {
 		return fmt.Errorf("open backup file: %w", err)
 	}
 	defer in.Close()
-	cmd := exec.CommandContext(ctx, "mysql", args...)
+	bin, err := toolPath("mysql")
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Stdin = in
 	cmd.Stderr = nil
 	if err := cmd.Run(); err != nil {
@@ -139,7 +369,11 @@ func runPGRestore(ctx context.Context, c *Conn, fpath string) error {
 		db = "postgres"
 	}
 	args := []string{"-h", c.Host, "-p", fmt.Sprintf("%d", c.Port), "-U", c.Username, "-d", db, "-f", fpath}
-	cmd := exec.CommandContext(ctx, "psql", args...)
+	bin, err := toolPath("psql")
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Env = append(os.Environ(), "PGPASSWORD="+c.Password)
 	cmd.Stderr = nil
 	if err := cmd.Run(); err != nil {
@@ -153,12 +387,19 @@ func runSQLiteRestore(ctx context.Context, c *Conn, fpath string) error {
 	if dbPath == "" {
 		return fmt.Errorf("sqlite restore requires database path")
 	}
+	bin, err := toolPath("sqlite3")
+	if err != nil {
+		if c.DB != nil {
+			return restoreSQLite(ctx, c.DB, fpath)
+		}
+		return err
+	}
 	in, err := os.Open(fpath)
 	if err != nil {
 		return fmt.Errorf("open backup file: %w", err)
 	}
 	defer in.Close()
-	cmd := exec.CommandContext(ctx, "sqlite3", dbPath)
+	cmd := exec.CommandContext(ctx, bin, dbPath)
 	cmd.Stdin = in
 	cmd.Stderr = nil
 	if err := cmd.Run(); err != nil {
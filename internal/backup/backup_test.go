@@ -4,7 +4,11 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestRunBackupUnsupported(t *testing.T) {
@@ -63,3 +67,171 @@ func TestRunBackupSQLiteDump(t *testing.T) {
 		t.Errorf("backup file missing: %v", err)
 	}
 }
+
+func TestRunBackupInvalidMode(t *testing.T) {
+	ctx := context.Background()
+	c := &Conn{Type: "sqlite", Database: "x.db", Mode: "bogus"}
+	path := filepath.Join(t.TempDir(), "out.sql")
+	if err := RunBackup(ctx, c, path); err == nil {
+		t.Fatal("expected error for unsupported mode")
+	}
+}
+
+func TestFilterSQLiteInserts(t *testing.T) {
+	dump := "PRAGMA foreign_keys=OFF;\n" +
+		"BEGIN TRANSACTION;\n" +
+		"CREATE TABLE t (id INTEGER, s TEXT);\n" +
+		"INSERT INTO t VALUES(1,'a');\n" +
+		"INSERT INTO t VALUES(2,'line1\nline2');\n" +
+		"INSERT INTO t VALUES(3,'x);\nINSERT INTO t VALUES(4,''y'');\nz');\n" +
+		"INSERT INTO \"t;x\" VALUES(5,'a;b');\n" +
+		"CREATE INDEX i ON t(s);\n" +
+		"COMMIT;\n"
+	var out strings.Builder
+	if err := filterSQLiteInserts(strings.NewReader(dump), &out); err != nil {
+		t.Fatalf("filterSQLiteInserts: %v", err)
+	}
+	want := "BEGIN TRANSACTION;\n" +
+		"INSERT INTO t VALUES(1,'a');\n" +
+		"INSERT INTO t VALUES(2,'line1\nline2');\n" +
+		"INSERT INTO t VALUES(3,'x);\nINSERT INTO t VALUES(4,''y'');\nz');\n" +
+		"INSERT INTO \"t;x\" VALUES(5,'a;b');\n" +
+		"COMMIT;\n"
+	if out.String() != want {
+		t.Errorf("got %q want %q", out.String(), want)
+	}
+}
+
+func TestToolPathConfigured(t *testing.T) {
+	defer SetToolPaths(ToolPaths{})
+	SetToolPaths(ToolPaths{MySQLDump: "/nonexistent/bin/mysqldump"})
+	if _, err := toolPath("mysqldump"); err == nil || !strings.Contains(err.Error(), "settings") {
+		t.Fatalf("expected settings hint for missing configured path, got %v", err)
+	}
+	bin := filepath.Join(t.TempDir(), "mysqldump")
+	_ = os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o755)
+	SetToolPaths(ToolPaths{MySQLDump: bin})
+	got, err := toolPath("mysqldump")
+	if err != nil || got != bin {
+		t.Fatalf("toolPath = %q, %v; want %q", got, err, bin)
+	}
+}
+
+func TestDetectDumpType(t *testing.T) {
+	tests := []struct {
+		head string
+		want string
+	}{
+		{"-- MySQL dump 10.13  Distrib 8.0.36\n--\n-- Host: localhost", "mysql"},
+		{"--\n-- PostgreSQL database dump\n--\nSET statement_timeout = 0;", "postgresql"},
+		{"PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\nCREATE TABLE t(id);", "sqlite"},
+		{"BEGIN TRANSACTION;\nINSERT INTO t VALUES(1);\nCOMMIT;", "sqlite"},
+		{"CREATE TABLE t (id INTEGER);", "sql"},
+		{"hello world", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectDumpType([]byte(tt.head)); got != tt.want {
+			t.Errorf("DetectDumpType(%q) = %q, want %q", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestSQLiteDumpWithoutCLI(t *testing.T) {
+	defer SetToolPaths(ToolPaths{})
+	SetToolPaths(ToolPaths{SQLite3: "/nonexistent/bin/sqlite3"})
+	ctx := context.Background()
+	dir := t.TempDir()
+	open := func(name string) *gorm.DB {
+		g, err := gorm.Open(sqlite.Open(filepath.Join(dir, name)), &gorm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB, _ := g.DB()
+		t.Cleanup(func() { sqlDB.Close() })
+		return g
+	}
+	src := open("src.db")
+	for _, q := range []string{
+		`CREATE TABLE "a b" (id INTEGER PRIMARY KEY AUTOINCREMENT, s TEXT, f REAL, b BLOB)`,
+		`INSERT INTO "a b" (s, f, b) VALUES ('it''s; a
+line', 1.5, X'00ff'), (NULL, NULL, NULL)`,
+		`CREATE INDEX idx_s ON "a b"(s)`,
+		`CREATE VIEW v AS SELECT s FROM "a b"`,
+	} {
+		if err := src.Exec(q).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(dir, "dump.sql")
+	if err := RunBackup(ctx, &Conn{Type: "sqlite", Database: "src.db", DB: src}, out); err != nil {
+		t.Fatalf("RunBackup: %v", err)
+	}
+	head, _ := os.ReadFile(out)
+	if got := DetectDumpType(head); got != "sqlite" {
+		t.Errorf("DetectDumpType = %q", got)
+	}
+	dst := open("dst.db")
+	// one pooled connection, so the check below sees the connection the restore ran on
+	dstDB, _ := dst.DB()
+	dstDB.SetMaxOpenConns(1)
+	dst.Exec("PRAGMA foreign_keys=ON")
+	if err := RunRestore(ctx, &Conn{Type: "sqlite", Database: "dst.db", DB: dst}, out); err != nil {
+		t.Fatalf("RunRestore: %v", err)
+	}
+	var fk int
+	if dst.Raw("PRAGMA foreign_keys").Scan(&fk); fk != 1 {
+		t.Error("foreign_keys left off after the restore")
+	}
+	var rows []struct {
+		ID int
+		S  *string
+		F  *float64
+		B  []byte
+	}
+	if err := dst.Raw(`SELECT id, s, f, b FROM "a b" ORDER BY id`).Scan(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].S == nil || *rows[0].S != "it's; a\nline" || *rows[0].F != 1.5 ||
+		string(rows[0].B) != "\x00\xff" || rows[1].S != nil {
+		t.Errorf("restored rows = %+v", rows)
+	}
+	var n int
+	dst.Raw(`SELECT count(*) FROM sqlite_master WHERE name IN ('idx_s', 'v')`).Scan(&n)
+	if n != 2 {
+		t.Errorf("restored %d of the index and view", n)
+	}
+	dst.Raw(`SELECT seq FROM sqlite_sequence WHERE name = 'a b'`).Scan(&n)
+	if n != 2 {
+		t.Errorf("sqlite_sequence = %d, want 2", n)
+	}
+
+	if err := RunBackup(ctx, &Conn{Type: "sqlite", Database: "src.db", Mode: ModeData, DB: src}, out); err != nil {
+		t.Fatalf("RunBackup data: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if strings.Contains(string(data), "CREATE ") || !strings.Contains(string(data), `INSERT INTO "a b" VALUES(1,`) {
+		t.Errorf("data-only dump:\n%s", data)
+	}
+
+	// a failing script leaves no open transaction behind
+	bad := filepath.Join(dir, "bad.sql")
+	_ = os.WriteFile(bad, []byte("BEGIN TRANSACTION;\nINSERT INTO missing VALUES(1);\nCOMMIT;\n"), 0o644)
+	if err := RunRestore(ctx, &Conn{Type: "sqlite", Database: "dst.db", DB: dst}, bad); err == nil {
+		t.Error("expected error restoring into a missing table")
+	}
+	if err := dst.Exec("BEGIN").Error; err != nil {
+		t.Errorf("transaction left open: %v", err)
+	}
+	dst.Exec("ROLLBACK")
+}
+
+func TestMySQLHostArgs(t *testing.T) {
+	if got := strings.Join(mysqlHostArgs(&Conn{Host: "db", Port: 3307}), " "); got != "-h db -P 3307" {
+		t.Errorf("tcp args = %q", got)
+	}
+	if got := strings.Join(mysqlHostArgs(&Conn{Host: "/run/mysqld/mysqld.sock", Port: 3306}), " "); got != "--socket=/run/mysqld/mysqld.sock" {
+		t.Errorf("socket args = %q", got)
+	}
+}
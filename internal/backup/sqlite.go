@@ -0,0 +1,220 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sqliteTimeFormat matches the first layout go-sqlite3 accepts back when reading a time column.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// sqliteObject is a row of sqlite_master.
+type sqliteObject struct {
+	typ, name, sql string
+}
+
+// dumpSQLite writes a sqlite3 .dump style script of the database behind g to out, without the sqlite3 CLI.
+// Tables are created and filled first; indexes, views and triggers follow so inserts do not fire triggers.
+func dumpSQLite(ctx context.Context, g *gorm.DB, mode, out string) error {
+	sqlDB, err := g.DB()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return fmt.Errorf("create backup file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	err = writeSQLiteDump(ctx, sqlDB, mode, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(out)
+		return fmt.Errorf("sqlite dump: %w", err)
+	}
+	return nil
+}
+
+func writeSQLiteDump(ctx context.Context, sqlDB *sql.DB, mode string, w io.Writer) error {
+	objs, err := sqliteObjects(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+	if mode != ModeSchema {
+		if mode != ModeData {
+			_, _ = io.WriteString(w, "PRAGMA foreign_keys=OFF;\n")
+		}
+		_, _ = io.WriteString(w, "BEGIN TRANSACTION;\n")
+	}
+	hasSequence := false
+	for _, o := range objs {
+		if o.typ != "table" {
+			continue
+		}
+		if o.name == "sqlite_sequence" {
+			hasSequence = true
+			continue
+		}
+		if mode != ModeData {
+			if _, err := fmt.Fprintf(w, "%s;\n", o.sql); err != nil {
+				return err
+			}
+		}
+		// virtual tables keep their rows in shadow tables, which are dumped themselves
+		if mode == ModeSchema || strings.HasPrefix(strings.ToUpper(o.sql), "CREATE VIRTUAL TABLE") {
+			continue
+		}
+		if err := writeSQLiteRows(ctx, sqlDB, o.name, w); err != nil {
+			return err
+		}
+	}
+	if hasSequence && mode != ModeSchema {
+		_, _ = io.WriteString(w, "DELETE FROM sqlite_sequence;\n")
+		if err := writeSQLiteRows(ctx, sqlDB, "sqlite_sequence", w); err != nil {
+			return err
+		}
+	}
+	if mode != ModeData {
+		for _, o := range objs {
+			if o.typ == "table" {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%s;\n", o.sql); err != nil {
+				return err
+			}
+		}
+	}
+	if mode != ModeSchema {
+		_, _ = io.WriteString(w, "COMMIT;\n")
+	}
+	return nil
+}
+
+// sqliteObjects lists the schema objects that have SQL, skipping autoindexes and internal tables other than sqlite_sequence.
+func sqliteObjects(ctx context.Context, sqlDB *sql.DB) ([]sqliteObject, error) {
+	rows, err := sqlDB.QueryContext(ctx,
+		`SELECT type, name, sql FROM sqlite_master
+		 WHERE sql IS NOT NULL AND (name NOT LIKE 'sqlite\_%' ESCAPE '\' OR name = 'sqlite_sequence')
+		 ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var objs []sqliteObject
+	for rows.Next() {
+		var o sqliteObject
+		if err := rows.Scan(&o.typ, &o.name, &o.sql); err != nil {
+			return nil, err
+		}
+		objs = append(objs, o)
+	}
+	return objs, rows.Err()
+}
+
+func writeSQLiteRows(ctx context.Context, sqlDB *sql.DB, table string, w io.Writer) error {
+	quoted := `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+	rows, err := sqlDB.QueryContext(ctx, "SELECT * FROM "+quoted)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	vals := make([]interface{}, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	var line strings.Builder
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		line.Reset()
+		line.WriteString("INSERT INTO ")
+		line.WriteString(quoted)
+		line.WriteString(" VALUES(")
+		for i, v := range vals {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			line.WriteString(sqliteLiteral(v))
+		}
+		line.WriteString(");\n")
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sqliteLiteral renders a scanned value as a SQLite literal.
+func sqliteLiteral(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case []byte:
+		return "X'" + hex.EncodeToString(x) + "'"
+	case time.Time:
+		return "'" + x.Format(sqliteTimeFormat) + "'"
+	case string:
+		return "'" + strings.ReplaceAll(x, "'", "''") + "'"
+	default:
+		return "'" + strings.ReplaceAll(fmt.Sprint(x), "'", "''") + "'"
+	}
+}
+
+// restoreSQLite runs the script at fpath through g on a single connection, rolling back an unfinished transaction on failure.
+// Dumps turn foreign_keys off, so its previous value is restored before the connection goes back to the pool.
+func restoreSQLite(ctx context.Context, g *gorm.DB, fpath string) error {
+	script, err := os.ReadFile(fpath)
+	if err != nil {
+		return fmt.Errorf("open backup file: %w", err)
+	}
+	sqlDB, err := g.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("sqlite restore: %w", err)
+	}
+	defer conn.Close()
+	var foreignKeys int
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return fmt.Errorf("sqlite restore: %w", err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA foreign_keys=%d", foreignKeys))
+	}()
+	if _, err := conn.ExecContext(ctx, string(script)); err != nil {
+		_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+		return fmt.Errorf("sqlite restore: %w", err)
+	}
+	return nil
+}
@@ -1,11 +1,15 @@
 package db
 
 import (
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 // testdbPath returns path to testdb (project root/testdb/...). Resolves relative to package dir so it works when go test runs from tmp.
@@ -49,7 +53,32 @@ func TestBuildDSN(t *testing.T) {
 		t.Fatal("expected non-empty DSN")
 	}
 	if dsn != "root:secret@tcp(127.0.0.1:3306)/mydb?charset=utf8mb4&parseTime=True&loc=Local" {
-		t.Logf("DSN: %s", dsn)
+		t.Errorf("mysql DSN = %q", dsn)
+	}
+	if got := MultiStatementDSN(dsn); got != dsn+"&multiStatements=true" {
+		t.Errorf("MultiStatementDSN = %q", got)
+	}
+
+	dir, err := os.MkdirTemp("", "sock") // socket paths are limited to ~100 bytes
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "mysqld.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+	dsn, err = BuildDSN("mysql", sock, 3306, "root", "secret", "mydb")
+	if err != nil || dsn != "root:secret@unix("+sock+")/mydb?charset=utf8mb4&parseTime=True&loc=Local" {
+		t.Errorf("mysql socket DSN = %q, %v", dsn, err)
+	}
+	if _, err := BuildDSN("mysql", filepath.Join(dir, "missing.sock"), 0, "root", "", ""); err == nil {
+		t.Error("missing socket accepted")
+	}
+	if _, err := BuildDSN("mysql", dir, 0, "root", "", ""); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("directory as socket: %v", err)
 	}
 
 	dsn, err = BuildDSN("postgresql", "127.0.0.1", 5432, "u", "p", "testdb")
@@ -114,3 +143,264 @@ func TestPingSQLite(t *testing.T) {
 		t.Errorf("Ping sqlite %q: %v", dsn, err)
 	}
 }
+
+func TestSQLiteURI(t *testing.T) {
+	tests := []struct {
+		dsn    string
+		params []string
+		want   string
+	}{
+		{"testdb/realm.db", nil, "testdb/realm.db"},
+		{"testdb/realm.db", []string{"mode", "ro"}, "file:testdb/realm.db?mode=ro"},
+		{"a?b#c%.db", []string{"mode", "ro", "immutable", "1"}, "file:a%3fb%23c%25.db?mode=ro&immutable=1"},
+		{"file:x.db?cache=shared", []string{"mode", "ro"}, "file:x.db?cache=shared&mode=ro"},
+	}
+	for _, tt := range tests {
+		if got := SQLiteURI(tt.dsn, tt.params...); got != tt.want {
+			t.Errorf("SQLiteURI(%q, %v) = %q, want %q", tt.dsn, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestWithDSNOptions(t *testing.T) {
+	my, _ := BuildDSN("mysql", "h", 3306, "u", "p?w", "app")
+	got, err := WithDSNOptions("mysql", my, map[string]string{"readTimeout": "30s", "loc": "UTC", "collation": "utf8mb4_bin"})
+	want := "u:p?w@tcp(h:3306)/app?charset=utf8mb4&parseTime=True&collation=utf8mb4_bin&loc=UTC&readTimeout=30s"
+	if err != nil || got != want {
+		t.Errorf("mysql = %q, %v; want %q", got, err, want)
+	}
+	pg, _ := BuildDSN("postgres", "h", 5432, "u", "p", "app")
+	got, err = WithDSNOptions("postgres", pg, map[string]string{"connect_timeout": "5", "application_name": `it's`})
+	if err != nil || !strings.HasSuffix(got, ` sslmode=disable application_name='it\'s' connect_timeout='5'`) {
+		t.Errorf("postgres = %q, %v", got, err)
+	}
+	for _, key := range []string{"", "1x", "a b", "a=b", "a&b"} {
+		if _, err := WithDSNOptions("mysql", my, map[string]string{key: "1"}); err == nil {
+			t.Errorf("key %q accepted", key)
+		}
+	}
+}
+
+func TestDSNDriver(t *testing.T) {
+	for _, c := range []struct{ in, driver, dsn string }{
+		{"postgres://u:p@h:5433/app?sslmode=require", "postgresql", "postgres://u:p@h:5433/app?sslmode=require"},
+		{"host=h dbname=app sslmode=require", "postgresql", "host=h dbname=app sslmode=require"},
+		{"mysql://u:p%40w@h/app?tls=true", "mysql", "u:p@w@tcp(h:3306)/app?tls=true"},
+		{"u:p@tcp(h:3307)/app?parseTime=true", "mysql", "u:p@tcp(h:3307)/app?parseTime=true"},
+		{"sqlite:///tmp/a.db", "sqlite", "/tmp/a.db"},
+		{"file:a.db?mode=ro", "sqlite", "file:a.db?mode=ro"},
+	} {
+		driver, dsn, err := DSNDriver(c.in)
+		if err != nil || driver != c.driver || dsn != c.dsn {
+			t.Errorf("DSNDriver(%q) = %q, %q, %v", c.in, driver, dsn, err)
+		}
+	}
+	for _, in := range []string{"", "redis://h", "just text"} {
+		if _, _, err := DSNDriver(in); err == nil {
+			t.Errorf("DSNDriver(%q) accepted", in)
+		}
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	info, err := ParseDSN("postgresql://u:p@db.example:5433/app?sslmode=require")
+	if err != nil || info.Driver != "postgresql" || info.Host != "db.example" || info.Port != 5433 ||
+		info.User != "u" || info.Password != "p" || info.Database != "app" || info.Options["sslmode"] != "require" {
+		t.Errorf("url = %+v, %v", info, err)
+	}
+	info, err = ParseDSN(`host=h port=5432 user=u password='it\'s me' dbname=app`)
+	if err != nil || info.Host != "h" || info.Port != 5432 || info.Password != "it's me" || info.Database != "app" || info.Options != nil {
+		t.Errorf("keywords = %+v, %v", info, err)
+	}
+	info, err = ParseDSN("u:p@ss@tcp(h:3307)/app?charset=latin1")
+	if err != nil || info.Driver != "mysql" || info.Host != "h" || info.Port != 3307 ||
+		info.User != "u" || info.Password != "p@ss" || info.Database != "app" || info.Options["charset"] != "latin1" {
+		t.Errorf("mysql = %+v, %v", info, err)
+	}
+	if _, err := ParseDSN("host='h"); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
+
+func TestClientName(t *testing.T) {
+	if got := ClientName(" a,b:c\n "); got != "a b c" {
+		t.Errorf("ClientName = %q", got)
+	}
+	long := strings.Repeat("é", 40)
+	if got := ClientName(long); len(got) != 62 || got != strings.Repeat("é", 31) {
+		t.Errorf("long name cut to %d bytes: %q", len(got), got)
+	}
+}
+
+func TestSetRetryPolicyBounds(t *testing.T) {
+	retries, delay := OpenRetries, OpenRetryDelay
+	t.Cleanup(func() { OpenRetries, OpenRetryDelay = retries, delay })
+	if err := SetRetryPolicy(0, time.Second); err == nil {
+		t.Error("retries 0 accepted")
+	}
+	if err := SetRetryPolicy(2, MaxOpenRetryDelay+time.Second); err == nil {
+		t.Error("delay above MaxOpenRetryDelay accepted")
+	}
+	if err := SetRetryPolicy(2, MaxOpenRetryDelay); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenBacksOffWithoutLock(t *testing.T) {
+	retries, delay := OpenRetries, OpenRetryDelay
+	t.Cleanup(func() { OpenRetries, OpenRetryDelay = retries, delay })
+	if err := SetRetryPolicy(2, 500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		// Nothing listens on port 1, so both attempts fail at once and Open spends its time in the backoff.
+		_, err := Open("unreachable", "", "postgresql", "host=127.0.0.1 port=1 user=u password=p dbname=d sslmode=disable connect_timeout=1")
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	Get("other", "")
+	Close("other", "")
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("cache calls blocked for %s while Open was backing off", elapsed)
+	}
+	if err := <-done; err == nil {
+		t.Error("Open of an unreachable server succeeded")
+	}
+}
+
+func TestResetPool(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "pool.db")
+	old, err := Open("reset", "s1", "sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close("reset", "s1")
+	if err := old.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := ResetPool("reset", "s1"); err != nil {
+		t.Fatalf("ResetPool: %v", err)
+	}
+	fresh, ok := Get("reset", "s1")
+	if !ok || fresh == old {
+		t.Fatal("pool was not replaced")
+	}
+	if sqlDB, _ := old.DB(); sqlDB.Ping() == nil {
+		t.Error("old pool still open")
+	}
+	if err := fresh.Exec("INSERT INTO t VALUES (1)").Error; err != nil {
+		t.Errorf("fresh pool: %v", err)
+	}
+	// once closed there is nothing to reset, and nothing is reopened
+	Close("reset", "s1")
+	if err := ResetPool("reset", "s1"); err != nil {
+		t.Error(err)
+	}
+	if _, ok := Get("reset", "s1"); ok {
+		t.Error("ResetPool reopened a closed pool")
+	}
+}
+
+func TestPingCachedEvictsDeadPools(t *testing.T) {
+	live, err := Open("keepalive", "live", "sqlite", filepath.Join(t.TempDir(), "live.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close("keepalive", "live")
+	dead, err := Open("keepalive", "dead", "sqlite", filepath.Join(t.TempDir(), "dead.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close("keepalive", "dead")
+	// stands in for a connection the server dropped
+	sqlDB, _ := dead.DB()
+	sqlDB.Close()
+
+	if n := PingCached(time.Second); n != 1 {
+		t.Errorf("evicted %d pools, want 1", n)
+	}
+	if _, ok := Get("keepalive", "dead"); ok {
+		t.Error("dead pool still cached")
+	}
+	if g, ok := Get("keepalive", "live"); !ok || g != live {
+		t.Error("live pool was evicted")
+	}
+}
+
+func TestSetPoolTimeouts(t *testing.T) {
+	idle, lifetime := ConnMaxIdleTime, ConnMaxLifetime
+	t.Cleanup(func() { ConnMaxIdleTime, ConnMaxLifetime = idle, lifetime })
+	if err := SetPoolTimeouts(-time.Second, time.Minute); err == nil {
+		t.Error("negative idle time accepted")
+	}
+	if err := SetPoolTimeouts(time.Minute, MaxPoolTimeout+time.Second); err == nil {
+		t.Error("lifetime above MaxPoolTimeout accepted")
+	}
+	if err := SetPoolTimeouts(time.Minute, time.Hour); err != nil || ConnMaxIdleTime != time.Minute || ConnMaxLifetime != time.Hour {
+		t.Errorf("SetPoolTimeouts: %v (%s, %s)", err, ConnMaxIdleTime, ConnMaxLifetime)
+	}
+}
+
+func TestSetStatementHooks(t *testing.T) {
+	var started, finished int
+	SetStatementHooks(func(*gorm.DB) { started++ }, func(*gorm.DB) { finished++ })
+	t.Cleanup(func() { SetStatementHooks(nil, nil) })
+	g, err := Open("hooks", "", "sqlite", filepath.Join(t.TempDir(), "hooks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close("hooks", "")
+	if err := g.Exec("CREATE TABLE t (id INTEGER)").Error; err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := g.Raw("SELECT COUNT(*) FROM t").Scan(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	if started != 2 || finished != 2 {
+		t.Errorf("hooks ran %d/%d times, want 2/2", started, finished)
+	}
+}
+
+func TestOpenDiscardsPoolClosedWhileDialing(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "gen.db")
+	for _, closeFn := range []func(){
+		func() { Close("gen", "s1") },
+		func() { CloseConnection("gen") },
+		CloseAll,
+	} {
+		mu.RLock()
+		gen := openGenLocked("gen", cacheKey("gen", "s1"))
+		mu.RUnlock()
+		g, err := openOnce("sqlite", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the connection is closed (e.g. updated or deleted) while the pool above was being dialed
+		closeFn()
+		if _, err := storeOpened("gen", cacheKey("gen", "s1"), g, poolSource{driver: "sqlite", dsn: dsn}, gen); err == nil {
+			t.Error("pool dialed before the close was cached")
+		}
+		if _, ok := Get("gen", "s1"); ok {
+			t.Error("stale pool cached")
+		}
+		if sqlDB, _ := g.DB(); sqlDB.Ping() == nil {
+			t.Error("stale pool left open")
+		}
+	}
+	// closing another session does not affect the dial
+	mu.RLock()
+	gen := openGenLocked("gen", cacheKey("gen", "s1"))
+	mu.RUnlock()
+	g, err := openOnce("sqlite", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Close("gen", "s2")
+	if got, err := storeOpened("gen", cacheKey("gen", "s1"), g, poolSource{driver: "sqlite", dsn: dsn}, gen); err != nil || got != g {
+		t.Errorf("storeOpened after closing another session: %v", err)
+	}
+	Close("gen", "s1")
+}
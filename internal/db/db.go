@@ -1,11 +1,20 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -16,8 +25,15 @@ import (
 // PoolConfig holds connection pool settings (defaults used when opening).
 var (
 	connCache = make(map[string]*gorm.DB)
+	sources   = make(map[string]poolSource) // same keys as connCache; how each pool was opened, for ResetPool
 	mu        sync.RWMutex
 
+	// closeGen counts Close calls per cache key and CloseConnection calls per connection (under connGenKey);
+	// closeAllGen counts CloseAll calls. Open compares them before and after dialing, so a pool dialed while its
+	// connection was closed (updated, deleted, disconnected) is discarded instead of cached.
+	closeGen    = make(map[string]uint64)
+	closeAllGen uint64
+
 	// Default pool settings: balanced for desktop app with multiple connections.
 	MaxIdleConns    = 5
 	MaxOpenConns    = 20
@@ -25,8 +41,64 @@ var (
 	ConnMaxIdleTime = 5 * time.Minute  // close idle connections after 5m (helps with server-side idle timeout)
 	OpenRetries     = 4                // total attempts (1 initial + 3 retries)
 	OpenRetryDelay  = time.Second      // backoff base: 1s, 2s, 4s
+
+	// statementStart and statementFinish run around every statement of pools opened by Open; see SetStatementHooks.
+	statementStart, statementFinish func(*gorm.DB)
 )
 
+// SetStatementHooks sets callbacks that run before and after every statement on pools opened from then on. They
+// are registered when a pool is created, before it is shared, since gorm does not synchronize callback changes
+// with running statements. Call it once at startup, before the first Open.
+func SetStatementHooks(start, finish func(*gorm.DB)) {
+	mu.Lock()
+	defer mu.Unlock()
+	statementStart, statementFinish = start, finish
+}
+
+// MaxOpenRetries bounds SetRetryPolicy; the backoff doubles per attempt, so more would mean waiting for hours.
+const MaxOpenRetries = 10
+
+// MaxOpenRetryDelay caps both the base delay accepted by SetRetryPolicy and each doubled backoff step.
+const MaxOpenRetryDelay = 30 * time.Second
+
+// SetRetryPolicy sets how many attempts Open makes to connect (1 = fail on the first error) and the delay before
+// the second one, doubled for each further attempt. SQLite ignores it: file errors are returned at once.
+func SetRetryPolicy(retries int, baseDelay time.Duration) error {
+	if retries < 1 || retries > MaxOpenRetries {
+		return fmt.Errorf("retries must be between 1 and %d", MaxOpenRetries)
+	}
+	if baseDelay < 0 || baseDelay > MaxOpenRetryDelay {
+		return fmt.Errorf("retry delay must be between 0 and %s", MaxOpenRetryDelay)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	OpenRetries = retries
+	OpenRetryDelay = baseDelay
+	return nil
+}
+
+// MaxPoolTimeout bounds the durations accepted by SetPoolTimeouts.
+const MaxPoolTimeout = 24 * time.Hour
+
+// SetPoolTimeouts sets after how long an idle pooled connection is closed and how long a connection is reused at
+// most (0 = no limit, as in database/sql). Open pools are updated too.
+func SetPoolTimeouts(idle, lifetime time.Duration) error {
+	if idle < 0 || idle > MaxPoolTimeout || lifetime < 0 || lifetime > MaxPoolTimeout {
+		return fmt.Errorf("pool timeouts must be between 0 and %s", MaxPoolTimeout)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	ConnMaxIdleTime = idle
+	ConnMaxLifetime = lifetime
+	for _, g := range connCache {
+		if sqlDB, err := g.DB(); err == nil {
+			sqlDB.SetConnMaxIdleTime(idle)
+			sqlDB.SetConnMaxLifetime(lifetime)
+		}
+	}
+	return nil
+}
+
 // cacheKey returns the map key for connection cache. Empty sessionID means shared connection per connID.
 func cacheKey(connID, sessionID string) string {
 	if sessionID == "" {
@@ -35,7 +107,17 @@ func cacheKey(connID, sessionID string) string {
 	return connID + "\x00" + sessionID
 }
 
+// MultiStatementDSN returns a MySQL DSN that lets a script return several result sets (see RawSelectMultiLimit).
+// Only script pools should use it: with it, any Exec or Raw call runs every statement in the string.
+func MultiStatementDSN(dsn string) string {
+	if strings.Contains(dsn, "?") {
+		return dsn + "&multiStatements=true"
+	}
+	return dsn + "?multiStatements=true"
+}
+
 // BuildDSN builds DSN for mysql, postgresql, or sqlite. For sqlite, host is unused; database is the file path.
+// A MySQL host starting with "/" is the path of the server's Unix socket (port is unused); it must exist.
 func BuildDSN(driver, host string, port int, user, pass, database string) (string, error) {
 	switch driver {
 	case "mysql":
@@ -43,8 +125,15 @@ func BuildDSN(driver, host string, port int, user, pass, database string) (strin
 		if db == "" {
 			db = "mysql"
 		}
-		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			user, pass, host, port, db), nil
+		if strings.HasPrefix(host, "/") {
+			if err := checkUnixSocket(host); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s:%s@unix(%s)/%s?charset=%s&parseTime=True&loc=Local",
+				user, pass, host, db, DefaultMySQLCharset), nil
+		}
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+			user, pass, host, port, db, DefaultMySQLCharset), nil
 	case "postgresql", "postgres":
 		db := database
 		if db == "" {
@@ -71,30 +160,449 @@ func BuildDSN(driver, host string, port int, user, pass, database string) (strin
 	}
 }
 
+// checkUnixSocket reports an error unless path is an existing Unix socket.
+func checkUnixSocket(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("MySQL socket %s: %w", path, err)
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("MySQL socket %s is not a socket", path)
+	}
+	return nil
+}
+
+// DefaultSQLiteBusyTimeout is how long (ms) a SQLite connection waits for a lock held by another
+// connection or process before failing with "database is locked".
+const DefaultSQLiteBusyTimeout = 5000
+
+// SQLiteJournalMode validates a journal_mode name and returns it upper-cased.
+func SQLiteJournalMode(mode string) (string, error) {
+	switch m := strings.ToUpper(strings.TrimSpace(mode)); m {
+	case "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid SQLite journal mode: %s", mode)
+}
+
+// DefaultMySQLCharset is the charset BuildDSN asks MySQL to use for the connection.
+const DefaultMySQLCharset = "utf8mb4"
+
+// Charset validates a connection charset / client encoding name (e.g. "latin1", "UTF8") and returns it trimmed.
+func Charset(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) >= 0 {
+		return "", fmt.Errorf("invalid charset: %q", name)
+	}
+	return name, nil
+}
+
+// DefaultApplicationName identifies this client in pg_stat_activity and MySQL's session_connect_attrs.
+const DefaultApplicationName = "topology"
+
+// ClientName cleans a client name for application_name / program_name: control characters and the ',' and ':'
+// separators of MySQL connection attributes become spaces, and it is cut to the 63 bytes PostgreSQL keeps.
+func ClientName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f || r == ',' || r == ':' {
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(name))
+	for len(name) > 63 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return strings.TrimSpace(name)
+}
+
+// WithClientName reports name to the server: application_name on PostgreSQL, the program_name connection
+// attribute on MySQL. A DSN that already sets either (e.g. from custom options) is left as is.
+func WithClientName(driver, dsn, name string) (string, error) {
+	name = ClientName(name)
+	if name == "" {
+		return dsn, nil
+	}
+	switch driver {
+	case "mysql":
+		if strings.Contains(dsn, "&connectionAttributes=") || strings.Contains(dsn, "?connectionAttributes=") {
+			return dsn, nil
+		}
+		return WithDSNOptions(driver, dsn, map[string]string{"connectionAttributes": "program_name:" + name})
+	case "postgresql", "postgres":
+		if strings.Contains(dsn, " application_name=") {
+			return dsn, nil
+		}
+		return WithDSNOptions(driver, dsn, map[string]string{"application_name": name})
+	default:
+		return dsn, nil
+	}
+}
+
+// DSNOptionKey validates the name of a custom DSN parameter (e.g. "readTimeout", "application_name").
+func DSNOptionKey(key string) error {
+	if key == "" || key[0] >= '0' && key[0] <= '9' || strings.IndexFunc(key, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.')
+	}) >= 0 {
+		return fmt.Errorf("invalid DSN parameter: %q", key)
+	}
+	return nil
+}
+
+// WithDSNOptions merges custom parameters into a MySQL or PostgreSQL DSN from BuildDSN. A parameter already in
+// the DSN (e.g. MySQL's loc) is replaced. Keys are applied in sorted order so the result is stable.
+func WithDSNOptions(driver, dsn string, opts map[string]string) (string, error) {
+	if len(opts) == 0 {
+		return dsn, nil
+	}
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		if err := DSNOptionKey(k); err != nil {
+			return "", err
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	switch driver {
+	case "mysql":
+		base, query := dsn, ""
+		if i := strings.LastIndex(dsn, "?"); i >= 0 {
+			base, query = dsn[:i], dsn[i+1:]
+		}
+		var params []string
+		for _, p := range strings.Split(query, "&") {
+			if _, ok := opts[strings.SplitN(p, "=", 2)[0]]; p != "" && !ok {
+				params = append(params, p)
+			}
+		}
+		for _, k := range keys {
+			params = append(params, k+"="+url.QueryEscape(opts[k]))
+		}
+		return base + "?" + strings.Join(params, "&"), nil
+	case "postgresql", "postgres":
+		// Later keywords win in a keyword/value DSN, so appending overrides sslmode and friends.
+		esc := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+		for _, k := range keys {
+			dsn += " " + k + "='" + esc.Replace(opts[k]) + "'"
+		}
+		return dsn, nil
+	default:
+		return "", fmt.Errorf("DSN parameters are not supported for %s", driver)
+	}
+}
+
+// SQLiteURI turns a SQLite DSN from BuildDSN into a file: URI carrying params (e.g. mode=ro), appending to any
+// query string already present. Params are added in the given order as key, value pairs.
+func SQLiteURI(dsn string, params ...string) string {
+	if len(params) == 0 {
+		return dsn
+	}
+	if !strings.HasPrefix(dsn, "file:") {
+		// '?' and '#' would otherwise start the query string or fragment of the URI
+		dsn = "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(dsn)
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	var b strings.Builder
+	b.WriteString(dsn)
+	for i := 0; i+1 < len(params); i += 2 {
+		b.WriteString(sep + url.QueryEscape(params[i]) + "=" + url.QueryEscape(params[i+1]))
+		sep = "&"
+	}
+	return b.String()
+}
+
+// DSNInfo is a connection string split into the fields of a connection (see ParseDSN).
+type DSNInfo struct {
+	Driver   string
+	Host     string
+	Port     int
+	User     string
+	Password string
+	// Database is the database name, or the file path (or file: URI) for SQLite.
+	Database string
+	// Options are the remaining parameters, e.g. sslmode or tls.
+	Options map[string]string
+}
+
+// DSNDriver detects the driver of a pasted connection string and returns the DSN to open it with:
+//   - postgres:// and postgresql:// URLs and key=value strings ("host=... dbname=...") are passed to PostgreSQL as is;
+//   - mysql:// URLs become the go-sql-driver form, which is itself accepted as is ("user:pass@tcp(host:3306)/db");
+//   - sqlite:// and sqlite: lose the scheme, and file: URIs are passed to SQLite as is.
+func DSNDriver(dsn string) (driver, driverDSN string, err error) {
+	dsn = strings.TrimSpace(dsn)
+	lower := strings.ToLower(dsn)
+	switch {
+	case dsn == "":
+		return "", "", fmt.Errorf("empty connection string")
+	case strings.HasPrefix(lower, "postgres://"), strings.HasPrefix(lower, "postgresql://"):
+		return "postgresql", dsn, nil
+	case strings.HasPrefix(lower, "mysql://"):
+		info, err := ParseDSN(dsn)
+		if err != nil {
+			return "", "", err
+		}
+		return "mysql", mysqlNativeDSN(info), nil
+	case strings.HasPrefix(lower, "sqlite://"):
+		return "sqlite", dsn[len("sqlite://"):], nil
+	case strings.HasPrefix(lower, "sqlite3://"):
+		return "sqlite", dsn[len("sqlite3://"):], nil
+	case strings.HasPrefix(lower, "sqlite:"):
+		return "sqlite", dsn[len("sqlite:"):], nil
+	case strings.HasPrefix(lower, "file:"):
+		return "sqlite", dsn, nil
+	case isMySQLNativeDSN(dsn):
+		return "mysql", dsn, nil
+	case isPgKeywordDSN(dsn):
+		return "postgresql", dsn, nil
+	}
+	return "", "", fmt.Errorf("unrecognized connection string: use a postgres://, mysql:// or sqlite:// URL")
+}
+
+// isMySQLNativeDSN reports whether dsn looks like "[user[:pass]@][tcp(host:port)]/db[?params]".
+func isMySQLNativeDSN(dsn string) bool {
+	for _, s := range []string{"@tcp(", "@unix(", "@/"} {
+		if strings.Contains(dsn, s) {
+			return true
+		}
+	}
+	return strings.HasPrefix(dsn, "tcp(") || strings.HasPrefix(dsn, "unix(")
+}
+
+// isPgKeywordDSN reports whether dsn is a libpq key=value string naming a host or database.
+func isPgKeywordDSN(dsn string) bool {
+	params, err := parsePgKeywords(dsn)
+	if err != nil {
+		return false
+	}
+	_, host := params["host"]
+	_, name := params["dbname"]
+	return host || name
+}
+
+// ParseDSN splits a connection string accepted by DSNDriver into its fields, e.g. to fill in a connection form.
+func ParseDSN(dsn string) (DSNInfo, error) {
+	dsn = strings.TrimSpace(dsn)
+	lower := strings.ToLower(dsn)
+	if strings.HasPrefix(lower, "mysql://") || strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") {
+		return parseDSNURL(dsn)
+	}
+	driver, driverDSN, err := DSNDriver(dsn)
+	if err != nil {
+		return DSNInfo{}, err
+	}
+	switch driver {
+	case "sqlite":
+		return DSNInfo{Driver: driver, Database: driverDSN}, nil
+	case "mysql":
+		return parseMySQLNativeDSN(dsn)
+	}
+	params, err := parsePgKeywords(dsn)
+	if err != nil {
+		return DSNInfo{}, err
+	}
+	info := DSNInfo{Driver: driver, Host: params["host"], User: params["user"], Password: params["password"], Database: params["dbname"]}
+	if p := params["port"]; p != "" {
+		if info.Port, err = strconv.Atoi(p); err != nil {
+			return DSNInfo{}, fmt.Errorf("invalid port: %q", p)
+		}
+	}
+	for _, k := range []string{"host", "port", "user", "password", "dbname"} {
+		delete(params, k)
+	}
+	if len(params) > 0 {
+		info.Options = params
+	}
+	return info, nil
+}
+
+// parseDSNURL parses a mysql:// or postgres(ql):// URL.
+func parseDSNURL(dsn string) (DSNInfo, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return DSNInfo{}, fmt.Errorf("invalid connection string: %w", err)
+	}
+	info := DSNInfo{Driver: "mysql", Host: u.Hostname(), Database: strings.TrimPrefix(u.Path, "/")}
+	if u.Scheme != "mysql" {
+		info.Driver = "postgresql"
+	}
+	if p := u.Port(); p != "" {
+		if info.Port, err = strconv.Atoi(p); err != nil {
+			return DSNInfo{}, fmt.Errorf("invalid port: %q", p)
+		}
+	}
+	if u.User != nil {
+		info.User = u.User.Username()
+		info.Password, _ = u.User.Password()
+	}
+	if q := u.Query(); len(q) > 0 {
+		info.Options = make(map[string]string, len(q))
+		for k, v := range q {
+			info.Options[k] = v[len(v)-1]
+		}
+	}
+	return info, nil
+}
+
+// parseMySQLNativeDSN parses the go-sql-driver form "[user[:pass]@][net[(addr)]]/db[?params]". Like the driver,
+// it splits at the last '/' and the last '@' before it, so a password may contain both.
+func parseMySQLNativeDSN(dsn string) (DSNInfo, error) {
+	info := DSNInfo{Driver: "mysql"}
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return DSNInfo{}, fmt.Errorf("invalid MySQL DSN: missing /database")
+	}
+	addr, rest := dsn[:slash], dsn[slash+1:]
+	if at := strings.LastIndex(addr, "@"); at >= 0 {
+		info.User, info.Password, _ = strings.Cut(addr[:at], ":")
+		addr = addr[at+1:]
+	}
+	if open := strings.Index(addr, "("); open >= 0 && strings.HasSuffix(addr, ")") {
+		host := addr[open+1 : len(addr)-1]
+		if addr[:open] == "tcp" {
+			if h, p, ok := strings.Cut(host, ":"); ok {
+				port, err := strconv.Atoi(p)
+				if err != nil {
+					return DSNInfo{}, fmt.Errorf("invalid port: %q", p)
+				}
+				host, info.Port = h, port
+			}
+		}
+		info.Host = host
+	}
+	info.Database, rest, _ = strings.Cut(rest, "?")
+	if rest != "" {
+		q, err := url.ParseQuery(rest)
+		if err != nil {
+			return DSNInfo{}, fmt.Errorf("invalid MySQL DSN parameters: %w", err)
+		}
+		info.Options = make(map[string]string, len(q))
+		for k, v := range q {
+			info.Options[k] = v[len(v)-1]
+		}
+	}
+	return info, nil
+}
+
+// mysqlNativeDSN builds the go-sql-driver form of info, keeping its options as they are.
+func mysqlNativeDSN(info DSNInfo) string {
+	var b strings.Builder
+	if info.User != "" || info.Password != "" {
+		b.WriteString(info.User)
+		if info.Password != "" {
+			b.WriteString(":" + info.Password)
+		}
+		b.WriteString("@")
+	}
+	if info.Host != "" {
+		port := info.Port
+		if port <= 0 {
+			port = 3306
+		}
+		fmt.Fprintf(&b, "tcp(%s)", net.JoinHostPort(info.Host, strconv.Itoa(port)))
+	}
+	b.WriteString("/" + info.Database)
+	if len(info.Options) > 0 {
+		keys := make([]string, 0, len(info.Options))
+		for k := range info.Options {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			sep := "&"
+			if i == 0 {
+				sep = "?"
+			}
+			b.WriteString(sep + k + "=" + url.QueryEscape(info.Options[k]))
+		}
+	}
+	return b.String()
+}
+
+// parsePgKeywords parses a libpq key=value connection string; values may be single-quoted with \ escapes.
+func parsePgKeywords(dsn string) (map[string]string, error) {
+	params := make(map[string]string)
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid connection string near %q", s)
+		}
+		key := strings.TrimSpace(s[:eq])
+		if strings.ContainsAny(key, " \t'") {
+			return nil, fmt.Errorf("invalid connection string near %q", s)
+		}
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var val strings.Builder
+		if strings.HasPrefix(s, "'") {
+			i, closed := 1, false
+			for ; i < len(s); i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+					val.WriteByte(s[i])
+				} else if s[i] == '\'' {
+					closed = true
+					break
+				} else {
+					val.WriteByte(s[i])
+				}
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted value for %s", key)
+			}
+			s = s[i+1:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			val.WriteString(s[:end])
+			s = s[end:]
+		}
+		params[key] = val.String()
+		s = strings.TrimLeft(s, " \t")
+	}
+	return params, nil
+}
+
 // Open opens a DB and caches it by connID and optional sessionID. Uses retry with backoff on transient failure.
-// When sessionID is non-empty, the connection is isolated per tab/session.
-func Open(connID, sessionID, driver, dsn string) (*gorm.DB, error) {
+// When sessionID is non-empty, the connection is isolated per tab/session. For SQLite, attach lists database
+// files attached to every connection of the new pool; a cached pool is returned as is, so close it first to
+// change them.
+func Open(connID, sessionID, driver, dsn string, attach ...Attachment) (*gorm.DB, error) {
 	key := cacheKey(connID, sessionID)
-	mu.Lock()
-	defer mu.Unlock()
-	if cached, ok := connCache[key]; ok {
+	mu.RLock()
+	cached, ok := connCache[key]
+	retries, backoff := OpenRetries, OpenRetryDelay
+	gen := openGenLocked(connID, key)
+	mu.RUnlock()
+	if ok {
 		sqlDB, _ := cached.DB()
 		if sqlDB != nil && sqlDB.Ping() == nil {
 			return cached, nil
 		}
-		delete(connCache, key)
+		mu.Lock()
+		if connCache[key] == cached {
+			delete(connCache, key)
+			delete(sources, key)
+		}
+		mu.Unlock()
 	}
 
+	// Dial and back off without holding mu, so a slow or unreachable server does not block every other connection.
 	var lastErr error
-	backoff := OpenRetryDelay
-	for attempt := 0; attempt < OpenRetries; attempt++ {
+	for attempt := 0; attempt < retries; attempt++ {
 		if attempt > 0 {
 			time.Sleep(backoff)
-			backoff *= 2
+			backoff = min(backoff*2, MaxOpenRetryDelay)
 		}
-		db, err := openOnce(key, driver, dsn)
+		db, err := openOnce(driver, dsn, attach...)
 		if err == nil {
-			return db, nil
+			return storeOpened(connID, key, db, poolSource{driver: driver, dsn: dsn, attach: attach}, gen)
 		}
 		lastErr = err
 		// SQLite file errors usually don't benefit from retry
@@ -106,22 +614,109 @@ func Open(connID, sessionID, driver, dsn string) (*gorm.DB, error) {
 	return nil, lastErr
 }
 
-// openOnce opens a single connection and configures the pool; caller holds mu. key is the cache map key.
-func openOnce(key, driver, dsn string) (*gorm.DB, error) {
+// poolSource is what a cached pool was opened with.
+type poolSource struct {
+	driver string
+	dsn    string
+	attach []Attachment
+}
+
+// connGenKey is the closeGen key counting CloseConnection calls for connID; cache keys never start with NUL.
+func connGenKey(connID string) string {
+	return "\x00" + connID
+}
+
+// openGenLocked snapshots the close counters that apply to the pool under key; caller holds mu.
+func openGenLocked(connID, key string) [3]uint64 {
+	return [3]uint64{closeGen[key], closeGen[connGenKey(connID)], closeAllGen}
+}
+
+// storeOpened caches db under key, unless a concurrent Open got there first: then db is closed and the cached one
+// returned. When the pool was closed since gen was taken, db was dialed for a connection that is gone or changed,
+// so it is closed and an error returned.
+func storeOpened(connID, key string, db *gorm.DB, src poolSource, gen [3]uint64) (*gorm.DB, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	closed := openGenLocked(connID, key) != gen
+	existing, ok := connCache[key]
+	if closed || ok {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+	if closed {
+		return nil, fmt.Errorf("connection was closed while it was being opened")
+	}
+	if ok {
+		return existing, nil
+	}
+	connCache[key] = db
+	sources[key] = src
+	return db, nil
+}
+
+// ResetPool replaces the cached pool of connID and sessionID with a freshly opened one (same DSN) and closes the
+// old one, dropping every pooled connection, e.g. after a server failover left them all dead. The cache entry
+// stays in place, so the session keeps whatever was chosen when it was opened. No-op when nothing is cached.
+// Transactions and rows still open on the old pool fail from then on.
+func ResetPool(connID, sessionID string) error {
+	key := cacheKey(connID, sessionID)
+	mu.RLock()
+	src, ok := sources[key]
+	mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	fresh, err := openOnce(src.driver, src.dsn, src.attach...)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	old, ok := connCache[key]
+	if !ok {
+		// closed while reopening; do not bring it back
+		if sqlDB, err := fresh.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		return nil
+	}
+	connCache[key] = fresh
+	if sqlDB, err := old.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+	return nil
+}
+
+// openOnce opens a single connection and configures the pool; the caller caches it.
+func openOnce(driver, dsn string, attach ...Attachment) (*gorm.DB, error) {
 	var dial gorm.Dialector
+	var attached *sql.DB
 	switch driver {
 	case "mysql":
 		dial = mysql.Open(dsn)
 	case "postgresql", "postgres":
 		dial = postgres.Open(dsn)
 	case "sqlite":
-		dial = sqlite.Open(dsn)
+		if len(attach) == 0 {
+			dial = sqlite.Open(dsn)
+			break
+		}
+		drv, err := sqliteDriver()
+		if err != nil {
+			return nil, err
+		}
+		attached = sql.OpenDB(&attachConnector{drv: drv, dsn: dsn, attach: attach})
+		dial = sqlite.New(sqlite.Config{Conn: attached})
 	default:
 		return nil, fmt.Errorf("unsupported driver: %s", driver)
 	}
 
 	db, err := gorm.Open(dial, &gorm.Config{})
 	if err != nil {
+		if attached != nil {
+			_ = attached.Close()
+		}
 		return nil, err
 	}
 
@@ -129,15 +724,83 @@ func openOnce(key, driver, dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	mu.RLock()
+	lifetime, idle := ConnMaxLifetime, ConnMaxIdleTime
+	start, finish := statementStart, statementFinish
+	mu.RUnlock()
+	if start != nil && finish != nil {
+		registerStatementHooks(db, start, finish)
+	}
 	sqlDB.SetMaxIdleConns(MaxIdleConns)
 	sqlDB.SetMaxOpenConns(MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(ConnMaxLifetime)
-	sqlDB.SetConnMaxIdleTime(ConnMaxIdleTime)
-
-	connCache[key] = db
+	sqlDB.SetConnMaxLifetime(lifetime)
+	sqlDB.SetConnMaxIdleTime(idle)
 	return db, nil
 }
 
+// registerStatementHooks adds start and finish around every kind of statement gorm runs on db.
+func registerStatementHooks(db *gorm.DB, start, finish func(*gorm.DB)) {
+	cb := db.Callback()
+	_ = cb.Create().Before("*").Register("topology:stmt_start", start)
+	_ = cb.Create().After("*").Register("topology:stmt_finish", finish)
+	_ = cb.Query().Before("*").Register("topology:stmt_start", start)
+	_ = cb.Query().After("*").Register("topology:stmt_finish", finish)
+	_ = cb.Update().Before("*").Register("topology:stmt_start", start)
+	_ = cb.Update().After("*").Register("topology:stmt_finish", finish)
+	_ = cb.Delete().Before("*").Register("topology:stmt_start", start)
+	_ = cb.Delete().After("*").Register("topology:stmt_finish", finish)
+	_ = cb.Row().Before("*").Register("topology:stmt_start", start)
+	_ = cb.Row().After("*").Register("topology:stmt_finish", finish)
+	_ = cb.Raw().Before("*").Register("topology:stmt_start", start)
+	_ = cb.Raw().After("*").Register("topology:stmt_finish", finish)
+}
+
+// Attachment is a SQLite database file attached under Alias (ATTACH DATABASE Path AS Alias).
+type Attachment struct {
+	Alias string `json:"alias"`
+	Path  string `json:"path"`
+}
+
+// attachConnector opens SQLite connections with the attachments already in place. ATTACH only affects the
+// connection it runs on, so every connection the pool opens has to repeat it.
+type attachConnector struct {
+	drv    driver.Driver
+	dsn    string
+	attach []Attachment
+}
+
+func (c *attachConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.drv.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	ex, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("sqlite driver cannot attach databases")
+	}
+	for _, a := range c.attach {
+		args := []driver.NamedValue{{Ordinal: 1, Value: a.Path}}
+		if _, err := ex.ExecContext(ctx, "ATTACH DATABASE ? AS "+quoteIdent("sqlite", a.Alias), args); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("attach %s: %w", a.Alias, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *attachConnector) Driver() driver.Driver { return c.drv }
+
+// sqliteDriver returns the driver registered by gorm's SQLite dialector.
+func sqliteDriver() (driver.Driver, error) {
+	d, err := sql.Open(sqlite.DriverName, "")
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	return d.Driver(), nil
+}
+
 // Get returns cached DB for connID and optional sessionID, or nil if not found.
 func Get(connID, sessionID string) (*gorm.DB, bool) {
 	key := cacheKey(connID, sessionID)
@@ -152,21 +815,55 @@ func Close(connID, sessionID string) {
 	key := cacheKey(connID, sessionID)
 	mu.Lock()
 	defer mu.Unlock()
+	closeGen[key]++
 	if db, ok := connCache[key]; ok {
 		if sqlDB, err := db.DB(); err == nil {
 			_ = sqlDB.Close()
 		}
 		delete(connCache, key)
+		delete(sources, key)
 	}
 }
 
+// PingConnection pings the cached pools of connID (all sessions), each within timeout, until one answers.
+// open is false when nothing is cached; err is the last failure when no pool answered.
+func PingConnection(connID string, timeout time.Duration) (open bool, err error) {
+	mu.RLock()
+	var pools []*gorm.DB
+	for k, g := range connCache {
+		if isConnectionKey(k, connID) {
+			pools = append(pools, g)
+		}
+	}
+	mu.RUnlock()
+	for _, g := range pools {
+		sqlDB, e := g.DB()
+		if e == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			e = sqlDB.PingContext(ctx)
+			cancel()
+		}
+		if e == nil {
+			return true, nil
+		}
+		err = e
+	}
+	return len(pools) > 0, err
+}
+
+// isConnectionKey reports whether cache key k belongs to connID (its shared pool or any session).
+func isConnectionKey(k, connID string) bool {
+	return k == connID || (len(k) > len(connID) && k[len(connID)] == '\x00' && k[:len(connID)] == connID)
+}
+
 // CloseConnection closes all cached DBs for this connection (all sessions). Used when connection is deleted or updated.
 func CloseConnection(connID string) {
 	mu.Lock()
 	defer mu.Unlock()
+	closeGen[connGenKey(connID)]++
 	var toDelete []string
 	for k := range connCache {
-		if k == connID || (len(k) > len(connID) && k[len(connID)] == '\x00' && k[:len(connID)] == connID) {
+		if isConnectionKey(k, connID) {
 			toDelete = append(toDelete, k)
 		}
 	}
@@ -176,19 +873,64 @@ func CloseConnection(connID string) {
 				_ = sqlDB.Close()
 			}
 			delete(connCache, k)
+			delete(sources, k)
+		}
+	}
+}
+
+// PingCached pings every cached pool, each within timeout, so that an idle session keeps a live connection
+// instead of finding it killed by the server's idle timeout (MySQL wait_timeout) on the next query. Pools that
+// fail the ping are closed and dropped from the cache, to be reopened by the next Open. Returns how many were.
+func PingCached(timeout time.Duration) int {
+	mu.RLock()
+	pools := make(map[string]*gorm.DB, len(connCache))
+	for k, g := range connCache {
+		pools[k] = g
+	}
+	mu.RUnlock()
+
+	// ping without holding mu, like Open, so one unreachable server does not block every other connection
+	var dead []string
+	for k, g := range pools {
+		sqlDB, err := g.DB()
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			err = sqlDB.PingContext(ctx)
+			cancel()
+		}
+		if err != nil {
+			dead = append(dead, k)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	evicted := 0
+	for _, k := range dead {
+		// skip pools replaced (ResetPool) or closed meanwhile
+		if connCache[k] != pools[k] {
+			continue
+		}
+		if sqlDB, err := pools[k].DB(); err == nil {
+			_ = sqlDB.Close()
 		}
+		delete(connCache, k)
+		delete(sources, k)
+		evicted++
 	}
+	return evicted
 }
 
 // CloseAll closes all cached connections.
 func CloseAll() {
 	mu.Lock()
 	defer mu.Unlock()
+	closeAllGen++
 	for id, db := range connCache {
 		if sqlDB, err := db.DB(); err == nil {
 			_ = sqlDB.Close()
 		}
 		delete(connCache, id)
+		delete(sources, id)
 	}
 }
 
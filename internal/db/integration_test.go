@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -33,13 +34,27 @@ func mysqlDSN(t *testing.T) (string, bool) {
 	return dsn, true
 }
 
+// sqliteDSN returns a temp copy of testdb/realm.db, so tests that write to it leave the checked-in file alone.
 func sqliteDSN(t *testing.T) (string, bool) {
 	path := itestPath("realm.db")
 	if _, err := os.Stat(path); err != nil {
 		t.Skipf("SQLite %s not found: %v", path, err)
 		return "", false
 	}
-	return path, true
+	return copyRealmDB(t, path), true
+}
+
+// copyRealmDB copies the SQLite file at path into a temp dir of tb and returns the copy's path.
+func copyRealmDB(tb testing.TB, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("read %s: %v", path, err)
+	}
+	dst := filepath.Join(tb.TempDir(), filepath.Base(path))
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		tb.Fatalf("copy %s: %v", path, err)
+	}
+	return dst
 }
 
 func postgresDSN(t *testing.T) (string, bool) {
@@ -168,6 +183,135 @@ func TestIntegration_RawSelectSQLite(t *testing.T) {
 	}
 }
 
+func TestIntegration_RawSelectDuplicateColumnsSQLite(t *testing.T) {
+	dsn, ok := sqliteDSN(t)
+	if !ok {
+		return
+	}
+	connID := "itest-sqlite-raw-dup"
+	db, err := Open(connID, "", "sqlite", dsn)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer Close(connID, "")
+
+	set, err := RawSelectResult(db, `SELECT 1 AS a, 2 AS a, 3 AS "", 4 AS a_2`, 0)
+	if err != nil {
+		t.Fatalf("RawSelectResult: %v", err)
+	}
+	want := []string{"a", "a_3", "column_3", "a_2"}
+	if strings.Join(set.Columns, ",") != strings.Join(want, ",") {
+		t.Fatalf("columns = %q, want %q", set.Columns, want)
+	}
+	row := set.Rows[0]
+	for i, c := range want {
+		if row[c] != int64(i+1) {
+			t.Errorf("row[%q] = %v, want %d", c, row[c], i+1)
+		}
+	}
+	if len(set.ColumnTypes) == 4 && set.ColumnTypes[1].Name != "a_3" {
+		t.Errorf("column meta name = %q", set.ColumnTypes[1].Name)
+	}
+}
+
+func TestIntegration_RawSelectLimitSQLite(t *testing.T) {
+	dsn, ok := sqliteDSN(t)
+	if !ok {
+		return
+	}
+	connID := "itest-sqlite-raw-limit"
+	db, err := Open(connID, "", "sqlite", dsn)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer Close(connID, "")
+
+	q := "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 5) SELECT i FROM n"
+	_, rows, truncated, err := RawSelectLimit(db, q, 3)
+	if err != nil {
+		t.Fatalf("RawSelectLimit: %v", err)
+	}
+	if len(rows) != 3 || !truncated {
+		t.Errorf("got %d rows, truncated=%v; want 3, true", len(rows), truncated)
+	}
+	if _, rows, truncated, _ = RawSelectLimit(db, q, 5); len(rows) != 5 || truncated {
+		t.Errorf("exact cap: got %d rows, truncated=%v", len(rows), truncated)
+	}
+}
+
+func TestIntegration_RawSelectMultiMySQL(t *testing.T) {
+	dsn, ok := mysqlDSN(t)
+	if !ok {
+		return
+	}
+	connID := "itest-mysql-raw-multi"
+	db, err := Open(connID, "", "mysql", dsn)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer Close(connID, "")
+
+	sets, err := RawSelectMulti(db, "SELECT 1 AS a; DO 0; SELECT 2 AS b, 3 AS c")
+	if err != nil {
+		t.Fatalf("RawSelectMulti: %v", err)
+	}
+	if len(sets) != 2 {
+		t.Fatalf("expected 2 result sets, got %d", len(sets))
+	}
+	if len(sets[0].Columns) != 1 || len(sets[1].Columns) != 2 || len(sets[1].Rows) != 1 {
+		t.Errorf("unexpected sets: %+v", sets)
+	}
+}
+
+func TestIntegration_RawSelectMultiSQLite(t *testing.T) {
+	dsn, ok := sqliteDSN(t)
+	if !ok {
+		return
+	}
+	connID := "itest-sqlite-raw-multi"
+	db, err := Open(connID, "", "sqlite", dsn)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer Close(connID, "")
+
+	sets, err := RawSelectMultiLimit(db, "SELECT 1 AS a UNION ALL SELECT 2", 1)
+	if err != nil {
+		t.Fatalf("RawSelectMultiLimit: %v", err)
+	}
+	if len(sets) != 1 || len(sets[0].Rows) != 1 || !sets[0].Truncated {
+		t.Errorf("unexpected sets: %+v", sets)
+	}
+}
+
+func TestIntegration_TableRowsAndApproxCountSQLite(t *testing.T) {
+	dsn, ok := sqliteDSN(t)
+	if !ok {
+		return
+	}
+	connID := "itest-sqlite-approx"
+	db, err := Open(connID, "", "sqlite", dsn)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer Close(connID, "")
+	tables, err := TableNames(db, "sqlite", "")
+	if err != nil || len(tables) == 0 {
+		t.Skipf("no tables: %v", err)
+	}
+	exact, err := TableRowCount(db, "sqlite", "", tables[0])
+	if err != nil {
+		t.Fatalf("TableRowCount: %v", err)
+	}
+	if n, err := ApproxRowCount(db, "sqlite", "", tables[0]); err != nil || n != int64(exact) {
+		t.Errorf("ApproxRowCount = %d, %v; want %d", n, err, exact)
+	}
+	_, rows, err := TableRows(db, "sqlite", "", tables[0], 2, 0)
+	if err != nil || len(rows) > 2 {
+		t.Errorf("TableRows: %d rows, %v", len(rows), err)
+	}
+}
+
 func TestIntegration_DatabaseNamesMySQL(t *testing.T) {
 	dsn, ok := mysqlDSN(t)
 	if !ok {
@@ -199,6 +343,29 @@ func TestIntegration_DatabaseNamesMySQL(t *testing.T) {
 	}
 }
 
+func TestIntegration_NoDatabaseSelectedMySQL(t *testing.T) {
+	dsn, ok := mysqlDSN(t)
+	if !ok {
+		return
+	}
+	connID := "itest-mysql-nodb"
+	db, err := Open(connID, "", "mysql", strings.Replace(dsn, "/testdb?", "/?", 1))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer Close(connID, "")
+
+	if _, err := TableSchema(db, "mysql", "", "user"); !errors.Is(err, ErrNoDatabaseSelected) {
+		t.Errorf("TableSchema without database: %v", err)
+	}
+	if _, err := TableExists(db, "mysql", "", "user"); !errors.Is(err, ErrNoDatabaseSelected) {
+		t.Errorf("TableExists without database: %v", err)
+	}
+	if info, err := TableSchema(db, "mysql", "mysql", "user"); err != nil || len(info.Columns) == 0 {
+		t.Errorf("TableSchema with database: %v, %v", info, err)
+	}
+}
+
 func TestIntegration_TableNamesMySQL(t *testing.T) {
 	dsn, ok := mysqlDSN(t)
 	if !ok {
@@ -487,7 +654,7 @@ func BenchmarkTableData10k(b *testing.B) {
 	if _, err := os.Stat(path); err != nil {
 		b.Skipf("SQLite %s not found", path)
 	}
-	dsn := path
+	dsn := copyRealmDB(b, path)
 	connID := "bench-sqlite-large"
 	db, err := Open(connID, "", "sqlite", dsn)
 	if err != nil {
@@ -878,3 +1045,30 @@ func TestIntegration_PGTreeFlow(t *testing.T) {
 		t.Fatalf("RawSelect: %v", err)
 	}
 }
+
+func TestIntegration_CompositePrimaryKeySQLite(t *testing.T) {
+	connID := "itest-sqlite-composite-pk"
+	db, err := Open(connID, "", "sqlite", filepath.Join(t.TempDir(), "pk.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer Close(connID, "")
+	// key order differs from column order
+	if err := db.Exec("CREATE TABLE m (a TEXT, b INTEGER, c TEXT, PRIMARY KEY (c, a)) WITHOUT ROWID").Error; err != nil {
+		t.Fatal(err)
+	}
+	info, err := TableSchema(db, "sqlite", "", "m")
+	if err != nil {
+		t.Fatalf("TableSchema: %v", err)
+	}
+	got := map[string]int{}
+	for _, c := range info.Columns {
+		if c.IsPrimaryKey != (c.PrimaryKeyOrdinal > 0) {
+			t.Errorf("%s: isPrimaryKey %v, ordinal %d", c.Name, c.IsPrimaryKey, c.PrimaryKeyOrdinal)
+		}
+		got[c.Name] = c.PrimaryKeyOrdinal
+	}
+	if got["c"] != 1 || got["a"] != 2 || got["b"] != 0 {
+		t.Errorf("ordinals = %v", got)
+	}
+}
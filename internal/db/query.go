@@ -1,57 +1,505 @@
 package db
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 // RawSelect runs a SELECT query and returns columns and rows as []map[string]interface{}.
 func RawSelect(db *gorm.DB, q string) (cols []string, rows []map[string]interface{}, err error) {
-	var rs *sql.Rows
-	rs, err = db.Raw(q).Rows()
+	cols, rows, _, err = RawSelectLimit(db, q, 0)
+	return cols, rows, err
+}
+
+// RawSelectLimit is RawSelect that stops reading after maxRows rows (0 = unlimited). truncated reports
+// whether more rows were available. args bind the query's ? placeholders.
+func RawSelectLimit(db *gorm.DB, q string, maxRows int, args ...interface{}) (cols []string, rows []map[string]interface{}, truncated bool, err error) {
+	set, err := RawSelectResult(db, q, maxRows, args...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return set.Columns, set.Rows, set.Truncated, nil
+}
+
+// RawSelectResult is RawSelectLimit returning the result set with its column metadata.
+func RawSelectResult(db *gorm.DB, q string, maxRows int, args ...interface{}) (ResultSet, error) {
+	rs, err := db.Raw(q, args...).Rows()
 	if err != nil {
-		return nil, nil, err
+		return ResultSet{}, err
 	}
 	defer rs.Close()
+	set, err := scanResultSet(rs, maxRows)
+	if err != nil {
+		return ResultSet{}, err
+	}
+	return set, rs.Err()
+}
 
-	cols, err = rs.Columns()
+// RawCall runs a CALL statement. A procedure may return result sets or none, and running it twice is not
+// an option, so the statement is always read as a query: cols is empty when it produced no result set.
+// Only the first result set is returned (capped at maxRows, 0 = unlimited); extraSets counts the others.
+func RawCall(db *gorm.DB, q string, maxRows int) (cols []string, rows []map[string]interface{}, truncated bool, extraSets int, err error) {
+	rs, err := db.Raw(q).Rows()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, false, 0, err
 	}
+	defer rs.Close()
+	set, err := scanResultSet(rs, maxRows)
+	if err != nil {
+		return nil, nil, false, 0, err
+	}
+	for rs.NextResultSet() {
+		// MySQL ends every CALL with a status result that has no columns
+		if c, _ := rs.Columns(); len(c) > 0 {
+			extraSets++
+		}
+	}
+	return set.Columns, set.Rows, set.Truncated, extraSets, rs.Err()
+}
+
+// ResultSet is one result set of a multi-result query.
+type ResultSet struct {
+	Columns []string
+	// ColumnTypes describes Columns, in the same order; nil when the driver reports no types.
+	ColumnTypes []ColumnMeta
+	Rows        []map[string]interface{}
+	Truncated   bool
+	// PrettyJSON holds, by row index and column, pretty-printed JSON found in TEXT/BLOB/untyped columns (see
+	// JSONFallback); Rows keep the value as scanned. nil when there is none.
+	PrettyJSON map[int]map[string]string
+}
+
+// ColumnMeta is the driver's description of a result column. Nullable and Length are only set when the
+// driver knows them (Length for variable-length text and binary types).
+type ColumnMeta struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"databaseType"`
+	ScanType     string `json:"scanType,omitempty"`
+	Nullable     *bool  `json:"nullable,omitempty"`
+	Length       int64  `json:"length,omitempty"`
+	// JSON is set for JSON/JSONB columns and for columns where JSONFallback recognized a value.
+	JSON bool `json:"json,omitempty"`
+}
+
+// columnMetas converts rs.ColumnTypes() to ColumnMeta, named after the matching entry of names (the
+// deduplicated column names) so metadata lines up with the row keys.
+func columnMetas(names []string, types []*sql.ColumnType) []ColumnMeta {
+	if len(types) == 0 {
+		return nil
+	}
+	metas := make([]ColumnMeta, len(types))
+	for i, t := range types {
+		m := ColumnMeta{Name: t.Name(), DatabaseType: t.DatabaseTypeName()}
+		if i < len(names) {
+			m.Name = names[i]
+		}
+		if st := t.ScanType(); st != nil {
+			m.ScanType = st.String()
+		}
+		if nullable, ok := t.Nullable(); ok {
+			m.Nullable = &nullable
+		}
+		if n, ok := t.Length(); ok && n > 0 && n < 1<<40 {
+			m.Length = n
+		}
+		m.JSON = strings.Contains(strings.ToUpper(m.DatabaseType), "JSON")
+		metas[i] = m
+	}
+	return metas
+}
+
+// RawSelectMulti runs q and returns every result set it produces, e.g. from a stored procedure or a
+// multi-statement script. Sets without columns (status results, statements that return no rows) are
+// skipped. Drivers that do not support multiple result sets return only the first.
+func RawSelectMulti(db *gorm.DB, q string) ([]ResultSet, error) {
+	return RawSelectMultiLimit(db, q, 0)
+}
+
+// RawSelectMultiLimit is RawSelectMulti that reads at most maxRows rows per result set (0 = unlimited).
+func RawSelectMultiLimit(db *gorm.DB, q string, maxRows int) ([]ResultSet, error) {
+	rs, err := db.Raw(q).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+	var sets []ResultSet
+	for {
+		set, err := scanResultSet(rs, maxRows)
+		if err != nil {
+			return nil, err
+		}
+		if len(set.Columns) > 0 {
+			sets = append(sets, set)
+		}
+		if !rs.NextResultSet() {
+			break
+		}
+	}
+	return sets, rs.Err()
+}
+
+// scanResultSet reads the current result set of rs, stopping after maxRows rows (0 = unlimited).
+func scanResultSet(rs *sql.Rows, maxRows int) (ResultSet, error) {
+	cols, err := rs.Columns()
+	if err != nil {
+		return ResultSet{}, err
+	}
+	cols = UniqueColumnNames(cols)
 	types, _ := rs.ColumnTypes()
+	set := ResultSet{Columns: cols, ColumnTypes: columnMetas(cols, types)}
+	if err := scanRows(rs, &set, types, maxRows, false); err != nil {
+		return ResultSet{}, err
+	}
+	return set, nil
+}
+
+// UniqueColumnNames returns cols with empty names replaced by "column_N" (N is the 1-based position) and
+// repeated names suffixed "_2", "_3", ... Rows are keyed by column name, so without this a SHOW or PRAGMA
+// result with duplicate or blank headers would collapse columns into one.
+func UniqueColumnNames(cols []string) []string {
+	out := make([]string, len(cols))
+	taken := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		taken[c] = true
+	}
+	seen := make(map[string]bool, len(cols))
+	changed := false
+	for i, c := range cols {
+		name := c
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		if seen[name] || (c == "" && taken[name]) {
+			base := name
+			for n := 2; seen[name] || taken[name]; n++ {
+				name = fmt.Sprintf("%s_%d", base, n)
+			}
+		}
+		if name != c {
+			changed = true
+		}
+		seen[name] = true
+		taken[name] = true
+		out[i] = name
+	}
+	if !changed {
+		return cols
+	}
+	return out
+}
+
+// scanRows appends up to maxRows rows (0 = all) of rs to set. On reaching the cap it sets Truncated and leaves
+// rs on the next row without scanning it; pass positioned to start with that row instead of advancing.
+func scanRows(rs *sql.Rows, set *ResultSet, types []*sql.ColumnType, maxRows int, positioned bool) error {
+	cols := set.Columns
 	scanners := make([]interface{}, len(cols))
 	for i := range cols {
 		var v interface{}
 		scanners[i] = &v
 	}
 
-	for rs.Next() {
-		if err = rs.Scan(scanners...); err != nil {
-			return nil, nil, err
+	for positioned || rs.Next() {
+		positioned = false
+		if maxRows > 0 && len(set.Rows) >= maxRows {
+			set.Truncated = true
+			break
+		}
+		if err := rs.Scan(scanners...); err != nil {
+			return err
 		}
 		row := make(map[string]interface{})
 		for i, c := range cols {
 			val := *(scanners[i].(*interface{}))
+			dbType := ""
+			if types != nil && i < len(types) {
+				dbType = types[i].DatabaseTypeName()
+			}
+			if pretty, ok := JSONFallback(val, dbType); ok {
+				if set.PrettyJSON == nil {
+					set.PrettyJSON = make(map[int]map[string]string)
+				}
+				if set.PrettyJSON[len(set.Rows)] == nil {
+					set.PrettyJSON[len(set.Rows)] = make(map[string]string)
+				}
+				set.PrettyJSON[len(set.Rows)][c] = pretty
+				if i < len(set.ColumnTypes) {
+					set.ColumnTypes[i].JSON = true
+				}
+			}
 			if val != nil && types != nil && i < len(types) {
-				row[c] = formatColumnValue(val, types[i].DatabaseTypeName())
+				row[c] = formatColumnValue(val, dbType)
 			} else if val != nil {
 				row[c] = val
 			} else {
 				row[c] = nil
 			}
 		}
-		rows = append(rows, row)
+		set.Rows = append(set.Rows, row)
+	}
+	return nil
+}
+
+// Cursor is a SELECT whose rows are read a page at a time. It holds a pooled connection until it is exhausted
+// or closed, so callers must Close cursors they abandon.
+type Cursor struct {
+	mu         sync.Mutex
+	rs         *sql.Rows
+	cols       []string
+	types      []*sql.ColumnType
+	positioned bool // rs is on a row not yet returned
+	closed     bool
+}
+
+// OpenCursor runs q and returns a Cursor over its rows without reading any.
+func OpenCursor(db *gorm.DB, q string, args ...interface{}) (*Cursor, error) {
+	rs, err := db.Raw(q, args...).Rows()
+	if err != nil {
+		return nil, err
 	}
-	return cols, rows, rs.Err()
+	cols, err := rs.Columns()
+	if err != nil {
+		rs.Close()
+		return nil, err
+	}
+	types, _ := rs.ColumnTypes()
+	return &Cursor{rs: rs, cols: UniqueColumnNames(cols), types: types}, nil
+}
+
+// Fetch returns the next n rows (n <= 0 = all remaining). The set is Truncated when more rows remain; otherwise
+// the cursor is closed. Fetch on a closed cursor returns an empty set.
+func (c *Cursor) Fetch(n int) (ResultSet, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set := ResultSet{Columns: c.cols, ColumnTypes: columnMetas(c.cols, c.types)}
+	if c.closed {
+		return set, nil
+	}
+	err := scanRows(c.rs, &set, c.types, n, c.positioned)
+	if err == nil && !set.Truncated {
+		err = c.rs.Err()
+	}
+	c.positioned = set.Truncated
+	if err != nil || !set.Truncated {
+		c.closed = true
+		c.rs.Close()
+	}
+	if err != nil {
+		return ResultSet{}, err
+	}
+	return set, nil
+}
+
+// Close releases the cursor's connection. It is safe to call more than once.
+func (c *Cursor) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rs.Close()
+}
+
+// JSONFallback pretty-prints val when it holds a JSON object or array but its column type does not say JSON:
+// TEXT, BLOB or unknown (MySQL reports JSON columns as BLOB/TEXT in some setups, e.g. over views). Scalars such
+// as numeric strings, "true" or "null" are valid JSON too and are deliberately not recognized. ok is false when
+// val is not such a value.
+func JSONFallback(val interface{}, dbType string) (pretty string, ok bool) {
+	dt := strings.ToUpper(dbType)
+	if strings.Contains(dt, "JSON") || (dt != "" && !strings.Contains(dt, "TEXT") && !strings.Contains(dt, "BLOB")) {
+		return "", false
+	}
+	var b []byte
+	switch v := val.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return "", false
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) < 2 || (b[0] != '{' && b[0] != '[') || !json.Valid(b) {
+		return "", false
+	}
+	var out bytes.Buffer
+	if json.Indent(&out, b, "", "  ") != nil {
+		return "", false
+	}
+	return out.String(), true
+}
+
+// BlobKey is the marker key for binary cell values, which are returned as {"__blob__": "<base64>"}.
+const BlobKey = "__blob__"
+
+// IsBinaryType reports whether a driver column type name holds raw bytes (BLOB, BINARY, VARBINARY, BYTEA).
+func IsBinaryType(dbType string) bool {
+	dt := strings.ToUpper(dbType)
+	return strings.Contains(dt, "BLOB") || strings.Contains(dt, "BINARY") || dt == "BYTEA"
+}
+
+// DecodeBlob returns the bytes of a {"__blob__": base64} marker (as decoded from JSON); ok is false for any other value.
+func DecodeBlob(v interface{}) ([]byte, bool) {
+	var enc interface{}
+	switch m := v.(type) {
+	case map[string]interface{}:
+		if len(m) != 1 {
+			return nil, false
+		}
+		enc = m[BlobKey]
+	case map[string]string:
+		if len(m) != 1 {
+			return nil, false
+		}
+		enc = m[BlobKey]
+	default:
+		return nil, false
+	}
+	s, ok := enc.(string)
+	if !ok {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+var (
+	displayLocMu    sync.RWMutex
+	displayLoc      *time.Location
+	numericBooleans bool
+)
+
+// SetDisplayLocation sets the zone TIMESTAMP/DATETIME values are converted to when read. nil keeps values as the
+// driver returns them (MySQL: loc=Local from the DSN; PostgreSQL: session/server zone). Writes are never converted.
+func SetDisplayLocation(loc *time.Location) {
+	displayLocMu.Lock()
+	displayLoc = loc
+	displayLocMu.Unlock()
+}
+
+// SetNumericBooleans renders boolean columns (see IsBooleanType) as 0/1 instead of false/true.
+func SetNumericBooleans(numeric bool) {
+	displayLocMu.Lock()
+	numericBooleans = numeric
+	displayLocMu.Unlock()
+}
+
+func booleansAreNumeric() bool {
+	displayLocMu.RLock()
+	defer displayLocMu.RUnlock()
+	return numericBooleans
+}
+
+func currentDisplayLocation() *time.Location {
+	displayLocMu.RLock()
+	defer displayLocMu.RUnlock()
+	return displayLoc
+}
+
+// isDateTimeType reports whether a driver column type name is a date-time with a time part
+// (DATETIME, TIMESTAMP, TIMESTAMPTZ); DATE and TIME are left alone.
+func isDateTimeType(dbType string) bool {
+	dt := strings.ToUpper(dbType)
+	return strings.Contains(dt, "TIMESTAMP") || strings.Contains(dt, "DATETIME")
+}
+
+// IsBooleanType reports whether a column type holds true/false: BOOL/BOOLEAN and MySQL's TINYINT(1) and BIT(1).
+// The MySQL driver names BIT columns of any width "BIT", so only single-byte 0/1 values of those count as booleans.
+func IsBooleanType(dbType string) bool {
+	switch strings.ToUpper(strings.ReplaceAll(dbType, " ", "")) {
+	case "BOOL", "BOOLEAN", "TINYINT(1)", "BIT", "BIT(1)":
+		return true
+	}
+	return false
+}
+
+// BooleanValue interprets a value of a boolean column: a bool, the numbers 0/1, a single 0x00/0x01 byte
+// (MySQL BIT) or the text "0", "1", "true" or "false". ok is false for anything else.
+func BooleanValue(v interface{}) (b bool, ok bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case int64:
+		return x == 1, x == 0 || x == 1
+	case int:
+		return x == 1, x == 0 || x == 1
+	case float64:
+		return x == 1, x == 0 || x == 1
+	case json.Number:
+		return BooleanValue(x.String())
+	case []byte:
+		if len(x) == 1 && x[0] <= 1 {
+			return x[0] == 1, true
+		}
+		return BooleanValue(string(x))
+	case string:
+		switch strings.ToLower(strings.TrimSpace(x)) {
+		case "1", "true":
+			return true, true
+		case "0", "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// BooleanArg converts a value written to a boolean column to the driver's representation: a bool for PostgreSQL,
+// 1/0 elsewhere. Values BooleanValue does not recognize are returned unchanged.
+func BooleanArg(driver string, v interface{}) interface{} {
+	b, ok := BooleanValue(v)
+	if !ok {
+		return v
+	}
+	if driver == "postgresql" || driver == "postgres" {
+		return b
+	}
+	if b {
+		return int64(1)
+	}
+	return int64(0)
 }
 
 func formatColumnValue(val interface{}, dbType string) interface{} {
+	return FormatColumnValueIn(val, dbType, currentDisplayLocation())
+}
+
+// FormatColumnValueIn converts a scanned value for display like query results, rendering TIMESTAMP/DATETIME
+// values in loc instead of the configured display location. nil loc leaves them as the driver returned them.
+// Boolean columns render as true/false, or 0/1 after SetNumericBooleans(true).
+func FormatColumnValueIn(val interface{}, dbType string, loc *time.Location) interface{} {
+	if IsBooleanType(dbType) {
+		if b, ok := BooleanValue(val); ok {
+			if !booleansAreNumeric() {
+				return b
+			}
+			if b {
+				return int64(1)
+			}
+			return int64(0)
+		}
+	}
 	switch v := val.(type) {
+	case time.Time:
+		if loc != nil && isDateTimeType(dbType) {
+			return v.In(loc)
+		}
+		return v
 	case []byte:
+		if IsBinaryType(dbType) {
+			return map[string]string{BlobKey: base64.StdEncoding.EncodeToString(v)}
+		}
 		s := string(v)
 		dt := strings.ToUpper(dbType)
 		if (strings.Contains(dt, "JSON") || strings.Contains(dt, "JSONB")) && len(v) > 0 {
@@ -69,21 +517,44 @@ func formatColumnValue(val interface{}, dbType string) interface{} {
 	}
 }
 
-// RawExec runs INSERT/UPDATE/DELETE and returns rows affected.
-func RawExec(db *gorm.DB, q string) (int64, error) {
-	tx := db.Exec(q)
+// RawExec runs INSERT/UPDATE/DELETE and returns rows affected. args bind the query's ? placeholders.
+func RawExec(db *gorm.DB, q string, args ...interface{}) (int64, error) {
+	tx := db.Exec(q, args...)
 	return tx.RowsAffected, tx.Error
 }
 
 // IsSelect returns true if the trimmed, upper-cased query looks like a SELECT.
-func IsSelect(q string) bool {
+// SELECT ... INTO (MySQL variables/OUTFILE, PostgreSQL new table) writes instead of returning rows.
+var (
+	selectIntoRegex = regexp.MustCompile(`(?i)\bINTO\b`)
+	// literalRegex matches quoted strings and identifiers plus -- and /* */ comments. MySQL only treats "--"
+	// followed by whitespace as a comment ("5--1" is arithmetic), so that is all that is matched here.
+	literalRegex = regexp.MustCompile("'(?:[^'\\\\]|\\\\.|'')*'|\"(?:[^\"]|\"\")*\"|`[^`]*`|--(?:[ \t\r\f][^\n]*)?(?:\n|$)|/\\*[\\s\\S]*?\\*/")
+)
+
+// blankLiterals replaces quoted strings with an empty literal and comments with a space so keywords and ';' in them are
+// not mistaken for SQL. MySQL executable comments (/*! ... */) run their contents and are kept.
+func blankLiterals(q string) string {
+	return literalRegex.ReplaceAllStringFunc(q, func(m string) string {
+		switch {
+		case strings.HasPrefix(m, "/*!"):
+			return m
+		case strings.HasPrefix(m, "--"), strings.HasPrefix(m, "/*"):
+			return " "
+		}
+		return "''"
+	})
+}
+
+// stripLeadingComments removes leading blanks and -- / /* */ comments. ok is false when nothing but
+// comments remain (including an unterminated block comment).
+func stripLeadingComments(q string) (string, bool) {
 	q = strings.TrimSpace(q)
-	// Remove leading comments and blanks
 	for len(q) > 0 {
 		if strings.HasPrefix(q, "--") {
 			i := strings.Index(q, "\n")
 			if i < 0 {
-				return false
+				return "", false
 			}
 			q = strings.TrimSpace(q[i+1:])
 			continue
@@ -91,19 +562,308 @@ func IsSelect(q string) bool {
 		if strings.HasPrefix(q, "/*") {
 			i := strings.Index(q, "*/")
 			if i < 0 {
-				return false
+				return "", false
 			}
 			q = strings.TrimSpace(q[i+2:])
 			continue
 		}
 		break
 	}
+	return q, q != ""
+}
+
+// IsSelect reports whether q returns rows: SELECT (except SELECT ... INTO), SHOW, DESCRIBE, EXPLAIN, PRAGMA.
+func IsSelect(q string) bool {
+	q, ok := stripLeadingComments(q)
+	if !ok {
+		return false
+	}
 	upper := strings.ToUpper(q)
-	return strings.HasPrefix(upper, "SELECT") || strings.HasPrefix(upper, "SHOW") ||
+	if strings.HasPrefix(upper, "SELECT") {
+		return !selectIntoRegex.MatchString(blankLiterals(q))
+	}
+	return strings.HasPrefix(upper, "SHOW") ||
 		strings.HasPrefix(upper, "DESCRIBE") || strings.HasPrefix(upper, "DESC") ||
 		strings.HasPrefix(upper, "EXPLAIN") || strings.HasPrefix(upper, "PRAGMA")
 }
 
+// HasMultipleStatements reports whether q contains more than one statement, i.e. a ';' outside quotes and
+// comments that is not the trailing terminator. MySQL # comments are not recognized, so the answer errs towards true.
+func HasMultipleStatements(q string) bool {
+	q = strings.TrimRight(blankLiterals(q), "; \t\r\n")
+	return strings.Contains(q, ";")
+}
+
+// namedParamRegex matches a :name placeholder; the character before it is checked separately so that
+// PostgreSQL casts (x::int) are not taken for parameters.
+var namedParamRegex = regexp.MustCompile(`:[A-Za-z_][A-Za-z0-9_]*`)
+
+// BindParams prepares q for execution with values from the UI. With a JSON array, q uses ? placeholders and
+// the values bind in order. With a JSON object, q uses :name placeholders, which are rewritten to ? (gorm turns
+// those into $n for PostgreSQL) with the values in placeholder order; a name may repeat. Placeholders inside
+// quotes and comments are ignored. JSON numbers bind as int64 when integral, objects and arrays as JSON text.
+func BindParams(q, paramsJSON string) (string, []interface{}, error) {
+	paramsJSON = strings.TrimSpace(paramsJSON)
+	if paramsJSON == "" {
+		paramsJSON = "[]"
+	}
+	dec := json.NewDecoder(strings.NewReader(paramsJSON))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return "", nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	literals := literalRegex.FindAllStringIndex(q, -1)
+	// gaps returns the parts of q outside quotes and comments as [start, end) offsets.
+	var gaps [][2]int
+	prev := 0
+	for _, l := range literals {
+		gaps = append(gaps, [2]int{prev, l[0]})
+		prev = l[1]
+	}
+	gaps = append(gaps, [2]int{prev, len(q)})
+
+	switch v := raw.(type) {
+	case []interface{}:
+		n := 0
+		for _, g := range gaps {
+			n += strings.Count(q[g[0]:g[1]], "?")
+		}
+		if n != len(v) {
+			return "", nil, fmt.Errorf("query has %d ? placeholders but %d values were given", n, len(v))
+		}
+		args := make([]interface{}, len(v))
+		for i, x := range v {
+			args[i] = paramValue(x)
+		}
+		return q, args, nil
+	case map[string]interface{}:
+		for _, g := range gaps {
+			if strings.Contains(q[g[0]:g[1]], "?") {
+				return "", nil, fmt.Errorf("query mixes ? placeholders with named parameters")
+			}
+		}
+		var b strings.Builder
+		var args []interface{}
+		var missing []string
+		prev := 0
+		for _, g := range gaps {
+			for _, m := range namedParamRegex.FindAllStringIndex(q[g[0]:g[1]], -1) {
+				start, end := g[0]+m[0], g[0]+m[1]
+				if start > 0 && q[start-1] == ':' {
+					continue
+				}
+				name := q[start+1 : end]
+				val, ok := v[name]
+				if !ok {
+					missing = append(missing, name)
+					continue
+				}
+				b.WriteString(q[prev:start])
+				b.WriteByte('?')
+				args = append(args, paramValue(val))
+				prev = end
+			}
+		}
+		if len(missing) > 0 {
+			return "", nil, fmt.Errorf("no value for parameter :%s", strings.Join(missing, ", :"))
+		}
+		b.WriteString(q[prev:])
+		return b.String(), args, nil
+	default:
+		return "", nil, fmt.Errorf("parameters must be a JSON array or object")
+	}
+}
+
+// paramValue converts a decoded JSON value into a driver argument.
+func paramValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(x)
+		return string(b)
+	default:
+		return v
+	}
+}
+
+// IsCall reports whether q is a stored procedure CALL, which may or may not return result sets.
+func IsCall(q string) bool {
+	q, ok := stripLeadingComments(q)
+	if !ok || len(q) < 5 {
+		return false
+	}
+	return strings.EqualFold(q[:4], "CALL") && (q[4] == ' ' || q[4] == '\t' || q[4] == '\n' || q[4] == '\r')
+}
+
+// Statement types reported by StatementType.
+const (
+	StatementSelect = "SELECT"
+	StatementInsert = "INSERT"
+	StatementUpdate = "UPDATE"
+	StatementDelete = "DELETE"
+	StatementDDL    = "DDL"
+	StatementOther  = "OTHER"
+)
+
+// statementWords returns the first n upper-cased words of q after leading comments, with punctuation that can
+// follow a keyword (e.g. "TABLE`t`" or "DROP(") cut off.
+func statementWords(q string, n int) []string {
+	q, ok := stripLeadingComments(q)
+	if !ok {
+		return nil
+	}
+	fields := strings.Fields(strings.ToUpper(q))
+	if len(fields) > n {
+		fields = fields[:n]
+	}
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if i := strings.IndexAny(f, "`\"'([;="); i >= 0 {
+			f = f[:i]
+		}
+		words = append(words, f)
+	}
+	return words
+}
+
+// StatementType classifies q by its leading keyword: StatementSelect for SELECT/SHOW/DESCRIBE/EXPLAIN/PRAGMA,
+// StatementInsert for INSERT/REPLACE, StatementUpdate, StatementDelete, StatementDDL for CREATE/ALTER/DROP/
+// TRUNCATE/RENAME/COMMENT, and StatementOther for everything else (CALL, WITH, SET, ...).
+func StatementType(q string) string {
+	words := statementWords(q, 1)
+	if len(words) == 0 {
+		return StatementOther
+	}
+	switch words[0] {
+	case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "PRAGMA":
+		return StatementSelect
+	case "INSERT", "REPLACE":
+		return StatementInsert
+	case "UPDATE":
+		return StatementUpdate
+	case "DELETE":
+		return StatementDelete
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT":
+		return StatementDDL
+	}
+	return StatementOther
+}
+
+var ddlVerbs = map[string]string{
+	"CREATE":   "created",
+	"ALTER":    "altered",
+	"DROP":     "dropped",
+	"TRUNCATE": "truncated",
+	"RENAME":   "renamed",
+}
+
+var ddlObjects = map[string]string{
+	"TABLE":        "Table",
+	"VIEW":         "View",
+	"MATERIALIZED": "Materialized view",
+	"INDEX":        "Index",
+	"DATABASE":     "Database",
+	"SCHEMA":       "Schema",
+	"PROCEDURE":    "Procedure",
+	"FUNCTION":     "Function",
+	"TRIGGER":      "Trigger",
+	"SEQUENCE":     "Sequence",
+	"EVENT":        "Event",
+	"TYPE":         "Type",
+	"EXTENSION":    "Extension",
+	"USER":         "User",
+	"ROLE":         "Role",
+	"COLUMN":       "Column",
+}
+
+// DDLSummary returns a short message describing a DDL statement, e.g. "Table created" or "Index dropped".
+// It returns "" when q is not DDL. The object is the first known object keyword after the verb, so modifiers
+// such as OR REPLACE, TEMPORARY or UNIQUE are skipped; TRUNCATE without one refers to a table.
+func DDLSummary(q string) string {
+	if StatementType(q) != StatementDDL {
+		return ""
+	}
+	words := statementWords(q, 8)
+	if words[0] == "COMMENT" {
+		return "Comment updated"
+	}
+	object := "Object"
+	if words[0] == "TRUNCATE" || words[0] == "RENAME" {
+		object = "Table"
+	}
+	for _, w := range words[1:] {
+		if name, ok := ddlObjects[w]; ok {
+			object = name
+			break
+		}
+	}
+	return object + " " + ddlVerbs[words[0]]
+}
+
+// ServerVersion returns the server version string: MySQL VERSION(), PostgreSQL server_version, SQLite sqlite_version().
+func ServerVersion(db *gorm.DB, driver string) (string, error) {
+	var q string
+	switch driver {
+	case "mysql":
+		q = "SELECT VERSION()"
+	case "postgresql", "postgres":
+		q = "SHOW server_version"
+	case "sqlite":
+		q = "SELECT sqlite_version()"
+	default:
+		return "", fmt.Errorf("unsupported driver: %s", driver)
+	}
+	var v string
+	if err := db.Raw(q).Scan(&v).Error; err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+// ConnectionCharset returns the character set settings of the session: MySQL character_set_client,
+// character_set_connection, character_set_results and (for reference) character_set_database; PostgreSQL
+// client_encoding and server_encoding; SQLite encoding.
+func ConnectionCharset(db *gorm.DB, driver string) (map[string]string, error) {
+	out := make(map[string]string)
+	switch driver {
+	case "mysql":
+		var raw []struct {
+			VariableName string `gorm:"column:Variable_name"`
+			Value        string `gorm:"column:Value"`
+		}
+		if err := db.Raw("SHOW SESSION VARIABLES WHERE Variable_name IN " +
+			"('character_set_client', 'character_set_connection', 'character_set_results', 'character_set_database')").Scan(&raw).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range raw {
+			out[r.VariableName] = r.Value
+		}
+	case "postgresql", "postgres":
+		for _, name := range []string{"client_encoding", "server_encoding"} {
+			var v string
+			if err := db.Raw("SHOW " + name).Scan(&v).Error; err != nil {
+				return nil, err
+			}
+			out[name] = v
+		}
+	case "sqlite":
+		var v string
+		if err := db.Raw("PRAGMA encoding").Scan(&v).Error; err != nil {
+			return nil, err
+		}
+		out["encoding"] = v
+	default:
+		return nil, fmt.Errorf("unsupported driver: %s", driver)
+	}
+	return out, nil
+}
+
 // SchemaNames returns schema names for the current PostgreSQL database (e.g. public, user schemas). Only for driver "postgresql"/"postgres".
 func SchemaNames(db *gorm.DB) ([]string, error) {
 	cols, rows, err := RawSelect(db, `SELECT schema_name FROM information_schema.schemata
@@ -125,7 +885,7 @@ func SchemaNames(db *gorm.DB) ([]string, error) {
 	return names, nil
 }
 
-// DatabaseNames returns database names for the given driver. MySQL: SHOW DATABASES; PostgreSQL: pg_database (or use SchemaNames for tree); SQLite: ["main"].
+// DatabaseNames returns database names for the given driver. MySQL: SHOW DATABASES; PostgreSQL: pg_database (or use SchemaNames for tree); SQLite: "main" and attached databases.
 func DatabaseNames(db *gorm.DB, driver string) ([]string, error) {
 	switch driver {
 	case "mysql":
@@ -161,13 +921,27 @@ func DatabaseNames(db *gorm.DB, driver string) ([]string, error) {
 		}
 		return names, nil
 	case "sqlite":
-		return []string{"main"}, nil
+		// main plus attached databases; temp only holds this connection's temporary tables
+		var list []struct {
+			Name string
+		}
+		if err := db.Raw("PRAGMA database_list").Scan(&list).Error; err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, d := range list {
+			if d.Name != "temp" {
+				names = append(names, d.Name)
+			}
+		}
+		return names, nil
 	default:
 		return nil, fmt.Errorf("unsupported driver: %s", driver)
 	}
 }
 
-// TableNames returns table names for the given driver and database. For SQLite, database is ignored. For PostgreSQL, database is schema (default "public").
+// TableNames returns table names for the given driver and database. For SQLite, database is the attached database
+// name (empty means "main"). For PostgreSQL, database is schema (default "public").
 func TableNames(db *gorm.DB, driver, database string) ([]string, error) {
 	var q string
 	switch driver {
@@ -184,7 +958,11 @@ func TableNames(db *gorm.DB, driver, database string) ([]string, error) {
 		}
 		q = "SELECT tablename FROM pg_tables WHERE schemaname = '" + schema + "' ORDER BY tablename"
 	case "sqlite":
-		q = "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+		master := "sqlite_master"
+		if database != "" && database != "main" {
+			master = quoteIdent(driver, database) + ".sqlite_master"
+		}
+		q = "SELECT name FROM " + master + " WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
 	default:
 		return nil, fmt.Errorf("unsupported driver: %s", driver)
 	}
@@ -211,6 +989,32 @@ func TableNames(db *gorm.DB, driver, database string) ([]string, error) {
 	return names, nil
 }
 
+// TableExists reports whether table is a table or view of database (MySQL database, defaulting to the current one;
+// PostgreSQL schema, default "public"; ignored for SQLite).
+func TableExists(db *gorm.DB, driver, database, table string) (bool, error) {
+	var n int64
+	var err error
+	switch driver {
+	case "mysql":
+		if database, err = CurrentMySQLDatabase(db, database); err != nil {
+			return false, err
+		}
+		err = db.Raw("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+			database, table).Scan(&n).Error
+	case "postgresql", "postgres":
+		schema := database
+		if schema == "" {
+			schema = "public"
+		}
+		err = db.Raw("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?", schema, table).Scan(&n).Error
+	case "sqlite":
+		err = db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view') AND name = ?", table).Scan(&n).Error
+	default:
+		return false, fmt.Errorf("unsupported driver: %s", driver)
+	}
+	return n > 0, err
+}
+
 // qualTable returns qualified table for queries: MySQL "`db`.`table`"; PostgreSQL "schema"."table" (default "public"); SQLite "table".
 func qualTable(driver, database, table string) string {
 	tbl := quoteIdent(driver, table)
@@ -246,10 +1050,97 @@ func TableData(db *gorm.DB, driver, database, table string, limit, offset int) (
 	if err != nil {
 		return nil, nil, 0, err
 	}
+	cols, rows, err = TableRows(db, driver, database, table, limit, offset)
+	return cols, rows, total, err
+}
+
+// TableRows returns columns and rows for limit/offset without counting the table. database is optional.
+func TableRows(db *gorm.DB, driver, database, table string, limit, offset int) (cols []string, rows []map[string]interface{}, err error) {
 	qt := qualTable(driver, database, table)
 	q := fmt.Sprintf("SELECT * FROM %s LIMIT %d OFFSET %d", qt, limit, offset)
-	cols, rows, err = RawSelect(db, q)
-	return cols, rows, total, err
+	return RawSelect(db, q)
+}
+
+// ApproxRowCount returns the planner's row estimate (MySQL information_schema.TABLES.TABLE_ROWS, PostgreSQL
+// pg_class.reltuples), which is cheap even for huge tables but may be off, especially right after bulk changes.
+// SQLite keeps no such statistic and gets an exact COUNT(*). Returns -1 when PostgreSQL has not analyzed the table yet.
+func ApproxRowCount(db *gorm.DB, driver, database, table string) (int64, error) {
+	var n int64
+	switch driver {
+	case "mysql":
+		q := "SELECT COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?"
+		err := db.Raw(q, database, table).Scan(&n).Error
+		return n, err
+	case "postgresql", "postgres":
+		schema := database
+		if schema == "" {
+			schema = "public"
+		}
+		var est float64
+		q := "SELECT c.reltuples FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname = ? AND c.relname = ?"
+		if err := db.Raw(q, schema, table).Scan(&est).Error; err != nil {
+			return 0, err
+		}
+		if est < 0 {
+			return -1, nil
+		}
+		return int64(est), nil
+	case "sqlite":
+		c, err := TableRowCount(db, driver, database, table)
+		return int64(c), err
+	default:
+		return 0, fmt.Errorf("unsupported driver: %s", driver)
+	}
+}
+
+// TableSize is the storage a table takes, in bytes. Data includes PostgreSQL TOAST; Total is Data + Index.
+type TableSize struct {
+	DataBytes  int64 `json:"dataBytes"`
+	IndexBytes int64 `json:"indexBytes"`
+	TotalBytes int64 `json:"totalBytes"`
+	// Available is false when the server cannot report sizes (SQLite built without the dbstat table); the sizes are 0.
+	Available bool `json:"available"`
+}
+
+// TableStorageSize returns the on-disk size of table and its indexes: MySQL information_schema.TABLES
+// DATA_LENGTH / INDEX_LENGTH (statistics, so approximate for InnoDB), PostgreSQL pg_table_size / pg_indexes_size,
+// SQLite the pages of the table and its indexes in dbstat. database is as for TableExists; for SQLite it is the
+// attached schema, default "main". Views have no storage and report 0.
+func TableStorageSize(db *gorm.DB, driver, database, table string) (TableSize, error) {
+	var size TableSize
+	switch driver {
+	case "mysql":
+		q := "SELECT COALESCE(DATA_LENGTH, 0) AS data_bytes, COALESCE(INDEX_LENGTH, 0) AS index_bytes FROM information_schema.TABLES " +
+			"WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?"
+		if err := db.Raw(q, database, table).Row().Scan(&size.DataBytes, &size.IndexBytes); err != nil && err != sql.ErrNoRows {
+			return size, err
+		}
+	case "postgresql", "postgres":
+		q := "SELECT pg_table_size(c.oid), pg_indexes_size(c.oid) FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace " +
+			"WHERE n.nspname = COALESCE(NULLIF(?, ''), 'public') AND c.relname = ?"
+		if err := db.Raw(q, database, table).Row().Scan(&size.DataBytes, &size.IndexBytes); err != nil && err != sql.ErrNoRows {
+			return size, err
+		}
+	case "sqlite":
+		schema := database
+		if schema == "" {
+			schema = "main"
+		}
+		master := quoteIdent(driver, schema) + ".sqlite_master"
+		q := "SELECT COALESCE(SUM(CASE WHEN s.name = ? THEN s.pgsize END), 0), COALESCE(SUM(CASE WHEN s.name <> ? THEN s.pgsize END), 0) " +
+			"FROM dbstat AS s WHERE s.schema = ? AND (s.name = ? OR s.name IN (SELECT name FROM " + master + " WHERE type = 'index' AND tbl_name = ?))"
+		if err := db.Raw(q, table, table, schema, table, table).Row().Scan(&size.DataBytes, &size.IndexBytes); err != nil {
+			if strings.Contains(err.Error(), "no such table: dbstat") {
+				return size, nil
+			}
+			return size, err
+		}
+	default:
+		return size, fmt.Errorf("unsupported driver: %s", driver)
+	}
+	size.TotalBytes = size.DataBytes + size.IndexBytes
+	size.Available = true
+	return size, nil
 }
 
 func quoteIdent(driver, name string) string {
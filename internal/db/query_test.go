@@ -1,6 +1,11 @@
 package db
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestIsSelect(t *testing.T) {
 	tests := []struct {
@@ -25,6 +30,19 @@ func TestIsSelect(t *testing.T) {
 		{"DROP TABLE t", false},
 		{"", false},
 		{"-- only comment", false},
+		{"SELECT id INTO @x FROM t", false},
+		{"select * into new_t from t", false},
+		{"SELECT 1 INTO OUTFILE '/tmp/x'", false},
+		{"SELECT 'into' FROM t", true},
+		{"SELECT `into` FROM t", true},
+		{"SELECT pointo FROM t", true},
+		{"SELECT a -- into later\nFROM t", true},
+		{"SELECT a /* into */ FROM t", true},
+		{"SELECT a FROM t -- copy into x", true},
+		{"SELECT '/*' INTO @x FROM t -- */", false},
+		{"SELECT a /*! INTO @x */ FROM t", false},
+		{"SELECT 5--1 INTO @x", false},
+		{"CALL p()", false},
 	}
 	for _, tt := range tests {
 		got := IsSelect(tt.sql)
@@ -33,3 +51,265 @@ func TestIsSelect(t *testing.T) {
 		}
 	}
 }
+
+func TestHasMultipleStatements(t *testing.T) {
+	tests := []struct {
+		sql    string
+		expect bool
+	}{
+		{"SELECT 1", false},
+		{"SELECT 1;", false},
+		{"SELECT 1;\n", false},
+		{"SELECT ';'", false},
+		{"SELECT 1; SELECT 2", true},
+		{"SELECT 1; DELETE FROM t;", true},
+		{"SELECT 1 -- ; DELETE FROM t", false},
+		{"SELECT 1 /* ; */", false},
+		{"SELECT 5--1; DELETE FROM t", true},
+		{"SELECT '/*'; DELETE FROM t -- */", true},
+	}
+	for _, tt := range tests {
+		if got := HasMultipleStatements(tt.sql); got != tt.expect {
+			t.Errorf("HasMultipleStatements(%q) = %v, want %v", tt.sql, got, tt.expect)
+		}
+	}
+}
+
+func TestIsCall(t *testing.T) {
+	tests := []struct {
+		sql    string
+		expect bool
+	}{
+		{"CALL p()", true},
+		{"call p(1, 'a')", true},
+		{"-- run it\nCALL\tp()", true},
+		{"CALLP()", false},
+		{"SELECT 'CALL p()'", false},
+		{"CALL", false},
+	}
+	for _, tt := range tests {
+		if got := IsCall(tt.sql); got != tt.expect {
+			t.Errorf("IsCall(%q) = %v, want %v", tt.sql, got, tt.expect)
+		}
+	}
+}
+
+func TestStatementType(t *testing.T) {
+	tests := []struct {
+		sql, wantType, wantSummary string
+	}{
+		{"SELECT 1", StatementSelect, ""},
+		{"/* c */ show tables", StatementSelect, ""},
+		{"insert into t values (1)", StatementInsert, ""},
+		{"REPLACE INTO t VALUES (1)", StatementInsert, ""},
+		{"-- fix\nUPDATE t SET x = 1", StatementUpdate, ""},
+		{"DELETE FROM t", StatementDelete, ""},
+		{"CREATE TABLE t (id int)", StatementDDL, "Table created"},
+		{"create table`t`(id int)", StatementDDL, "Table created"},
+		{"CREATE UNIQUE INDEX i ON t (a)", StatementDDL, "Index created"},
+		{"CREATE OR REPLACE VIEW v AS SELECT 1", StatementDDL, "View created"},
+		{"CREATE MATERIALIZED VIEW v AS SELECT 1", StatementDDL, "Materialized view created"},
+		{"ALTER TABLE t ADD COLUMN c int", StatementDDL, "Table altered"},
+		{"DROP TABLE IF EXISTS t", StatementDDL, "Table dropped"},
+		{"TRUNCATE t", StatementDDL, "Table truncated"},
+		{"COMMENT ON TABLE t IS 'x'", StatementDDL, "Comment updated"},
+		{"CALL p()", StatementOther, ""},
+		{"SET NAMES utf8mb4", StatementOther, ""},
+		{"", StatementOther, ""},
+		{"SELECTED", StatementOther, ""},
+	}
+	for _, tt := range tests {
+		if got := StatementType(tt.sql); got != tt.wantType {
+			t.Errorf("StatementType(%q) = %q, want %q", tt.sql, got, tt.wantType)
+		}
+		if got := DDLSummary(tt.sql); got != tt.wantSummary {
+			t.Errorf("DDLSummary(%q) = %q, want %q", tt.sql, got, tt.wantSummary)
+		}
+	}
+}
+
+func TestBlobRoundTrip(t *testing.T) {
+	raw := []byte{0x00, 0xff, 'a'}
+	v := formatColumnValue(raw, "LONGBLOB")
+	m, ok := v.(map[string]string)
+	if !ok || m[BlobKey] != "AP9h" {
+		t.Fatalf("formatColumnValue = %#v", v)
+	}
+	if s := formatColumnValue([]byte("text"), "VARCHAR"); s != "text" {
+		t.Errorf("text column = %#v", s)
+	}
+	// values come back from the frontend as decoded JSON
+	b, ok := DecodeBlob(map[string]interface{}{BlobKey: "AP9h"})
+	if !ok || string(b) != string(raw) {
+		t.Errorf("DecodeBlob = %v, %v", b, ok)
+	}
+	if _, ok := DecodeBlob(map[string]interface{}{BlobKey: "AP9h", "x": 1}); ok {
+		t.Error("DecodeBlob accepted extra keys")
+	}
+	for typ, want := range map[string]bool{"BLOB": true, "VARBINARY": true, "bytea": true, "TEXT": false, "JSON": false} {
+		if IsBinaryType(typ) != want {
+			t.Errorf("IsBinaryType(%q) != %v", typ, want)
+		}
+	}
+}
+
+func TestFormatColumnValueBooleans(t *testing.T) {
+	tests := []struct {
+		val    interface{}
+		dbType string
+		want   interface{}
+	}{
+		{[]byte{0x01}, "BIT", true},
+		{[]byte{0x00}, "BIT", false},
+		{[]byte{0x05}, "BIT", "\x05"},
+		{[]byte("1"), "TINYINT(1)", true},
+		{int64(0), "tinyint(1)", false},
+		{int64(1), "BOOLEAN", true},
+		{true, "BOOL", true},
+		{[]byte("1"), "TINYINT", "1"},
+		{int64(2), "BOOLEAN", int64(2)},
+	}
+	for _, tt := range tests {
+		if got := formatColumnValue(tt.val, tt.dbType); got != tt.want {
+			t.Errorf("formatColumnValue(%#v, %q) = %#v, want %#v", tt.val, tt.dbType, got, tt.want)
+		}
+	}
+	SetNumericBooleans(true)
+	defer SetNumericBooleans(false)
+	if got := formatColumnValue([]byte{0x01}, "BIT"); got != int64(1) {
+		t.Errorf("numeric BIT = %#v", got)
+	}
+	if got := formatColumnValue(false, "BOOL"); got != int64(0) {
+		t.Errorf("numeric BOOL = %#v", got)
+	}
+}
+
+func TestBooleanArg(t *testing.T) {
+	for _, v := range []interface{}{true, "true", "1", float64(1), []byte{0x01}} {
+		if got := BooleanArg("mysql", v); got != int64(1) {
+			t.Errorf("BooleanArg(mysql, %#v) = %#v", v, got)
+		}
+		if got := BooleanArg("postgresql", v); got != true {
+			t.Errorf("BooleanArg(postgresql, %#v) = %#v", v, got)
+		}
+	}
+	if got := BooleanArg("sqlite", "False"); got != int64(0) {
+		t.Errorf("BooleanArg(sqlite, False) = %#v", got)
+	}
+	if got := BooleanArg("mysql", "yes"); got != "yes" {
+		t.Errorf("unrecognized value changed: %#v", got)
+	}
+}
+
+func TestFormatColumnValueDisplayLocation(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 8*3600))
+	SetDisplayLocation(time.UTC)
+	defer SetDisplayLocation(nil)
+	got, ok := formatColumnValue(ts, "DATETIME").(time.Time)
+	if !ok || got.Location() != time.UTC || got.Hour() != 19 {
+		t.Errorf("DATETIME = %v", got)
+	}
+	if d := formatColumnValue(ts, "DATE").(time.Time); d.Location() == time.UTC {
+		t.Error("DATE should not be converted")
+	}
+	SetDisplayLocation(nil)
+	if v := formatColumnValue(ts, "TIMESTAMP").(time.Time); !v.Equal(ts) || v.Location() != ts.Location() {
+		t.Errorf("unconverted TIMESTAMP = %v", v)
+	}
+	if v := FormatColumnValueIn(ts, "TIMESTAMP", time.UTC).(time.Time); v.Location() != time.UTC || v.Hour() != 19 {
+		t.Errorf("TIMESTAMP in UTC = %v", v)
+	}
+}
+
+func TestJSONFallback(t *testing.T) {
+	tests := []struct {
+		val    interface{}
+		dbType string
+		want   string
+		ok     bool
+	}{
+		{[]byte(`{"a":1}`), "BLOB", "{\n  \"a\": 1\n}", true},
+		{` [1,2] `, "TEXT", "[\n  1,\n  2\n]", true},
+		{[]byte(`{"a":[]}`), "", "{\n  \"a\": []\n}", true},
+		{[]byte(`{"a":1}`), "JSON", "", false},
+		{[]byte(`{"a":1}`), "VARCHAR", "", false},
+		{[]byte("12345"), "TEXT", "", false},
+		{[]byte("1e5"), "", "", false},
+		{[]byte("true"), "TEXT", "", false},
+		{[]byte(`"quoted"`), "TEXT", "", false},
+		{[]byte("{not json}"), "TEXT", "", false},
+		{int64(1), "", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := JSONFallback(tt.val, tt.dbType)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("JSONFallback(%v, %q) = %q, %v; want %q, %v", tt.val, tt.dbType, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	tests := []struct {
+		sql, params string
+		want        string
+		args        string
+	}{
+		{"SELECT * FROM t WHERE id = ? AND name = ?", `[7, "a"]`, "SELECT * FROM t WHERE id = ? AND name = ?", "[7 a]"},
+		{"SELECT * FROM t WHERE a = :a OR b = :b OR c = :a", `{"a": 1.5, "b": null}`, "SELECT * FROM t WHERE a = ? OR b = ? OR c = ?", "[1.5 <nil> 1.5]"},
+		{"SELECT x::int, ':skip', \"c:d\" FROM t WHERE j = :doc -- :not", `{"doc": {"k": [1]}}`, "SELECT x::int, ':skip', \"c:d\" FROM t WHERE j = ? -- :not", `[{"k":[1]}]`},
+		{"SELECT 1", "", "SELECT 1", "[]"},
+	}
+	for _, tt := range tests {
+		q, args, err := BindParams(tt.sql, tt.params)
+		if err != nil || q != tt.want || fmt.Sprint(args) != tt.args {
+			t.Errorf("BindParams(%q, %s) = %q, %v, %v", tt.sql, tt.params, q, args, err)
+		}
+	}
+	for _, bad := range [][2]string{
+		{"SELECT ?", "[]"},
+		{"SELECT :a, :b", `{"a": 1}`},
+		{"SELECT :a, ?", `{"a": 1}`},
+		{"SELECT 1", `"x"`},
+		{"SELECT 1", `{`},
+	} {
+		if _, _, err := BindParams(bad[0], bad[1]); err == nil {
+			t.Errorf("BindParams(%q, %s) accepted", bad[0], bad[1])
+		}
+	}
+}
+
+func TestUniqueColumnNames(t *testing.T) {
+	tests := []struct {
+		in, want []string
+	}{
+		{[]string{"id", "name"}, []string{"id", "name"}},
+		{[]string{"Level", "Code", "Message", "Code"}, []string{"Level", "Code", "Message", "Code_2"}},
+		{[]string{"", ""}, []string{"column_1", "column_2"}},
+		{[]string{"", "column_1"}, []string{"column_1_2", "column_1"}},
+		{[]string{"x", "x", "x"}, []string{"x", "x_2", "x_3"}},
+	}
+	for _, tt := range tests {
+		if got := UniqueColumnNames(tt.in); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("UniqueColumnNames(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMySQLEnumValues(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want []string
+	}{
+		{"enum('a','b c')", []string{"a", "b c"}},
+		{"ENUM('it''s','x,y')", []string{"it's", "x,y"}},
+		{"set('r','w','x')", []string{"r", "w", "x"}},
+		{"enum('')", []string{""}},
+		{"varchar(10)", nil},
+		{"enum('a", nil},
+	} {
+		got := parseMySQLEnumValues(c.in)
+		if (got == nil) != (c.want == nil) || strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("parseMySQLEnumValues(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -15,6 +17,12 @@ type SchemaColumn struct {
 	DefaultValue string `json:"defaultValue,omitempty"`
 	IsPrimaryKey bool   `json:"isPrimaryKey"`
 	IsUnique     bool   `json:"isUnique"`
+	// PrimaryKeyOrdinal is the 1-based position of the column in a composite primary key (SQLite; 0 elsewhere).
+	PrimaryKeyOrdinal int `json:"primaryKeyOrdinal,omitempty"`
+	// AutoIncrement marks server-assigned keys (MySQL auto_increment, PostgreSQL serial/identity, SQLite INTEGER PRIMARY KEY).
+	AutoIncrement bool `json:"autoIncrement,omitempty"`
+	// Generated marks computed columns that cannot be inserted into.
+	Generated bool `json:"generated,omitempty"`
 }
 
 // SchemaForeignKey holds FK metadata for a table.
@@ -49,7 +57,209 @@ func TableSchema(db *gorm.DB, driver, database, table string) (*TableSchemaInfo,
 	}
 }
 
+// CreateTableDDL returns the server's own CREATE TABLE statement (MySQL SHOW CREATE TABLE, SQLite sqlite_master),
+// without a trailing semicolon. PostgreSQL has no equivalent and returns "" so callers can generate one from TableSchema.
+func CreateTableDDL(db *gorm.DB, driver, database, table string) (string, error) {
+	switch driver {
+	case "mysql":
+		rows, err := db.Raw("SHOW CREATE TABLE " + qualTable(driver, database, table)).Rows()
+		if err != nil {
+			return "", err
+		}
+		defer rows.Close()
+		var name, ddl string
+		if !rows.Next() {
+			return "", fmt.Errorf("table %s not found", table)
+		}
+		if err := rows.Scan(&name, &ddl); err != nil {
+			return "", err
+		}
+		return ddl, nil
+	case "sqlite":
+		var ddl string
+		if err := db.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&ddl).Error; err != nil {
+			return "", err
+		}
+		if ddl == "" {
+			return "", fmt.Errorf("table %s not found", table)
+		}
+		return ddl, nil
+	case "postgresql", "postgres":
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported driver: %s", driver)
+	}
+}
+
+// ViewDDL is a view and the statement that creates it.
+type ViewDDL struct {
+	Name string
+	DDL  string
+}
+
+// ViewDDLs returns the views of database (MySQL database, defaulting to the current one; PostgreSQL schema, default
+// "public"; ignored for SQLite) with their CREATE VIEW statements, without a trailing ';'. They are ordered by name,
+// except on SQLite where creation order keeps views that select from other views after them.
+func ViewDDLs(db *gorm.DB, driver, database string) ([]ViewDDL, error) {
+	var views []ViewDDL
+	switch driver {
+	case "mysql":
+		var names []string
+		if err := db.Raw("SELECT TABLE_NAME FROM information_schema.VIEWS WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) ORDER BY TABLE_NAME",
+			database).Scan(&names).Error; err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			_, rows, err := RawSelect(db, "SHOW CREATE VIEW "+qualTable(driver, database, name))
+			if err != nil {
+				return nil, err
+			}
+			if len(rows) == 0 || rows[0]["Create View"] == nil {
+				return nil, fmt.Errorf("view %s not found", name)
+			}
+			views = append(views, ViewDDL{Name: name, DDL: fmt.Sprint(rows[0]["Create View"])})
+		}
+	case "postgresql", "postgres":
+		schema := database
+		if schema == "" {
+			schema = "public"
+		}
+		var raw []struct {
+			Viewname   string
+			Definition string
+		}
+		if err := db.Raw("SELECT viewname, definition FROM pg_views WHERE schemaname = ? ORDER BY viewname", schema).Scan(&raw).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range raw {
+			def := strings.TrimSuffix(strings.TrimSpace(r.Definition), ";")
+			views = append(views, ViewDDL{Name: r.Viewname, DDL: "CREATE VIEW " + qualTable(driver, database, r.Viewname) + " AS\n" + def})
+		}
+	case "sqlite":
+		var raw []struct {
+			Name string
+			SQL  string `gorm:"column:sql"`
+		}
+		if err := db.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'view' ORDER BY rowid").Scan(&raw).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range raw {
+			views = append(views, ViewDDL{Name: r.Name, DDL: strings.TrimSuffix(strings.TrimSpace(r.SQL), ";")})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported driver: %s", driver)
+	}
+	return views, nil
+}
+
+// ColumnAllowedValues returns the values a column is restricted to, in declaration order: the members of a MySQL
+// ENUM or SET column type, or the labels of a PostgreSQL enum type. Any other column (and every SQLite column)
+// returns an empty list. database is as for TableSchema.
+func ColumnAllowedValues(db *gorm.DB, driver, database, table, column string) ([]string, error) {
+	values := []string{}
+	switch driver {
+	case "mysql":
+		database, err := CurrentMySQLDatabase(db, database)
+		if err != nil {
+			return nil, err
+		}
+		var colType sql.NullString
+		err = db.Raw("SELECT COLUMN_TYPE FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+			database, table, column).Row().Scan(&colType)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if v := parseMySQLEnumValues(colType.String); v != nil {
+			values = v
+		}
+	case "postgresql", "postgres":
+		schema := database
+		if schema == "" {
+			schema = "public"
+		}
+		err := db.Raw(`SELECT e.enumlabel FROM pg_attribute a
+			JOIN pg_class c ON c.oid = a.attrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			JOIN pg_enum e ON e.enumtypid = a.atttypid
+			WHERE n.nspname = ? AND c.relname = ? AND a.attname = ? AND NOT a.attisdropped
+			ORDER BY e.enumsortorder`, schema, table, column).Scan(&values).Error
+		if err != nil {
+			return nil, err
+		}
+	case "sqlite":
+	default:
+		return nil, fmt.Errorf("unsupported driver: %s", driver)
+	}
+	return values, nil
+}
+
+// parseMySQLEnumValues returns the quoted members of a MySQL "enum('a','b')" or "set(...)" COLUMN_TYPE, with doubled
+// quotes unescaped, or nil for any other type.
+func parseMySQLEnumValues(colType string) []string {
+	low := strings.ToLower(colType)
+	var rest string
+	switch {
+	case strings.HasPrefix(low, "enum(") && strings.HasSuffix(low, ")"):
+		rest = colType[len("enum(") : len(colType)-1]
+	case strings.HasPrefix(low, "set(") && strings.HasSuffix(low, ")"):
+		rest = colType[len("set(") : len(colType)-1]
+	default:
+		return nil
+	}
+	values := []string{}
+	for rest != "" {
+		if rest[0] != '\'' {
+			return nil
+		}
+		var b strings.Builder
+		i := 1
+		for ; i < len(rest); i++ {
+			if rest[i] != '\'' {
+				b.WriteByte(rest[i])
+				continue
+			}
+			if i+1 < len(rest) && rest[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			break
+		}
+		if i >= len(rest) {
+			return nil // unterminated
+		}
+		values = append(values, b.String())
+		rest = strings.TrimPrefix(rest[i+1:], ",")
+	}
+	return values
+}
+
+// ErrNoDatabaseSelected is returned by MySQL lookups that default to the current database when the connection has
+// none (it was opened without a database and none was passed).
+var ErrNoDatabaseSelected = errors.New("no database selected; pass a database name")
+
+// CurrentMySQLDatabase returns database, or the connection's current database when it is empty, failing with
+// ErrNoDatabaseSelected when there is none: a NULL DATABASE() would otherwise match nothing and look like an
+// empty schema.
+func CurrentMySQLDatabase(db *gorm.DB, database string) (string, error) {
+	if database != "" {
+		return database, nil
+	}
+	var cur sql.NullString
+	if err := db.Raw("SELECT DATABASE()").Row().Scan(&cur); err != nil {
+		return "", err
+	}
+	if !cur.Valid || cur.String == "" {
+		return "", ErrNoDatabaseSelected
+	}
+	return cur.String, nil
+}
+
 func mysqlTableSchema(db *gorm.DB, database, table string, info *TableSchemaInfo) (*TableSchemaInfo, error) {
+	database, err := CurrentMySQLDatabase(db, database)
+	if err != nil {
+		return nil, err
+	}
 	q := "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY, EXTRA FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION"
 	var raw []struct {
 		COLUMN_NAME    string
@@ -59,16 +269,8 @@ func mysqlTableSchema(db *gorm.DB, database, table string, info *TableSchemaInfo
 		COLUMN_KEY     string
 		EXTRA          string
 	}
-	if database != "" {
-		if err := db.Raw(q, database, table).Scan(&raw).Error; err != nil {
-			return nil, err
-		}
-	} else {
-		// use DATABASE() for current connection DB
-		q2 := "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY, EXTRA FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION"
-		if err := db.Raw(q2, table).Scan(&raw).Error; err != nil {
-			return nil, err
-		}
+	if err := db.Raw(q, database, table).Scan(&raw).Error; err != nil {
+		return nil, err
 	}
 	for _, r := range raw {
 		def := ""
@@ -82,6 +284,9 @@ func mysqlTableSchema(db *gorm.DB, database, table string, info *TableSchemaInfo
 			DefaultValue: def,
 			IsPrimaryKey: strings.ToUpper(r.COLUMN_KEY) == "PRI",
 			IsUnique:     strings.ToUpper(r.COLUMN_KEY) == "UNI",
+			// EXTRA is e.g. "auto_increment", "VIRTUAL GENERATED", "STORED GENERATED" (not "DEFAULT_GENERATED").
+			AutoIncrement: strings.Contains(strings.ToLower(r.EXTRA), "auto_increment"),
+			Generated:     strings.Contains(strings.ToUpper(r.EXTRA), " GENERATED"),
 		})
 	}
 	fks, _ := mysqlTableForeignKeys(db, database, table)
@@ -89,6 +294,7 @@ func mysqlTableSchema(db *gorm.DB, database, table string, info *TableSchemaInfo
 	return info, nil
 }
 
+// mysqlTableForeignKeys lists the foreign keys of table in database, which must be resolved (see CurrentMySQLDatabase).
 func mysqlTableForeignKeys(db *gorm.DB, database, table string) ([]SchemaForeignKey, error) {
 	q := `SELECT kcu.CONSTRAINT_NAME, kcu.COLUMN_NAME, kcu.REFERENCED_TABLE_NAME, kcu.REFERENCED_COLUMN_NAME,
 		rc.DELETE_RULE, rc.UPDATE_RULE
@@ -105,21 +311,8 @@ func mysqlTableForeignKeys(db *gorm.DB, database, table string) ([]SchemaForeign
 		DeleteRule       string `gorm:"column:DELETE_RULE"`
 		UpdateRule       string `gorm:"column:UPDATE_RULE"`
 	}
-	if database == "" {
-		q = `SELECT kcu.CONSTRAINT_NAME, kcu.COLUMN_NAME, kcu.REFERENCED_TABLE_NAME, kcu.REFERENCED_COLUMN_NAME,
-			rc.DELETE_RULE, rc.UPDATE_RULE
-			FROM information_schema.KEY_COLUMN_USAGE kcu
-			JOIN information_schema.REFERENTIAL_CONSTRAINTS rc
-			  ON kcu.CONSTRAINT_NAME = rc.CONSTRAINT_NAME AND kcu.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA
-			WHERE kcu.TABLE_SCHEMA = DATABASE() AND kcu.TABLE_NAME = ? AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
-			ORDER BY kcu.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`
-		if err := db.Raw(q, table).Scan(&raw).Error; err != nil {
-			return nil, err
-		}
-	} else {
-		if err := db.Raw(q, database, table).Scan(&raw).Error; err != nil {
-			return nil, err
-		}
+	if err := db.Raw(q, database, table).Scan(&raw).Error; err != nil {
+		return nil, err
 	}
 	byName := make(map[string]*SchemaForeignKey)
 	for _, r := range raw {
@@ -177,18 +370,37 @@ func postgresTableSchema(db *gorm.DB, database, table string, info *TableSchemaI
 	for _, r := range pkCheck {
 		pkCols[r.Attname] = true
 	}
+	// identity (PG 10+) and generated (PG 12+) columns; best effort on older servers
+	identityCols := make(map[string]bool)
+	generatedCols := make(map[string]bool)
+	var extra []struct {
+		Attname      string
+		Attidentity  string
+		Attgenerated string
+	}
+	_ = db.Raw(`SELECT a.attname, a.attidentity::text AS attidentity, a.attgenerated::text AS attgenerated FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = ? AND c.relname = ? AND a.attnum > 0 AND NOT a.attisdropped`,
+		schema, table).Scan(&extra)
+	for _, r := range extra {
+		identityCols[r.Attname] = r.Attidentity != ""
+		generatedCols[r.Attname] = r.Attgenerated != ""
+	}
 	for _, r := range raw {
 		def := ""
 		if r.ColumnDefault != nil {
 			def = *r.ColumnDefault
 		}
 		info.Columns = append(info.Columns, SchemaColumn{
-			Name:         r.ColumnName,
-			Type:         r.DataType,
-			Nullable:     strings.ToUpper(r.IsNullable) == "YES",
-			DefaultValue: def,
-			IsPrimaryKey: pkCols[r.ColumnName],
-			IsUnique:     false,
+			Name:          r.ColumnName,
+			Type:          r.DataType,
+			Nullable:      strings.ToUpper(r.IsNullable) == "YES",
+			DefaultValue:  def,
+			IsPrimaryKey:  pkCols[r.ColumnName],
+			IsUnique:      false,
+			AutoIncrement: identityCols[r.ColumnName] || strings.HasPrefix(def, "nextval("),
+			Generated:     generatedCols[r.ColumnName],
 		})
 	}
 	fks, _ := postgresTableForeignKeys(db, schema, table)
@@ -258,6 +470,12 @@ func sqliteTableSchema(db *gorm.DB, table string, info *TableSchemaInfo) (*Table
 	if err := db.Raw(q).Scan(&raw).Error; err != nil {
 		return nil, err
 	}
+	pkCount := 0
+	for _, r := range raw {
+		if r.PK > 0 {
+			pkCount++
+		}
+	}
 	for _, r := range raw {
 		def := ""
 		if r.Dflt != nil {
@@ -270,6 +488,10 @@ func sqliteTableSchema(db *gorm.DB, table string, info *TableSchemaInfo) (*Table
 			DefaultValue: def,
 			IsPrimaryKey: r.PK > 0,
 			IsUnique:     false, // would need PRAGMA index_list
+			// PRAGMA table_info reports pk as the position in the key, which may differ from column order
+			PrimaryKeyOrdinal: r.PK,
+			// a lone INTEGER PRIMARY KEY aliases the rowid and is assigned automatically
+			AutoIncrement: r.PK > 0 && pkCount == 1 && strings.EqualFold(r.Type, "INTEGER"),
 		})
 	}
 	fks, _ := sqliteTableForeignKeys(db, table)
@@ -0,0 +1,137 @@
+// Package keyring stores secrets in the OS keychain: the macOS login keychain via security(1), the
+// Secret Service (GNOME Keyring, KWallet) via secret-tool(1) from libsecret, and the Windows Credential
+// Manager. Other platforms report ErrUnsupported so callers can fall back to their own storage.
+package keyring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned by Get when no secret is stored for service/user.
+	ErrNotFound = errors.New("secret not found in keychain")
+	// ErrUnsupported is returned when no keychain is available on this system.
+	ErrUnsupported = errors.New("keychain not available")
+)
+
+// run executes name with args, feeding stdin, and returns stdout. Replaced in tests.
+var run = func(stdin, name string, args ...string) (string, error) {
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return "", ErrUnsupported
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s: %w", name, msg, err)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// exitCode reports the exit status of a failed command, or -1.
+func exitCode(err error) int {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
+
+// Available reports whether a keychain backend can be used on this system.
+func Available() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "linux", "freebsd", "openbsd":
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	case "windows":
+		return credAvailable()
+	}
+	return false
+}
+
+// Set stores secret for service/user, replacing any existing entry.
+func Set(service, user, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// security -i reads commands from stdin so the secret never appears in the process list;
+		// -X takes it hex-encoded, which avoids quoting.
+		cmd := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+			quote(service), quote(user), hex.EncodeToString([]byte(secret)))
+		_, err := run(cmd, "security", "-i")
+		return err
+	case "linux", "freebsd", "openbsd":
+		_, err := run(secret, "secret-tool", "store", "--label="+service+" "+user, "service", service, "username", user)
+		return err
+	case "windows":
+		return credSet(service, user, secret)
+	}
+	return ErrUnsupported
+}
+
+// Get returns the secret stored for service/user.
+func Get(service, user string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := run("", "security", "find-generic-password", "-s", service, "-a", user, "-w")
+		if err != nil {
+			if strings.Contains(err.Error(), "could not be found") {
+				return "", ErrNotFound
+			}
+			return "", err
+		}
+		return strings.TrimSuffix(out, "\n"), nil
+	case "linux", "freebsd", "openbsd":
+		out, err := run("", "secret-tool", "lookup", "service", service, "username", user)
+		if err != nil {
+			if exitCode(err) == 1 {
+				return "", ErrNotFound
+			}
+			return "", err
+		}
+		if out == "" {
+			return "", ErrNotFound
+		}
+		return out, nil
+	case "windows":
+		return credGet(service, user)
+	}
+	return "", ErrUnsupported
+}
+
+// Delete removes the secret for service/user. Deleting a missing entry is not an error.
+func Delete(service, user string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := run("", "security", "delete-generic-password", "-s", service, "-a", user)
+		if err != nil && strings.Contains(err.Error(), "could not be found") {
+			return nil
+		}
+		return err
+	case "linux", "freebsd", "openbsd":
+		_, err := run("", "secret-tool", "clear", "service", service, "username", user)
+		return err
+	case "windows":
+		return credDelete(service, user)
+	}
+	return ErrUnsupported
+}
+
+// quote wraps s in double quotes for the security -i command parser.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
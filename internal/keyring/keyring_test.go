@@ -0,0 +1,66 @@
+package keyring
+
+import (
+	"encoding/hex"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type call struct {
+	stdin string
+	name  string
+	args  []string
+}
+
+func stubRun(t *testing.T, out string) *[]call {
+	var calls []call
+	orig := run
+	run = func(stdin, name string, args ...string) (string, error) {
+		calls = append(calls, call{stdin, name, args})
+		return out, nil
+	}
+	t.Cleanup(func() { run = orig })
+	return &calls
+}
+
+func TestSetKeepsSecretOffCommandLine(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skip("no keychain backend on " + runtime.GOOS)
+	}
+	calls := stubRun(t, "")
+	if err := Set("topology", "42", `p"w d`); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("calls: %+v", *calls)
+	}
+	c := (*calls)[0]
+	if strings.Contains(strings.Join(c.args, " "), "p\"w d") {
+		t.Errorf("secret passed as argument: %v", c.args)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		if !strings.Contains(c.stdin, hex.EncodeToString([]byte(`p"w d`))) || !strings.Contains(c.stdin, `-a "42"`) {
+			t.Errorf("stdin = %q", c.stdin)
+		}
+	case "linux":
+		if c.stdin != `p"w d` || c.name != "secret-tool" {
+			t.Errorf("call = %+v", c)
+		}
+	}
+}
+
+func TestGetEmptyIsNotFound(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("secret-tool only")
+	}
+	stubRun(t, "")
+	if _, err := Get("topology", "42"); err != ErrNotFound {
+		t.Errorf("err = %v", err)
+	}
+	stubRun(t, "secret")
+	if pw, err := Get("topology", "42"); err != nil || pw != "secret" {
+		t.Errorf("Get = %q, %v", pw, err)
+	}
+}
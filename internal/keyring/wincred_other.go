@@ -0,0 +1,11 @@
+//go:build !windows
+
+package keyring
+
+func credAvailable() bool { return false }
+
+func credSet(service, user, secret string) error { return ErrUnsupported }
+
+func credGet(service, user string) (string, error) { return "", ErrUnsupported }
+
+func credDelete(service, user string) error { return ErrUnsupported }
@@ -103,7 +103,7 @@ func logf(level Level, format string, args ...interface{}) {
 		mu.Unlock()
 		return
 	}
-	msg := fmt.Sprintf(format, args...)
+	msg := Redact(fmt.Sprintf(format, args...))
 	line := fmt.Sprintf("%s [%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), level.String(), msg)
 	_, _ = io.WriteString(file, line)
 	mu.Unlock()
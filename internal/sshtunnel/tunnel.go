@@ -92,6 +92,27 @@ func GetOrStart(connID string, cfg Config) (localPort int, err error) {
 	return port, nil
 }
 
+// Alive reports whether the tunnel for connID is running and its SSH connection answers a keepalive within timeout.
+func Alive(connID string, timeout time.Duration) bool {
+	mu.RLock()
+	t, ok := tunnels[connID]
+	mu.RUnlock()
+	if !ok {
+		return false
+	}
+	reply := make(chan error, 1)
+	go func() {
+		_, _, err := t.client.SendRequest("keepalive@openssh.com", true, nil)
+		reply <- err
+	}()
+	select {
+	case err := <-reply:
+		return err == nil
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func buildAuth(password, privateKeyPEM string) ([]ssh.AuthMethod, error) {
 	if privateKeyPEM != "" {
 		signer, err := ssh.ParsePrivateKey([]byte(privateKeyPEM))
@@ -152,3 +173,15 @@ func Stop(connID string) {
 	_ = t.client.Close()
 	delete(tunnels, connID)
 }
+
+// StopAll closes every running SSH tunnel (used on application shutdown).
+func StopAll() {
+	mu.Lock()
+	defer mu.Unlock()
+	for connID, t := range tunnels {
+		close(t.done)
+		_ = t.listener.Close()
+		_ = t.client.Close()
+		delete(tunnels, connID)
+	}
+}